@@ -0,0 +1,59 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"log"
+	"math/rand"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// A local stand-in for an upstream price provider. It serves the rates from a
+// JSON file, nudging each price by up to ±jitter percent on every request so
+// the price feed has something to refresh against.
+func main() {
+	addr := flag.String("addr", ":8090", "listen address")
+	file := flag.String("file", "data/prices.json", "price file to serve")
+	jitter := flag.Float64("jitter", 1, "max random price movement in percent")
+	flag.Parse()
+
+	http.HandleFunc("/prices", func(w http.ResponseWriter, r *http.Request) {
+		raw, err := os.ReadFile(*file)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		var payload struct {
+			Rates map[string]map[string]string `json:"rates"`
+		}
+		if err := json.Unmarshal(raw, &payload); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		for _, rates := range payload.Rates {
+			for currency, value := range rates {
+				price, err := decimal.NewFromString(value)
+				if err != nil {
+					continue
+				}
+				movement := (rand.Float64()*2 - 1) * *jitter / 100
+				rates[currency] = price.Mul(decimal.NewFromFloat(1 + movement)).StringFixed(6)
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"as_of": time.Now().UTC(),
+			"rates": payload.Rates,
+		})
+	})
+
+	log.Printf("Price stub serving %s on %s/prices", *file, *addr)
+	log.Fatal(http.ListenAndServe(*addr, nil))
+}
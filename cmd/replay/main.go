@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"math"
+
+	"wallet-service/internal/cache"
+	"wallet-service/internal/config"
+	"wallet-service/internal/events"
+	"wallet-service/internal/persistence"
+	"wallet-service/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// Re-publishes outbox events to the configured sink, whether or not the relay
+// already delivered them. Useful for rebuilding a downstream projection.
+func main() {
+	fromSeq := flag.Int64("from", 1, "first outbox sequence to replay")
+	toSeq := flag.Int64("to", math.MaxInt64, "last outbox sequence to replay")
+	aggregate := flag.String("aggregate", "", "only replay events of this wallet or transaction ID")
+	sinkKind := flag.String("sink", "", "override EVENT_SINK (redis or stdout)")
+	flag.Parse()
+
+	cfg := config.Load()
+	if *sinkKind != "" {
+		cfg.EventSink = *sinkKind
+	}
+
+	var aggregateIDs []uuid.UUID
+	if *aggregate != "" {
+		id, err := uuid.Parse(*aggregate)
+		if err != nil {
+			log.Fatal("Invalid aggregate ID:", err)
+		}
+		aggregateIDs = []uuid.UUID{id}
+	}
+
+	db, err := persistence.NewPQConnection(cfg.DatabaseURL)
+	if err != nil {
+		log.Fatal("Failed to connect to database:", err)
+	}
+	defer db.Close()
+
+	redisClient := cache.NewRedisClient(cfg.RedisHost, cfg.RedisPort)
+	defer redisClient.Close()
+
+	sink, err := events.NewSink(cfg.EventSink, redisClient, cfg.EventStream)
+	if err != nil {
+		log.Fatal("Failed to configure event sink:", err)
+	}
+
+	var outboxRepo repository.IOutboxRepository = repository.NewOutboxRepository(db)
+
+	ctx := context.Background()
+	replayed := 0
+	next := *fromSeq
+	for next <= *toSeq {
+		batch, err := outboxRepo.GetRange(next, *toSeq, aggregateIDs, cfg.OutboxBatchSize)
+		if err != nil {
+			log.Fatal("Failed to read outbox:", err)
+		}
+		if len(batch) == 0 {
+			break
+		}
+
+		for i := range batch {
+			if err := sink.Publish(ctx, &batch[i]); err != nil {
+				log.Fatalf("Failed to replay event %d: %v", batch[i].Sequence, err)
+			}
+		}
+
+		replayed += len(batch)
+		next = batch[len(batch)-1].Sequence + 1
+	}
+
+	log.Printf("Replayed %d events", replayed)
+}
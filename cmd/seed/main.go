@@ -1,12 +1,15 @@
 package main
 
 import (
+	"context"
+	"database/sql"
 	"fmt"
 	"log"
 	"math/rand"
 
 	"wallet-service/internal/config"
 	"wallet-service/internal/models"
+	"wallet-service/internal/password"
 	"wallet-service/internal/persistence"
 	"wallet-service/internal/repository"
 
@@ -23,11 +26,12 @@ func main() {
 	defer db.Close()
 
 	// Initialize
+	txManager := repository.NewTransactionManager(db)
 	var userRepo repository.IUserRepository = repository.NewUserRepository(db)
 	var walletRepo repository.IWalletRepository = repository.NewWalletRepository(db)
 
 	// Seed users and wallets
-	err = seedData(userRepo, walletRepo)
+	err = seedData(context.Background(), txManager, userRepo, walletRepo)
 	if err != nil {
 		log.Fatal("Failed to seed data:", err)
 	}
@@ -35,42 +39,108 @@ func main() {
 	log.Println("Database seeded successfully!")
 }
 
-func seedData(userRepo repository.IUserRepository, walletRepo repository.IWalletRepository) error {
+// testPassword is set for every seeded user
+const testPassword = "Wallet-Test-2024"
+
+// seededTiers are handed out in turn, so every KYC tier has users to try
+var seededTiers = []models.KYCTier{models.KYCTierFull, models.KYCTierBasic, models.KYCTierUnverified}
+
+func seedData(ctx context.Context, txManager *repository.TransactionManager, userRepo repository.IUserRepository, walletRepo repository.IWalletRepository) error {
 	// Create 20 users with wallets
 	for i := 1; i <= 20; i++ {
+		passwordHash, err := password.Hash(testPassword)
+		if err != nil {
+			return fmt.Errorf("failed to hash password for user %d: %w", i, err)
+		}
+
 		// Create user
 		user := &models.User{
-			ID:    uuid.New(),
-			Name:  fmt.Sprintf("user_%03d", i),
-			Email: fmt.Sprintf("user_%03d@example.com", i),
+			ID:           uuid.New(),
+			Name:         fmt.Sprintf("user_%03d", i),
+			Email:        fmt.Sprintf("user_%03d@example.com", i),
+			PasswordHash: passwordHash,
+			KYCTier:      seededTiers[(i-1)%len(seededTiers)],
+		}
+
+		// Each user is created together with their wallets
+		err = txManager.ExecuteTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+			created, err := userRepo.Create(ctx, tx, user)
+			if err != nil {
+				return fmt.Errorf("failed to create user %d: %w", i, err)
+			}
+			if !created {
+				return fmt.Errorf("user %s already exists", user.Email)
+			}
+
+			// Create wallets for each coin type
+			coinTypes := []models.CoinType{models.CoinTypeBTC, models.CoinTypeETH, models.CoinTypeADA}
+			for _, coinType := range coinTypes {
+				// Generate random initial balance between 10 and 1000
+				initialBalance := decimal.NewFromFloat(rand.Float64()*990 + 10)
+
+				wallet := &models.Wallet{
+					ID:           uuid.New(),
+					UserID:       user.ID,
+					CoinType:     coinType,
+					Amount:       initialBalance,
+					FrozenAmount: decimal.Zero,
+				}
+
+				if _, err := walletRepo.Create(ctx, tx, wallet); err != nil {
+					return fmt.Errorf("failed to create wallet for user %d, coin %s: %w", i, coinType, err)
+				}
+			}
+
+			return nil
+		})
+		if err != nil {
+			return err
 		}
 
-		err := userRepo.Create(user)
+		log.Printf("Created %s user %s with 3 wallets (password %q)", user.KYCTier, user.Name, testPassword)
+	}
+
+	// One staff account per role, without wallets, and a second admin so
+	// balance adjustments can be approved by someone other than their maker
+	staff := []struct {
+		name string
+		role models.Role
+	}{
+		{"support-readonly", models.RoleSupportReadOnly},
+		{"operator", models.RoleOperator},
+		{"admin", models.RoleAdmin},
+		{"admin-2", models.RoleAdmin},
+	}
+	for _, member := range staff {
+		role := member.role
+		passwordHash, err := password.Hash(testPassword)
 		if err != nil {
-			return fmt.Errorf("failed to create user %d: %w", i, err)
+			return fmt.Errorf("failed to hash password for %s: %w", member.name, err)
 		}
 
-		// Create wallets for each coin type
-		coinTypes := []models.CoinType{models.CoinTypeBTC, models.CoinTypeETH, models.CoinTypeADA}
-		for _, coinType := range coinTypes {
-			// Generate random initial balance between 10 and 1000
-			initialBalance := decimal.NewFromFloat(rand.Float64()*990 + 10)
-
-			wallet := &models.Wallet{
-				ID:           uuid.New(),
-				UserID:       user.ID,
-				CoinType:     coinType,
-				Amount:       initialBalance,
-				FrozenAmount: decimal.Zero,
-			}
+		user := &models.User{
+			ID:           uuid.New(),
+			Name:         member.name,
+			Email:        fmt.Sprintf("%s@example.com", member.name),
+			PasswordHash: passwordHash,
+			Role:         role,
+		}
 
-			err := walletRepo.Create(wallet)
+		err = txManager.ExecuteTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+			created, err := userRepo.Create(ctx, tx, user)
 			if err != nil {
-				return fmt.Errorf("failed to create wallet for user %d, coin %s: %w", i, coinType, err)
+				return fmt.Errorf("failed to create %s user: %w", role, err)
+			}
+			if !created {
+				return fmt.Errorf("user %s already exists", user.Email)
 			}
+			return nil
+		})
+		if err != nil {
+			return err
 		}
 
-		log.Printf("Created user %s with 3 wallets", user.Name)
+		log.Printf("Created %s staff user %s (password %q)", role, user.Email, testPassword)
 	}
 
 	return nil
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"log"
+	"math/rand"
+	"net/http"
+	"time"
+
+	"wallet-service/internal/webhooks"
+)
+
+// A local webhook endpoint for trying out deliveries. It verifies signatures
+// and timestamps like a real receiver should, and can fail a share of requests
+// to exercise retries and dead-lettering.
+func main() {
+	addr := flag.String("addr", ":9090", "listen address")
+	secret := flag.String("secret", "", "endpoint signing secret returned by POST /webhooks")
+	tolerance := flag.Duration("tolerance", 5*time.Minute, "max accepted timestamp skew")
+	failRate := flag.Float64("fail-rate", 0, "share of requests answered with 500, between 0 and 1")
+	flag.Parse()
+
+	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, "failed to read body", http.StatusBadRequest)
+			return
+		}
+
+		id := r.Header.Get(webhooks.HeaderID)
+		event := r.Header.Get(webhooks.HeaderEvent)
+
+		if *secret != "" {
+			err := webhooks.Verify(*secret, r.Header.Get(webhooks.HeaderTimestamp), body, r.Header.Get(webhooks.HeaderSignature), *tolerance, time.Now())
+			if err != nil {
+				log.Printf("REJECTED %s %s: %v", event, id, err)
+				http.Error(w, err.Error(), http.StatusUnauthorized)
+				return
+			}
+		}
+
+		if rand.Float64() < *failRate {
+			log.Printf("FAILING  %s %s", event, id)
+			http.Error(w, "simulated failure", http.StatusInternalServerError)
+			return
+		}
+
+		log.Printf("ACCEPTED %s %s %s", event, id, body)
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	log.Printf("Webhook receiver listening on %s", *addr)
+	log.Fatal(http.ListenAndServe(*addr, nil))
+}
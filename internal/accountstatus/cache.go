@@ -0,0 +1,65 @@
+package accountstatus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"wallet-service/internal/models"
+	"wallet-service/internal/repository"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+var ErrUserNotFound = errors.New("user not found")
+
+// Cache fronts users.status with Redis so the status check costs no database
+// round trip on every request. Entries expire after ttl, which bounds how long
+// a change made elsewhere can go unseen; changes made through Set are seen at
+// once.
+type Cache struct {
+	userRepo    repository.IUserRepository
+	redisClient *redis.Client
+	ttl         time.Duration
+}
+
+func NewCache(userRepo repository.IUserRepository, redisClient *redis.Client, ttl time.Duration) *Cache {
+	return &Cache{userRepo: userRepo, redisClient: redisClient, ttl: ttl}
+}
+
+func cacheKey(userID uuid.UUID) string {
+	return fmt.Sprintf("user_status:%s", userID)
+}
+
+// Get returns the user's status, reading through to the database when it is
+// not cached or Redis is unavailable
+func (c *Cache) Get(ctx context.Context, userID uuid.UUID) (models.UserStatus, error) {
+	cached, err := c.redisClient.Get(ctx, cacheKey(userID)).Result()
+	if err == nil {
+		return models.UserStatus(cached), nil
+	}
+	if err != redis.Nil {
+		log.Printf("Warning: user status cache read failed: %v", err)
+	}
+
+	user, err := c.userRepo.GetByID(userID)
+	if err != nil {
+		return "", err
+	}
+	if user == nil {
+		return "", ErrUserNotFound
+	}
+
+	c.Set(ctx, userID, user.Status)
+	return user.Status, nil
+}
+
+// Set caches a status the caller has just committed
+func (c *Cache) Set(ctx context.Context, userID uuid.UUID, status models.UserStatus) {
+	if err := c.redisClient.Set(ctx, cacheKey(userID), string(status), c.ttl).Err(); err != nil {
+		log.Printf("Warning: user status cache write failed: %v", err)
+	}
+}
@@ -0,0 +1,80 @@
+package apikey
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"wallet-service/internal/models"
+	"wallet-service/internal/repository"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+func TestGenerate(t *testing.T) {
+	key, prefix, hash, err := Generate()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if !IsKey(key) || !strings.HasPrefix(key, prefix) {
+		t.Errorf("Expected key %q to start with %q", key, prefix)
+	}
+	if hash != Hash(key) || strings.Contains(hash, key) {
+		t.Error("Expected stored hash to be the digest of the key")
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	repo := repository.NewMockAPIKeyRepository()
+	authenticator := NewAuthenticator(repo)
+	ctx := context.Background()
+
+	create := func(mutate func(*models.APIKey)) string {
+		key, prefix, hash, _ := Generate()
+		record := &models.APIKey{ID: uuid.New(), UserID: uuid.New(), Prefix: prefix, KeyHash: hash}
+		mutate(record)
+		repo.Create(ctx, record)
+		return key
+	}
+
+	active := create(func(*models.APIKey) {})
+	if _, err := authenticator.Authenticate(ctx, active); err != nil {
+		t.Errorf("Expected active key to authenticate, got %v", err)
+	}
+
+	past := time.Now().Add(-time.Hour)
+	expired := create(func(k *models.APIKey) { k.ExpiresAt = &past })
+	revoked := create(func(k *models.APIKey) { k.RevokedAt = &past })
+
+	for name, key := range map[string]string{"expired": expired, "revoked": revoked, "unknown": KeyPrefix + "unknown"} {
+		if _, err := authenticator.Authenticate(ctx, key); err != ErrInvalidKey {
+			t.Errorf("%s: expected ErrInvalidKey, got %v", name, err)
+		}
+	}
+}
+
+func TestRestrictions(t *testing.T) {
+	allowed := uuid.New()
+	limit := decimal.NewFromInt(100)
+	key := &models.APIKey{
+		Scopes:    []models.APIScope{models.ScopeWalletsRead},
+		WalletIDs: []uuid.UUID{allowed},
+		MaxAmount: &limit,
+	}
+
+	if !HasScope(key, models.ScopeWalletsRead) || HasScope(key, models.ScopeTransfersWrite) {
+		t.Error("Expected only the granted scope")
+	}
+	if !AllowsWallet(key, allowed) || AllowsWallet(key, uuid.New()) {
+		t.Error("Expected only the listed wallet")
+	}
+	if !AllowsAmount(key, decimal.NewFromInt(100)) || AllowsAmount(key, decimal.NewFromInt(101)) {
+		t.Error("Expected amounts up to the limit")
+	}
+	if !AllowsWallet(&models.APIKey{}, uuid.New()) {
+		t.Error("Expected an unrestricted key to allow any wallet")
+	}
+}
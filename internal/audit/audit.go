@@ -0,0 +1,175 @@
+package audit
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"wallet-service/internal/models"
+	"wallet-service/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+const verifyBatchSize = 1000
+
+// Auditor appends records to the hash-chained audit log
+type Auditor struct {
+	auditRepo repository.IAuditRepository
+	txManager *repository.TransactionManager
+}
+
+func NewAuditor(auditRepo repository.IAuditRepository, txManager *repository.TransactionManager) *Auditor {
+	return &Auditor{
+		auditRepo: auditRepo,
+		txManager: txManager,
+	}
+}
+
+// Record links entry to the current head of the chain and appends it
+func (a *Auditor) Record(ctx context.Context, entry *models.AuditLog) error {
+	return a.txManager.ExecuteTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		return a.RecordTx(ctx, tx, entry)
+	})
+}
+
+// RecordTx appends entry inside tx, so that the record commits or rolls back
+// together with the change it describes
+func (a *Auditor) RecordTx(ctx context.Context, tx *sql.Tx, entry *models.AuditLog) error {
+	if entry.ID == uuid.Nil {
+		entry.ID = uuid.New()
+	}
+	if entry.WalletIDs == nil {
+		entry.WalletIDs = []uuid.UUID{}
+	}
+	// Match what Postgres will store so the record hashes the same when read back
+	entry.CreatedAt = time.Now().UTC().Truncate(time.Microsecond)
+	if entry.Amount != nil {
+		amount := entry.Amount.Round(6)
+		entry.Amount = &amount
+	}
+
+	if err := a.auditRepo.LockChain(ctx, tx); err != nil {
+		return err
+	}
+
+	prevHash, err := a.auditRepo.GetLastHash(ctx, tx)
+	if err != nil {
+		return err
+	}
+
+	entry.PrevHash = prevHash
+	entry.Hash = ComputeHash(entry)
+
+	return a.auditRepo.Append(ctx, tx, entry)
+}
+
+// Verify walks the whole chain and reports the first record whose hash or
+// link to its predecessor does not match
+func (a *Auditor) Verify(ctx context.Context) (*models.AuditChainVerification, error) {
+	result := &models.AuditChainVerification{Valid: true}
+
+	var afterSeq int64
+	prevHash := ""
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		batch, err := a.auditRepo.GetChain(afterSeq, verifyBatchSize)
+		if err != nil {
+			return nil, err
+		}
+
+		if brokenAt := VerifyChain(prevHash, batch); brokenAt != nil {
+			result.Valid = false
+			result.BrokenAt = brokenAt
+			result.Checked += int(*brokenAt - afterSeq)
+			return result, nil
+		}
+
+		result.Checked += len(batch)
+		if len(batch) < verifyBatchSize {
+			return result, nil
+		}
+
+		afterSeq = batch[len(batch)-1].Sequence
+		prevHash = batch[len(batch)-1].Hash
+	}
+}
+
+// VerifyChain checks consecutive records starting from prevHash and returns
+// the sequence of the first broken one, or nil if the chain holds
+func VerifyChain(prevHash string, logs []models.AuditLog) *int64 {
+	for i := range logs {
+		if logs[i].PrevHash != prevHash || ComputeHash(&logs[i]) != logs[i].Hash {
+			return &logs[i].Sequence
+		}
+		prevHash = logs[i].Hash
+	}
+	return nil
+}
+
+// ComputeHash returns the hex SHA-256 over the previous hash and every
+// recorded field of entry, in a fixed order
+func ComputeHash(entry *models.AuditLog) string {
+	actorID := ""
+	if entry.ActorID != nil {
+		actorID = entry.ActorID.String()
+	}
+
+	walletIDs := make([]string, 0, len(entry.WalletIDs))
+	for _, walletID := range entry.WalletIDs {
+		walletIDs = append(walletIDs, walletID.String())
+	}
+
+	amount := ""
+	if entry.Amount != nil {
+		amount = entry.Amount.String()
+	}
+
+	fields := []string{
+		entry.PrevHash,
+		entry.ID.String(),
+		actorID,
+		entry.IP,
+		entry.UserAgent,
+		entry.Action,
+		entry.Method,
+		entry.Route,
+		strings.Join(walletIDs, ","),
+		amount,
+		entry.IdempotencyKey,
+		string(entry.Details),
+		strconv.Itoa(entry.StatusCode),
+		string(entry.Outcome),
+		strconv.FormatInt(entry.CreatedAt.UnixMicro(), 10),
+	}
+
+	h := sha256.New()
+	for _, field := range fields {
+		// Length-prefix each field so values cannot bleed into each other
+		h.Write([]byte(strconv.Itoa(len(field))))
+		h.Write([]byte(":"))
+		h.Write([]byte(field))
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Outcome classifies a request by the HTTP status it was answered with
+func Outcome(status int) models.AuditOutcome {
+	switch {
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		return models.AuditOutcomeDenied
+	case status >= http.StatusBadRequest:
+		return models.AuditOutcomeFailure
+	default:
+		return models.AuditOutcomeSuccess
+	}
+}
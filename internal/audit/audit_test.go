@@ -0,0 +1,67 @@
+package audit
+
+import (
+	"testing"
+	"time"
+
+	"wallet-service/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+func buildChain(n int) []models.AuditLog {
+	logs := make([]models.AuditLog, 0, n)
+	prevHash := ""
+	for i := 0; i < n; i++ {
+		actorID := uuid.New()
+		amount := decimal.NewFromInt(int64(10 * (i + 1)))
+		entry := models.AuditLog{
+			Sequence:   int64(i + 1),
+			ID:         uuid.New(),
+			ActorID:    &actorID,
+			IP:         "127.0.0.1",
+			UserAgent:  "curl/8.0",
+			Action:     "wallet.deposit",
+			Method:     "POST",
+			Route:      "/wallets/:wallet_id/deposit",
+			WalletIDs:  []uuid.UUID{uuid.New()},
+			Amount:     &amount,
+			StatusCode: 200,
+			Outcome:    models.AuditOutcomeSuccess,
+			CreatedAt:  time.Now().UTC(),
+			PrevHash:   prevHash,
+		}
+		entry.Hash = ComputeHash(&entry)
+		prevHash = entry.Hash
+		logs = append(logs, entry)
+	}
+	return logs
+}
+
+func TestVerifyChain_Intact(t *testing.T) {
+	if brokenAt := VerifyChain("", buildChain(5)); brokenAt != nil {
+		t.Errorf("Expected intact chain, broken at %d", *brokenAt)
+	}
+}
+
+func TestVerifyChain_DetectsTampering(t *testing.T) {
+	logs := buildChain(5)
+	tampered := decimal.NewFromInt(1)
+	logs[2].Amount = &tampered
+
+	brokenAt := VerifyChain("", logs)
+	if brokenAt == nil || *brokenAt != 3 {
+		t.Errorf("Expected chain broken at 3, got %v", brokenAt)
+	}
+}
+
+func TestVerifyChain_DetectsDeletion(t *testing.T) {
+	logs := buildChain(5)
+	logs = append(logs[:1], logs[2:]...)
+
+	brokenAt := VerifyChain("", logs)
+	if brokenAt == nil || *brokenAt != 3 {
+		t.Errorf("Expected chain broken at 3, got %v", brokenAt)
+	}
+}
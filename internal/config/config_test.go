@@ -0,0 +1,71 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestConfig_Validate(t *testing.T) {
+	strong := strings.Repeat("k9-", 12)
+	other := strings.Repeat("q7-", 12)
+
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantErr string
+	}{
+		{"strong secrets", Config{JWTSecret: strong, TOTPEncryptionKey: other}, ""},
+		{"default JWT secret", Config{JWTSecret: defaultJWTSecret, TOTPEncryptionKey: other}, "JWT_SECRET is a published default"},
+		{"short TOTP key", Config{JWTSecret: strong, TOTPEncryptionKey: "short"}, "TOTP_ENCRYPTION_KEY must be at least"},
+		{"default TOTP key", Config{JWTSecret: strong, TOTPEncryptionKey: defaultTOTPEncryptionKey}, "TOTP_ENCRYPTION_KEY is a published default"},
+		{"TOTP key reusing the JWT secret", Config{JWTSecret: strong, TOTPEncryptionKey: strong}, "TOTP_ENCRYPTION_KEY must differ from JWT_SECRET"},
+		{"weak admin token", Config{JWTSecret: strong, TOTPEncryptionKey: other, AdminAPIToken: "admin"}, "ADMIN_API_TOKEN must be at least"},
+	}
+
+	for _, tt := range tests {
+		err := tt.cfg.Validate()
+		if tt.wantErr == "" && err != nil {
+			t.Errorf("%s: expected no error, got %v", tt.name, err)
+		}
+		if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+			t.Errorf("%s: expected error containing %q, got %v", tt.name, tt.wantErr, err)
+		}
+	}
+}
+
+func TestGetEnvRateLimit(t *testing.T) {
+	fallback := RateLimit{Limit: 10, Window: time.Minute}
+
+	tests := []struct {
+		value string
+		want  RateLimit
+	}{
+		{"", fallback},
+		{"5/30s", RateLimit{Limit: 5, Window: 30 * time.Second}},
+		{" 100 / 1h ", RateLimit{Limit: 100, Window: time.Hour}},
+		{"0/1m", RateLimit{Limit: 0, Window: time.Minute}},
+		{"5", fallback},
+		{"five/1m", fallback},
+		{"-1/1m", fallback},
+		{"5/0s", fallback},
+	}
+
+	for _, tt := range tests {
+		t.Setenv("RATE_LIMIT_TEST", tt.value)
+		if got := getEnvRateLimit("RATE_LIMIT_TEST", fallback); got != tt.want {
+			t.Errorf("%q: expected %+v, got %+v", tt.value, tt.want, got)
+		}
+	}
+}
+
+func TestGetEnvDecimalMap(t *testing.T) {
+	t.Setenv("DECIMAL_MAP_TEST", " BTC: 1.5 ,ETH:20,ADA,XRP:lots")
+
+	got := getEnvDecimalMap("DECIMAL_MAP_TEST", "")
+	if len(got) != 2 || !got["BTC"].Equal(decimal.RequireFromString("1.5")) || !got["ETH"].Equal(decimal.NewFromInt(20)) {
+		t.Errorf("Expected BTC:1.5 and ETH:20 with invalid pairs skipped, got %v", got)
+	}
+}
@@ -0,0 +1,138 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"wallet-service/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// TransactionCompleted is emitted once per committed money movement
+type TransactionCompleted struct {
+	TransactionID uuid.UUID                 `json:"transaction_id"`
+	Type          models.TransactionType    `json:"transaction_type"`
+	Status        models.TransactionStatus  `json:"status"`
+	Entries       []models.TransactionEntry `json:"entries"`
+	OccurredAt    time.Time                 `json:"occurred_at"`
+}
+
+// WalletBalanceChanged is the payload of WalletDebited and WalletCredited
+type WalletBalanceChanged struct {
+	WalletID             uuid.UUID              `json:"wallet_id"`
+	UserID               uuid.UUID              `json:"user_id"`
+	CoinType             models.CoinType        `json:"coin_type"`
+	TransactionID        uuid.UUID              `json:"transaction_id"`
+	TransactionType      models.TransactionType `json:"transaction_type"`
+	EntryID              uuid.UUID              `json:"entry_id"`
+	Amount               decimal.Decimal        `json:"amount"`
+	Balance              decimal.Decimal        `json:"balance"`
+	CounterpartyWalletID *uuid.UUID             `json:"counterparty_wallet_id,omitempty"`
+	OccurredAt           time.Time              `json:"occurred_at"`
+}
+
+// WithdrawalStatusChanged is emitted when a withdrawal held for review is
+// requested and again when it is approved, rejected or cancelled
+type WithdrawalStatusChanged struct {
+	TransactionID uuid.UUID                     `json:"transaction_id"`
+	WalletID      uuid.UUID                     `json:"wallet_id"`
+	UserID        uuid.UUID                     `json:"user_id"`
+	CoinType      models.CoinType               `json:"coin_type"`
+	Amount        decimal.Decimal               `json:"amount"`
+	Status        models.WithdrawalReviewStatus `json:"status"`
+	Reason        string                        `json:"reason,omitempty"`
+	OccurredAt    time.Time                     `json:"occurred_at"`
+}
+
+// BalanceChange pairs a ledger entry with the wallet it touched and the
+// wallet's balance once the entry is applied
+type BalanceChange struct {
+	Wallet  *models.Wallet
+	Entry   *models.TransactionEntry
+	Balance decimal.Decimal
+}
+
+// ForTransaction builds the outbox events for a committed transaction: one
+// WalletDebited/WalletCredited per entry followed by TransactionCompleted
+func ForTransaction(transaction *models.Transaction, changes []BalanceChange) ([]*models.OutboxEvent, error) {
+	occurredAt := transaction.CreatedAt
+	if occurredAt.IsZero() {
+		occurredAt = time.Now().UTC()
+	}
+
+	outboxEvents := make([]*models.OutboxEvent, 0, len(changes)+1)
+	entries := make([]models.TransactionEntry, 0, len(changes))
+
+	for _, change := range changes {
+		eventType := models.EventTypeWalletCredited
+		if change.Entry.Direction == models.DirectionOut {
+			eventType = models.EventTypeWalletDebited
+		}
+
+		event, err := New(eventType, change.Wallet.ID, WalletBalanceChanged{
+			WalletID:             change.Wallet.ID,
+			UserID:               change.Wallet.UserID,
+			CoinType:             change.Wallet.CoinType,
+			TransactionID:        transaction.ID,
+			TransactionType:      transaction.Type,
+			EntryID:              change.Entry.ID,
+			Amount:               change.Entry.Amount,
+			Balance:              change.Balance,
+			CounterpartyWalletID: change.Entry.CounterpartyWalletID,
+			OccurredAt:           occurredAt,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		outboxEvents = append(outboxEvents, event)
+		entries = append(entries, *change.Entry)
+	}
+
+	completed, err := New(models.EventTypeTransactionCompleted, transaction.ID, TransactionCompleted{
+		TransactionID: transaction.ID,
+		Type:          transaction.Type,
+		Status:        transaction.Status,
+		Entries:       entries,
+		OccurredAt:    occurredAt,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return append(outboxEvents, completed), nil
+}
+
+// ForWithdrawalReview builds the WithdrawalStatusChanged event for a
+// withdrawal's current review status. It is keyed by wallet so it streams
+// alongside the wallet's balance events.
+func ForWithdrawalReview(review *models.WithdrawalReview) (*models.OutboxEvent, error) {
+	return New(models.EventTypeWithdrawalStatusChanged, review.WalletID, WithdrawalStatusChanged{
+		TransactionID: review.TransactionID,
+		WalletID:      review.WalletID,
+		UserID:        review.UserID,
+		CoinType:      review.CoinType,
+		Amount:        review.Amount,
+		Status:        review.Status,
+		Reason:        review.ReviewNote,
+		OccurredAt:    time.Now().UTC(),
+	})
+}
+
+// New wraps a payload into an outbox event
+func New(eventType models.EventType, aggregateID uuid.UUID, payload interface{}) (*models.OutboxEvent, error) {
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal %s payload: %w", eventType, err)
+	}
+
+	return &models.OutboxEvent{
+		ID:          uuid.New(),
+		EventType:   eventType,
+		AggregateID: aggregateID,
+		Payload:     raw,
+	}, nil
+}
@@ -0,0 +1,143 @@
+package events
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"time"
+
+	"wallet-service/internal/repository"
+)
+
+// Relay moves committed outbox events to its consumers. Each consumer gets
+// events in publish sequence order and has its own cursor, advanced only past
+// events its sink accepted, which gives at-least-once delivery that preserves
+// per-wallet ordering. A failing consumer is retried from its cursor without
+// replaying anything to the others.
+//
+// The outbox seq is assigned when an event is inserted, not when its
+// transaction commits, so it is not commit order: with overlapping
+// transactions seq N+1 can become visible before seq N. The relay therefore
+// first gives every committed event a publish sequence, in a transaction of
+// its own, and only then publishes. An event that commits late gets a later
+// publish sequence rather than slipping in behind one a consumer has already
+// seen, so a stream resuming from a publish sequence misses nothing.
+type Relay struct {
+	outboxRepo repository.IOutboxRepository
+	txManager  repository.ITransactionManager
+	consumers  []Consumer
+	interval   time.Duration
+	batchSize  int
+}
+
+func NewRelay(outboxRepo repository.IOutboxRepository, txManager repository.ITransactionManager, consumers []Consumer, interval time.Duration, batchSize int) *Relay {
+	return &Relay{
+		outboxRepo: outboxRepo,
+		txManager:  txManager,
+		consumers:  consumers,
+		interval:   interval,
+		batchSize:  batchSize,
+	}
+}
+
+// Start polls the outbox on every interval until ctx is done
+func (r *Relay) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(r.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				// Drain full batches before waiting for the next tick
+				for {
+					moved, err := r.RelayOnce(ctx)
+					if err != nil {
+						log.Printf("Warning: outbox relay failed: %v", err)
+					}
+					if err != nil || moved < r.batchSize {
+						break
+					}
+				}
+			}
+		}
+	}()
+}
+
+// RelayOnce numbers up to one batch of newly committed events, then moves up
+// to one batch to each consumer. It returns the most events any of those
+// steps handled, so a full batch means there may be more. Only one instance
+// numbers events and only one publishes to a given consumer at a time.
+func (r *Relay) RelayOnce(ctx context.Context) (int, error) {
+	// Commit the publish sequence before anything is published, so a consumer
+	// that saw an event can always find everything before it in the outbox
+	moved := 0
+	err := r.txManager.ExecuteTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		locked, err := r.outboxRepo.LockRelay(ctx, tx)
+		if err != nil || !locked {
+			return err
+		}
+
+		moved, err = r.outboxRepo.AssignPublishSequence(ctx, tx, r.batchSize)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	for _, consumer := range r.consumers {
+		published, err := r.publish(ctx, consumer)
+		if err != nil {
+			log.Printf("Warning: outbox relay to %s failed: %v", consumer.Name, err)
+			continue
+		}
+		if published > moved {
+			moved = published
+		}
+	}
+
+	return moved, nil
+}
+
+// publish hands the events after the consumer's cursor to its sink, stopping
+// at the first failure so a later event never goes out ahead of an earlier
+// one, and moves the cursor past what made it out
+func (r *Relay) publish(ctx context.Context, consumer Consumer) (int, error) {
+	published := 0
+
+	err := r.txManager.ExecuteTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		cursor, locked, err := r.outboxRepo.LockCursor(ctx, tx, consumer.Name)
+		if err != nil || !locked {
+			return err
+		}
+
+		pending, err := r.outboxRepo.GetAfter(cursor, nil, r.batchSize)
+		if err != nil {
+			return err
+		}
+
+		var publishErr error
+		for i := range pending {
+			if publishErr = consumer.Sink.Publish(ctx, &pending[i]); publishErr != nil {
+				break
+			}
+			cursor = pending[i].PublishSequence
+			published++
+		}
+
+		if published > 0 {
+			if err := r.outboxRepo.SaveCursor(ctx, tx, consumer.Name, cursor); err != nil {
+				return err
+			}
+		}
+
+		if publishErr != nil {
+			log.Printf("Warning: outbox publish to %s stopped at %d/%d events: %v", consumer.Name, published, len(pending), publishErr)
+		}
+		return nil
+	})
+
+	return published, err
+}
@@ -0,0 +1,155 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"wallet-service/internal/models"
+	"wallet-service/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// recordingSink remembers what it published and fails every event in failing
+type recordingSink struct {
+	published   []int64
+	publishSeqs []int64
+	failing     map[int64]bool
+}
+
+func (s *recordingSink) Publish(ctx context.Context, event *models.OutboxEvent) error {
+	if s.failing[event.Sequence] {
+		return errors.New("sink unavailable")
+	}
+	s.published = append(s.published, event.Sequence)
+	s.publishSeqs = append(s.publishSeqs, event.PublishSequence)
+	return nil
+}
+
+func newTestOutbox(t *testing.T, n int) *repository.MockOutboxRepository {
+	outboxRepo := repository.NewMockOutboxRepository()
+	for i := 0; i < n; i++ {
+		event := &models.OutboxEvent{ID: uuid.New(), EventType: models.EventTypeTransactionCompleted, AggregateID: uuid.New(), Payload: json.RawMessage(`{}`)}
+		if err := outboxRepo.Create(context.Background(), nil, event); err != nil {
+			t.Fatalf("Failed to create event: %v", err)
+		}
+	}
+	return outboxRepo
+}
+
+func newTestRelay(outboxRepo *repository.MockOutboxRepository, batchSize int, sinks map[string]Sink) *Relay {
+	var consumers []Consumer
+	for _, name := range []string{"events", "webhooks"} {
+		if sink, ok := sinks[name]; ok {
+			consumers = append(consumers, Consumer{Name: name, Sink: sink})
+		}
+	}
+	return NewRelay(outboxRepo, repository.NewMockTransactionManager(), consumers, time.Second, batchSize)
+}
+
+func equalSeqs(a, b []int64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestRelayOnce_PublishesInOrderAndAdvancesCursor(t *testing.T) {
+	outboxRepo := newTestOutbox(t, 3)
+	sink := &recordingSink{}
+	relay := newTestRelay(outboxRepo, 10, map[string]Sink{"events": sink})
+
+	moved, err := relay.RelayOnce(context.Background())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if moved != 3 {
+		t.Errorf("Expected 3 events relayed, got %d", moved)
+	}
+	if !equalSeqs(sink.published, []int64{1, 2, 3}) {
+		t.Errorf("Expected events in order, got %v", sink.published)
+	}
+	if !equalSeqs(sink.publishSeqs, []int64{1, 2, 3}) {
+		t.Errorf("Expected events to carry their publish sequence, got %v", sink.publishSeqs)
+	}
+	if cursor := outboxRepo.Cursor("events"); cursor != 3 {
+		t.Errorf("Expected the cursor past every event, got %d", cursor)
+	}
+
+	// A second pass has nothing left to send
+	if moved, _ := relay.RelayOnce(context.Background()); moved != 0 || len(sink.published) != 3 {
+		t.Errorf("Expected published events not to be sent again, got %d more", moved)
+	}
+}
+
+func TestRelayOnce_RespectsBatchSize(t *testing.T) {
+	outboxRepo := newTestOutbox(t, 5)
+	sink := &recordingSink{}
+	relay := newTestRelay(outboxRepo, 2, map[string]Sink{"events": sink})
+
+	if moved, _ := relay.RelayOnce(context.Background()); moved != 2 {
+		t.Errorf("Expected one batch of 2, got %d", moved)
+	}
+	if cursor := outboxRepo.Cursor("events"); cursor != 2 {
+		t.Errorf("Expected the rest of the outbox left for the next pass, got cursor %d", cursor)
+	}
+}
+
+func TestRelayOnce_SinkFailureLeavesRestForRetry(t *testing.T) {
+	outboxRepo := newTestOutbox(t, 4)
+	sink := &recordingSink{failing: map[int64]bool{2: true}}
+	relay := newTestRelay(outboxRepo, 10, map[string]Sink{"events": sink})
+
+	if _, err := relay.RelayOnce(context.Background()); err != nil {
+		t.Fatalf("A sink failure should not fail the pass, got %v", err)
+	}
+	if !equalSeqs(sink.published, []int64{1}) {
+		t.Errorf("Expected only the event before the failure published, got %v", sink.published)
+	}
+	if cursor := outboxRepo.Cursor("events"); cursor != 1 {
+		t.Errorf("Expected the cursor to stop before the failed event, got %d", cursor)
+	}
+
+	// Once the sink recovers the rest goes out, still in order
+	sink.failing = nil
+	if moved, _ := relay.RelayOnce(context.Background()); moved != 3 {
+		t.Errorf("Expected the 3 remaining events retried, got %d", moved)
+	}
+	if !equalSeqs(sink.published, []int64{1, 2, 3, 4}) {
+		t.Errorf("Expected every event published once in order, got %v", sink.published)
+	}
+}
+
+func TestRelayOnce_FailingConsumerDoesNotReplayOthers(t *testing.T) {
+	outboxRepo := newTestOutbox(t, 3)
+	healthy := &recordingSink{}
+	failing := &recordingSink{failing: map[int64]bool{1: true, 2: true, 3: true}}
+	relay := newTestRelay(outboxRepo, 10, map[string]Sink{"events": healthy, "webhooks": failing})
+
+	for i := 0; i < 3; i++ {
+		if _, err := relay.RelayOnce(context.Background()); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+
+	if !equalSeqs(healthy.published, []int64{1, 2, 3}) {
+		t.Errorf("Expected the healthy consumer to get each event once, got %v", healthy.published)
+	}
+	if cursor := outboxRepo.Cursor("webhooks"); cursor != 0 {
+		t.Errorf("Expected the failing consumer's cursor not to move, got %d", cursor)
+	}
+
+	failing.failing = nil
+	relay.RelayOnce(context.Background())
+	if !equalSeqs(failing.published, []int64{1, 2, 3}) || len(healthy.published) != 3 {
+		t.Errorf("Expected only the recovered consumer to catch up, got %v and %v", failing.published, healthy.published)
+	}
+}
@@ -0,0 +1,137 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"sync"
+
+	"wallet-service/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// Sink publishes outbox events to downstream consumers. Publish may be called
+// more than once for the same event, so consumers should dedupe on event ID.
+type Sink interface {
+	Publish(ctx context.Context, event *models.OutboxEvent) error
+}
+
+// NewSink builds the sink named in config: "redis" or "stdout"
+func NewSink(kind string, redisClient *redis.Client, stream string) (Sink, error) {
+	switch kind {
+	case "redis":
+		return NewRedisStreamSink(redisClient, stream), nil
+	case "stdout":
+		return NewWriterSink(os.Stdout), nil
+	default:
+		return nil, fmt.Errorf("unknown event sink %q", kind)
+	}
+}
+
+// Consumer is a sink together with the name its outbox cursor is stored
+// under. Each consumer advances on its own, so one that keeps failing holds
+// back only itself.
+type Consumer struct {
+	Name string
+	Sink Sink
+}
+
+// RedisStreamSink appends events to a Redis Stream
+type RedisStreamSink struct {
+	redisClient *redis.Client
+	stream      string
+}
+
+func NewRedisStreamSink(redisClient *redis.Client, stream string) *RedisStreamSink {
+	return &RedisStreamSink{
+		redisClient: redisClient,
+		stream:      stream,
+	}
+}
+
+func (s *RedisStreamSink) Publish(ctx context.Context, event *models.OutboxEvent) error {
+	err := s.redisClient.XAdd(ctx, &redis.XAddArgs{
+		Stream: s.stream,
+		Values: map[string]interface{}{
+			"id":           event.ID.String(),
+			"sequence":     strconv.FormatInt(event.Sequence, 10),
+			"type":         string(event.EventType),
+			"aggregate_id": event.AggregateID.String(),
+			"occurred_at":  event.CreatedAt.UTC().Format("2006-01-02T15:04:05.000000Z07:00"),
+			"payload":      string(event.Payload),
+		},
+	}).Err()
+	if err != nil {
+		return fmt.Errorf("failed to add event to stream %s: %w", s.stream, err)
+	}
+
+	return nil
+}
+
+// PubSubSink publishes wallet balance and withdrawal review events on a
+// per-user Redis channel (<prefix>:<user_id>) for live streaming. Pub/sub is
+// fire-and-forget: a subscriber that misses a message catches up from the
+// outbox.
+type PubSubSink struct {
+	redisClient *redis.Client
+	prefix      string
+}
+
+func NewPubSubSink(redisClient *redis.Client, prefix string) *PubSubSink {
+	return &PubSubSink{
+		redisClient: redisClient,
+		prefix:      prefix,
+	}
+}
+
+func (s *PubSubSink) Publish(ctx context.Context, event *models.OutboxEvent) error {
+	var payload struct {
+		UserID uuid.UUID `json:"user_id"`
+	}
+	switch event.EventType {
+	case models.EventTypeWalletCredited, models.EventTypeWalletDebited, models.EventTypeWithdrawalStatusChanged:
+		if err := json.Unmarshal(event.Payload, &payload); err != nil {
+			return fmt.Errorf("failed to decode %s payload: %w", event.EventType, err)
+		}
+	default:
+		return nil
+	}
+
+	message, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	channel := fmt.Sprintf("%s:%s", s.prefix, payload.UserID)
+	if err := s.redisClient.Publish(ctx, channel, message).Err(); err != nil {
+		return fmt.Errorf("failed to publish event to %s: %w", channel, err)
+	}
+
+	return nil
+}
+
+// WriterSink writes one JSON document per event, e.g. to stdout
+type WriterSink struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func NewWriterSink(w io.Writer) *WriterSink {
+	return &WriterSink{w: w}
+}
+
+func (s *WriterSink) Publish(ctx context.Context, event *models.OutboxEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := json.NewEncoder(s.w).Encode(event); err != nil {
+		return fmt.Errorf("failed to write event: %w", err)
+	}
+
+	return nil
+}
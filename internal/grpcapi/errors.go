@@ -0,0 +1,132 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"wallet-service/internal/middleware"
+	"wallet-service/internal/problem"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// errorDomain names this service in google.rpc.ErrorInfo details
+const errorDomain = "wallet-service"
+
+// problemError carries a problem, built by an interceptor or RPC, up to
+// the error interceptor that turns it into a gRPC status
+type problemError struct {
+	problem *problem.Problem
+}
+
+func (e *problemError) Error() string {
+	return fmt.Sprintf("%s: %s", e.problem.Code, e.problem.Detail)
+}
+
+func fail(code problem.Code, detail string) error {
+	return &problemError{problem.New(code, detail)}
+}
+
+func failWith(p *problem.Problem) error {
+	return &problemError{p}
+}
+
+// asProblem is the problem of an RPC's error: the one it failed with, or the
+// REST API's mapping of a wallet service error
+func asProblem(err error) *problem.Problem {
+	var failed *problemError
+	if errors.As(err, &failed) {
+		return failed.problem
+	}
+	return middleware.ErrorProblem(err)
+}
+
+// statusCodes map the HTTP status of a problem to the nearest gRPC code.
+// Codes whose retry semantics differ are overridden in problemCodes.
+var statusCodes = map[int]codes.Code{
+	http.StatusBadRequest:            codes.InvalidArgument,
+	http.StatusUnauthorized:          codes.Unauthenticated,
+	http.StatusForbidden:             codes.PermissionDenied,
+	http.StatusNotFound:              codes.NotFound,
+	http.StatusConflict:              codes.FailedPrecondition,
+	http.StatusRequestEntityTooLarge: codes.InvalidArgument,
+	http.StatusUnsupportedMediaType:  codes.InvalidArgument,
+	http.StatusUnprocessableEntity:   codes.FailedPrecondition,
+	http.StatusTooManyRequests:       codes.ResourceExhausted,
+}
+
+var problemCodes = map[problem.Code]codes.Code{
+	problem.CodeWalletExists:             codes.AlreadyExists,
+	problem.CodeIdempotencyKeyInProgress: codes.Aborted,
+	problem.CodeIdempotencyKeyProcessed:  codes.Aborted,
+}
+
+// grpcCode is the gRPC code of a problem
+func grpcCode(p *problem.Problem) codes.Code {
+	if code, ok := problemCodes[p.Code]; ok {
+		return code
+	}
+	if code, ok := statusCodes[p.Status]; ok {
+		return code
+	}
+	return codes.Internal
+}
+
+// toStatus answers a problem with a gRPC status whose ErrorInfo reason is the
+// problem's stable code. Extensions become ErrorInfo metadata and rejected
+// fields a BadRequest detail.
+func toStatus(p *problem.Problem) *status.Status {
+	message := p.Detail
+	if message == "" {
+		message = p.Title
+	}
+	st := status.New(grpcCode(p), message)
+
+	info := &errdetails.ErrorInfo{Reason: string(p.Code), Domain: errorDomain}
+	if len(p.Extensions) > 0 {
+		info.Metadata = make(map[string]string, len(p.Extensions))
+		for key, value := range p.Extensions {
+			info.Metadata[key] = fmt.Sprint(value)
+		}
+	}
+
+	withInfo, err := st.WithDetails(info)
+	if err != nil {
+		return st
+	}
+	st = withInfo
+
+	if len(p.Errors) > 0 {
+		violations := make([]*errdetails.BadRequest_FieldViolation, 0, len(p.Errors))
+		for _, field := range p.Errors {
+			violations = append(violations, &errdetails.BadRequest_FieldViolation{Field: field.Field, Description: field.Message})
+		}
+		if withFields, err := st.WithDetails(&errdetails.BadRequest{FieldViolations: violations}); err == nil {
+			st = withFields
+		}
+	}
+
+	return st
+}
+
+// statusError converts the error an RPC ended with to a gRPC status error,
+// logging internal failures, whose details never reach the client
+func statusError(method string, err error) error {
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return status.FromContextError(err).Err()
+	}
+
+	p := asProblem(err)
+	if p.Status >= http.StatusInternalServerError {
+		log.Printf("Error: %s: %v", method, err)
+	}
+	return toStatus(p).Err()
+}
@@ -0,0 +1,175 @@
+package grpcapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"wallet-service/internal/grpcapi/walletpb"
+	"wallet-service/internal/middleware"
+	"wallet-service/internal/models"
+	"wallet-service/internal/problem"
+	"wallet-service/internal/service"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+func TestStatusErrorCarriesProblemCode(t *testing.T) {
+	tests := []struct {
+		err    error
+		code   codes.Code
+		reason problem.Code
+	}{
+		{service.ErrInsufficientFunds, codes.InvalidArgument, problem.CodeInsufficientFunds},
+		{service.ErrWalletNotFound, codes.NotFound, problem.CodeWalletNotFound},
+		{service.ErrForbidden, codes.PermissionDenied, problem.CodeForbidden},
+		{service.ErrWalletExists, codes.AlreadyExists, problem.CodeWalletExists},
+		{&service.WalletNotActiveError{WalletID: uuid.New(), Status: models.WalletStatusFrozen}, codes.FailedPrecondition, problem.CodeWalletNotActive},
+		{fail(problem.CodeUnauthenticated, "Invalid token"), codes.Unauthenticated, problem.CodeUnauthenticated},
+		{fail(problem.CodeIdempotencyKeyInProgress, ""), codes.Aborted, problem.CodeIdempotencyKeyInProgress},
+		{fail(problem.CodeRateLimited, ""), codes.ResourceExhausted, problem.CodeRateLimited},
+		{errors.New("pq: connection reset"), codes.Internal, problem.CodeInternal},
+	}
+
+	for _, tt := range tests {
+		st, _ := status.FromError(statusError("/test", tt.err))
+		if st.Code() != tt.code {
+			t.Errorf("%v: expected %s, got %s", tt.err, tt.code, st.Code())
+		}
+		if info := errorInfo(st); info == nil || info.Reason != string(tt.reason) || info.Domain != errorDomain {
+			t.Errorf("%v: expected reason %s, got %v", tt.err, tt.reason, info)
+		}
+	}
+
+	st, _ := status.FromError(statusError("/test", errors.New("pq: connection reset")))
+	if st.Message() != "Internal server error" {
+		t.Errorf("Internal failures must not leak details, got %q", st.Message())
+	}
+}
+
+func TestStatusErrorCarriesExtensionsAndFields(t *testing.T) {
+	err := failWith(problem.New(problem.CodeOTPRequired, "One-time code required").With("otp_required", true))
+	st, _ := status.FromError(statusError("/test", err))
+	if info := errorInfo(st); info == nil || info.Metadata["otp_required"] != "true" {
+		t.Errorf("Expected otp_required metadata, got %v", info)
+	}
+
+	_, err = parseAmount("1e-8x")
+	st, _ = status.FromError(statusError("/test", err))
+	var fields *errdetails.BadRequest
+	for _, detail := range st.Details() {
+		if badRequest, ok := detail.(*errdetails.BadRequest); ok {
+			fields = badRequest
+		}
+	}
+	if fields == nil || len(fields.FieldViolations) != 1 || fields.FieldViolations[0].Field != "amount" {
+		t.Errorf("Expected an amount field violation, got %v", fields)
+	}
+}
+
+func TestParseAmountIsExact(t *testing.T) {
+	amount, err := parseAmount("0.000000000000000001")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if amount.String() != "0.000000000000000001" {
+		t.Errorf("Expected the amount unchanged, got %s", amount)
+	}
+
+	for _, raw := range []string{"", "abc", "1,5"} {
+		if _, err := parseAmount(raw); err == nil {
+			t.Errorf("%q: expected an error", raw)
+		}
+	}
+}
+
+func TestRequestFingerprint(t *testing.T) {
+	walletID := uuid.NewString()
+	first, _ := requestFingerprint(walletpb.WalletService_Deposit_FullMethodName, &walletpb.DepositRequest{WalletId: walletID, Amount: "1"})
+	same, _ := requestFingerprint(walletpb.WalletService_Deposit_FullMethodName, &walletpb.DepositRequest{WalletId: walletID, Amount: "1"})
+	otherAmount, _ := requestFingerprint(walletpb.WalletService_Deposit_FullMethodName, &walletpb.DepositRequest{WalletId: walletID, Amount: "2"})
+	otherMethod, _ := requestFingerprint(walletpb.WalletService_Withdraw_FullMethodName, &walletpb.WithdrawRequest{WalletId: walletID, Amount: "1"})
+
+	if first != same {
+		t.Error("Expected equal requests to share a fingerprint")
+	}
+	if first == otherAmount || first == otherMethod {
+		t.Error("Expected different requests to have different fingerprints")
+	}
+}
+
+func TestReplay(t *testing.T) {
+	stored, _ := protojson.Marshal(&walletpb.MovementResponse{TransactionId: uuid.NewString(), Type: "DEPOSIT", Status: "DONE", Amount: "0.1"})
+	resp, err := replay(&models.IdempotencyRecord{StatusCode: http.StatusOK, ResponseBody: stored})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if movement := resp.(*walletpb.MovementResponse); movement.Amount != "0.1" || movement.Status != "DONE" {
+		t.Errorf("Expected the stored response, got %v", movement)
+	}
+
+	storedProblem, _ := json.Marshal(problem.New(problem.CodeInsufficientFunds, "Insufficient balance"))
+	_, err = replay(&models.IdempotencyRecord{StatusCode: http.StatusBadRequest, ResponseBody: storedProblem})
+	if p := asProblem(err); p.Code != problem.CodeInsufficientFunds {
+		t.Errorf("Expected the stored problem, got %v", p)
+	}
+}
+
+func TestEveryMethodHasARule(t *testing.T) {
+	for _, method := range walletpb.WalletService_ServiceDesc.Methods {
+		if _, ok := rules["/"+walletpb.WalletService_ServiceDesc.ServiceName+"/"+method.MethodName]; !ok {
+			t.Errorf("%s has no access rule", method.MethodName)
+		}
+	}
+	for _, stream := range walletpb.WalletService_ServiceDesc.Streams {
+		if _, ok := rules["/"+walletpb.WalletService_ServiceDesc.ServiceName+"/"+stream.StreamName]; !ok {
+			t.Errorf("%s has no access rule", stream.StreamName)
+		}
+	}
+}
+
+func TestMoneyMethodsSpendTheMoneyLimit(t *testing.T) {
+	money := map[string]bool{
+		walletpb.WalletService_Deposit_FullMethodName:  true,
+		walletpb.WalletService_Withdraw_FullMethodName: true,
+		walletpb.WalletService_Transfer_FullMethodName: true,
+	}
+	for method, methodRule := range rules {
+		if methodRule.moneyLimited != money[method] {
+			t.Errorf("%s: expected moneyLimited %v", method, money[method])
+		}
+	}
+}
+
+func TestTakeLetsCallsThroughWithoutRedis(t *testing.T) {
+	redisClient := redis.NewClient(&redis.Options{Addr: "127.0.0.1:1"})
+	defer redisClient.Close()
+
+	policy := middleware.RateLimitPolicy{Name: "api", Limit: 1, Window: time.Minute}
+	guard := &Guard{limiter: middleware.NewRateLimiter(redisClient), apiLimit: policy}
+	if err := guard.take(context.Background(), policy, middleware.IPKey("203.0.113.7")); err != nil {
+		t.Errorf("Expected an unreachable Redis to let the call through, got %v", err)
+	}
+
+	// A disabled policy never asks Redis
+	if err := guard.take(context.Background(), middleware.RateLimitPolicy{Name: "off"}, "ip:203.0.113.7"); err != nil {
+		t.Errorf("Expected a disabled policy to let the call through, got %v", err)
+	}
+}
+
+func errorInfo(st *status.Status) *errdetails.ErrorInfo {
+	for _, detail := range st.Details() {
+		if info, ok := detail.(*errdetails.ErrorInfo); ok {
+			return info
+		}
+	}
+	return nil
+}
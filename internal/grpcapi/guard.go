@@ -0,0 +1,582 @@
+package grpcapi
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+
+	"wallet-service/internal/accountstatus"
+	"wallet-service/internal/apikey"
+	"wallet-service/internal/audit"
+	"wallet-service/internal/grpcapi/walletpb"
+	"wallet-service/internal/idempotency"
+	"wallet-service/internal/middleware"
+	"wallet-service/internal/models"
+	"wallet-service/internal/problem"
+	"wallet-service/internal/session"
+	"wallet-service/internal/twofactor"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// Metadata keys, the gRPC spelling of the REST API's headers
+const (
+	authorizationKey  = "authorization"
+	apiKeyKey         = "x-api-key"
+	idempotencyKeyKey = "x-idempotency-key"
+	otpKey            = "x-otp"
+	replayedKey       = "idempotent-replayed"
+)
+
+type stepUpPolicy int
+
+const (
+	stepUpNever stepUpPolicy = iota
+	stepUpAlways
+	// Only amounts above the step-up threshold of the wallet's coin need a
+	// code
+	stepUpAboveThreshold
+)
+
+// rule is what a method requires beyond authentication; it mirrors the
+// middleware of the method's REST route
+type rule struct {
+	scope models.APIScope
+	// action names the audit record of a mutating method; read-only methods
+	// have none and stay open to restricted accounts with read access
+	action string
+	// moneyLimited methods also spend the money rate limit
+	moneyLimited bool
+	idempotent   bool
+	stepUp       stepUpPolicy
+}
+
+var rules = map[string]rule{
+	walletpb.WalletService_ListWallets_FullMethodName:        {scope: models.ScopeWalletsRead},
+	walletpb.WalletService_GetBalance_FullMethodName:         {scope: models.ScopeWalletsRead},
+	walletpb.WalletService_Deposit_FullMethodName:            {scope: models.ScopeDepositsWrite, action: "wallet.deposit", moneyLimited: true, idempotent: true},
+	walletpb.WalletService_Withdraw_FullMethodName:           {scope: models.ScopeWithdrawalsWrite, action: "wallet.withdraw", moneyLimited: true, idempotent: true, stepUp: stepUpAlways},
+	walletpb.WalletService_Transfer_FullMethodName:           {scope: models.ScopeTransfersWrite, action: "wallet.transfer", moneyLimited: true, idempotent: true, stepUp: stepUpAboveThreshold},
+	walletpb.WalletService_GetHistory_FullMethodName:         {scope: models.ScopeTransactionsRead},
+	walletpb.WalletService_StreamTransactions_FullMethodName: {scope: models.ScopeTransactionsRead},
+}
+
+// Requests expose their fields through the generated getters
+type walletRequest interface{ GetWalletId() string }
+type receiverRequest interface{ GetReceiverWalletId() string }
+type amountRequest interface{ GetAmount() string }
+
+// caller is who a call authenticated as
+type caller struct {
+	UserID uuid.UUID
+	// Token is the access token of a session, re-checked by long streams
+	Token string
+	// APIKey is set, and Token empty, for calls made with an API key
+	APIKey *models.APIKey
+	// presentedKey is the API key as sent, re-checked by long streams
+	presentedKey string
+}
+
+type callerKey struct{}
+type pendingKey struct{}
+
+func callerFrom(ctx context.Context) *caller {
+	c, _ := ctx.Value(callerKey{}).(*caller)
+	return c
+}
+
+func pendingFrom(ctx context.Context) *idempotency.Pending {
+	p, _ := ctx.Value(pendingKey{}).(*idempotency.Pending)
+	return p
+}
+
+// Guard runs the REST API's request pipeline as gRPC interceptors: rate
+// limits, authentication, account status and scopes, the audit log, idempotency keys and step-up codes, in the order the REST routes apply
+// them. Every error leaves as a gRPC status carrying the problem's code.
+type Guard struct {
+	tokens           middleware.TokenConfig
+	sessions         *session.Store
+	apiKeys          *apikey.Authenticator
+	statuses         *accountstatus.Cache
+	readAccess       []models.UserStatus
+	twoFactor        *twofactor.Service
+	stepUpThresholds *middleware.StepUpThresholds
+	idempotency      *idempotency.Store
+	auditor          *audit.Auditor
+	limiter          *middleware.RateLimiter
+	apiLimit         middleware.RateLimitPolicy
+	moneyLimit       middleware.RateLimitPolicy
+}
+
+func NewGuard(tokens middleware.TokenConfig, sessions *session.Store, apiKeys *apikey.Authenticator, statuses *accountstatus.Cache, readAccess []models.UserStatus, twoFactor *twofactor.Service, stepUpThresholds *middleware.StepUpThresholds, idempotencyStore *idempotency.Store, auditor *audit.Auditor, limiter *middleware.RateLimiter, apiLimit, moneyLimit middleware.RateLimitPolicy) *Guard {
+	return &Guard{
+		tokens:           tokens,
+		sessions:         sessions,
+		apiKeys:          apiKeys,
+		statuses:         statuses,
+		readAccess:       readAccess,
+		twoFactor:        twoFactor,
+		stepUpThresholds: stepUpThresholds,
+		idempotency:      idempotencyStore,
+		auditor:          auditor,
+		limiter:          limiter,
+		apiLimit:         apiLimit,
+		moneyLimit:       moneyLimit,
+	}
+}
+
+// ServerOptions installs the interceptors on a gRPC server
+func (g *Guard) ServerOptions() []grpc.ServerOption {
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(g.handleErrors, g.limitByIP, g.authenticate, g.limit, g.audit, g.authorize, g.idempotent, g.requireOTP),
+		grpc.ChainStreamInterceptor(g.handleStreamErrors, g.limitStreamByIP, g.authenticateStream, g.limitStream),
+	}
+}
+
+// handleErrors answers every error, and any panic, with a gRPC status
+func (g *Guard) handleErrors(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Error: %s panicked: %v", info.FullMethod, r)
+			resp, err = nil, toStatus(problem.New(problem.CodeInternal, "")).Err()
+		}
+	}()
+
+	resp, err = handler(ctx, req)
+	if err != nil {
+		return nil, statusError(info.FullMethod, err)
+	}
+	return resp, nil
+}
+
+func (g *Guard) handleStreamErrors(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Error: %s panicked: %v", info.FullMethod, r)
+			err = toStatus(problem.New(problem.CodeInternal, "")).Err()
+		}
+	}()
+
+	if err := handler(srv, stream); err != nil {
+		return statusError(info.FullMethod, err)
+	}
+	return nil
+}
+
+// authenticate resolves the caller and stops suspended and locked accounts,
+// like AuthMiddleware and UserStatusGuard
+func (g *Guard) authenticate(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	ctx, err := g.admit(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+// authenticateStream is authenticate for streams, which also check the
+// method's scope here since they have no unary chain
+func (g *Guard) authenticateStream(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := g.admit(stream.Context(), info.FullMethod)
+	if err != nil {
+		return err
+	}
+	if key := callerFrom(ctx).APIKey; key != nil && !apikey.HasScope(key, rules[info.FullMethod].scope) {
+		return fail(problem.CodeInsufficientScope, "API key lacks scope "+string(rules[info.FullMethod].scope))
+	}
+	return handler(srv, &contextStream{ServerStream: stream, ctx: ctx})
+}
+
+// contextStream hands the handler a stream whose context carries the caller
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
+
+func (g *Guard) admit(ctx context.Context, method string) (context.Context, error) {
+	methodRule, ok := rules[method]
+	if !ok {
+		return nil, fail(problem.CodeForbidden, "No access rule for "+method)
+	}
+
+	c, err := g.identify(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	status, err := g.statuses.Get(ctx, c.UserID)
+	if err != nil {
+		if errors.Is(err, accountstatus.ErrUserNotFound) {
+			return nil, fail(problem.CodeUnauthenticated, "User not found")
+		}
+		return nil, fail(problem.CodeInternal, "Failed to check account status")
+	}
+	httpMethod := http.MethodPost
+	if methodRule.action == "" {
+		httpMethod = http.MethodGet
+	}
+	if !middleware.StatusAllows(status, httpMethod, g.readAccess) {
+		return nil, failWith(problem.New(problem.CodeAccountRestricted, "Account is "+strings.ReplaceAll(string(status), "-", " ")).With("account_status", status))
+	}
+
+	return context.WithValue(ctx, callerKey{}, c), nil
+}
+
+// identify accepts a session access token, or an API key either as the
+// bearer credential or in x-api-key
+func (g *Guard) identify(ctx context.Context) (*caller, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	if key := firstValue(md, apiKeyKey); key != "" {
+		return g.identifyAPIKey(ctx, key)
+	}
+
+	authorization := firstValue(md, authorizationKey)
+	if authorization == "" {
+		return nil, fail(problem.CodeUnauthenticated, "authorization metadata required")
+	}
+
+	parts := strings.Split(authorization, " ")
+	if len(parts) != 2 || parts[0] != "Bearer" {
+		return nil, fail(problem.CodeUnauthenticated, "Invalid authorization metadata format")
+	}
+
+	if apikey.IsKey(parts[1]) {
+		return g.identifyAPIKey(ctx, parts[1])
+	}
+
+	claims, err := middleware.ValidateToken(ctx, parts[1], g.tokens, g.sessions)
+	switch {
+	case errors.Is(err, middleware.ErrTokenRevoked):
+		return nil, fail(problem.CodeUnauthenticated, "Token has been revoked")
+	case errors.Is(err, middleware.ErrInvalidToken):
+		return nil, fail(problem.CodeUnauthenticated, "Invalid token")
+	case err != nil:
+		return nil, fail(problem.CodeInternal, "Failed to check token cache")
+	}
+
+	// Best effort: a failed touch only leaves last-used stale
+	sessionID, _ := uuid.Parse(claims.SessionID)
+	g.sessions.Touch(ctx, sessionID, peerIP(ctx))
+
+	userID, _ := uuid.Parse(claims.UserID)
+	return &caller{UserID: userID, Token: parts[1]}, nil
+}
+
+func (g *Guard) identifyAPIKey(ctx context.Context, presented string) (*caller, error) {
+	key, err := g.apiKeys.Authenticate(ctx, presented)
+	switch {
+	case errors.Is(err, apikey.ErrInvalidKey):
+		return nil, fail(problem.CodeUnauthenticated, "Invalid API key")
+	case err != nil:
+		return nil, fail(problem.CodeInternal, "Failed to check API key")
+	}
+
+	return &caller{UserID: key.UserID, APIKey: key, presentedKey: presented}, nil
+}
+
+// stillValid re-checks a long stream's credential so a revoked session or
+// API key stops streaming
+func (g *Guard) stillValid(ctx context.Context, c *caller) error {
+	if c.APIKey != nil {
+		_, err := g.apiKeys.Authenticate(ctx, c.presentedKey)
+		return err
+	}
+	_, err := middleware.ValidateToken(ctx, c.Token, g.tokens, g.sessions)
+	return err
+}
+
+// audit records mutating calls in the hash-chained audit log once they have
+// run, as middleware.Audit does for REST routes
+func (g *Guard) audit(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	action := rules[info.FullMethod].action
+	if action == "" {
+		return handler(ctx, req)
+	}
+
+	resp, err := handler(ctx, req)
+
+	statusCode := http.StatusOK
+	if err != nil {
+		statusCode = asProblem(err).Status
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	entry := &models.AuditLog{
+		IP:             peerIP(ctx),
+		UserAgent:      firstValue(md, "user-agent"),
+		Action:         action,
+		Method:         "GRPC",
+		Route:          info.FullMethod,
+		IdempotencyKey: firstValue(md, idempotencyKeyKey),
+		StatusCode:     statusCode,
+		Outcome:        audit.Outcome(statusCode),
+	}
+
+	c := callerFrom(ctx)
+	entry.ActorID = &c.UserID
+	if c.APIKey != nil {
+		entry.Details, _ = json.Marshal(map[string]string{"actor": "api-key:" + c.APIKey.ID.String()})
+	}
+
+	if r, ok := req.(walletRequest); ok {
+		if walletID, err := uuid.Parse(r.GetWalletId()); err == nil {
+			entry.WalletIDs = append(entry.WalletIDs, walletID)
+		}
+	}
+	if r, ok := req.(receiverRequest); ok {
+		if walletID, err := uuid.Parse(r.GetReceiverWalletId()); err == nil {
+			entry.WalletIDs = append(entry.WalletIDs, walletID)
+		}
+	}
+	if r, ok := req.(amountRequest); ok {
+		if amount, err := decimal.NewFromString(r.GetAmount()); err == nil {
+			entry.Amount = &amount
+		}
+	}
+
+	// The call's context may already be cancelled by a departing client; the
+	// record must be written regardless
+	if err := g.auditor.Record(context.Background(), entry); err != nil {
+		log.Printf("Error: failed to write audit log for %s: %v", action, err)
+	}
+
+	return resp, err
+}
+
+// authorize applies RequireScope. KYC limits are left to the wallet service,
+// which checks them inside the ledger transaction.
+func (g *Guard) authorize(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	methodRule := rules[info.FullMethod]
+	c := callerFrom(ctx)
+
+	amount, hasAmount, err := requestAmount(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if key := c.APIKey; key != nil {
+		if !apikey.HasScope(key, methodRule.scope) {
+			return nil, fail(problem.CodeInsufficientScope, "API key lacks scope "+string(methodRule.scope))
+		}
+		if r, ok := req.(walletRequest); ok {
+			if walletID, err := uuid.Parse(r.GetWalletId()); err == nil && !apikey.AllowsWallet(key, walletID) {
+				return nil, fail(problem.CodeInsufficientScope, "API key is not allowed to use this wallet")
+			}
+		}
+		if hasAmount && !apikey.AllowsAmount(key, amount) {
+			return nil, fail(problem.CodeAPIKeyLimitExceeded, "Amount exceeds the API key limit")
+		}
+	}
+
+	return handler(ctx, req)
+}
+
+// idempotent makes a mutating call safe to retry with the same
+// x-idempotency-key, with IdempotencyGuard's rules. Keys are scoped per user
+// and method, apart from the REST routes' keys. The RPC stores its response
+// inside its ledger transaction through the Pending left in the context;
+// repeats get the stored response, or the stored error, again.
+func (g *Guard) idempotent(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if !rules[info.FullMethod].idempotent {
+		return handler(ctx, req)
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	idempotencyKey := firstValue(md, idempotencyKeyKey)
+	if idempotencyKey == "" {
+		return nil, fail(problem.CodeIdempotencyKeyRequired, "x-idempotency-key metadata required")
+	}
+
+	fingerprint, err := requestFingerprint(info.FullMethod, req)
+	if err != nil {
+		return nil, fail(problem.CodeInvalidRequest, "Invalid request")
+	}
+
+	userID := callerFrom(ctx).UserID
+	storeCtx := context.Background()
+
+	record, inFlight, err := g.idempotency.Lookup(storeCtx, userID, info.FullMethod, idempotencyKey)
+	if err != nil {
+		return nil, fail(problem.CodeInternal, "Failed to check idempotency")
+	}
+
+	if record != nil {
+		if record.Fingerprint != fingerprint {
+			return nil, fail(problem.CodeIdempotencyKeyReused, "Idempotency key already used for a different request")
+		}
+
+		grpc.SetHeader(ctx, metadata.Pairs(replayedKey, "true"))
+		return replay(record)
+	}
+
+	var pending *idempotency.Pending
+	if !inFlight {
+		pending = g.idempotency.Begin(storeCtx, userID, info.FullMethod, idempotencyKey, fingerprint)
+	}
+	if pending == nil {
+		return nil, fail(problem.CodeIdempotencyKeyInProgress, "Request with this idempotency key is still in progress")
+	}
+
+	resp, err := handler(context.WithValue(ctx, pendingKey{}, pending), req)
+
+	if err != nil {
+		p := asProblem(err)
+		body, _ := json.Marshal(p)
+		g.idempotency.Finish(storeCtx, pending, p.Status, problem.ContentType, body)
+		return nil, err
+	}
+
+	body, _ := protojson.Marshal(resp.(proto.Message))
+	g.idempotency.Finish(storeCtx, pending, http.StatusOK, "application/json", body)
+	return resp, nil
+}
+
+// replay answers a repeat with the stored MovementResponse, the only response
+// of idempotent methods, or the stored problem
+func replay(record *models.IdempotencyRecord) (interface{}, error) {
+	if record.StatusCode >= http.StatusBadRequest {
+		var p problem.Problem
+		if err := json.Unmarshal(record.ResponseBody, &p); err != nil {
+			return nil, fail(problem.CodeInternal, "Failed to read stored response")
+		}
+		return nil, failWith(&p)
+	}
+
+	resp := &walletpb.MovementResponse{}
+	if err := protojson.Unmarshal(record.ResponseBody, resp); err != nil {
+		return nil, fail(problem.CodeInternal, "Failed to read stored response")
+	}
+	return resp, nil
+}
+
+// requireOTP demands a fresh one-time code in x-otp, like RequireOTP and
+// RequireOTPAbove. API keys pass, held instead to their scopes and limits.
+func (g *Guard) requireOTP(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	policy := rules[info.FullMethod].stepUp
+	c := callerFrom(ctx)
+	if policy == stepUpNever || c.APIKey != nil {
+		return handler(ctx, req)
+	}
+
+	if policy == stepUpAboveThreshold {
+		amount, ok, err := requestAmount(req)
+		if err != nil {
+			return nil, err
+		}
+		if r, isWallet := req.(walletRequest); ok && isWallet {
+			if walletID, err := uuid.Parse(r.GetWalletId()); err == nil {
+				exempt, err := g.stepUpThresholds.Exempt(walletID, amount)
+				if err != nil {
+					return nil, fail(problem.CodeInternal, "Failed to get wallet")
+				}
+				if exempt {
+					return handler(ctx, req)
+				}
+			}
+		}
+	}
+
+	enabled, err := g.twoFactor.Enabled(ctx, c.UserID)
+	if err != nil {
+		return nil, fail(problem.CodeInternal, "Failed to check two-factor status")
+	}
+	if !enabled {
+		return handler(ctx, req)
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	code := firstValue(md, otpKey)
+	if code == "" {
+		return nil, failWith(problem.New(problem.CodeOTPRequired, "One-time code required in x-otp metadata").With("otp_required", true))
+	}
+
+	err = g.twoFactor.Verify(ctx, c.UserID, code)
+	switch {
+	case errors.Is(err, twofactor.ErrTooManyAttempts):
+		return nil, fail(problem.CodeRateLimited, "Too many one-time code attempts, try again later")
+	case errors.Is(err, twofactor.ErrInvalidCode):
+		return nil, failWith(problem.New(problem.CodeOTPInvalid, "Invalid or already used one-time code").With("otp_required", true))
+	case err != nil:
+		return nil, fail(problem.CodeInternal, "Failed to verify one-time code")
+	}
+
+	return handler(ctx, req)
+}
+
+// requestAmount parses the amount of requests that carry one. ok is false
+// for requests without an amount field.
+func requestAmount(req interface{}) (amount decimal.Decimal, ok bool, err error) {
+	r, isAmount := req.(amountRequest)
+	if !isAmount {
+		return decimal.Zero, false, nil
+	}
+	amount, err = parseAmount(r.GetAmount())
+	if err != nil {
+		return decimal.Zero, false, err
+	}
+	return amount, true, nil
+}
+
+// parseAmount reads a decimal string exactly; floats never carry money
+func parseAmount(raw string) (decimal.Decimal, error) {
+	amount, err := decimal.NewFromString(raw)
+	if err != nil {
+		return decimal.Zero, failWith(problem.New(problem.CodeValidationFailed, "Invalid request").
+			Field("amount", "decimal", "amount must be a decimal string such as \"0.5\""))
+	}
+	return amount, nil
+}
+
+// requestFingerprint identifies the call a key was first used for
+func requestFingerprint(method string, req interface{}) (string, error) {
+	message, ok := req.(proto.Message)
+	if !ok {
+		return "", errors.New("request is not a protobuf message")
+	}
+	body, err := proto.MarshalOptions{Deterministic: true}.Marshal(message)
+	if err != nil {
+		return "", err
+	}
+
+	h := sha256.New()
+	h.Write([]byte(method))
+	h.Write([]byte{0})
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func firstValue(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// peerIP is the address the call came from, without its port
+func peerIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+	return host
+}
@@ -0,0 +1,72 @@
+package grpcapi
+
+import (
+	"context"
+
+	"wallet-service/internal/middleware"
+	"wallet-service/internal/problem"
+
+	"google.golang.org/grpc"
+)
+
+// limitByIP spends the API policy per client IP before authentication, so
+// guessing credentials costs budget like any other call
+func (g *Guard) limitByIP(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if err := g.take(ctx, g.apiLimit, middleware.IPKey(peerIP(ctx))); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (g *Guard) limitStreamByIP(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if err := g.take(stream.Context(), g.apiLimit, middleware.IPKey(peerIP(stream.Context()))); err != nil {
+		return err
+	}
+	return handler(srv, stream)
+}
+
+// limit spends the API policy per caller and, for money movements, the money
+// policy too, like the REST routes' apiLimit and moneyLimit. It runs before
+// auditing, idempotency and step-up codes, so a flood of guesses is stopped
+// before any of them.
+func (g *Guard) limit(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if err := g.limitCaller(ctx, info.FullMethod); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (g *Guard) limitStream(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if err := g.limitCaller(stream.Context(), info.FullMethod); err != nil {
+		return err
+	}
+	return handler(srv, stream)
+}
+
+func (g *Guard) limitCaller(ctx context.Context, method string) error {
+	c := callerFrom(ctx)
+	key := middleware.CallerKey(c.APIKey, c.UserID.String())
+
+	if err := g.take(ctx, g.apiLimit, key); err != nil {
+		return err
+	}
+	if rules[method].moneyLimited {
+		return g.take(ctx, g.moneyLimit, key)
+	}
+	return nil
+}
+
+// take spends one token of the policy's bucket for key. An empty bucket is
+// answered with RATE_LIMITED and the seconds to wait in retry_after; an
+// unreachable Redis lets the call through, as on the REST routes.
+func (g *Guard) take(ctx context.Context, policy middleware.RateLimitPolicy, key string) error {
+	if g.limiter == nil || !policy.Enabled() {
+		return nil
+	}
+
+	result, err := g.limiter.Allow(ctx, policy, key)
+	if err != nil || result.Allowed {
+		return nil
+	}
+	return failWith(problem.New(problem.CodeRateLimited, "Too many requests").With("retry_after", result.RetryAfter))
+}
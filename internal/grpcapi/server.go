@@ -0,0 +1,365 @@
+// Package grpcapi serves the wallet API over gRPC for internal services. It is
+// a second frontend of service.WalletService next to the REST handlers, with
+// the same credentials, rules, audit log and idempotency store; Guard applies
+// them as interceptors.
+package grpcapi
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"wallet-service/internal/apikey"
+	"wallet-service/internal/grpcapi/walletpb"
+	"wallet-service/internal/models"
+	"wallet-service/internal/problem"
+	"wallet-service/internal/repository"
+	"wallet-service/internal/service"
+	"wallet-service/internal/stream"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Server implements walletpb.WalletServiceServer
+type Server struct {
+	walletpb.UnimplementedWalletServiceServer
+
+	wallets    *service.WalletService
+	walletRepo repository.IWalletRepository
+	outboxRepo repository.IOutboxRepository
+	hub        *stream.Hub
+	guard      *Guard
+	// How often a stream re-checks that its credential is still valid
+	recheck time.Duration
+}
+
+func NewServer(wallets *service.WalletService, walletRepo repository.IWalletRepository, outboxRepo repository.IOutboxRepository, hub *stream.Hub, guard *Guard, recheck time.Duration) *Server {
+	return &Server{
+		wallets:    wallets,
+		walletRepo: walletRepo,
+		outboxRepo: outboxRepo,
+		hub:        hub,
+		guard:      guard,
+		recheck:    recheck,
+	}
+}
+
+// NewGRPCServer builds a gRPC server with the guard's interceptors and the
+// wallet service registered
+func NewGRPCServer(server *Server) *grpc.Server {
+	grpcServer := grpc.NewServer(server.guard.ServerOptions()...)
+	walletpb.RegisterWalletServiceServer(grpcServer, server)
+	return grpcServer
+}
+
+func (s *Server) ListWallets(ctx context.Context, req *walletpb.ListWalletsRequest) (*walletpb.ListWalletsResponse, error) {
+	c := callerFrom(ctx)
+
+	wallets, err := s.wallets.ListWallets(c.UserID, req.IncludeClosed)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &walletpb.ListWalletsResponse{UserId: c.UserID.String()}
+	for i := range wallets {
+		// A wallet-restricted API key only sees its wallets
+		if c.APIKey != nil && !apikey.AllowsWallet(c.APIKey, wallets[i].ID) {
+			continue
+		}
+		response.Wallets = append(response.Wallets, walletMessage(&wallets[i]))
+	}
+	return response, nil
+}
+
+func (s *Server) GetBalance(ctx context.Context, req *walletpb.GetBalanceRequest) (*walletpb.Balance, error) {
+	walletID, err := parseWalletID(req.WalletId)
+	if err != nil {
+		return nil, err
+	}
+
+	balance, err := s.wallets.Balance(callerFrom(ctx).UserID, walletID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &walletpb.Balance{
+		WalletId:     balance.WalletID.String(),
+		CoinType:     string(balance.CoinType),
+		Amount:       balance.Amount.String(),
+		FrozenAmount: balance.FrozenAmount.String(),
+		Status:       string(balance.Status),
+	}, nil
+}
+
+func (s *Server) Deposit(ctx context.Context, req *walletpb.DepositRequest) (*walletpb.MovementResponse, error) {
+	walletID, err := parseWalletID(req.WalletId)
+	if err != nil {
+		return nil, err
+	}
+	amount, err := parseAmount(req.Amount)
+	if err != nil {
+		return nil, err
+	}
+
+	receipt, err := s.wallets.Deposit(ctx, callerFrom(ctx).UserID, walletID, amount, claim(ctx))
+	if err != nil {
+		return nil, err
+	}
+	return movementResponse(receipt), nil
+}
+
+// Withdraw debits the wallet. Large withdrawals are held for review instead
+// and answered with the PENDING transaction.
+func (s *Server) Withdraw(ctx context.Context, req *walletpb.WithdrawRequest) (*walletpb.MovementResponse, error) {
+	walletID, err := parseWalletID(req.WalletId)
+	if err != nil {
+		return nil, err
+	}
+	amount, err := parseAmount(req.Amount)
+	if err != nil {
+		return nil, err
+	}
+
+	receipt, err := s.wallets.Withdraw(ctx, callerFrom(ctx).UserID, walletID, amount, claim(ctx))
+	if err != nil {
+		return nil, err
+	}
+	return movementResponse(receipt), nil
+}
+
+func (s *Server) Transfer(ctx context.Context, req *walletpb.TransferRequest) (*walletpb.MovementResponse, error) {
+	walletID, err := parseWalletID(req.WalletId)
+	if err != nil {
+		return nil, err
+	}
+	receiverWalletID, err := uuid.Parse(req.ReceiverWalletId)
+	if err != nil {
+		return nil, fail(problem.CodeInvalidRequest, "Invalid receiver wallet ID")
+	}
+	amount, err := parseAmount(req.Amount)
+	if err != nil {
+		return nil, err
+	}
+
+	receipt, err := s.wallets.Transfer(ctx, callerFrom(ctx).UserID, walletID, receiverWalletID, amount, claim(ctx))
+	if err != nil {
+		return nil, err
+	}
+	return movementResponse(receipt), nil
+}
+
+func (s *Server) GetHistory(ctx context.Context, req *walletpb.GetHistoryRequest) (*walletpb.GetHistoryResponse, error) {
+	walletID, err := parseWalletID(req.WalletId)
+	if err != nil {
+		return nil, err
+	}
+
+	var historyReq models.TransactionHistoryRequest
+	if req.StartDate != nil {
+		startDate := req.StartDate.AsTime()
+		historyReq.StartDate = &startDate
+	}
+	if req.EndDate != nil {
+		endDate := req.EndDate.AsTime()
+		historyReq.EndDate = &endDate
+	}
+	if req.CounterpartyWalletId != "" {
+		counterpartyID, err := uuid.Parse(req.CounterpartyWalletId)
+		if err != nil {
+			return nil, fail(problem.CodeInvalidRequest, "Invalid counterparty wallet ID")
+		}
+		historyReq.CounterpartyWalletID = &counterpartyID
+	}
+	if req.Limit > 0 {
+		limit := int(req.Limit)
+		historyReq.Limit = &limit
+	}
+	if req.Offset > 0 {
+		offset := int(req.Offset)
+		historyReq.Offset = &offset
+	}
+
+	history, err := s.wallets.History(callerFrom(ctx).UserID, walletID, &historyReq)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &walletpb.GetHistoryResponse{Total: int32(history.Total)}
+	for i := range history.Transactions {
+		response.Transactions = append(response.Transactions, entryMessage(&history.Transactions[i]))
+	}
+	return response, nil
+}
+
+// StreamTransactions sends the events of the caller's wallets as they are
+// published, first replaying those after after_sequence, like the SSE stream
+func (s *Server) StreamTransactions(req *walletpb.StreamTransactionsRequest, srv walletpb.WalletService_StreamTransactionsServer) error {
+	ctx := srv.Context()
+	c := callerFrom(ctx)
+
+	wallets, err := s.walletRepo.GetByUserID(c.UserID)
+	if err != nil {
+		return fail(problem.CodeInternal, "Failed to get user wallets")
+	}
+
+	walletIDs := make([]uuid.UUID, 0, len(wallets))
+	for _, wallet := range wallets {
+		// A wallet-restricted API key only hears of its wallets
+		if c.APIKey == nil || apikey.AllowsWallet(c.APIKey, wallet.ID) {
+			walletIDs = append(walletIDs, wallet.ID)
+		}
+	}
+	// Live events of wallets opened later still reach unrestricted callers
+	filtered := c.APIKey != nil && len(c.APIKey.WalletIDs) > 0
+
+	// Optionally narrow the stream to one of the caller's wallets
+	if req.WalletId != "" {
+		walletID, err := parseWalletID(req.WalletId)
+		if err != nil {
+			return err
+		}
+		if !containsWallet(walletIDs, walletID) {
+			return fail(problem.CodeForbidden, "Access denied")
+		}
+		walletIDs = []uuid.UUID{walletID}
+		filtered = true
+	}
+
+	// Subscribe before replaying so nothing published in between is lost;
+	// the cursor drops what arrives both ways
+	sub := s.hub.Subscribe(c.UserID)
+	defer s.hub.Unsubscribe(sub)
+
+	cursor := stream.NewCursor(req.AfterSequence)
+	if req.AfterSequence > 0 {
+		var sendErr error
+		err := stream.Replay(s.outboxRepo, cursor, walletIDs, func(event *models.OutboxEvent) error {
+			sendErr = srv.Send(eventMessage(event))
+			return sendErr
+		})
+		if sendErr != nil {
+			return sendErr
+		}
+		if err != nil {
+			return fail(problem.CodeInternal, "Failed to replay events")
+		}
+	}
+
+	ticker := time.NewTicker(s.recheck)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case event, ok := <-sub.Events:
+			if !ok {
+				// Dropped for falling behind; the client resumes with after_sequence
+				return fail(problem.CodeConflict, "Stream fell behind; reconnect with after_sequence")
+			}
+			if filtered && !containsWallet(walletIDs, event.AggregateID) {
+				continue
+			}
+			if !cursor.Admit(&event) {
+				continue
+			}
+			if err := srv.Send(eventMessage(&event)); err != nil {
+				return err
+			}
+
+		case <-ticker.C:
+			// Re-check the credential so a revoked session or key stops streaming
+			if err := s.guard.stillValid(ctx, c); err != nil {
+				return fail(problem.CodeUnauthenticated, "Credential no longer valid")
+			}
+		}
+	}
+}
+
+// claim stores the response of a money movement under the call's
+// idempotency key in the movement's own transaction, so it is kept exactly
+// when the ledger change commits
+func claim(ctx context.Context) service.Claim {
+	pending := pendingFrom(ctx)
+	return func(ctx context.Context, tx *sql.Tx, receipt *service.Receipt) error {
+		body, err := protojson.Marshal(movementResponse(receipt))
+		if err != nil {
+			return err
+		}
+		return pending.Record(ctx, tx, http.StatusOK, json.RawMessage(body))
+	}
+}
+
+func parseWalletID(raw string) (uuid.UUID, error) {
+	walletID, err := uuid.Parse(raw)
+	if err != nil {
+		return uuid.Nil, fail(problem.CodeInvalidRequest, "Invalid wallet ID")
+	}
+	return walletID, nil
+}
+
+func containsWallet(walletIDs []uuid.UUID, walletID uuid.UUID) bool {
+	for _, id := range walletIDs {
+		if id == walletID {
+			return true
+		}
+	}
+	return false
+}
+
+func movementResponse(receipt *service.Receipt) *walletpb.MovementResponse {
+	return &walletpb.MovementResponse{
+		TransactionId: receipt.TransactionID.String(),
+		Type:          string(receipt.Type),
+		Status:        string(receipt.Status),
+		Amount:        receipt.Amount.String(),
+	}
+}
+
+func walletMessage(wallet *models.Wallet) *walletpb.Wallet {
+	message := &walletpb.Wallet{
+		Id:           wallet.ID.String(),
+		UserId:       wallet.UserID.String(),
+		CoinType:     string(wallet.CoinType),
+		Amount:       wallet.Amount.String(),
+		FrozenAmount: wallet.FrozenAmount.String(),
+		Status:       string(wallet.Status),
+		CreatedAt:    timestamppb.New(wallet.CreatedAt),
+	}
+	if wallet.ClosedAt != nil {
+		message.ClosedAt = timestamppb.New(*wallet.ClosedAt)
+	}
+	return message
+}
+
+func entryMessage(entry *models.TransactionEntry) *walletpb.TransactionEntry {
+	message := &walletpb.TransactionEntry{
+		Id:        entry.ID.String(),
+		TxnId:     entry.TxnID.String(),
+		WalletId:  entry.WalletID.String(),
+		Direction: string(entry.Direction),
+		Amount:    entry.Amount.String(),
+		CreatedAt: timestamppb.New(entry.CreatedAt),
+	}
+	if entry.CounterpartyWalletID != nil {
+		message.CounterpartyWalletId = entry.CounterpartyWalletID.String()
+	}
+	return message
+}
+
+func eventMessage(event *models.OutboxEvent) *walletpb.TransactionEvent {
+	return &walletpb.TransactionEvent{
+		Sequence:   event.PublishSequence,
+		Id:         event.ID.String(),
+		Type:       string(event.EventType),
+		WalletId:   event.AggregateID.String(),
+		Payload:    string(event.Payload),
+		OccurredAt: timestamppb.New(event.CreatedAt),
+	}
+}
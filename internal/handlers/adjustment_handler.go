@@ -0,0 +1,245 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"wallet-service/internal/models"
+	"wallet-service/internal/problem"
+	"wallet-service/internal/repository"
+	"wallet-service/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+var (
+	errAdjustmentNotFound   = errors.New("adjustment not found")
+	errAdjustmentNotPending = errors.New("adjustment already reviewed")
+	errAdjustmentExpired    = errors.New("adjustment expired")
+	errSelfApproval         = errors.New("adjustment approved by its requester")
+)
+
+// AdjustmentHandler lets staff correct balances under maker-checker control:
+// one staff member files an adjustment, and a different admin approves it
+// before it books anything
+type AdjustmentHandler struct {
+	adjustmentRepo repository.IAdjustmentRepository
+	// wallets books approved adjustments with the same locking and events as
+	// user-initiated money movements
+	wallets *service.WalletService
+	// expiry expires an overdue adjustment met on review, audited like the
+	// background sweep
+	expiry    *service.AdjustmentExpiry
+	txManager repository.ITransactionManager
+	ttl       time.Duration
+}
+
+// NewAdjustmentHandler leaves adjustments open for approval for ttl
+func NewAdjustmentHandler(adjustmentRepo repository.IAdjustmentRepository, wallets *service.WalletService, expiry *service.AdjustmentExpiry, txManager repository.ITransactionManager, ttl time.Duration) *AdjustmentHandler {
+	return &AdjustmentHandler{
+		adjustmentRepo: adjustmentRepo,
+		wallets:        wallets,
+		expiry:         expiry,
+		txManager:      txManager,
+		ttl:            ttl,
+	}
+}
+
+func (h *AdjustmentHandler) CreateAdjustment(c *gin.Context) {
+	staffID, ok := namedStaffID(c)
+	if !ok {
+		return
+	}
+
+	var req models.CreateAdjustmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		problem.Invalid(c, "Invalid request body", err)
+		return
+	}
+
+	wallet, err := h.wallets.Wallet(req.WalletID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if wallet.Status == models.WalletStatusClosed {
+		c.Error(&service.WalletNotActiveError{WalletID: wallet.ID, Status: wallet.Status})
+		return
+	}
+
+	adjustment := &models.BalanceAdjustment{
+		ID:          uuid.New(),
+		WalletID:    wallet.ID,
+		Direction:   req.Direction,
+		Amount:      req.Amount,
+		Reason:      req.Reason,
+		TicketRef:   req.TicketRef,
+		RequestedBy: staffID,
+		ExpiresAt:   time.Now().UTC().Add(h.ttl),
+	}
+	if err := h.adjustmentRepo.Create(c.Request.Context(), adjustment); err != nil {
+		problem.Respond(c, problem.CodeInternal, "Failed to create adjustment")
+		return
+	}
+
+	c.JSON(http.StatusCreated, adjustment)
+}
+
+// ListAdjustments returns adjustments newest first; ?status=PENDING is the
+// approval queue
+func (h *AdjustmentHandler) ListAdjustments(c *gin.Context) {
+	status := models.AdjustmentStatus(c.Query("status"))
+	switch status {
+	case "", models.AdjustmentStatusPending, models.AdjustmentStatusExecuted, models.AdjustmentStatusRejected, models.AdjustmentStatusExpired:
+	default:
+		problem.Respond(c, problem.CodeInvalidRequest, "Invalid status")
+		return
+	}
+
+	limit, offset := pagination(c, 20, 100)
+	adjustments, total, err := h.adjustmentRepo.List(c.Request.Context(), status, limit, offset)
+	if err != nil {
+		problem.Respond(c, problem.CodeInternal, "Failed to list adjustments")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.AdjustmentListResponse{Adjustments: adjustments, Total: total})
+}
+
+func (h *AdjustmentHandler) GetAdjustment(c *gin.Context) {
+	adjustmentID, ok := adjustmentIDParam(c)
+	if !ok {
+		return
+	}
+
+	adjustment, err := h.adjustmentRepo.GetByID(c.Request.Context(), adjustmentID)
+	if err != nil {
+		problem.Respond(c, problem.CodeInternal, "Failed to get adjustment")
+		return
+	}
+	if adjustment == nil {
+		respondAdjustmentError(c, errAdjustmentNotFound)
+		return
+	}
+
+	c.JSON(http.StatusOK, adjustment)
+}
+
+// ApproveAdjustment books the adjustment as an ADJUSTMENT transaction. The
+// approver must be an admin other than the requester, and the approval, the
+// balance change and its events commit together.
+func (h *AdjustmentHandler) ApproveAdjustment(c *gin.Context) {
+	h.review(c, true)
+}
+
+func (h *AdjustmentHandler) RejectAdjustment(c *gin.Context) {
+	h.review(c, false)
+}
+
+func (h *AdjustmentHandler) review(c *gin.Context, approve bool) {
+	reviewerID, ok := namedStaffID(c)
+	if !ok {
+		return
+	}
+	adjustmentID, ok := adjustmentIDParam(c)
+	if !ok {
+		return
+	}
+
+	// The body is optional on approval
+	var req models.ReviewAdjustmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		problem.Invalid(c, "Invalid request body", err)
+		return
+	}
+	if !approve && req.Reason == "" {
+		problem.Write(c, problem.New(problem.CodeValidationFailed, "Reason required to reject").Field("reason", "required", "is required"))
+		return
+	}
+
+	expired := false
+	err := h.txManager.ExecuteTransaction(c.Request.Context(), func(ctx context.Context, tx *sql.Tx) error {
+		adjustment, err := h.adjustmentRepo.GetByIDForUpdate(ctx, tx, adjustmentID)
+		if err != nil {
+			return err
+		}
+		if adjustment == nil {
+			return errAdjustmentNotFound
+		}
+		if adjustment.Status != models.AdjustmentStatusPending {
+			return errAdjustmentNotPending
+		}
+		// Expiry is recorded, so commit rather than roll back
+		if !adjustment.ExpiresAt.After(time.Now().UTC()) {
+			expired = true
+			return h.expiry.Expire(ctx, tx, adjustment)
+		}
+
+		if !approve {
+			return h.adjustmentRepo.Review(ctx, tx, adjustment.ID, models.AdjustmentStatusRejected, &reviewerID, req.Reason, nil)
+		}
+
+		if adjustment.RequestedBy == reviewerID {
+			return errSelfApproval
+		}
+		transactionID, err := h.wallets.BookAdjustment(ctx, tx, adjustment.WalletID, adjustment.Direction, adjustment.Amount)
+		if err != nil {
+			return err
+		}
+		return h.adjustmentRepo.Review(ctx, tx, adjustment.ID, models.AdjustmentStatusExecuted, &reviewerID, req.Reason, &transactionID)
+	})
+	if expired {
+		err = errAdjustmentExpired
+	}
+	if err != nil {
+		respondAdjustmentError(c, err)
+		return
+	}
+
+	adjustment, err := h.adjustmentRepo.GetByID(c.Request.Context(), adjustmentID)
+	if err != nil || adjustment == nil {
+		problem.Respond(c, problem.CodeInternal, "Failed to get adjustment")
+		return
+	}
+	c.JSON(http.StatusOK, adjustment)
+}
+
+// namedStaffID returns the logged-in staff member. The shared admin token
+// names nobody, so it can neither file nor review an adjustment.
+func namedStaffID(c *gin.Context) (uuid.UUID, bool) {
+	staffID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		problem.Respond(c, problem.CodeForbidden, "Adjustments need a personal staff login")
+		return uuid.Nil, false
+	}
+	return staffID, true
+}
+
+func adjustmentIDParam(c *gin.Context) (uuid.UUID, bool) {
+	adjustmentID, err := uuid.Parse(c.Param("adjustment_id"))
+	if err != nil {
+		problem.Respond(c, problem.CodeInvalidRequest, "Invalid adjustment ID")
+		return uuid.Nil, false
+	}
+	return adjustmentID, true
+}
+
+func respondAdjustmentError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, errAdjustmentNotFound):
+		problem.Respond(c, problem.CodeNotFound, "Adjustment not found")
+	case errors.Is(err, errAdjustmentNotPending):
+		problem.Respond(c, problem.CodeAlreadyReviewed, "Adjustment already reviewed")
+	case errors.Is(err, errAdjustmentExpired):
+		problem.Respond(c, problem.CodeAdjustmentExpired, "Adjustment expired")
+	case errors.Is(err, errSelfApproval):
+		problem.Respond(c, problem.CodeSelfApproval, "A different admin must approve this adjustment")
+	default:
+		c.Error(err)
+	}
+}
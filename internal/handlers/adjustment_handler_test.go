@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"wallet-service/internal/models"
+	"wallet-service/internal/repository"
+	"wallet-service/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+func TestAdjustmentHandler_CreateAdjustment(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	walletRepo := repository.NewMockWalletRepository()
+	adjustmentRepo := repository.NewMockAdjustmentRepository()
+	handler := NewAdjustmentHandler(adjustmentRepo, service.NewWalletService(walletRepo, nil, nil, nil, nil, nil, nil, nil), nil, nil, time.Hour)
+
+	wallet := &models.Wallet{ID: uuid.New(), UserID: uuid.New(), CoinType: models.CoinTypeBTC, Amount: decimal.NewFromInt(5)}
+	walletRepo.Create(context.Background(), nil, wallet)
+	body := `{"wallet_id": "` + wallet.ID.String() + `", "amount": "2.5", "direction": "IN", "reason": "Deposit lost by the node", "ticket_ref": "OPS-42"}`
+
+	create := func(staffID string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodPost, "/admin/adjustments", bytes.NewBufferString(body))
+		c.Request.Header.Set("Content-Type", "application/json")
+		if staffID != "" {
+			c.Set("user_id", staffID)
+		}
+		handler.CreateAdjustment(c)
+		return w
+	}
+
+	// The shared admin token names nobody, so it cannot file an adjustment
+	if w := create(""); w.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 for the admin token, got %d", w.Code)
+	}
+
+	staffID := uuid.New()
+	if w := create(staffID.String()); w.Code != http.StatusCreated {
+		t.Fatalf("Expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+
+	pending, total, _ := adjustmentRepo.List(context.Background(), models.AdjustmentStatusPending, 10, 0)
+	if total != 1 || pending[0].RequestedBy != staffID || !pending[0].Amount.Equal(decimal.RequireFromString("2.5")) {
+		t.Errorf("Expected one pending adjustment filed by the staff member, got %+v", pending)
+	}
+
+	// Filing an adjustment moves no money
+	if stored, _ := walletRepo.GetByID(wallet.ID); !stored.Amount.Equal(decimal.NewFromInt(5)) {
+		t.Errorf("Expected balance untouched until approval, got %s", stored.Amount)
+	}
+}
+
+func TestAdjustmentHandler_ReadsDoNotExpire(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	adjustmentRepo := repository.NewMockAdjustmentRepository()
+	handler := NewAdjustmentHandler(adjustmentRepo, nil, nil, nil, time.Hour)
+
+	overdue := &models.BalanceAdjustment{ID: uuid.New(), ExpiresAt: time.Now().Add(-time.Minute)}
+	adjustmentRepo.Create(context.Background(), overdue)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/admin/adjustments", nil)
+	handler.ListAdjustments(c)
+
+	w = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/admin/adjustments/"+overdue.ID.String(), nil)
+	c.Params = gin.Params{{Key: "adjustment_id", Value: overdue.ID.String()}}
+	handler.GetAdjustment(c)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// Expiry is left to the audited sweep
+	if overdue.Status != models.AdjustmentStatusPending {
+		t.Errorf("Expected reads to leave the adjustment pending, got %s", overdue.Status)
+	}
+}
+
+func TestRespondAdjustmentError(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		err    error
+		status int
+	}{
+		{errAdjustmentNotFound, http.StatusNotFound},
+		{errAdjustmentNotPending, http.StatusConflict},
+		{errAdjustmentExpired, http.StatusConflict},
+		{errSelfApproval, http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		respondAdjustmentError(c, tt.err)
+		if w.Code != tt.status {
+			t.Errorf("%v: expected %d, got %d", tt.err, tt.status, w.Code)
+		}
+	}
+
+	// Ledger errors are left to the error middleware
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	respondAdjustmentError(c, service.ErrInsufficientFunds)
+	if c.Writer.Written() || len(c.Errors) == 0 || !errors.Is(c.Errors.Last().Err, service.ErrInsufficientFunds) {
+		t.Errorf("Expected ErrInsufficientFunds reported with c.Error, got %v", c.Errors)
+	}
+}
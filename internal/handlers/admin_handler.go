@@ -0,0 +1,271 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"wallet-service/internal/accountstatus"
+	"wallet-service/internal/models"
+	"wallet-service/internal/problem"
+	"wallet-service/internal/repository"
+	"wallet-service/internal/session"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// AdminHandler serves staff lookups of any user's data. Ownership is not
+// checked here; the admin routes are gated by role instead.
+type AdminHandler struct {
+	userRepo         repository.IUserRepository
+	walletRepo       repository.IWalletRepository
+	transactionRepo  repository.ITransactionRepository
+	refreshTokenRepo repository.IRefreshTokenRepository
+	txManager        *repository.TransactionManager
+	sessions         *session.Store
+	statuses         *accountstatus.Cache
+}
+
+func NewAdminHandler(userRepo repository.IUserRepository, walletRepo repository.IWalletRepository, transactionRepo repository.ITransactionRepository, refreshTokenRepo repository.IRefreshTokenRepository, txManager *repository.TransactionManager, sessions *session.Store, statuses *accountstatus.Cache) *AdminHandler {
+	return &AdminHandler{
+		userRepo:         userRepo,
+		walletRepo:       walletRepo,
+		transactionRepo:  transactionRepo,
+		refreshTokenRepo: refreshTokenRepo,
+		txManager:        txManager,
+		sessions:         sessions,
+		statuses:         statuses,
+	}
+}
+
+func (h *AdminHandler) GetUser(c *gin.Context) {
+	user, ok := h.lookupUser(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+func (h *AdminHandler) GetUserWallets(c *gin.Context) {
+	user, ok := h.lookupUser(c)
+	if !ok {
+		return
+	}
+
+	wallets, err := h.walletRepo.GetByUserID(user.ID)
+	if err != nil {
+		problem.Respond(c, problem.CodeInternal, "Failed to get user wallets")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.UserWalletsResponse{
+		UserID:  user.ID,
+		Wallets: wallets,
+	})
+}
+
+func (h *AdminHandler) GetWalletTransactions(c *gin.Context) {
+	walletID, err := uuid.Parse(c.Param("wallet_id"))
+	if err != nil {
+		problem.Respond(c, problem.CodeInvalidRequest, "Invalid wallet ID")
+		return
+	}
+
+	wallet, err := h.walletRepo.GetByID(walletID)
+	if err != nil {
+		problem.Respond(c, problem.CodeInternal, "Failed to get wallet")
+		return
+	}
+
+	if wallet == nil {
+		problem.Respond(c, problem.CodeWalletNotFound, "Wallet not found")
+		return
+	}
+
+	limit, offset := pagination(c, 20, 100)
+	req := models.TransactionHistoryRequest{
+		Limit:  &limit,
+		Offset: &offset,
+	}
+
+	response, err := h.transactionRepo.GetTransactionHistory(walletID, &req)
+	if err != nil {
+		problem.Respond(c, problem.CodeInternal, "Failed to get transaction history")
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// UpdateUserRole grants or withdraws a role. The user's refresh tokens and
+// sessions are revoked so no token carrying the old role outlives the change.
+func (h *AdminHandler) UpdateUserRole(c *gin.Context) {
+	var req models.UpdateRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		problem.Invalid(c, "Invalid request body", err)
+		return
+	}
+
+	user, ok := h.lookupUser(c)
+	if !ok {
+		return
+	}
+
+	// An admin cannot lock themselves out
+	if c.GetString("user_id") == user.ID.String() {
+		problem.Respond(c, problem.CodeForbidden, "Cannot change your own role")
+		return
+	}
+
+	err := h.txManager.ExecuteTransaction(c.Request.Context(), func(ctx context.Context, tx *sql.Tx) error {
+		if err := h.userRepo.UpdateRole(ctx, tx, user.ID, req.Role); err != nil {
+			return err
+		}
+		return h.refreshTokenRepo.RevokeForUser(ctx, tx, user.ID)
+	})
+	if err != nil {
+		problem.Respond(c, problem.CodeInternal, "Failed to update role")
+		return
+	}
+
+	if _, err := h.sessions.RevokeAll(c.Request.Context(), user.ID); err != nil {
+		log.Printf("Failed to revoke sessions of user %s: %v", user.ID, err)
+	}
+
+	user.Role = req.Role
+	c.JSON(http.StatusOK, user)
+}
+
+// UpdateUserStatus suspends, locks or reinstates a user. Taking an account out
+// of active also revokes its refresh tokens and sessions, so the user has to
+// log in again and meets the status check at once.
+func (h *AdminHandler) UpdateUserStatus(c *gin.Context) {
+	var req models.UpdateUserStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		problem.Invalid(c, "Invalid request body", err)
+		return
+	}
+
+	user, ok := h.lookupUser(c)
+	if !ok {
+		return
+	}
+
+	if c.GetString("user_id") == user.ID.String() {
+		problem.Respond(c, problem.CodeForbidden, "Cannot change your own status")
+		return
+	}
+
+	revoke := req.Status != models.UserStatusActive
+	err := h.txManager.ExecuteTransaction(c.Request.Context(), func(ctx context.Context, tx *sql.Tx) error {
+		if err := h.userRepo.UpdateStatus(ctx, tx, user.ID, req.Status, req.Reason); err != nil {
+			return err
+		}
+		if !revoke {
+			return nil
+		}
+		return h.refreshTokenRepo.RevokeForUser(ctx, tx, user.ID)
+	})
+	if err != nil {
+		problem.Respond(c, problem.CodeInternal, "Failed to update status")
+		return
+	}
+
+	h.statuses.Set(c.Request.Context(), user.ID, req.Status)
+	if revoke {
+		if _, err := h.sessions.RevokeAll(c.Request.Context(), user.ID); err != nil {
+			log.Printf("Failed to revoke sessions of user %s: %v", user.ID, err)
+		}
+	}
+
+	updated, err := h.userRepo.GetByID(user.ID)
+	if err != nil || updated == nil {
+		problem.Respond(c, problem.CodeInternal, "Failed to get user")
+		return
+	}
+	c.JSON(http.StatusOK, updated)
+}
+
+// FreezeWallet stops an active wallet from moving money until it is unfrozen
+func (h *AdminHandler) FreezeWallet(c *gin.Context) {
+	h.changeWalletStatus(c, models.WalletStatusActive, models.WalletStatusFrozen)
+}
+
+func (h *AdminHandler) UnfreezeWallet(c *gin.Context) {
+	h.changeWalletStatus(c, models.WalletStatusFrozen, models.WalletStatusActive)
+}
+
+// changeWalletStatus moves the :wallet_id wallet from one status to another.
+// The reason is required so the audit log says why.
+func (h *AdminHandler) changeWalletStatus(c *gin.Context, from, to models.WalletStatus) {
+	walletID, err := uuid.Parse(c.Param("wallet_id"))
+	if err != nil {
+		problem.Respond(c, problem.CodeInvalidRequest, "Invalid wallet ID")
+		return
+	}
+
+	var req models.WalletStatusChangeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		problem.Invalid(c, "Invalid request body", err)
+		return
+	}
+
+	var wallet *models.Wallet
+	err = h.txManager.ExecuteTransaction(c.Request.Context(), func(ctx context.Context, tx *sql.Tx) error {
+		var err error
+		wallet, err = h.walletRepo.GetByIDForUpdate(ctx, tx, walletID)
+		if err != nil || wallet == nil || wallet.Status != from {
+			return err
+		}
+
+		if err := h.walletRepo.UpdateStatus(ctx, tx, wallet.ID, to); err != nil {
+			return err
+		}
+		wallet.Status = to
+		return nil
+	})
+	if err != nil {
+		problem.Respond(c, problem.CodeInternal, "Failed to update wallet status")
+		return
+	}
+
+	if wallet == nil {
+		problem.Respond(c, problem.CodeWalletNotFound, "Wallet not found")
+		return
+	}
+
+	if wallet.Status != to {
+		problem.Respond(c, problem.CodeWalletNotActive, fmt.Sprintf("Wallet is %s", strings.ToLower(string(wallet.Status))))
+		return
+	}
+
+	c.JSON(http.StatusOK, wallet)
+}
+
+// lookupUser loads the user named by the :user_id parameter, writing the error
+// response itself when there is none
+func (h *AdminHandler) lookupUser(c *gin.Context) (*models.User, bool) {
+	userID, err := uuid.Parse(c.Param("user_id"))
+	if err != nil {
+		problem.Respond(c, problem.CodeInvalidRequest, "Invalid user ID")
+		return nil, false
+	}
+
+	user, err := h.userRepo.GetByID(userID)
+	if err != nil {
+		problem.Respond(c, problem.CodeInternal, "Failed to get user")
+		return nil, false
+	}
+
+	if user == nil {
+		problem.Respond(c, problem.CodeNotFound, "User not found")
+		return nil, false
+	}
+
+	return user, true
+}
@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"wallet-service/internal/apikey"
+	"wallet-service/internal/models"
+	"wallet-service/internal/problem"
+	"wallet-service/internal/repository"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type APIKeyHandler struct {
+	apiKeyRepo repository.IAPIKeyRepository
+	walletRepo repository.IWalletRepository
+}
+
+func NewAPIKeyHandler(apiKeyRepo repository.IAPIKeyRepository, walletRepo repository.IWalletRepository) *APIKeyHandler {
+	return &APIKeyHandler{
+		apiKeyRepo: apiKeyRepo,
+		walletRepo: walletRepo,
+	}
+}
+
+// CreateAPIKey issues a key for the caller. The key is returned only in this
+// response; afterwards just its prefix is shown.
+func (h *APIKeyHandler) CreateAPIKey(c *gin.Context) {
+	var req models.CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		problem.Invalid(c, "Invalid request body", err)
+		return
+	}
+
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	if req.MaxAmount != nil && !req.MaxAmount.IsPositive() {
+		problem.Write(c, problem.New(problem.CodeValidationFailed, "Max amount must be positive").Field("max_amount", "gt", "must be greater than 0"))
+		return
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		problem.Write(c, problem.New(problem.CodeValidationFailed, "Expiry must be in the future").Field("expires_at", "future", "must be in the future"))
+		return
+	}
+
+	// Restricted wallets must belong to the caller
+	for _, walletID := range req.WalletIDs {
+		wallet, err := h.walletRepo.GetByID(walletID)
+		if err != nil {
+			problem.Respond(c, problem.CodeInternal, "Failed to get wallet")
+			return
+		}
+		if wallet == nil || wallet.UserID != userID {
+			problem.Write(c, problem.New(problem.CodeValidationFailed, "Unknown wallet "+walletID.String()).Field("wallet_ids", "owned", "must be wallets of the caller"))
+			return
+		}
+	}
+
+	key, prefix, hash, err := apikey.Generate()
+	if err != nil {
+		problem.Respond(c, problem.CodeInternal, "Failed to generate API key")
+		return
+	}
+
+	record := &models.APIKey{
+		ID:        uuid.New(),
+		UserID:    userID,
+		Name:      req.Name,
+		Prefix:    prefix,
+		KeyHash:   hash,
+		Scopes:    req.Scopes,
+		WalletIDs: req.WalletIDs,
+		MaxAmount: req.MaxAmount,
+		ExpiresAt: req.ExpiresAt,
+	}
+	if record.WalletIDs == nil {
+		record.WalletIDs = []uuid.UUID{}
+	}
+
+	if err := h.apiKeyRepo.Create(c.Request.Context(), record); err != nil {
+		problem.Respond(c, problem.CodeInternal, "Failed to create API key")
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.CreateAPIKeyResponse{APIKey: *record, Key: key})
+}
+
+func (h *APIKeyHandler) ListAPIKeys(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	keys, err := h.apiKeyRepo.GetByUserID(c.Request.Context(), userID)
+	if err != nil {
+		problem.Respond(c, problem.CodeInternal, "Failed to get API keys")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIKeyListResponse{APIKeys: keys})
+}
+
+func (h *APIKeyHandler) RevokeAPIKey(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	keyID, err := uuid.Parse(c.Param("key_id"))
+	if err != nil {
+		problem.Respond(c, problem.CodeInvalidRequest, "Invalid API key ID")
+		return
+	}
+
+	revoked, err := h.apiKeyRepo.Revoke(c.Request.Context(), userID, keyID)
+	if err != nil {
+		problem.Respond(c, problem.CodeInternal, "Failed to revoke API key")
+		return
+	}
+	if !revoked {
+		problem.Respond(c, problem.CodeNotFound, "API key not found")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "API key revoked"})
+}
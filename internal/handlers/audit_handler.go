@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"net/http"
+
+	"wallet-service/internal/audit"
+	"wallet-service/internal/models"
+	"wallet-service/internal/problem"
+	"wallet-service/internal/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AuditHandler struct {
+	auditRepo repository.IAuditRepository
+	auditor   *audit.Auditor
+}
+
+func NewAuditHandler(auditRepo repository.IAuditRepository, auditor *audit.Auditor) *AuditHandler {
+	return &AuditHandler{
+		auditRepo: auditRepo,
+		auditor:   auditor,
+	}
+}
+
+func (h *AuditHandler) ListAuditLogs(c *gin.Context) {
+	var query models.AuditLogQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		problem.Invalid(c, "Invalid query parameters", err)
+		return
+	}
+
+	query.Limit, query.Offset = pagination(c, 100, 1000)
+
+	logs, total, err := h.auditRepo.Query(&query)
+	if err != nil {
+		problem.Respond(c, problem.CodeInternal, "Failed to query audit logs")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.AuditLogListResponse{
+		AuditLogs: logs,
+		Total:     total,
+	})
+}
+
+func (h *AuditHandler) VerifyAuditChain(c *gin.Context) {
+	result, err := h.auditor.Verify(c.Request.Context())
+	if err != nil {
+		problem.Respond(c, problem.CodeInternal, "Failed to verify audit chain")
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
@@ -2,85 +2,463 @@ package handlers
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"log"
 	"net/http"
 	"time"
 
+	"wallet-service/internal/mailer"
+	"wallet-service/internal/middleware"
 	"wallet-service/internal/models"
+	"wallet-service/internal/password"
+	"wallet-service/internal/problem"
 	"wallet-service/internal/repository"
+	"wallet-service/internal/session"
+	"wallet-service/internal/twofactor"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
-	"github.com/redis/go-redis/v9"
+	"github.com/google/uuid"
+)
+
+var (
+	errInvalidResetToken   = errors.New("invalid or expired reset token")
+	errInvalidRefreshToken = errors.New("invalid or expired refresh token")
+	errRefreshTokenReused  = errors.New("refresh token reused")
 )
 
 type AuthHandler struct {
-	userRepo    repository.IUserRepository
-	redisClient *redis.Client
-	jwtSecret   string
+	userRepo          repository.IUserRepository
+	passwordResetRepo repository.IPasswordResetRepository
+	refreshTokenRepo  repository.IRefreshTokenRepository
+	txManager         *repository.TransactionManager
+	sessions          *session.Store
+	twoFactor         *twofactor.Service
+	mailer            mailer.Mailer
+	tokens            middleware.TokenConfig
+	refreshTokenTTL   time.Duration
+	passwordResetTTL  time.Duration
 }
 
-func NewAuthHandler(userRepo repository.IUserRepository, redisClient *redis.Client, jwtSecret string) *AuthHandler {
+func NewAuthHandler(userRepo repository.IUserRepository, passwordResetRepo repository.IPasswordResetRepository, refreshTokenRepo repository.IRefreshTokenRepository, txManager *repository.TransactionManager, sessions *session.Store, twoFactor *twofactor.Service, mailer mailer.Mailer, tokens middleware.TokenConfig, refreshTokenTTL, passwordResetTTL time.Duration) *AuthHandler {
 	return &AuthHandler{
-		userRepo:    userRepo,
-		redisClient: redisClient,
-		jwtSecret:   jwtSecret,
+		userRepo:          userRepo,
+		passwordResetRepo: passwordResetRepo,
+		refreshTokenRepo:  refreshTokenRepo,
+		txManager:         txManager,
+		sessions:          sessions,
+		twoFactor:         twoFactor,
+		mailer:            mailer,
+		tokens:            tokens,
+		refreshTokenTTL:   refreshTokenTTL,
+		passwordResetTTL:  passwordResetTTL,
 	}
 }
 
 func (h *AuthHandler) Login(c *gin.Context) {
 	var req models.LoginRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		problem.Invalid(c, "Invalid request body", err)
 		return
 	}
 
 	// Get user by email
 	user, err := h.userRepo.GetByEmail(req.Email)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get user"})
+		problem.Respond(c, problem.CodeInternal, "Failed to get user")
 		return
 	}
 
+	// Unknown emails still pay for a hash so timing does not reveal which exist
 	if user == nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
+		password.VerifyDummy(req.Password)
+		problem.Respond(c, problem.CodeInvalidCredentials, "Invalid credentials")
+		return
+	}
+
+	if err := password.Verify(user.PasswordHash, req.Password); err != nil {
+		problem.Respond(c, problem.CodeInvalidCredentials, "Invalid credentials")
 		return
 	}
 
-	// Generate JWT token
-	token, err := h.generateToken(user.ID.String())
+	// Second factor, only asked for once the password is known to be right
+	enabled, err := h.twoFactor.Enabled(c.Request.Context(), user.ID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		problem.Respond(c, problem.CodeInternal, "Failed to check two-factor status")
 		return
 	}
+	if enabled {
+		c.Set("user_id", user.ID.String())
+		if req.OTP == "" {
+			problem.Write(c, problem.New(problem.CodeOTPRequired, "One-time code required").With("otp_required", true))
+			return
+		}
+		if err := h.twoFactor.Verify(c.Request.Context(), user.ID, req.OTP); err != nil {
+			respondTwoFactorError(c, err)
+			return
+		}
+	}
+
+	// Each login starts a new session, which is also its refresh token family
+	sess := &models.Session{
+		ID:     uuid.New(),
+		UserID: user.ID,
+		Device: c.Request.UserAgent(),
+		IP:     c.ClientIP(),
+	}
 
-	// Cache token in Redis
-	ctx := context.Background()
-	redisKey := fmt.Sprintf("jwt:%s", token)
-	err = h.redisClient.Set(ctx, redisKey, "1", 24*time.Hour).Err()
+	var refreshToken string
+	err = h.txManager.ExecuteTransaction(c.Request.Context(), func(ctx context.Context, tx *sql.Tx) error {
+		var err error
+		refreshToken, err = h.createRefreshToken(ctx, tx, user.ID, sess.ID)
+		return err
+	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to cache token"})
+		problem.Respond(c, problem.CodeInternal, "Failed to generate token")
 		return
 	}
 
+	if err := h.sessions.Create(c.Request.Context(), sess); err != nil {
+		problem.Respond(c, problem.CodeInternal, "Failed to create session")
+		return
+	}
+
+	tokens, err := h.issueTokens(c.Request.Context(), user, sess.ID, refreshToken)
+	if err != nil {
+		problem.Respond(c, problem.CodeInternal, "Failed to generate token")
+		return
+	}
+
+	// Expose the authenticated user to the audit log
+	c.Set("user_id", user.ID.String())
+
 	response := models.LoginResponse{
-		Token: token,
-		User:  *user,
+		TokenResponse: *tokens,
+		User:          *user,
 	}
 
 	c.JSON(http.StatusOK, response)
 }
 
-func (h *AuthHandler) generateToken(userID string) (string, error) {
-	// Create token with 100 years expiration for test user
-	expirationTime := time.Now().Add(100 * 365 * 24 * time.Hour)
+// Refresh exchanges a refresh token for a new access token and a new refresh
+// token. Presenting a token that was already rotated means it leaked, so the
+// whole family descending from that login is revoked.
+func (h *AuthHandler) Refresh(c *gin.Context) {
+	var req models.RefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		problem.Invalid(c, "Invalid request body", err)
+		return
+	}
+
+	var current *models.RefreshToken
+	var refreshToken string
+	err := h.txManager.ExecuteTransaction(c.Request.Context(), func(ctx context.Context, tx *sql.Tx) error {
+		var err error
+		current, err = h.refreshTokenRepo.GetByHashForUpdate(ctx, tx, hashRefreshToken(req.RefreshToken))
+		if err != nil {
+			return err
+		}
+		if current == nil || current.RevokedAt != nil {
+			return errInvalidRefreshToken
+		}
+
+		// Commit the revocation, then report the reuse
+		if current.UsedAt != nil {
+			if err := h.refreshTokenRepo.RevokeFamily(ctx, tx, current.FamilyID); err != nil {
+				return err
+			}
+			return nil
+		}
+
+		if !current.ExpiresAt.After(time.Now()) {
+			return errInvalidRefreshToken
+		}
+
+		if err := h.refreshTokenRepo.MarkUsed(ctx, tx, current.ID); err != nil {
+			return err
+		}
+
+		refreshToken, err = h.createRefreshToken(ctx, tx, current.UserID, current.FamilyID)
+		return err
+	})
+	if err == nil && current.UsedAt != nil {
+		err = errRefreshTokenReused
+	}
+
+	switch {
+	case errors.Is(err, errInvalidRefreshToken):
+		problem.Respond(c, problem.CodeUnauthenticated, "Invalid or expired refresh token")
+		return
+	case errors.Is(err, errRefreshTokenReused):
+		log.Printf("Refresh token reuse detected for user %s, revoked token family %s", current.UserID, current.FamilyID)
+		if err := h.sessions.Revoke(c.Request.Context(), current.UserID, current.FamilyID); err != nil && !errors.Is(err, session.ErrSessionNotFound) {
+			log.Printf("Failed to revoke session %s: %v", current.FamilyID, err)
+		}
+		c.Set("user_id", current.UserID.String())
+		problem.Respond(c, problem.CodeUnauthenticated, "Refresh token reuse detected, please log in again")
+		return
+	case err != nil:
+		problem.Respond(c, problem.CodeInternal, "Failed to refresh token")
+		return
+	}
+
+	// The role is read again so a role change applies from the next refresh
+	user, err := h.userRepo.GetByID(current.UserID)
+	if err != nil {
+		problem.Respond(c, problem.CodeInternal, "Failed to get user")
+		return
+	}
+	if user == nil {
+		problem.Respond(c, problem.CodeUnauthenticated, "Invalid or expired refresh token")
+		return
+	}
+
+	tokens, err := h.issueTokens(c.Request.Context(), user, current.FamilyID, refreshToken)
+	if errors.Is(err, session.ErrSessionNotFound) {
+		problem.Respond(c, problem.CodeUnauthenticated, "Session has been revoked")
+		return
+	}
+	if err != nil {
+		problem.Respond(c, problem.CodeInternal, "Failed to generate token")
+		return
+	}
+
+	c.Set("user_id", current.UserID.String())
+	c.JSON(http.StatusOK, tokens)
+}
+
+func (h *AuthHandler) ChangePassword(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var req models.ChangePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		problem.Invalid(c, "Invalid request body", err)
+		return
+	}
+
+	user, err := h.userRepo.GetByID(userID)
+	if err != nil {
+		problem.Respond(c, problem.CodeInternal, "Failed to get user")
+		return
+	}
+	if user == nil {
+		problem.Respond(c, problem.CodeNotFound, "User not found")
+		return
+	}
+
+	if err := password.Verify(user.PasswordHash, req.CurrentPassword); err != nil {
+		problem.Respond(c, problem.CodeInvalidCredentials, "Current password is incorrect")
+		return
+	}
+
+	if err := h.setPassword(c.Request.Context(), user, req.NewPassword); err != nil {
+		respondPasswordError(c, err)
+		return
+	}
+	h.endSessions(c.Request.Context(), user.ID)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Password changed, please log in again"})
+}
+
+// RequestPasswordReset always answers 202 so it cannot be used to probe for
+// registered emails
+func (h *AuthHandler) RequestPasswordReset(c *gin.Context) {
+	var req models.PasswordResetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		problem.Invalid(c, "Invalid request body", err)
+		return
+	}
+
+	response := gin.H{"message": "If the email is registered, a password reset token has been mailed"}
+
+	user, err := h.userRepo.GetByEmail(req.Email)
+	if err != nil {
+		problem.Respond(c, problem.CodeInternal, "Failed to get user")
+		return
+	}
+	if user == nil {
+		c.JSON(http.StatusAccepted, response)
+		return
+	}
+
+	token, tokenHash, err := password.NewResetToken()
+	if err != nil {
+		problem.Respond(c, problem.CodeInternal, "Failed to create reset token")
+		return
+	}
+
+	resetToken := &models.PasswordResetToken{
+		ID:        uuid.New(),
+		UserID:    user.ID,
+		TokenHash: tokenHash,
+		ExpiresAt: time.Now().Add(h.passwordResetTTL),
+	}
+	if err := h.passwordResetRepo.Create(c.Request.Context(), resetToken); err != nil {
+		problem.Respond(c, problem.CodeInternal, "Failed to create reset token")
+		return
+	}
+
+	sendMail(h.mailer, mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Use this token to reset your password before %s:\n\n%s\n\nIf you did not ask for a reset, ignore this message.",
+			resetToken.ExpiresAt.Format(time.RFC1123), token),
+	})
+
+	c.Set("user_id", user.ID.String())
+	c.JSON(http.StatusAccepted, response)
+}
+
+func (h *AuthHandler) ResetPassword(c *gin.Context) {
+	var req models.PasswordResetConfirmRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		problem.Invalid(c, "Invalid request body", err)
+		return
+	}
+
+	var user *models.User
+	err := h.txManager.ExecuteTransaction(c.Request.Context(), func(ctx context.Context, tx *sql.Tx) error {
+		token, err := h.passwordResetRepo.Consume(ctx, tx, password.HashResetToken(req.Token))
+		if err != nil {
+			return err
+		}
+		if token == nil {
+			return errInvalidResetToken
+		}
+
+		user, err = h.userRepo.GetByID(token.UserID)
+		if err != nil {
+			return err
+		}
+		if user == nil {
+			return errInvalidResetToken
+		}
+
+		if err := password.Validate(req.NewPassword, user.Email); err != nil {
+			return err
+		}
+
+		hash, err := password.Hash(req.NewPassword)
+		if err != nil {
+			return err
+		}
+
+		if err := h.userRepo.UpdatePassword(ctx, tx, user.ID, hash); err != nil {
+			return err
+		}
+
+		// Any other outstanding token for the user dies with this reset
+		if err := h.passwordResetRepo.InvalidateForUser(ctx, tx, user.ID); err != nil {
+			return err
+		}
+		return h.refreshTokenRepo.RevokeForUser(ctx, tx, user.ID)
+	})
+	if err != nil {
+		respondPasswordError(c, err)
+		return
+	}
+
+	h.endSessions(c.Request.Context(), user.ID)
+
+	c.Set("user_id", user.ID.String())
+	c.JSON(http.StatusOK, gin.H{"message": "Password reset"})
+}
+
+// setPassword validates and stores a new password, discarding any pending
+// reset tokens and refresh tokens for the user
+func (h *AuthHandler) setPassword(ctx context.Context, user *models.User, newPassword string) error {
+	if err := password.Validate(newPassword, user.Email); err != nil {
+		return err
+	}
+
+	hash, err := password.Hash(newPassword)
+	if err != nil {
+		return err
+	}
 
-	claims := jwt.MapClaims{
-		"user_id": userID,
-		"exp":     expirationTime.Unix(),
-		"iat":     time.Now().Unix(),
+	return h.txManager.ExecuteTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		if err := h.userRepo.UpdatePassword(ctx, tx, user.ID, hash); err != nil {
+			return err
+		}
+		if err := h.passwordResetRepo.InvalidateForUser(ctx, tx, user.ID); err != nil {
+			return err
+		}
+		return h.refreshTokenRepo.RevokeForUser(ctx, tx, user.ID)
+	})
+}
+
+// endSessions logs the user out everywhere once their refresh tokens have been
+// revoked; leftover access tokens would otherwise live until they expire
+func (h *AuthHandler) endSessions(ctx context.Context, userID uuid.UUID) {
+	if _, err := h.sessions.RevokeAll(ctx, userID); err != nil {
+		log.Printf("Failed to revoke sessions of user %s: %v", userID, err)
 	}
+}
+
+func respondPasswordError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, password.ErrTooShort), errors.Is(err, password.ErrTooLong),
+		errors.Is(err, password.ErrTooSimple), errors.Is(err, password.ErrContainsEmail):
+		problem.Respond(c, problem.CodeWeakPassword, err.Error())
+	case errors.Is(err, errInvalidResetToken):
+		problem.Respond(c, problem.CodeInvalidRequest, "Invalid or expired reset token")
+	default:
+		problem.Respond(c, problem.CodeInternal, "Failed to update password")
+	}
+}
+
+// issueTokens signs an access token and makes it the session's live token,
+// which revokes the access token it replaces
+func (h *AuthHandler) issueTokens(ctx context.Context, user *models.User, sessionID uuid.UUID, refreshToken string) (*models.TokenResponse, error) {
+	token, claims, err := h.tokens.Issue(user.ID.String(), sessionID.String(), user.Role)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := h.sessions.Activate(ctx, sessionID, claims.ID, h.tokens.AccessTTL); err != nil {
+		return nil, err
+	}
+
+	return &models.TokenResponse{
+		Token:        token,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(h.tokens.AccessTTL.Seconds()),
+		RefreshToken: refreshToken,
+	}, nil
+}
+
+// createRefreshToken stores a new refresh token in the family and returns the
+// opaque value handed to the client; only its hash is kept
+func (h *AuthHandler) createRefreshToken(ctx context.Context, tx *sql.Tx, userID, familyID uuid.UUID) (string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+	value := base64.RawURLEncoding.EncodeToString(raw)
+
+	token := &models.RefreshToken{
+		ID:        uuid.New(),
+		FamilyID:  familyID,
+		UserID:    userID,
+		TokenHash: hashRefreshToken(value),
+		ExpiresAt: time.Now().Add(h.refreshTokenTTL),
+	}
+	if err := h.refreshTokenRepo.Create(ctx, tx, token); err != nil {
+		return "", err
+	}
+
+	return value, nil
+}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(h.jwtSecret))
+func hashRefreshToken(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:])
 }
@@ -1,10 +1,15 @@
 package handlers
 
 import (
+	"context"
 	"testing"
+	"time"
 
+	"wallet-service/internal/middleware"
 	"wallet-service/internal/models"
 	"wallet-service/internal/repository"
+	"wallet-service/internal/session"
+	"wallet-service/internal/signing"
 
 	"github.com/google/uuid"
 	"github.com/redis/go-redis/v9"
@@ -20,7 +25,7 @@ func TestAuthHandler_Login(t *testing.T) {
 		Name:  "Test User",
 		Email: "test@example.com",
 	}
-	mockUserRepo.Create(testUser)
+	mockUserRepo.Create(context.Background(), nil, testUser)
 
 	// Create mock Redis client (you might want to create a mock for this too)
 	redisClient := redis.NewClient(&redis.Options{
@@ -28,7 +33,7 @@ func TestAuthHandler_Login(t *testing.T) {
 	})
 
 	// Create auth handler with mock dependencies
-	authHandler := NewAuthHandler(mockUserRepo, redisClient, "test-secret")
+	authHandler := NewAuthHandler(mockUserRepo, repository.NewMockPasswordResetRepository(), repository.NewMockRefreshTokenRepository(), nil, session.NewStore(redisClient, 30*24*time.Hour), nil, nil, middleware.TokenConfig{Keys: signing.NewKeyring(), Issuer: "wallet-service", Audience: "wallet-api", AccessTTL: 15 * time.Minute}, 30*24*time.Hour, 30*time.Minute)
 
 	// Test that the handler was created successfully
 	if authHandler == nil {
@@ -52,7 +57,7 @@ func TestAuthHandler_WithMockDependencies(t *testing.T) {
 		Name:  "Test User",
 		Email: "test@example.com",
 	}
-	mockUserRepo.Create(testUser)
+	mockUserRepo.Create(context.Background(), nil, testUser)
 
 	// Verify the mock works
 	retrievedUser, err := mockUserRepo.GetByEmail("test@example.com")
@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"net/http"
+
+	"wallet-service/internal/problem"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ErrorHandler publishes the error catalog that problem types link to
+type ErrorHandler struct{}
+
+func NewErrorHandler() *ErrorHandler {
+	return &ErrorHandler{}
+}
+
+// ListErrors lists every error code with its status and meaning
+func (h *ErrorHandler) ListErrors(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=3600")
+	c.JSON(http.StatusOK, gin.H{"errors": problem.Catalog()})
+}
+
+// GetError documents the code behind a problem's type URI
+func (h *ErrorHandler) GetError(c *gin.Context) {
+	entry, ok := problem.LookupSlug(c.Param("code"))
+	if !ok {
+		problem.Respond(c, problem.CodeNotFound, "Unknown error code")
+		return
+	}
+
+	c.Header("Cache-Control", "public, max-age=3600")
+	c.JSON(http.StatusOK, entry)
+}
@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"time"
+
+	"wallet-service/internal/apikey"
+	"wallet-service/internal/mailer"
+	"wallet-service/internal/problem"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// currentUserID returns the authenticated user set by AuthMiddleware, writing
+// the error response itself when it is missing
+func currentUserID(c *gin.Context) (uuid.UUID, bool) {
+	userIDStr, exists := c.Get("user_id")
+	if !exists {
+		problem.Respond(c, problem.CodeUnauthenticated, "User ID not found")
+		return uuid.Nil, false
+	}
+
+	userID, err := uuid.Parse(userIDStr.(string))
+	if err != nil {
+		problem.Respond(c, problem.CodeInternal, "Invalid user ID")
+		return uuid.Nil, false
+	}
+
+	return userID, true
+}
+
+// keyWallets returns the wallets an API key is restricted to, nil when the
+// caller is not using a key or the key may use every wallet
+func keyWallets(c *gin.Context) []uuid.UUID {
+	if key := apikey.FromContext(c); key != nil {
+		return key.WalletIDs
+	}
+	return nil
+}
+
+// pagination reads limit/offset query parameters, falling back to defaultLimit
+// and capping the limit at maxLimit
+func pagination(c *gin.Context, defaultLimit, maxLimit int) (int, int) {
+	limit, offset := defaultLimit, 0
+
+	if limitStr := c.Query("limit"); limitStr != "" {
+		if value, err := strconv.Atoi(limitStr); err == nil && value > 0 {
+			limit = value
+		}
+	}
+	if limit > maxLimit {
+		limit = maxLimit
+	}
+
+	if offsetStr := c.Query("offset"); offsetStr != "" {
+		if value, err := strconv.Atoi(offsetStr); err == nil && value >= 0 {
+			offset = value
+		}
+	}
+
+	return limit, offset
+}
+
+// sendMail delivers msg in the background so the response time does not
+// depend on whether a message was sent, which would reveal registered emails
+func sendMail(m mailer.Mailer, msg mailer.Message) {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		if err := m.Send(ctx, msg); err != nil {
+			log.Printf("Failed to send %q to %s: %v", msg.Subject, msg.To, err)
+		}
+	}()
+}
@@ -0,0 +1,24 @@
+package handlers
+
+import (
+	"net/http"
+
+	"wallet-service/internal/signing"
+
+	"github.com/gin-gonic/gin"
+)
+
+type JWKSHandler struct {
+	keyring *signing.Keyring
+}
+
+func NewJWKSHandler(keyring *signing.Keyring) *JWKSHandler {
+	return &JWKSHandler{keyring: keyring}
+}
+
+// GetJWKS publishes the public keys that verify access tokens. Upcoming keys
+// are listed a full rotation period early, so a short cache is always safe.
+func (h *JWKSHandler) GetJWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, h.keyring.JWKS())
+}
@@ -0,0 +1,272 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"path/filepath"
+	"strconv"
+
+	"wallet-service/internal/kyc"
+	"wallet-service/internal/models"
+	"wallet-service/internal/problem"
+	"wallet-service/internal/repository"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// multipartOverhead is allowed on top of the document for the form fields and
+// part headers of an upload
+const multipartOverhead = 1 << 20
+
+// KYCHandler takes identity documents from users and lets staff review them
+// and set tiers
+type KYCHandler struct {
+	userRepo        repository.IUserRepository
+	kycRepo         repository.IKYCRepository
+	kyc             *kyc.Service
+	maxDocumentSize int64
+}
+
+func NewKYCHandler(userRepo repository.IUserRepository, kycRepo repository.IKYCRepository, kycService *kyc.Service, maxDocumentSize int64) *KYCHandler {
+	return &KYCHandler{
+		userRepo:        userRepo,
+		kycRepo:         kycRepo,
+		kyc:             kycService,
+		maxDocumentSize: maxDocumentSize,
+	}
+}
+
+// SubmitDocument takes a multipart upload: the document as "file", with
+// "tier" and "document_type" form fields
+func (h *KYCHandler) SubmitDocument(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.maxDocumentSize+multipartOverhead)
+
+	var req models.SubmitKYCRequest
+	if err := c.ShouldBind(&req); err != nil {
+		problem.Invalid(c, "Invalid request body", err)
+		return
+	}
+
+	header, err := c.FormFile("file")
+	if err != nil {
+		problem.Write(c, problem.New(problem.CodeValidationFailed, "Document file required").Field("file", "required", "is required"))
+		return
+	}
+	if header.Size > h.maxDocumentSize {
+		respondKYCError(c, kyc.ErrDocumentTooLarge)
+		return
+	}
+	file, err := header.Open()
+	if err != nil {
+		problem.Respond(c, problem.CodeInvalidRequest, "Failed to read document")
+		return
+	}
+	defer file.Close()
+
+	user, err := h.userRepo.GetByID(userID)
+	if err != nil {
+		problem.Respond(c, problem.CodeInternal, "Failed to get user")
+		return
+	}
+	if user == nil {
+		problem.Respond(c, problem.CodeNotFound, "User not found")
+		return
+	}
+
+	submission, err := h.kyc.Submit(c.Request.Context(), user, req.Tier, req.DocumentType, filepath.Base(header.Filename), file)
+	if err != nil {
+		respondKYCError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, submission)
+}
+
+// GetStatus returns the caller's tier and their submissions
+func (h *KYCHandler) GetStatus(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	user, err := h.userRepo.GetByID(userID)
+	if err != nil {
+		problem.Respond(c, problem.CodeInternal, "Failed to get user")
+		return
+	}
+	if user == nil {
+		problem.Respond(c, problem.CodeNotFound, "User not found")
+		return
+	}
+
+	submissions, err := h.kycRepo.GetByUserID(c.Request.Context(), userID)
+	if err != nil {
+		problem.Respond(c, problem.CodeInternal, "Failed to get KYC submissions")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.KYCStatusResponse{Tier: user.KYCTier, Submissions: submissions})
+}
+
+// ListSubmissions is the staff review queue, oldest first; ?status=pending
+// shows only what still needs a decision
+func (h *KYCHandler) ListSubmissions(c *gin.Context) {
+	status := models.KYCSubmissionStatus(c.Query("status"))
+	switch status {
+	case "", models.KYCSubmissionPending, models.KYCSubmissionApproved, models.KYCSubmissionRejected:
+	default:
+		problem.Respond(c, problem.CodeInvalidRequest, "Invalid status")
+		return
+	}
+
+	limit, offset := pagination(c, 20, 100)
+	submissions, total, err := h.kycRepo.List(c.Request.Context(), status, limit, offset)
+	if err != nil {
+		problem.Respond(c, problem.CodeInternal, "Failed to list KYC submissions")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.KYCSubmissionListResponse{Submissions: submissions, Total: total})
+}
+
+// GetDocument serves a submitted file to a reviewer
+func (h *KYCHandler) GetDocument(c *gin.Context) {
+	submissionID, ok := submissionIDParam(c)
+	if !ok {
+		return
+	}
+
+	submission, file, err := h.kyc.OpenDocument(c.Request.Context(), submissionID)
+	if err != nil {
+		respondKYCError(c, err)
+		return
+	}
+	defer file.Close()
+
+	// Always a download, never rendered inline from our origin
+	c.Header("Content-Disposition", "attachment; filename="+strconv.Quote(submission.FileName))
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.DataFromReader(http.StatusOK, submission.SizeBytes, submission.ContentType, file, nil)
+}
+
+func (h *KYCHandler) ApproveSubmission(c *gin.Context) {
+	submissionID, ok := submissionIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req models.ApproveKYCRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		problem.Invalid(c, "Invalid request body", err)
+		return
+	}
+
+	submission, err := h.kyc.Approve(c.Request.Context(), submissionID, reviewerID(c), req.Tier, req.Reason)
+	if err != nil {
+		respondKYCError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, submission)
+}
+
+func (h *KYCHandler) RejectSubmission(c *gin.Context) {
+	submissionID, ok := submissionIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req models.RejectKYCRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		problem.Invalid(c, "Invalid request body", err)
+		return
+	}
+
+	submission, err := h.kyc.Reject(c.Request.Context(), submissionID, reviewerID(c), req.Reason)
+	if err != nil {
+		respondKYCError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, submission)
+}
+
+// UpdateUserTier sets the :user_id user's tier directly, e.g. to downgrade
+// it when a document turns out to have expired
+func (h *KYCHandler) UpdateUserTier(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("user_id"))
+	if err != nil {
+		problem.Respond(c, problem.CodeInvalidRequest, "Invalid user ID")
+		return
+	}
+
+	var req models.UpdateKYCTierRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		problem.Invalid(c, "Invalid request body", err)
+		return
+	}
+
+	if c.GetString("user_id") == userID.String() {
+		problem.Respond(c, problem.CodeForbidden, "Cannot change your own KYC tier")
+		return
+	}
+
+	user, err := h.userRepo.GetByID(userID)
+	if err != nil {
+		problem.Respond(c, problem.CodeInternal, "Failed to get user")
+		return
+	}
+	if user == nil {
+		problem.Respond(c, problem.CodeNotFound, "User not found")
+		return
+	}
+
+	if err := h.kyc.SetTier(c.Request.Context(), user.ID, req.Tier); err != nil {
+		problem.Respond(c, problem.CodeInternal, "Failed to update KYC tier")
+		return
+	}
+
+	user.KYCTier = req.Tier
+	c.JSON(http.StatusOK, user)
+}
+
+func submissionIDParam(c *gin.Context) (uuid.UUID, bool) {
+	submissionID, err := uuid.Parse(c.Param("submission_id"))
+	if err != nil {
+		problem.Respond(c, problem.CodeInvalidRequest, "Invalid submission ID")
+		return uuid.Nil, false
+	}
+	return submissionID, true
+}
+
+// reviewerID is the staff member deciding, or nil for the shared admin token
+func reviewerID(c *gin.Context) *uuid.UUID {
+	id, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		return nil
+	}
+	return &id
+}
+
+func respondKYCError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, kyc.ErrSubmissionNotFound):
+		problem.Respond(c, problem.CodeNotFound, "KYC submission not found")
+	case errors.Is(err, kyc.ErrAlreadyReviewed):
+		problem.Respond(c, problem.CodeAlreadyReviewed, "KYC submission already reviewed")
+	case errors.Is(err, kyc.ErrTierHeld):
+		problem.Respond(c, problem.CodeConflict, "You already hold this tier or a higher one")
+	case errors.Is(err, kyc.ErrDocumentTooLarge):
+		problem.Respond(c, problem.CodePayloadTooLarge, "Document too large")
+	case errors.Is(err, kyc.ErrUnsupportedDocument):
+		problem.Respond(c, problem.CodeUnsupportedMediaType, "Document must be a PDF, JPEG or PNG")
+	default:
+		problem.Respond(c, problem.CodeInternal, "Failed to process KYC submission")
+	}
+}
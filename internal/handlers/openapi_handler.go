@@ -0,0 +1,29 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"wallet-service/internal/openapi"
+
+	"github.com/gin-gonic/gin"
+)
+
+// OpenAPIHandler serves the API's OpenAPI document
+type OpenAPIHandler struct {
+	document []byte
+}
+
+func NewOpenAPIHandler(doc *openapi.Document) (*OpenAPIHandler, error) {
+	document, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	return &OpenAPIHandler{document: document}, nil
+}
+
+// GetSpec serves the document, which only changes with a deploy
+func (h *OpenAPIHandler) GetSpec(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.Data(http.StatusOK, "application/json; charset=utf-8", h.document)
+}
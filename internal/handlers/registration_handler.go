@@ -0,0 +1,283 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"wallet-service/internal/mailer"
+	"wallet-service/internal/models"
+	"wallet-service/internal/password"
+	"wallet-service/internal/problem"
+	"wallet-service/internal/repository"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+var (
+	errInvalidVerification = errors.New("invalid or expired verification")
+	errEmailTaken          = errors.New("email already registered")
+)
+
+// RegistrationHandler signs up new users. The account only exists once the
+// email is verified, so nobody can claim an address they do not control.
+type RegistrationHandler struct {
+	userRepo         repository.IUserRepository
+	walletRepo       repository.IWalletRepository
+	registrationRepo repository.IRegistrationRepository
+	txManager        *repository.TransactionManager
+	mailer           mailer.Mailer
+	coins            []models.CoinType
+	publicBaseURL    string
+	ttl              time.Duration
+	maxAttempts      int
+}
+
+// NewRegistrationHandler provisions a wallet for each of coins when a sign-up
+// is verified. Verification links point at publicBaseURL.
+func NewRegistrationHandler(userRepo repository.IUserRepository, walletRepo repository.IWalletRepository, registrationRepo repository.IRegistrationRepository, txManager *repository.TransactionManager, mailer mailer.Mailer, coins []models.CoinType, publicBaseURL string, ttl time.Duration, maxAttempts int) *RegistrationHandler {
+	return &RegistrationHandler{
+		userRepo:         userRepo,
+		walletRepo:       walletRepo,
+		registrationRepo: registrationRepo,
+		txManager:        txManager,
+		mailer:           mailer,
+		coins:            coins,
+		publicBaseURL:    strings.TrimRight(publicBaseURL, "/"),
+		ttl:              ttl,
+		maxAttempts:      maxAttempts,
+	}
+}
+
+// Register always answers 202 so it cannot be used to probe for registered
+// emails; an address that already has an account is told so by mail instead
+func (h *RegistrationHandler) Register(c *gin.Context) {
+	var req models.RegisterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		problem.Invalid(c, "Invalid request body", err)
+		return
+	}
+	req.Email = strings.TrimSpace(req.Email)
+
+	if err := password.Validate(req.Password, req.Email); err != nil {
+		problem.Respond(c, problem.CodeWeakPassword, err.Error())
+		return
+	}
+
+	// Hash before looking the email up so both paths take as long
+	hash, err := password.Hash(req.Password)
+	if err != nil {
+		problem.Respond(c, problem.CodeInternal, "Failed to hash password")
+		return
+	}
+
+	response := gin.H{"message": "Check your email to finish signing up"}
+
+	existing, err := h.userRepo.GetByEmail(req.Email)
+	if err != nil {
+		problem.Respond(c, problem.CodeInternal, "Failed to get user")
+		return
+	}
+	if existing != nil {
+		sendMail(h.mailer, mailer.Message{
+			To:      existing.Email,
+			Subject: "You already have an account",
+			Body:    "Someone tried to sign up with this email, which already has an account.\n\nIf it was you, log in or ask for a password reset instead. Otherwise, ignore this message.",
+		})
+		c.JSON(http.StatusAccepted, response)
+		return
+	}
+
+	token, tokenHash, err := password.NewResetToken()
+	if err != nil {
+		problem.Respond(c, problem.CodeInternal, "Failed to create registration")
+		return
+	}
+	code, err := newVerificationCode()
+	if err != nil {
+		problem.Respond(c, problem.CodeInternal, "Failed to create registration")
+		return
+	}
+
+	registration := &models.Registration{
+		ID:           uuid.New(),
+		Email:        req.Email,
+		Name:         req.Name,
+		PasswordHash: hash,
+		TokenHash:    tokenHash,
+		ExpiresAt:    time.Now().Add(h.ttl),
+	}
+	registration.CodeHash = hashVerificationCode(registration.ID, code)
+	if err := h.registrationRepo.Create(c.Request.Context(), registration); err != nil {
+		problem.Respond(c, problem.CodeInternal, "Failed to create registration")
+		return
+	}
+
+	link := h.publicBaseURL + "/auth/verify-email?token=" + url.QueryEscape(token)
+	sendMail(h.mailer, mailer.Message{
+		To:      registration.Email,
+		Subject: "Verify your email",
+		Body: fmt.Sprintf("Welcome, %s.\n\nOpen this link to verify your email and create your account:\n\n%s\n\nor enter the code %s. Both expire at %s.\n\nIf you did not sign up, ignore this message.",
+			registration.Name, link, code, registration.ExpiresAt.Format(time.RFC1123)),
+	})
+
+	c.JSON(http.StatusAccepted, response)
+}
+
+// VerifyEmail accepts the token from the link, as a query parameter or in the
+// body, or the email and code. The user and their wallets are created in one
+// transaction, so a failure leaves no half-provisioned account behind.
+func (h *RegistrationHandler) VerifyEmail(c *gin.Context) {
+	var req models.VerifyEmailRequest
+	if c.Request.Method == http.MethodGet {
+		req.Token = c.Query("token")
+	} else if err := c.ShouldBindJSON(&req); err != nil {
+		problem.Invalid(c, "Invalid request body", err)
+		return
+	}
+	if req.Token == "" && (req.Email == "" || req.Code == "") {
+		problem.Respond(c, problem.CodeInvalidRequest, "Token, or email and code, required")
+		return
+	}
+
+	var response models.RegistrationResponse
+	failed := false
+	err := h.txManager.ExecuteTransaction(c.Request.Context(), func(ctx context.Context, tx *sql.Tx) error {
+		registration, err := h.verify(ctx, tx, &req)
+		if err != nil {
+			return err
+		}
+		// A wrong code is counted, so commit rather than roll back
+		if registration == nil {
+			failed = true
+			return nil
+		}
+
+		// Any other sign-up for the address is moot now
+		if err := h.registrationRepo.InvalidateForEmail(ctx, tx, registration.Email); err != nil {
+			return err
+		}
+
+		user := &models.User{
+			ID:           uuid.New(),
+			Name:         registration.Name,
+			Email:        registration.Email,
+			PasswordHash: registration.PasswordHash,
+			Role:         models.RoleUser,
+		}
+		created, err := h.userRepo.Create(ctx, tx, user)
+		if err != nil {
+			return err
+		}
+		if !created {
+			return errEmailTaken
+		}
+
+		wallets := make([]models.Wallet, 0, len(h.coins))
+		for _, coin := range h.coins {
+			wallet := models.Wallet{
+				ID:           uuid.New(),
+				UserID:       user.ID,
+				CoinType:     coin,
+				Amount:       decimal.Zero,
+				FrozenAmount: decimal.Zero,
+			}
+			created, err := h.walletRepo.Create(ctx, tx, &wallet)
+			if err != nil {
+				return err
+			}
+			if !created {
+				return fmt.Errorf("user %s already has a %s wallet", user.ID, coin)
+			}
+			wallets = append(wallets, wallet)
+		}
+
+		response = models.RegistrationResponse{User: *user, Wallets: wallets}
+		return nil
+	})
+	if failed {
+		err = errInvalidVerification
+	}
+	if err != nil {
+		switch {
+		case errors.Is(err, errInvalidVerification):
+			problem.Respond(c, problem.CodeInvalidRequest, "Invalid or expired verification")
+		case errors.Is(err, errEmailTaken):
+			problem.Respond(c, problem.CodeEmailAlreadyRegistered, "Email already registered")
+		default:
+			problem.Respond(c, problem.CodeInternal, "Failed to create account")
+		}
+		return
+	}
+
+	// Expose the new user to the audit log
+	c.Set("user_id", response.User.ID.String())
+	c.JSON(http.StatusCreated, response)
+}
+
+// verify uses up the registration the request proves, or returns nil after
+// counting a wrong code against every pending registration for the email.
+// Codes are checked against all of them, so a stranger signing up with the
+// same address cannot lock its owner out.
+func (h *RegistrationHandler) verify(ctx context.Context, tx *sql.Tx, req *models.VerifyEmailRequest) (*models.Registration, error) {
+	if req.Token != "" {
+		registration, err := h.registrationRepo.ConsumeByToken(ctx, tx, password.HashResetToken(req.Token))
+		if err != nil {
+			return nil, err
+		}
+		if registration == nil {
+			return nil, errInvalidVerification
+		}
+		return registration, nil
+	}
+
+	pending, err := h.registrationRepo.GetPendingByEmailForUpdate(ctx, tx, strings.TrimSpace(req.Email))
+	if err != nil {
+		return nil, err
+	}
+	if len(pending) == 0 {
+		return nil, errInvalidVerification
+	}
+
+	ids := make([]uuid.UUID, 0, len(pending))
+	for i := range pending {
+		expected := pending[i].CodeHash
+		if subtle.ConstantTimeCompare([]byte(hashVerificationCode(pending[i].ID, req.Code)), []byte(expected)) == 1 {
+			return &pending[i], nil
+		}
+		ids = append(ids, pending[i].ID)
+	}
+
+	if err := h.registrationRepo.RecordFailedAttempt(ctx, tx, ids, h.maxAttempts); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+// newVerificationCode returns a random six digit code
+func newVerificationCode() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+	if err != nil {
+		return "", fmt.Errorf("failed to generate verification code: %w", err)
+	}
+	return fmt.Sprintf("%06d", n.Int64()), nil
+}
+
+// hashVerificationCode binds the code to its registration, so equal codes do
+// not hash alike; the attempt limit is what keeps six digits from being guessed
+func hashVerificationCode(registrationID uuid.UUID, code string) string {
+	sum := sha256.Sum256([]byte(registrationID.String() + ":" + code))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"wallet-service/internal/models"
+	"wallet-service/internal/problem"
+	"wallet-service/internal/repository"
+	"wallet-service/internal/session"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type SessionHandler struct {
+	refreshTokenRepo repository.IRefreshTokenRepository
+	txManager        *repository.TransactionManager
+	sessions         *session.Store
+}
+
+func NewSessionHandler(refreshTokenRepo repository.IRefreshTokenRepository, txManager *repository.TransactionManager, sessions *session.Store) *SessionHandler {
+	return &SessionHandler{
+		refreshTokenRepo: refreshTokenRepo,
+		txManager:        txManager,
+		sessions:         sessions,
+	}
+}
+
+// Logout ends the caller's current session
+func (h *SessionHandler) Logout(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	sessionID, err := uuid.Parse(c.GetString("session_id"))
+	if err != nil {
+		problem.Respond(c, problem.CodeUnauthenticated, "Session ID not found")
+		return
+	}
+
+	if err := h.revoke(c.Request.Context(), userID, sessionID); err != nil && !errors.Is(err, session.ErrSessionNotFound) {
+		problem.Respond(c, problem.CodeInternal, "Failed to log out")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Logged out"})
+}
+
+func (h *SessionHandler) ListSessions(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	sessions, err := h.sessions.List(c.Request.Context(), userID)
+	if err != nil {
+		problem.Respond(c, problem.CodeInternal, "Failed to list sessions")
+		return
+	}
+
+	current := c.GetString("session_id")
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID.String() == current
+	}
+
+	c.JSON(http.StatusOK, models.SessionListResponse{Sessions: sessions})
+}
+
+func (h *SessionHandler) RevokeSession(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	sessionID, err := uuid.Parse(c.Param("session_id"))
+	if err != nil {
+		problem.Respond(c, problem.CodeInvalidRequest, "Invalid session ID")
+		return
+	}
+
+	err = h.revoke(c.Request.Context(), userID, sessionID)
+	if errors.Is(err, session.ErrSessionNotFound) {
+		problem.Respond(c, problem.CodeNotFound, "Session not found")
+		return
+	}
+	if err != nil {
+		problem.Respond(c, problem.CodeInternal, "Failed to revoke session")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Session revoked"})
+}
+
+// RevokeAllSessions logs the caller out everywhere, including this session
+func (h *SessionHandler) RevokeAllSessions(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	revoked, err := h.revokeAll(c.Request.Context(), userID)
+	if err != nil {
+		problem.Respond(c, problem.CodeInternal, "Failed to revoke sessions")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "All sessions revoked", "revoked": revoked})
+}
+
+// RevokeUserSessions lets support kill every session of a compromised user
+func (h *SessionHandler) RevokeUserSessions(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("user_id"))
+	if err != nil {
+		problem.Respond(c, problem.CodeInvalidRequest, "Invalid user ID")
+		return
+	}
+
+	revoked, err := h.revokeAll(c.Request.Context(), userID)
+	if err != nil {
+		problem.Respond(c, problem.CodeInternal, "Failed to revoke sessions")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "All sessions revoked", "revoked": revoked})
+}
+
+// revoke kills the session's refresh token family first, so it cannot mint a
+// new access token once its live one is deleted
+func (h *SessionHandler) revoke(ctx context.Context, userID, sessionID uuid.UUID) error {
+	if _, err := h.sessions.Get(ctx, userID, sessionID); err != nil {
+		return err
+	}
+
+	err := h.txManager.ExecuteTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		return h.refreshTokenRepo.RevokeFamily(ctx, tx, sessionID)
+	})
+	if err != nil {
+		return err
+	}
+
+	return h.sessions.Revoke(ctx, userID, sessionID)
+}
+
+func (h *SessionHandler) revokeAll(ctx context.Context, userID uuid.UUID) (int, error) {
+	err := h.txManager.ExecuteTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		return h.refreshTokenRepo.RevokeForUser(ctx, tx, userID)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return h.sessions.RevokeAll(ctx, userID)
+}
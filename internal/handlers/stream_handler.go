@@ -0,0 +1,182 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"wallet-service/internal/middleware"
+	"wallet-service/internal/models"
+	"wallet-service/internal/problem"
+	"wallet-service/internal/repository"
+	"wallet-service/internal/session"
+	"wallet-service/internal/stream"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type StreamHandler struct {
+	walletRepo repository.IWalletRepository
+	outboxRepo repository.IOutboxRepository
+	hub        *stream.Hub
+	sessions   *session.Store
+	tokens     middleware.TokenConfig
+	heartbeat  time.Duration
+}
+
+func NewStreamHandler(walletRepo repository.IWalletRepository, outboxRepo repository.IOutboxRepository, hub *stream.Hub, sessions *session.Store, tokens middleware.TokenConfig, heartbeat time.Duration) *StreamHandler {
+	return &StreamHandler{
+		walletRepo: walletRepo,
+		outboxRepo: outboxRepo,
+		hub:        hub,
+		sessions:   sessions,
+		tokens:     tokens,
+		heartbeat:  heartbeat,
+	}
+}
+
+// StreamEvents pushes WalletCredited/WalletDebited events for the caller's
+// wallets as Server-Sent Events. The SSE id is the event's publish sequence,
+// which follows commit order, so a client reconnecting with Last-Event-ID
+// first receives everything published after it.
+func (h *StreamHandler) StreamEvents(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	wallets, err := h.walletRepo.GetByUserID(userID)
+	if err != nil {
+		problem.Respond(c, problem.CodeInternal, "Failed to get user wallets")
+		return
+	}
+
+	walletIDs := make([]uuid.UUID, 0, len(wallets))
+	for _, wallet := range wallets {
+		walletIDs = append(walletIDs, wallet.ID)
+	}
+
+	// Optionally narrow the stream to one of the caller's wallets
+	var walletFilter *uuid.UUID
+	if walletIDStr := c.Query("wallet_id"); walletIDStr != "" {
+		walletID, err := uuid.Parse(walletIDStr)
+		if err != nil {
+			problem.Respond(c, problem.CodeInvalidRequest, "Invalid wallet ID")
+			return
+		}
+		if !containsWallet(walletIDs, walletID) {
+			problem.Respond(c, problem.CodeForbidden, "Access denied")
+			return
+		}
+		walletFilter = &walletID
+		walletIDs = []uuid.UUID{walletID}
+	}
+
+	after, err := lastEventID(c)
+	if err != nil {
+		problem.Respond(c, problem.CodeInvalidRequest, "Invalid Last-Event-ID")
+		return
+	}
+
+	// Subscribe before replaying so nothing published in between is lost;
+	// the cursor drops what arrives both ways
+	sub := h.hub.Subscribe(userID)
+	defer h.hub.Unsubscribe(sub)
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	fmt.Fprintf(c.Writer, "retry: %d\n\n", 3000)
+	c.Writer.Flush()
+
+	cursor := stream.NewCursor(after)
+	if after > 0 {
+		err := stream.Replay(h.outboxRepo, cursor, walletIDs, func(event *models.OutboxEvent) error {
+			writeEvent(c.Writer, event)
+			c.Writer.Flush()
+			return nil
+		})
+		if err != nil {
+			writeSSE(c.Writer, "error", "", problem.New(problem.CodeInternal, "Failed to replay events"))
+			return
+		}
+	}
+
+	tokenString := c.GetString("token")
+	ticker := time.NewTicker(h.heartbeat)
+	defer ticker.Stop()
+
+	ctx := c.Request.Context()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case event, ok := <-sub.Events:
+			if !ok {
+				// Dropped for falling behind; the client resumes via Last-Event-ID
+				return
+			}
+			if walletFilter != nil && event.AggregateID != *walletFilter {
+				continue
+			}
+			if !cursor.Admit(&event) {
+				continue
+			}
+			writeEvent(c.Writer, &event)
+			c.Writer.Flush()
+
+		case <-ticker.C:
+			// Re-check the token so a revoked session stops streaming
+			if _, err := middleware.ValidateToken(ctx, tokenString, h.tokens, h.sessions); err != nil {
+				writeSSE(c.Writer, "error", "", problem.New(problem.CodeUnauthenticated, "Token no longer valid"))
+				c.Writer.Flush()
+				return
+			}
+			writeSSE(c.Writer, "heartbeat", "", gin.H{"time": time.Now().UTC()})
+			c.Writer.Flush()
+		}
+	}
+}
+
+func lastEventID(c *gin.Context) (int64, error) {
+	raw := c.GetHeader("Last-Event-ID")
+	if raw == "" {
+		raw = c.Query("last_event_id")
+	}
+	if raw == "" {
+		return 0, nil
+	}
+	return strconv.ParseInt(raw, 10, 64)
+}
+
+func containsWallet(walletIDs []uuid.UUID, walletID uuid.UUID) bool {
+	for _, id := range walletIDs {
+		if id == walletID {
+			return true
+		}
+	}
+	return false
+}
+
+func writeEvent(w io.Writer, event *models.OutboxEvent) {
+	writeSSE(w, string(event.EventType), strconv.FormatInt(event.PublishSequence, 10), event)
+}
+
+func writeSSE(w io.Writer, eventName, id string, data interface{}) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return
+	}
+
+	if id != "" {
+		fmt.Fprintf(w, "id: %s\n", id)
+	}
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", eventName, payload)
+}
@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"wallet-service/internal/models"
+	"wallet-service/internal/problem"
+	"wallet-service/internal/repository"
+	"wallet-service/internal/twofactor"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TwoFactorHandler struct {
+	userRepo  repository.IUserRepository
+	twoFactor *twofactor.Service
+}
+
+func NewTwoFactorHandler(userRepo repository.IUserRepository, twoFactor *twofactor.Service) *TwoFactorHandler {
+	return &TwoFactorHandler{
+		userRepo:  userRepo,
+		twoFactor: twoFactor,
+	}
+}
+
+// Enroll returns a new TOTP secret and its otpauth:// provisioning URI to be
+// shown as a QR code; two-factor stays off until Confirm
+func (h *TwoFactorHandler) Enroll(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	user, err := h.userRepo.GetByID(userID)
+	if err != nil {
+		problem.Respond(c, problem.CodeInternal, "Failed to get user")
+		return
+	}
+	if user == nil {
+		problem.Respond(c, problem.CodeNotFound, "User not found")
+		return
+	}
+
+	response, err := h.twoFactor.Enroll(c.Request.Context(), user)
+	if err != nil {
+		respondTwoFactorError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// Confirm enables two-factor with a first code and returns the recovery
+// codes, which cannot be retrieved again
+func (h *TwoFactorHandler) Confirm(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var req models.TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		problem.Invalid(c, "Invalid request body", err)
+		return
+	}
+
+	codes, err := h.twoFactor.Confirm(c.Request.Context(), userID, req.Code)
+	if err != nil {
+		respondTwoFactorError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// Disable turns two-factor off; the route requires a step-up code
+func (h *TwoFactorHandler) Disable(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.twoFactor.Disable(c.Request.Context(), userID); err != nil {
+		respondTwoFactorError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Two-factor authentication disabled"})
+}
+
+// RegenerateRecoveryCodes replaces all recovery codes; the route requires a
+// step-up code
+func (h *TwoFactorHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	codes, err := h.twoFactor.RegenerateRecoveryCodes(c.Request.Context(), userID)
+	if err != nil {
+		respondTwoFactorError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+func respondTwoFactorError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, twofactor.ErrNotEnrolled):
+		problem.Respond(c, problem.CodeConflict, "Two-factor authentication is not enabled")
+	case errors.Is(err, twofactor.ErrAlreadyEnabled):
+		problem.Respond(c, problem.CodeConflict, "Two-factor authentication is already enabled")
+	case errors.Is(err, twofactor.ErrInvalidCode):
+		problem.Respond(c, problem.CodeOTPInvalid, "Invalid or already used one-time code")
+	case errors.Is(err, twofactor.ErrTooManyAttempts):
+		problem.Respond(c, problem.CodeRateLimited, "Too many one-time code attempts, try again later")
+	default:
+		problem.Respond(c, problem.CodeInternal, "Failed to update two-factor authentication")
+	}
+}
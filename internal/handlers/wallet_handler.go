@@ -3,489 +3,327 @@ package handlers
 import (
 	"context"
 	"database/sql"
-	"fmt"
+	"errors"
+	"io"
 	"net/http"
 	"strconv"
 
+	"wallet-service/internal/apikey"
+	"wallet-service/internal/idempotency"
 	"wallet-service/internal/models"
-	"wallet-service/internal/repository"
+	"wallet-service/internal/pricefeed"
+	"wallet-service/internal/problem"
+	"wallet-service/internal/service"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
-	"github.com/shopspring/decimal"
 )
 
+// WalletHandler is the HTTP frontend of the wallet service. It parses
+// requests and shapes responses; the rules live in service.WalletService, and
+// its errors are reported with c.Error for middleware.HandleErrors to answer.
 type WalletHandler struct {
-	walletRepo      repository.IWalletRepository
-	transactionRepo repository.ITransactionRepository
-	txManager       *repository.TransactionManager
+	wallets   *service.WalletService
+	priceFeed *pricefeed.Feed
 }
 
-func NewWalletHandler(walletRepo repository.IWalletRepository, transactionRepo repository.ITransactionRepository, txManager *repository.TransactionManager) *WalletHandler {
+func NewWalletHandler(wallets *service.WalletService, priceFeed *pricefeed.Feed) *WalletHandler {
 	return &WalletHandler{
-		walletRepo:      walletRepo,
-		transactionRepo: transactionRepo,
-		txManager:       txManager,
+		wallets:   wallets,
+		priceFeed: priceFeed,
 	}
 }
 
 func (h *WalletHandler) Deposit(c *gin.Context) {
-	walletID, err := uuid.Parse(c.Param("wallet_id"))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid wallet ID"})
+	walletID, ok := walletIDParam(c)
+	if !ok {
 		return
 	}
 
 	var req models.DepositRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
-		return
-	}
-
-	// Get user ID from context
-	userIDStr, exists := c.Get("user_id")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found"})
+		problem.Invalid(c, "Invalid request body", err)
 		return
 	}
 
-	userID, err := uuid.Parse(userIDStr.(string))
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid user ID"})
+	userID, ok := currentUserID(c)
+	if !ok {
 		return
 	}
 
-	// Get wallet and verify ownership
-	wallet, err := h.walletRepo.GetByID(walletID)
+	var response gin.H
+	receipt, err := h.wallets.Deposit(c.Request.Context(), userID, walletID, req.Amount, claim(c, func(receipt *service.Receipt) gin.H {
+		response = gin.H{"message": "Deposit successful", "amount": receipt.Amount}
+		return response
+	}))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get wallet"})
+		c.Error(err)
 		return
 	}
 
-	if wallet == nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Wallet not found"})
-		return
-	}
-
-	if wallet.UserID != userID {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
-		return
-	}
-
-	// Perform deposit transaction
-	err = h.performDeposit(c.Request.Context(), wallet, req.Amount)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to process deposit: %v", err)})
-		return
-	}
-
-	c.JSON(http.StatusOK, gin.H{"message": "Deposit successful", "amount": req.Amount})
+	c.JSON(receiptStatus(receipt), response)
 }
 
+// Withdraw debits the wallet. Large withdrawals are held for review instead
+// and answered with 202 and the PENDING transaction.
 func (h *WalletHandler) Withdraw(c *gin.Context) {
-	walletID, err := uuid.Parse(c.Param("wallet_id"))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid wallet ID"})
+	walletID, ok := walletIDParam(c)
+	if !ok {
 		return
 	}
 
 	var req models.WithdrawRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		problem.Invalid(c, "Invalid request body", err)
 		return
 	}
 
-	// Get user ID from context
-	userIDStr, exists := c.Get("user_id")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found"})
+	userID, ok := currentUserID(c)
+	if !ok {
 		return
 	}
 
-	userID, err := uuid.Parse(userIDStr.(string))
+	var response gin.H
+	receipt, err := h.wallets.Withdraw(c.Request.Context(), userID, walletID, req.Amount, claim(c, func(receipt *service.Receipt) gin.H {
+		response = gin.H{"message": "Withdrawal successful", "amount": receipt.Amount}
+		if receipt.Status == models.TransactionStatusPending {
+			response = gin.H{"message": "Withdrawal is pending review", "amount": receipt.Amount, "transaction_id": receipt.TransactionID, "status": receipt.Status}
+		}
+		return response
+	}))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid user ID"})
+		c.Error(err)
 		return
 	}
 
-	// Get wallet and verify ownership
-	wallet, err := h.walletRepo.GetByID(walletID)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get wallet"})
-		return
-	}
+	c.JSON(receiptStatus(receipt), response)
+}
 
-	if wallet == nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Wallet not found"})
+func (h *WalletHandler) Transfer(c *gin.Context) {
+	walletID, ok := walletIDParam(c)
+	if !ok {
 		return
 	}
 
-	if wallet.UserID != userID {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+	var req models.TransferRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		problem.Invalid(c, "Invalid request body", err)
 		return
 	}
 
-	// Check sufficient balance
-	if wallet.Amount.LessThan(req.Amount) {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Insufficient balance"})
+	userID, ok := currentUserID(c)
+	if !ok {
 		return
 	}
 
-	// Perform withdrawal transaction
-	err = h.performWithdrawal(c.Request.Context(), wallet, req.Amount)
+	var response gin.H
+	receipt, err := h.wallets.Transfer(c.Request.Context(), userID, walletID, req.ReceiverWalletID, req.Amount, claim(c, func(receipt *service.Receipt) gin.H {
+		response = gin.H{"message": "Transfer successful", "amount": receipt.Amount}
+		return response
+	}))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to process withdrawal: %v", err)})
+		c.Error(err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Withdrawal successful", "amount": req.Amount})
+	c.JSON(receiptStatus(receipt), response)
 }
 
-func (h *WalletHandler) Transfer(c *gin.Context) {
-	walletID, err := uuid.Parse(c.Param("wallet_id"))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid wallet ID"})
-		return
-	}
-
-	var req models.TransferRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+func (h *WalletHandler) GetBalance(c *gin.Context) {
+	walletID, ok := walletIDParam(c)
+	if !ok {
 		return
 	}
 
-	// Get user ID from context
-	userIDStr, exists := c.Get("user_id")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found"})
+	userID, ok := currentUserID(c)
+	if !ok {
 		return
 	}
 
-	userID, err := uuid.Parse(userIDStr.(string))
+	response, err := h.wallets.Balance(userID, walletID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid user ID"})
+		c.Error(err)
 		return
 	}
 
-	// Get sender wallet and verify ownership
-	senderWallet, err := h.walletRepo.GetByID(walletID)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get sender wallet"})
-		return
-	}
+	c.JSON(http.StatusOK, response)
+}
 
-	if senderWallet == nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Sender wallet not found"})
+func (h *WalletHandler) GetTransactions(c *gin.Context) {
+	walletID, ok := walletIDParam(c)
+	if !ok {
 		return
 	}
 
-	if senderWallet.UserID != userID {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+	userID, ok := currentUserID(c)
+	if !ok {
 		return
 	}
 
-	// Get receiver wallet
-	receiverWallet, err := h.walletRepo.GetByID(req.ReceiverWalletID)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get receiver wallet"})
-		return
-	}
+	// Parse query parameters
+	var req models.TransactionHistoryRequest
 
-	if receiverWallet == nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Receiver wallet not found"})
-		return
+	if limitStr := c.Query("limit"); limitStr != "" {
+		if limit, err := strconv.Atoi(limitStr); err == nil {
+			req.Limit = &limit
+		}
 	}
 
-	// Check sufficient balance
-	if senderWallet.Amount.LessThan(req.Amount) {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Insufficient balance"})
-		return
+	if offsetStr := c.Query("offset"); offsetStr != "" {
+		if offset, err := strconv.Atoi(offsetStr); err == nil {
+			req.Offset = &offset
+		}
 	}
 
-	// Perform transfer transaction
-	err = h.performTransfer(c.Request.Context(), senderWallet, receiverWallet, req.Amount)
+	response, err := h.wallets.History(userID, walletID, &req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to process transfer: %v", err)})
+		c.Error(err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Transfer successful", "amount": req.Amount})
+	c.JSON(http.StatusOK, response)
 }
 
-func (h *WalletHandler) GetBalance(c *gin.Context) {
-	walletID, err := uuid.Parse(c.Param("wallet_id"))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid wallet ID"})
+func (h *WalletHandler) GetUserWallets(c *gin.Context) {
+	var req models.UserWalletsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		problem.Invalid(c, "Invalid query parameters", err)
 		return
 	}
 
-	// Get user ID from context
-	userIDStr, exists := c.Get("user_id")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found"})
+	if req.Currency != "" && !h.priceFeed.Supports(req.Currency) {
+		problem.Respond(c, problem.CodeInvalidRequest, "Unsupported currency")
 		return
 	}
 
-	userID, err := uuid.Parse(userIDStr.(string))
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid user ID"})
+	userID, ok := currentUserID(c)
+	if !ok {
 		return
 	}
 
-	// Get wallet and verify ownership
-	wallet, err := h.walletRepo.GetByID(walletID)
+	wallets, err := h.wallets.ListWallets(userID, req.IncludeClosed)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get wallet"})
+		c.Error(err)
 		return
 	}
 
-	if wallet == nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Wallet not found"})
-		return
+	// A wallet-restricted API key only sees its wallets
+	if key := apikey.FromContext(c); key != nil {
+		allowed := make([]models.Wallet, 0, len(wallets))
+		for _, wallet := range wallets {
+			if apikey.AllowsWallet(key, wallet.ID) {
+				allowed = append(allowed, wallet)
+			}
+		}
+		wallets = allowed
 	}
 
-	if wallet.UserID != userID {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
-		return
+	response := models.UserWalletsResponse{
+		UserID:  userID,
+		Wallets: wallets,
 	}
 
-	response := models.BalanceResponse{
-		WalletID:     wallet.ID,
-		CoinType:     wallet.CoinType,
-		Amount:       wallet.Amount,
-		FrozenAmount: wallet.FrozenAmount,
+	// Value wallets in the requested fiat currency
+	if req.Currency != "" {
+		valuation, err := h.priceFeed.Valuate(req.Currency, wallets)
+		if err != nil {
+			problem.Respond(c, problem.CodeInternal, "Failed to value wallets")
+			return
+		}
+		response.Valuation = valuation
 	}
 
 	c.JSON(http.StatusOK, response)
 }
 
-func (h *WalletHandler) GetTransactions(c *gin.Context) {
-	walletID, err := uuid.Parse(c.Param("wallet_id"))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid wallet ID"})
+// OpenWallet opens a wallet in a coin the caller has no open wallet in
+func (h *WalletHandler) OpenWallet(c *gin.Context) {
+	var req models.OpenWalletRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		problem.Invalid(c, "Invalid request body", err)
 		return
 	}
 
-	// Get user ID from context
-	userIDStr, exists := c.Get("user_id")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found"})
+	userID, ok := currentUserID(c)
+	if !ok {
 		return
 	}
 
-	userID, err := uuid.Parse(userIDStr.(string))
+	wallet, err := h.wallets.OpenWallet(c.Request.Context(), userID, req.CoinType)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid user ID"})
+		c.Error(err)
 		return
 	}
 
-	// Get wallet and verify ownership
-	wallet, err := h.walletRepo.GetByID(walletID)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get wallet"})
+	c.JSON(http.StatusCreated, wallet)
+}
+
+// CloseWallet closes one of the caller's wallets, sweeping any balance to
+// sweep_to_wallet_id; see service.WalletService.CloseWallet
+func (h *WalletHandler) CloseWallet(c *gin.Context) {
+	walletID, ok := walletIDParam(c)
+	if !ok {
 		return
 	}
 
-	if wallet == nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Wallet not found"})
+	// The body is optional when there is nothing to sweep
+	var req models.CloseWalletRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		problem.Invalid(c, "Invalid request body", err)
 		return
 	}
 
-	if wallet.UserID != userID {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+	userID, ok := currentUserID(c)
+	if !ok {
 		return
 	}
 
-	// Parse query parameters
-	var req models.TransactionHistoryRequest
-
-	if limitStr := c.Query("limit"); limitStr != "" {
-		if limit, err := strconv.Atoi(limitStr); err == nil {
-			req.Limit = &limit
+	var response gin.H
+	pending := idempotency.PendingFrom(c)
+	_, err := h.wallets.CloseWallet(c.Request.Context(), userID, walletID, req.SweepToWalletID, func(ctx context.Context, tx *sql.Tx, closure *service.Closure) error {
+		response = gin.H{"message": "Wallet closed", "wallet_id": closure.WalletID, "status": closure.Status}
+		if closure.Status == models.WalletStatusClosing {
+			response["message"] = "Wallet is closing; close it again once its frozen funds are released"
 		}
-	}
-
-	if offsetStr := c.Query("offset"); offsetStr != "" {
-		if offset, err := strconv.Atoi(offsetStr); err == nil {
-			req.Offset = &offset
+		if closure.SweepToWalletID != nil {
+			response["swept_amount"] = closure.SweptAmount
+			response["sweep_to_wallet_id"] = *closure.SweepToWalletID
 		}
-	}
 
-	// Get transaction history
-	response, err := h.transactionRepo.GetTransactionHistory(walletID, &req)
+		// Claim the idempotency key before any money moves
+		return pending.Record(ctx, tx, http.StatusOK, response)
+	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get transaction history"})
+		c.Error(err)
 		return
 	}
 
 	c.JSON(http.StatusOK, response)
 }
 
-func (h *WalletHandler) GetUserWallets(c *gin.Context) {
-	// Get user ID from context
-	userIDStr, exists := c.Get("user_id")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found"})
-		return
+// claim builds the response of a money movement and stores it under the
+// request's idempotency key in the movement's own transaction, so the
+// response is kept exactly when the ledger change commits
+func claim(c *gin.Context, respond func(receipt *service.Receipt) gin.H) service.Claim {
+	pending := idempotency.PendingFrom(c)
+	return func(ctx context.Context, tx *sql.Tx, receipt *service.Receipt) error {
+		return pending.Record(ctx, tx, receiptStatus(receipt), respond(receipt))
 	}
+}
 
-	userID, err := uuid.Parse(userIDStr.(string))
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid user ID"})
-		return
+// receiptStatus answers a withdrawal held for review with 202
+func receiptStatus(receipt *service.Receipt) int {
+	if receipt.Status == models.TransactionStatusPending {
+		return http.StatusAccepted
 	}
+	return http.StatusOK
+}
 
-	// Get all wallets for the user
-	wallets, err := h.walletRepo.GetByUserID(userID)
+func walletIDParam(c *gin.Context) (uuid.UUID, bool) {
+	walletID, err := uuid.Parse(c.Param("wallet_id"))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get user wallets"})
-		return
-	}
-
-	response := models.UserWalletsResponse{
-		UserID:  userID,
-		Wallets: wallets,
+		problem.Respond(c, problem.CodeInvalidRequest, "Invalid wallet ID")
+		return uuid.Nil, false
 	}
-
-	c.JSON(http.StatusOK, response)
-}
-
-// Helper methods for transaction processing
-func (h *WalletHandler) performDeposit(ctx context.Context, wallet *models.Wallet, amount decimal.Decimal) error {
-	return h.txManager.ExecuteTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
-		// Create transaction record
-		transaction := &models.Transaction{
-			ID:     uuid.New(),
-			Type:   models.TransactionTypeDeposit,
-			Status: models.TransactionStatusDone,
-		}
-
-		err := h.transactionRepo.CreateTransaction(ctx, tx, transaction)
-		if err != nil {
-			return fmt.Errorf("failed to create transaction: %w", err)
-		}
-
-		// Update wallet amount
-		newAmount := wallet.Amount.Add(amount)
-		err = h.walletRepo.UpdateAmount(ctx, tx, wallet.ID, newAmount)
-		if err != nil {
-			return fmt.Errorf("failed to update wallet amount: %w", err)
-		}
-
-		// Create transaction entry
-		entry := &models.TransactionEntry{
-			ID:        uuid.New(),
-			TxnID:     transaction.ID,
-			WalletID:  wallet.ID,
-			Direction: models.DirectionIn,
-			Amount:    amount,
-		}
-
-		err = h.transactionRepo.CreateTransactionEntry(ctx, tx, entry)
-		if err != nil {
-			return fmt.Errorf("failed to create transaction entry: %w", err)
-		}
-
-		return nil
-	})
-}
-
-func (h *WalletHandler) performWithdrawal(ctx context.Context, wallet *models.Wallet, amount decimal.Decimal) error {
-	return h.txManager.ExecuteTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
-		// Create transaction record
-		transaction := &models.Transaction{
-			ID:     uuid.New(),
-			Type:   models.TransactionTypeWithdrawal,
-			Status: models.TransactionStatusDone,
-		}
-
-		err := h.transactionRepo.CreateTransaction(ctx, tx, transaction)
-		if err != nil {
-			return fmt.Errorf("failed to create transaction: %w", err)
-		}
-
-		// Update wallet amount
-		newAmount := wallet.Amount.Sub(amount)
-		err = h.walletRepo.UpdateAmount(ctx, tx, wallet.ID, newAmount)
-		if err != nil {
-			return fmt.Errorf("failed to update wallet amount: %w", err)
-		}
-
-		// Create transaction entry
-		entry := &models.TransactionEntry{
-			ID:        uuid.New(),
-			TxnID:     transaction.ID,
-			WalletID:  wallet.ID,
-			Direction: models.DirectionOut,
-			Amount:    amount,
-		}
-
-		err = h.transactionRepo.CreateTransactionEntry(ctx, tx, entry)
-		if err != nil {
-			return fmt.Errorf("failed to create transaction entry: %w", err)
-		}
-
-		return nil
-	})
-}
-
-func (h *WalletHandler) performTransfer(ctx context.Context, senderWallet, receiverWallet *models.Wallet, amount decimal.Decimal) error {
-	return h.txManager.ExecuteTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
-		// Create transaction record
-		transaction := &models.Transaction{
-			ID:     uuid.New(),
-			Type:   models.TransactionTypeTransfer,
-			Status: models.TransactionStatusDone,
-		}
-
-		err := h.transactionRepo.CreateTransaction(ctx, tx, transaction)
-		if err != nil {
-			return fmt.Errorf("failed to create transaction: %w", err)
-		}
-
-		// Update sender wallet amount
-		senderNewAmount := senderWallet.Amount.Sub(amount)
-		err = h.walletRepo.UpdateAmount(ctx, tx, senderWallet.ID, senderNewAmount)
-		if err != nil {
-			return fmt.Errorf("failed to update sender wallet amount: %w", err)
-		}
-
-		// Update receiver wallet amount
-		receiverNewAmount := receiverWallet.Amount.Add(amount)
-		err = h.walletRepo.UpdateAmount(ctx, tx, receiverWallet.ID, receiverNewAmount)
-		if err != nil {
-			return fmt.Errorf("failed to update receiver wallet amount: %w", err)
-		}
-
-		// Create transaction entries
-		senderEntry := &models.TransactionEntry{
-			ID:                   uuid.New(),
-			TxnID:                transaction.ID,
-			WalletID:             senderWallet.ID,
-			Direction:            models.DirectionOut,
-			Amount:               amount,
-			CounterpartyWalletID: &receiverWallet.ID,
-		}
-
-		receiverEntry := &models.TransactionEntry{
-			ID:                   uuid.New(),
-			TxnID:                transaction.ID,
-			WalletID:             receiverWallet.ID,
-			Direction:            models.DirectionIn,
-			Amount:               amount,
-			CounterpartyWalletID: &senderWallet.ID,
-		}
-
-		err = h.transactionRepo.CreateTransactionEntry(ctx, tx, senderEntry)
-		if err != nil {
-			return fmt.Errorf("failed to create sender transaction entry: %w", err)
-		}
-
-		err = h.transactionRepo.CreateTransactionEntry(ctx, tx, receiverEntry)
-		if err != nil {
-			return fmt.Errorf("failed to create receiver transaction entry: %w", err)
-		}
-
-		return nil
-	})
+	return walletID, true
 }
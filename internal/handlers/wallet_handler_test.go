@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+
+	"wallet-service/internal/models"
+	"wallet-service/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+func TestMockWalletRepository_OneOpenWalletPerCoin(t *testing.T) {
+	walletRepo := repository.NewMockWalletRepository()
+	userID := uuid.New()
+
+	first := &models.Wallet{ID: uuid.New(), UserID: userID, CoinType: models.CoinTypeBTC}
+	if created, _ := walletRepo.Create(context.Background(), nil, first); !created {
+		t.Fatal("Expected first BTC wallet to be created")
+	}
+	if created, _ := walletRepo.Create(context.Background(), nil, &models.Wallet{ID: uuid.New(), UserID: userID, CoinType: models.CoinTypeBTC}); created {
+		t.Error("Expected a second open BTC wallet to be refused")
+	}
+
+	// Once closed, the coin can be opened again
+	walletRepo.UpdateStatus(context.Background(), nil, first.ID, models.WalletStatusClosed)
+	if first.ClosedAt == nil {
+		t.Error("Expected closed_at to be set")
+	}
+	if created, _ := walletRepo.Create(context.Background(), nil, &models.Wallet{ID: uuid.New(), UserID: userID, CoinType: models.CoinTypeBTC}); !created {
+		t.Error("Expected a new BTC wallet after closing the old one")
+	}
+}
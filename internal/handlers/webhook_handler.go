@@ -0,0 +1,251 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"wallet-service/internal/events"
+	"wallet-service/internal/models"
+	"wallet-service/internal/problem"
+	"wallet-service/internal/repository"
+	"wallet-service/internal/webhooks"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+type WebhookHandler struct {
+	webhookRepo repository.IWebhookRepository
+	worker      *webhooks.Worker
+}
+
+func NewWebhookHandler(webhookRepo repository.IWebhookRepository, worker *webhooks.Worker) *WebhookHandler {
+	return &WebhookHandler{
+		webhookRepo: webhookRepo,
+		worker:      worker,
+	}
+}
+
+func (h *WebhookHandler) CreateWebhook(c *gin.Context) {
+	var req models.CreateWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		problem.Invalid(c, "Invalid request body", err)
+		return
+	}
+
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	// Same message whatever the reason, so the check cannot be used to probe
+	// which internal hosts exist
+	if err := h.worker.ValidateURL(c.Request.Context(), req.URL); err != nil {
+		problem.Write(c, problem.New(problem.CodeValidationFailed, "Invalid request body").
+			Field("url", "public_https", "must be an https URL whose host resolves to public addresses"))
+		return
+	}
+
+	secret := req.Secret
+	if secret == "" {
+		generated, err := webhooks.GenerateSecret()
+		if err != nil {
+			problem.Respond(c, problem.CodeInternal, "Failed to generate secret")
+			return
+		}
+		secret = generated
+	}
+
+	endpoint := &models.WebhookEndpoint{
+		ID:         uuid.New(),
+		UserID:     userID,
+		URL:        req.URL,
+		Secret:     secret,
+		EventTypes: req.EventTypes,
+	}
+
+	if err := h.webhookRepo.CreateEndpoint(endpoint); err != nil {
+		problem.Respond(c, problem.CodeInternal, "Failed to create webhook")
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.CreateWebhookResponse{
+		WebhookEndpoint: *endpoint,
+		Secret:          secret,
+	})
+}
+
+func (h *WebhookHandler) ListWebhooks(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	endpoints, err := h.webhookRepo.GetEndpointsByUserID(userID)
+	if err != nil {
+		problem.Respond(c, problem.CodeInternal, "Failed to get webhooks")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"webhooks": endpoints})
+}
+
+func (h *WebhookHandler) DeleteWebhook(c *gin.Context) {
+	endpoint, ok := h.ownedEndpoint(c)
+	if !ok {
+		return
+	}
+
+	if err := h.webhookRepo.DeleteEndpoint(endpoint.ID); err != nil {
+		problem.Respond(c, problem.CodeInternal, "Failed to delete webhook")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *WebhookHandler) GetDeliveries(c *gin.Context) {
+	endpoint, ok := h.ownedEndpoint(c)
+	if !ok {
+		return
+	}
+
+	limit, offset := pagination(c, 50, 200)
+
+	deliveries, total, err := h.webhookRepo.GetDeliveriesByEndpointID(endpoint.ID, limit, offset)
+	if err != nil {
+		problem.Respond(c, problem.CodeInternal, "Failed to get deliveries")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.WebhookDeliveryListResponse{
+		Deliveries: deliveries,
+		Total:      total,
+	})
+}
+
+// RetryDelivery puts a delivery, typically a dead-lettered one, back in the queue
+func (h *WebhookHandler) RetryDelivery(c *gin.Context) {
+	endpoint, ok := h.ownedEndpoint(c)
+	if !ok {
+		return
+	}
+
+	deliveryID, err := uuid.Parse(c.Param("delivery_id"))
+	if err != nil {
+		problem.Respond(c, problem.CodeInvalidRequest, "Invalid delivery ID")
+		return
+	}
+
+	delivery, err := h.webhookRepo.GetDeliveryByID(deliveryID)
+	if err != nil {
+		problem.Respond(c, problem.CodeInternal, "Failed to get delivery")
+		return
+	}
+
+	if delivery == nil || delivery.EndpointID != endpoint.ID {
+		problem.Respond(c, problem.CodeNotFound, "Delivery not found")
+		return
+	}
+
+	if delivery.Status == models.WebhookDeliveryStatusDelivered {
+		problem.Respond(c, problem.CodeConflict, "Delivery already succeeded")
+		return
+	}
+
+	now := time.Now().UTC()
+	delivery.Status = models.WebhookDeliveryStatusPending
+	delivery.Attempts = 0
+	delivery.NextAttemptAt = &now
+
+	if err := h.webhookRepo.UpdateDelivery(c.Request.Context(), delivery); err != nil {
+		problem.Respond(c, problem.CodeInternal, "Failed to retry delivery")
+		return
+	}
+
+	c.JSON(http.StatusAccepted, delivery)
+}
+
+// TestWebhook sends a sample event to the endpoint right away and returns the
+// resulting delivery record
+func (h *WebhookHandler) TestWebhook(c *gin.Context) {
+	endpoint, ok := h.ownedEndpoint(c)
+	if !ok {
+		return
+	}
+
+	counterparty := uuid.New()
+	sample, err := json.Marshal(events.WalletBalanceChanged{
+		WalletID:             uuid.New(),
+		UserID:               endpoint.UserID,
+		CoinType:             models.CoinTypeBTC,
+		TransactionID:        uuid.New(),
+		TransactionType:      models.TransactionTypeTransfer,
+		EntryID:              uuid.New(),
+		Amount:               decimal.RequireFromString("0.015"),
+		Balance:              decimal.RequireFromString("1.25"),
+		CounterpartyWalletID: &counterparty,
+		OccurredAt:           time.Now().UTC(),
+	})
+	if err != nil {
+		problem.Respond(c, problem.CodeInternal, "Failed to build sample event")
+		return
+	}
+
+	delivery, err := webhooks.NewDelivery(endpoint.ID, uuid.New(), models.EventTypeWebhookTest, time.Now().UTC(), sample)
+	if err != nil {
+		problem.Respond(c, problem.CodeInternal, "Failed to build sample event")
+		return
+	}
+
+	// Keep the background worker away while this request attempts it
+	leasedUntil := time.Now().UTC().Add(time.Minute)
+	delivery.NextAttemptAt = &leasedUntil
+
+	ctx := c.Request.Context()
+	if err := h.webhookRepo.CreateDelivery(ctx, delivery); err != nil {
+		problem.Respond(c, problem.CodeInternal, "Failed to queue test delivery")
+		return
+	}
+
+	if err := h.worker.Attempt(ctx, endpoint, delivery); err != nil {
+		problem.Respond(c, problem.CodeInternal, "Failed to record test delivery")
+		return
+	}
+
+	c.JSON(http.StatusOK, delivery)
+}
+
+// ownedEndpoint loads the :webhook_id endpoint and verifies the caller owns it
+func (h *WebhookHandler) ownedEndpoint(c *gin.Context) (*models.WebhookEndpoint, bool) {
+	endpointID, err := uuid.Parse(c.Param("webhook_id"))
+	if err != nil {
+		problem.Respond(c, problem.CodeInvalidRequest, "Invalid webhook ID")
+		return nil, false
+	}
+
+	userID, ok := currentUserID(c)
+	if !ok {
+		return nil, false
+	}
+
+	endpoint, err := h.webhookRepo.GetEndpointByID(endpointID)
+	if err != nil {
+		problem.Respond(c, problem.CodeInternal, "Failed to get webhook")
+		return nil, false
+	}
+
+	if endpoint == nil {
+		problem.Respond(c, problem.CodeNotFound, "Webhook not found")
+		return nil, false
+	}
+
+	if endpoint.UserID != userID {
+		problem.Respond(c, problem.CodeForbidden, "Access denied")
+		return nil, false
+	}
+
+	return endpoint, true
+}
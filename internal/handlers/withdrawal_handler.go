@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+
+	"wallet-service/internal/mailer"
+	"wallet-service/internal/models"
+	"wallet-service/internal/problem"
+	"wallet-service/internal/repository"
+	"wallet-service/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// WithdrawalHandler serves the review queue for withdrawals held above their
+// coin's threshold: operators approve or reject them, and users may cancel
+// their own while they are pending
+type WithdrawalHandler struct {
+	userRepo repository.IUserRepository
+	wallets  *service.WalletService
+	mailer   mailer.Mailer
+}
+
+func NewWithdrawalHandler(userRepo repository.IUserRepository, wallets *service.WalletService, mailer mailer.Mailer) *WithdrawalHandler {
+	return &WithdrawalHandler{
+		userRepo: userRepo,
+		wallets:  wallets,
+		mailer:   mailer,
+	}
+}
+
+// ListUserWithdrawals returns the caller's withdrawals held for review, oldest
+// first. A wallet-restricted API key only sees its wallets' withdrawals.
+func (h *WithdrawalHandler) ListUserWithdrawals(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	h.list(c, &userID, keyWallets(c))
+}
+
+// ListWithdrawals is the staff review queue; ?status=PENDING shows only what
+// still needs a decision
+func (h *WithdrawalHandler) ListWithdrawals(c *gin.Context) {
+	h.list(c, nil, nil)
+}
+
+func (h *WithdrawalHandler) list(c *gin.Context, userID *uuid.UUID, walletIDs []uuid.UUID) {
+	status := models.WithdrawalReviewStatus(c.Query("status"))
+	switch status {
+	case "", models.WithdrawalReviewPending, models.WithdrawalReviewApproved, models.WithdrawalReviewRejected, models.WithdrawalReviewCancelled:
+	default:
+		problem.Respond(c, problem.CodeInvalidRequest, "Invalid status")
+		return
+	}
+
+	limit, offset := pagination(c, 20, 100)
+	withdrawals, total, err := h.wallets.ListWithdrawals(c.Request.Context(), status, userID, walletIDs, limit, offset)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.WithdrawalReviewListResponse{Withdrawals: withdrawals, Total: total})
+}
+
+// CancelWithdrawal lets the owner withdraw a pending withdrawal, releasing the
+// held amount. A wallet-restricted API key may only cancel its wallets'.
+func (h *WithdrawalHandler) CancelWithdrawal(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	transactionID, ok := transactionIDParam(c)
+	if !ok {
+		return
+	}
+
+	review, err := h.wallets.CancelWithdrawal(c.Request.Context(), userID, transactionID, keyWallets(c))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, review)
+}
+
+// ApproveWithdrawal books the held withdrawal: the amount leaves both the
+// wallet's balance and its frozen balance, and the transaction becomes DONE
+func (h *WithdrawalHandler) ApproveWithdrawal(c *gin.Context) {
+	h.review(c, true)
+}
+
+// RejectWithdrawal releases the held amount and fails the transaction
+func (h *WithdrawalHandler) RejectWithdrawal(c *gin.Context) {
+	h.review(c, false)
+}
+
+func (h *WithdrawalHandler) review(c *gin.Context, approve bool) {
+	transactionID, ok := transactionIDParam(c)
+	if !ok {
+		return
+	}
+
+	// The body is optional on approval
+	var req models.ReviewWithdrawalRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		problem.Invalid(c, "Invalid request body", err)
+		return
+	}
+	if !approve && req.Reason == "" {
+		problem.Write(c, problem.New(problem.CodeValidationFailed, "Reason required to reject").Field("reason", "required", "is required"))
+		return
+	}
+
+	decide := h.wallets.RejectWithdrawal
+	if approve {
+		decide = h.wallets.ApproveWithdrawal
+	}
+	review, err := decide(c.Request.Context(), transactionID, reviewerID(c), req.Reason)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	h.notify(review)
+	c.JSON(http.StatusOK, review)
+}
+
+// notify emails the owner about a staff decision; webhooks and the live
+// stream hear of it through the outbox
+func (h *WithdrawalHandler) notify(review *models.WithdrawalReview) {
+	user, err := h.userRepo.GetByID(review.UserID)
+	if err != nil || user == nil {
+		log.Printf("Failed to look up user %s to notify about withdrawal %s: %v", review.UserID, review.TransactionID, err)
+		return
+	}
+
+	msg := mailer.Message{
+		To:      user.Email,
+		Subject: "Your withdrawal was approved",
+		Body: fmt.Sprintf("Hello %s,\n\nyour withdrawal of %s %s (%s) was approved and has been debited from your wallet.",
+			user.Name, review.Amount, review.CoinType, review.TransactionID),
+	}
+	if review.Status == models.WithdrawalReviewRejected {
+		msg.Subject = "Your withdrawal was rejected"
+		msg.Body = fmt.Sprintf("Hello %s,\n\nyour withdrawal of %s %s (%s) was rejected: %s\n\nThe amount is available in your wallet again.",
+			user.Name, review.Amount, review.CoinType, review.TransactionID, review.ReviewNote)
+	}
+	sendMail(h.mailer, msg)
+}
+
+func transactionIDParam(c *gin.Context) (uuid.UUID, bool) {
+	transactionID, err := uuid.Parse(c.Param("transaction_id"))
+	if err != nil {
+		problem.Respond(c, problem.CodeInvalidRequest, "Invalid transaction ID")
+		return uuid.Nil, false
+	}
+	return transactionID, true
+}
@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"wallet-service/internal/apikey"
+	"wallet-service/internal/models"
+	"wallet-service/internal/repository"
+	"wallet-service/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+func TestWithdrawalHandler_UserAccess(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	reviewRepo := repository.NewMockWithdrawalReviewRepository()
+	handler := NewWithdrawalHandler(nil, service.NewWalletService(nil, nil, nil, reviewRepo, nil, nil, nil, nil), nil)
+
+	owner, other := uuid.New(), uuid.New()
+	review := &models.WithdrawalReview{TransactionID: uuid.New(), WalletID: uuid.New(), UserID: owner, CoinType: models.CoinTypeBTC, Amount: decimal.NewFromInt(5)}
+	reviewRepo.Create(context.Background(), nil, review)
+	reviewRepo.Create(context.Background(), nil, &models.WithdrawalReview{TransactionID: uuid.New(), UserID: other, Amount: decimal.NewFromInt(2)})
+
+	restricted := &models.APIKey{UserID: owner, WalletIDs: []uuid.UUID{uuid.New()}}
+
+	cancel := func(userID uuid.UUID, transactionID uuid.UUID, key *models.APIKey) error {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodPost, "/withdrawals/"+transactionID.String()+"/cancel", nil)
+		c.Params = gin.Params{{Key: "transaction_id", Value: transactionID.String()}}
+		c.Set("user_id", userID.String())
+		if key != nil {
+			c.Set(apikey.ContextKey, key)
+		}
+		handler.CancelWithdrawal(c)
+		if len(c.Errors) == 0 {
+			return nil
+		}
+		return c.Errors.Last().Err
+	}
+
+	if err := cancel(other, review.TransactionID, nil); !errors.Is(err, service.ErrForbidden) {
+		t.Errorf("Expected ErrForbidden cancelling another user's withdrawal, got %v", err)
+	}
+	if err := cancel(owner, review.TransactionID, restricted); !errors.Is(err, service.ErrForbidden) {
+		t.Errorf("Expected ErrForbidden cancelling with a key restricted to another wallet, got %v", err)
+	}
+	if err := cancel(owner, uuid.New(), nil); !errors.Is(err, service.ErrWithdrawalNotFound) {
+		t.Errorf("Expected ErrWithdrawalNotFound for an unknown withdrawal, got %v", err)
+	}
+	if review.Status != models.WithdrawalReviewPending {
+		t.Errorf("Expected the withdrawal still pending, got %s", review.Status)
+	}
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/withdrawals", nil)
+	c.Set("user_id", owner.String())
+	handler.ListUserWithdrawals(c)
+
+	var response models.WithdrawalReviewListResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if response.Total != 1 || response.Withdrawals[0].TransactionID != review.TransactionID {
+		t.Errorf("Expected only the caller's withdrawal, got %+v", response)
+	}
+
+	w = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/withdrawals", nil)
+	c.Set("user_id", owner.String())
+	c.Set(apikey.ContextKey, restricted)
+	handler.ListUserWithdrawals(c)
+
+	response = models.WithdrawalReviewListResponse{}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if response.Total != 0 || len(response.Withdrawals) != 0 {
+		t.Errorf("Expected no withdrawals for a key restricted to another wallet, got %+v", response)
+	}
+}
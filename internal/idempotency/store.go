@@ -0,0 +1,264 @@
+package idempotency
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"wallet-service/internal/models"
+	"wallet-service/internal/repository"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+const cleanupBatchSize = 1000
+
+// ErrKeyAlreadyUsed is returned by Pending.Record when another request with
+// the same key committed first
+var ErrKeyAlreadyUsed = errors.New("idempotency key already used")
+
+// Store keeps idempotent responses in Postgres, where they are committed in
+// the same transaction as the ledger change. Redis is only a fast path: it
+// caches completed responses and marks keys in flight to turn concurrent
+// repeats away early, and the Store stays correct without it.
+type Store struct {
+	idempotencyRepo repository.IIdempotencyRepository
+	txManager       *repository.TransactionManager
+	redisClient     *redis.Client
+	retention       time.Duration
+	lockTTL         time.Duration
+}
+
+func NewStore(idempotencyRepo repository.IIdempotencyRepository, txManager *repository.TransactionManager, redisClient *redis.Client, retention, lockTTL time.Duration) *Store {
+	return &Store{
+		idempotencyRepo: idempotencyRepo,
+		txManager:       txManager,
+		redisClient:     redisClient,
+		retention:       retention,
+		lockTTL:         lockTTL,
+	}
+}
+
+// Pending is a request that holds a key which has no stored response yet
+type Pending struct {
+	store       *Store
+	UserID      uuid.UUID
+	Endpoint    string
+	Key         string
+	Fingerprint string
+	lockValue   string
+
+	recorded *models.IdempotencyRecord
+}
+
+// ContextKey is where IdempotencyGuard leaves the request's Pending
+const ContextKey = "idempotency"
+
+// PendingFrom returns the Pending set by IdempotencyGuard, or nil
+func PendingFrom(c *gin.Context) *Pending {
+	pending, _ := c.Get(ContextKey)
+	p, _ := pending.(*Pending)
+	return p
+}
+
+// Record stores the response inside the caller's ledger transaction so the
+// response exists if and only if the money moved. It returns
+// ErrKeyAlreadyUsed, rolling the transaction back, when a concurrent request
+// with the same key committed first. A nil Pending records nothing.
+func (p *Pending) Record(ctx context.Context, tx *sql.Tx, statusCode int, body interface{}) error {
+	if p == nil {
+		return nil
+	}
+
+	raw, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to marshal idempotent response: %w", err)
+	}
+
+	record := p.newRecord(statusCode, "application/json; charset=utf-8", raw)
+	created, err := p.store.idempotencyRepo.Create(ctx, tx, record)
+	if err != nil {
+		return err
+	}
+	if !created {
+		return ErrKeyAlreadyUsed
+	}
+
+	p.recorded = record
+	return nil
+}
+
+func (p *Pending) newRecord(statusCode int, contentType string, body []byte) *models.IdempotencyRecord {
+	return &models.IdempotencyRecord{
+		ID:           uuid.New(),
+		UserID:       p.UserID,
+		Endpoint:     p.Endpoint,
+		Key:          p.Key,
+		Fingerprint:  p.Fingerprint,
+		StatusCode:   statusCode,
+		ContentType:  contentType,
+		ResponseBody: body,
+		ExpiresAt:    time.Now().UTC().Add(p.store.retention),
+	}
+}
+
+// Lookup returns the stored response for a key, checking the Redis cache
+// before Postgres. inFlight reports that another request holds the key.
+func (s *Store) Lookup(ctx context.Context, userID uuid.UUID, endpoint, key string) (record *models.IdempotencyRecord, inFlight bool, err error) {
+	cacheKey := s.cacheKey(userID, endpoint, key)
+
+	raw, err := s.redisClient.Get(ctx, cacheKey).Bytes()
+	if err == nil {
+		var cached cacheEntry
+		if json.Unmarshal(raw, &cached) == nil {
+			if cached.Record != nil {
+				return cached.Record, false, nil
+			}
+			inFlight = true
+		}
+	} else if err != redis.Nil {
+		log.Printf("Warning: idempotency cache unavailable: %v", err)
+	}
+
+	record, err = s.idempotencyRepo.Get(ctx, userID, endpoint, key)
+	if err != nil {
+		return nil, false, err
+	}
+	if record != nil {
+		s.cache(ctx, record)
+		return record, false, nil
+	}
+
+	return nil, inFlight, nil
+}
+
+// Begin claims a key that has no stored response. It returns nil when another
+// request already holds the key in Redis. When Redis is unavailable the claim
+// still succeeds and the unique constraint settles any race.
+func (s *Store) Begin(ctx context.Context, userID uuid.UUID, endpoint, key, fingerprint string) *Pending {
+	pending := &Pending{
+		store:       s,
+		UserID:      userID,
+		Endpoint:    endpoint,
+		Key:         key,
+		Fingerprint: fingerprint,
+	}
+
+	lockValue, _ := json.Marshal(cacheEntry{Owner: uuid.NewString()})
+	acquired, err := s.redisClient.SetNX(ctx, s.cacheKey(userID, endpoint, key), lockValue, s.lockTTL).Result()
+	if err != nil {
+		log.Printf("Warning: idempotency cache unavailable: %v", err)
+		return pending
+	}
+	if !acquired {
+		return nil
+	}
+
+	pending.lockValue = string(lockValue)
+	return pending
+}
+
+// Finish settles a Pending once the handler has run. A response recorded in
+// the ledger transaction is cached; other non-5xx outcomes (validation or
+// balance errors that moved no money) are stored on their own so repeats
+// replay them too; 5xx, 401 and 429 outcomes store nothing and free the key
+// for a retry, since a server error, a missing step-up code or a rate limit
+// says nothing final about the request. A request that lost the key to a
+// concurrent one caches the winner's stored response, never its own.
+func (s *Store) Finish(ctx context.Context, pending *Pending, statusCode int, contentType string, body []byte) {
+	record := pending.recorded
+
+	if record == nil && isFinal(statusCode) {
+		record = pending.newRecord(statusCode, contentType, body)
+		created := false
+		err := s.txManager.ExecuteTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+			var err error
+			created, err = s.idempotencyRepo.Create(ctx, tx, record)
+			return err
+		})
+		if err == nil && !created {
+			record, err = s.idempotencyRepo.Get(ctx, pending.UserID, pending.Endpoint, pending.Key)
+		}
+		if err != nil {
+			log.Printf("Warning: failed to store idempotent response: %v", err)
+			record = nil
+		}
+	}
+
+	if record != nil {
+		s.cache(ctx, record)
+		return
+	}
+
+	if pending.lockValue != "" {
+		releaseScript.Run(ctx, s.redisClient, []string{s.cacheKey(pending.UserID, pending.Endpoint, pending.Key)}, pending.lockValue)
+	}
+}
+
+func isFinal(statusCode int) bool {
+	return statusCode < 500 && statusCode != 401 && statusCode != 429
+}
+
+// StartCleanup deletes expired keys on every interval until ctx is done
+func (s *Store) StartCleanup(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				for {
+					deleted, err := s.idempotencyRepo.DeleteExpired(ctx, cleanupBatchSize)
+					if err != nil {
+						log.Printf("Warning: idempotency key cleanup failed: %v", err)
+					}
+					if err != nil || deleted < cleanupBatchSize {
+						break
+					}
+				}
+			}
+		}
+	}()
+}
+
+// cacheEntry is the Redis value for a key: an owner marker while in flight,
+// then the completed record
+type cacheEntry struct {
+	Owner  string                    `json:"owner,omitempty"`
+	Record *models.IdempotencyRecord `json:"record,omitempty"`
+}
+
+var releaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+
+func (s *Store) cache(ctx context.Context, record *models.IdempotencyRecord) {
+	ttl := time.Until(record.ExpiresAt)
+	if ttl <= 0 {
+		return
+	}
+
+	raw, err := json.Marshal(cacheEntry{Record: record})
+	if err != nil {
+		return
+	}
+
+	if err := s.redisClient.Set(ctx, s.cacheKey(record.UserID, record.Endpoint, record.Key), raw, ttl).Err(); err != nil {
+		log.Printf("Warning: failed to cache idempotent response: %v", err)
+	}
+}
+
+func (s *Store) cacheKey(userID uuid.UUID, endpoint, key string) string {
+	return fmt.Sprintf("idempotency:%s:%s:%s", userID, endpoint, key)
+}
@@ -0,0 +1,97 @@
+package kyc
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"wallet-service/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+func TestPolicy_Allows(t *testing.T) {
+	policy := NewPolicy(map[models.KYCTier]map[models.CoinType]decimal.Decimal{
+		models.KYCTierUnverified: {models.CoinTypeBTC: decimal.RequireFromString("0.01"), models.CoinTypeADA: decimal.NewFromInt(1000)},
+		models.KYCTierBasic:      {models.CoinTypeBTC: decimal.NewFromInt(1), models.CoinTypeETH: decimal.NewFromInt(-1)},
+	}, 24*time.Hour)
+
+	tests := []struct {
+		tier    models.KYCTier
+		coin    models.CoinType
+		used    string
+		amount  string
+		allowed bool
+	}{
+		{models.KYCTierUnverified, models.CoinTypeBTC, "0", "0.01", true},
+		{models.KYCTierUnverified, models.CoinTypeBTC, "0", "0.011", false},
+		// Each coin has its own cap
+		{models.KYCTierUnverified, models.CoinTypeADA, "0", "1000", true},
+		{models.KYCTierUnverified, models.CoinTypeADA, "0", "1001", false},
+		// What was already sent in the window counts
+		{models.KYCTierUnverified, models.CoinTypeADA, "900", "101", false},
+		{models.KYCTierBasic, models.CoinTypeBTC, "0.5", "0.5", true},
+		{models.KYCTierBasic, models.CoinTypeBTC, "0.5", "0.6", false},
+		// A negative cap, a coin left out and a tier left out are uncapped
+		{models.KYCTierBasic, models.CoinTypeETH, "0", "1000000", true},
+		{models.KYCTierBasic, models.CoinTypeADA, "0", "1000000", true},
+		{models.KYCTierFull, models.CoinTypeBTC, "0", "1000000", true},
+		// An unknown tier is held to the unverified cap
+		{models.KYCTier("platinum"), models.CoinTypeBTC, "0", "0.02", false},
+	}
+
+	for _, tt := range tests {
+		if got := policy.Allows(tt.tier, tt.coin, decimal.RequireFromString(tt.used), decimal.RequireFromString(tt.amount)); got != tt.allowed {
+			t.Errorf("%s %s %s+%s: expected %v, got %v", tt.tier, tt.coin, tt.used, tt.amount, tt.allowed, got)
+		}
+	}
+}
+
+func TestHigher(t *testing.T) {
+	if got := Higher(models.KYCTierFull, models.KYCTierBasic); got != models.KYCTierFull {
+		t.Errorf("Expected full to outrank basic, got %s", got)
+	}
+	if got := Higher(models.KYCTierUnverified, models.KYCTierBasic); got != models.KYCTierBasic {
+		t.Errorf("Expected basic to outrank unverified, got %s", got)
+	}
+}
+
+func TestStore_Save(t *testing.T) {
+	dir := t.TempDir()
+	store := NewStore(dir, 64)
+	pdf := []byte("%PDF-1.4\n% test document\n")
+
+	stored, err := store.Save(uuid.New(), bytes.NewReader(pdf))
+	if err != nil {
+		t.Fatalf("Expected PDF accepted, got %v", err)
+	}
+	if stored.ContentType != "application/pdf" || stored.Size != int64(len(pdf)) || filepath.Ext(stored.Path) != ".pdf" {
+		t.Errorf("Unexpected stored document %+v", stored)
+	}
+	if written, _ := os.ReadFile(stored.Path); !bytes.Equal(written, pdf) {
+		t.Error("Expected the file written unchanged")
+	}
+
+	if _, err := store.Save(uuid.New(), bytes.NewReader([]byte("#!/bin/sh\nrm -rf /\n"))); !errors.Is(err, ErrUnsupportedDocument) {
+		t.Errorf("Expected ErrUnsupportedDocument, got %v", err)
+	}
+
+	large := append([]byte("%PDF-1.4\n"), bytes.Repeat([]byte("x"), 64)...)
+	if _, err := store.Save(uuid.New(), bytes.NewReader(large)); !errors.Is(err, ErrDocumentTooLarge) {
+		t.Errorf("Expected ErrDocumentTooLarge, got %v", err)
+	}
+
+	// Refused documents leave nothing behind
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("Expected only the accepted document on disk, found %d files", len(entries))
+	}
+
+	if _, err := store.Open(filepath.Join(dir, "..", "secrets")); err == nil {
+		t.Error("Expected a path outside the store to be refused")
+	}
+}
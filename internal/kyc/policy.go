@@ -0,0 +1,78 @@
+package kyc
+
+import (
+	"time"
+
+	"wallet-service/internal/models"
+
+	"github.com/shopspring/decimal"
+)
+
+// tierRank orders tiers from least to most verified
+var tierRank = map[models.KYCTier]int{
+	models.KYCTierUnverified: 0,
+	models.KYCTierBasic:      1,
+	models.KYCTierFull:       2,
+}
+
+// IsTier reports whether tier is one the service knows
+func IsTier(tier models.KYCTier) bool {
+	_, ok := tierRank[tier]
+	return ok
+}
+
+// Higher returns the more verified of two tiers
+func Higher(a, b models.KYCTier) models.KYCTier {
+	if tierRank[b] > tierRank[a] {
+		return b
+	}
+	return a
+}
+
+// Policy caps how much of each coin a user may withdraw or transfer out
+// over a rolling window, by tier. Deposits are never capped, and neither is a
+// coin a tier has no limit for.
+type Policy struct {
+	limits map[models.KYCTier]map[models.CoinType]decimal.Decimal
+	window time.Duration
+}
+
+// NewPolicy takes each tier's per-coin caps and the window they apply over;
+// a negative cap removes it
+func NewPolicy(limits map[models.KYCTier]map[models.CoinType]decimal.Decimal, window time.Duration) *Policy {
+	policy := &Policy{
+		limits: make(map[models.KYCTier]map[models.CoinType]decimal.Decimal),
+		window: window,
+	}
+	for tier, coins := range limits {
+		policy.limits[tier] = make(map[models.CoinType]decimal.Decimal)
+		for coin, limit := range coins {
+			if !limit.IsNegative() {
+				policy.limits[tier][coin] = limit
+			}
+		}
+	}
+	return policy
+}
+
+// Window is how far back outflows count towards the caps
+func (p *Policy) Window() time.Duration {
+	return p.window
+}
+
+// Limit returns the tier's cap for coin, and false when it has none
+func (p *Policy) Limit(tier models.KYCTier, coin models.CoinType) (decimal.Decimal, bool) {
+	// An unknown tier is treated as unverified rather than uncapped
+	if !IsTier(tier) {
+		tier = models.KYCTierUnverified
+	}
+	limit, ok := p.limits[tier][coin]
+	return limit, ok
+}
+
+// Allows reports whether a user in tier who has sent used of coin within the
+// window may send amount more
+func (p *Policy) Allows(tier models.KYCTier, coin models.CoinType, used, amount decimal.Decimal) bool {
+	limit, ok := p.Limit(tier, coin)
+	return !ok || used.Add(amount).LessThanOrEqual(limit)
+}
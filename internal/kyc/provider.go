@@ -0,0 +1,39 @@
+package kyc
+
+import (
+	"context"
+
+	"wallet-service/internal/models"
+)
+
+// Decision is a provider's verdict on a submission. Tier is the tier granted
+// when Approved, which may be lower than the one requested.
+type Decision struct {
+	Approved bool
+	Tier     models.KYCTier
+	Note     string
+}
+
+// Provider checks submitted documents. A provider that can decide on the spot
+// returns its decision; one that needs more time, or a person, returns nil and
+// the submission waits in the review queue.
+type Provider interface {
+	Name() string
+	Check(ctx context.Context, submission *models.KYCSubmission, document *StoredDocument) (*Decision, error)
+}
+
+// ManualReview decides nothing itself: every submission goes to staff, who
+// approve or reject it under /admin/kyc
+type ManualReview struct{}
+
+func NewManualReview() *ManualReview {
+	return &ManualReview{}
+}
+
+func (p *ManualReview) Name() string {
+	return "manual"
+}
+
+func (p *ManualReview) Check(ctx context.Context, submission *models.KYCSubmission, document *StoredDocument) (*Decision, error) {
+	return nil, nil
+}
@@ -0,0 +1,162 @@
+package kyc
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"wallet-service/internal/models"
+	"wallet-service/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrSubmissionNotFound = errors.New("KYC submission not found")
+	ErrAlreadyReviewed    = errors.New("KYC submission already reviewed")
+	ErrTierHeld           = errors.New("KYC tier already held")
+)
+
+// Service takes document submissions, hands them to the provider and applies
+// its decisions, or those of staff, to the user's tier
+type Service struct {
+	kycRepo   repository.IKYCRepository
+	userRepo  repository.IUserRepository
+	txManager *repository.TransactionManager
+	store     *Store
+	provider  Provider
+}
+
+func NewService(kycRepo repository.IKYCRepository, userRepo repository.IUserRepository, txManager *repository.TransactionManager, store *Store, provider Provider) *Service {
+	return &Service{
+		kycRepo:   kycRepo,
+		userRepo:  userRepo,
+		txManager: txManager,
+		store:     store,
+		provider:  provider,
+	}
+}
+
+// Submit stores a document for user towards tier and passes it to the
+// provider. The submission stays pending unless the provider decides at once.
+func (s *Service) Submit(ctx context.Context, user *models.User, tier models.KYCTier, documentType models.KYCDocumentType, fileName string, document io.Reader) (*models.KYCSubmission, error) {
+	if Higher(user.KYCTier, tier) == user.KYCTier {
+		return nil, ErrTierHeld
+	}
+
+	id := uuid.New()
+	stored, err := s.store.Save(id, document)
+	if err != nil {
+		return nil, err
+	}
+
+	submission := &models.KYCSubmission{
+		ID:            id,
+		UserID:        user.ID,
+		RequestedTier: tier,
+		DocumentType:  documentType,
+		FileName:      fileName,
+		ContentType:   stored.ContentType,
+		SizeBytes:     stored.Size,
+		SHA256:        stored.SHA256,
+		StoragePath:   stored.Path,
+		Provider:      s.provider.Name(),
+	}
+	if err := s.kycRepo.Create(ctx, submission); err != nil {
+		s.store.Remove(stored.Path)
+		return nil, err
+	}
+
+	// A provider failure leaves the submission in the queue for staff
+	decision, err := s.provider.Check(ctx, submission, stored)
+	if err != nil {
+		log.Printf("KYC provider %s failed on submission %s: %v", s.provider.Name(), submission.ID, err)
+		return submission, nil
+	}
+	if decision == nil {
+		return submission, nil
+	}
+
+	return s.decide(ctx, submission.ID, nil, decision)
+}
+
+// Approve grants tier for a pending submission. A user already holding a
+// higher tier keeps it.
+func (s *Service) Approve(ctx context.Context, submissionID uuid.UUID, reviewerID *uuid.UUID, tier models.KYCTier, note string) (*models.KYCSubmission, error) {
+	return s.decide(ctx, submissionID, reviewerID, &Decision{Approved: true, Tier: tier, Note: note})
+}
+
+func (s *Service) Reject(ctx context.Context, submissionID uuid.UUID, reviewerID *uuid.UUID, note string) (*models.KYCSubmission, error) {
+	return s.decide(ctx, submissionID, reviewerID, &Decision{Note: note})
+}
+
+// SetTier sets a user's tier outright, downgrades included
+func (s *Service) SetTier(ctx context.Context, userID uuid.UUID, tier models.KYCTier) error {
+	return s.txManager.ExecuteTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		return s.userRepo.UpdateKYCTier(ctx, tx, userID, tier)
+	})
+}
+
+// OpenDocument returns a submission and its stored file; the caller closes it
+func (s *Service) OpenDocument(ctx context.Context, submissionID uuid.UUID) (*models.KYCSubmission, *os.File, error) {
+	submission, err := s.kycRepo.GetByID(ctx, submissionID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if submission == nil {
+		return nil, nil, ErrSubmissionNotFound
+	}
+
+	file, err := s.store.Open(submission.StoragePath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open document: %w", err)
+	}
+	return submission, file, nil
+}
+
+// decide records decision on a pending submission and, when it approves,
+// raises the user's tier in the same transaction
+func (s *Service) decide(ctx context.Context, submissionID uuid.UUID, reviewerID *uuid.UUID, decision *Decision) (*models.KYCSubmission, error) {
+	status := models.KYCSubmissionRejected
+	if decision.Approved {
+		status = models.KYCSubmissionApproved
+	}
+
+	err := s.txManager.ExecuteTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		submission, err := s.kycRepo.GetByIDForUpdate(ctx, tx, submissionID)
+		if err != nil {
+			return err
+		}
+		if submission == nil {
+			return ErrSubmissionNotFound
+		}
+		if submission.Status != models.KYCSubmissionPending {
+			return ErrAlreadyReviewed
+		}
+
+		if err := s.kycRepo.Review(ctx, tx, submission.ID, status, reviewerID, decision.Note); err != nil {
+			return err
+		}
+		if !decision.Approved {
+			return nil
+		}
+
+		user, err := s.userRepo.GetByID(submission.UserID)
+		if err != nil {
+			return err
+		}
+		if user == nil {
+			return fmt.Errorf("user %s not found", submission.UserID)
+		}
+		return s.userRepo.UpdateKYCTier(ctx, tx, user.ID, Higher(user.KYCTier, decision.Tier))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.kycRepo.GetByID(ctx, submissionID)
+}
@@ -0,0 +1,115 @@
+package kyc
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrDocumentTooLarge    = errors.New("document too large")
+	ErrUnsupportedDocument = errors.New("unsupported document type")
+)
+
+// documentTypes are the file types accepted, by sniffed content type, with the
+// extension they are stored under
+var documentTypes = map[string]string{
+	"application/pdf": ".pdf",
+	"image/jpeg":      ".jpg",
+	"image/png":       ".png",
+}
+
+// StoredDocument describes a document written to the store
+type StoredDocument struct {
+	Path        string
+	ContentType string
+	Size        int64
+	SHA256      string
+}
+
+// Store keeps submitted documents on local disk, one file per submission,
+// named after the submission rather than anything the user sent
+type Store struct {
+	dir     string
+	maxSize int64
+}
+
+func NewStore(dir string, maxSize int64) *Store {
+	return &Store{dir: dir, maxSize: maxSize}
+}
+
+// Save writes the document for submission id. The type is taken from the
+// content, not the file name, and anything but a PDF, JPEG or PNG is refused.
+func (s *Store) Save(id uuid.UUID, r io.Reader) (*StoredDocument, error) {
+	head := make([]byte, 512)
+	n, err := io.ReadFull(r, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return nil, fmt.Errorf("failed to read document: %w", err)
+	}
+	head = head[:n]
+
+	contentType := http.DetectContentType(head)
+	ext, ok := documentTypes[contentType]
+	if !ok {
+		return nil, ErrUnsupportedDocument
+	}
+
+	if err := os.MkdirAll(s.dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create document directory: %w", err)
+	}
+
+	path := filepath.Join(s.dir, id.String()+ext)
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create document: %w", err)
+	}
+
+	hash := sha256.New()
+	// Read one byte past the limit to tell a file of exactly maxSize from a
+	// larger one
+	size, err := io.Copy(io.MultiWriter(file, hash), io.LimitReader(io.MultiReader(bytes.NewReader(head), r), s.maxSize+1))
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil && size > s.maxSize {
+		err = ErrDocumentTooLarge
+	}
+	if err != nil {
+		os.Remove(path)
+		if errors.Is(err, ErrDocumentTooLarge) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to write document: %w", err)
+	}
+
+	return &StoredDocument{
+		Path:        path,
+		ContentType: contentType,
+		Size:        size,
+		SHA256:      hex.EncodeToString(hash.Sum(nil)),
+	}, nil
+}
+
+// Open returns a stored document for a reviewer to read
+func (s *Store) Open(path string) (*os.File, error) {
+	// Paths come from the database, but never serve anything outside the store
+	rel, err := filepath.Rel(s.dir, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return nil, fmt.Errorf("document path outside the store")
+	}
+	return os.Open(path)
+}
+
+// Remove deletes a stored document that was never recorded
+func (s *Store) Remove(path string) {
+	os.Remove(path)
+}
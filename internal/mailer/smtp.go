@@ -0,0 +1,65 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/url"
+)
+
+// SMTPMailer sends through an SMTP relay, authenticating with PLAIN when the
+// URL carries credentials
+type SMTPMailer struct {
+	addr string
+	auth smtp.Auth
+	from string
+	// sender is the bare address used in MAIL FROM
+	sender string
+}
+
+func NewSMTPMailer(u *url.URL, from string) (*SMTPMailer, error) {
+	host := u.Hostname()
+	if host == "" {
+		return nil, fmt.Errorf("SMTP URL has no host")
+	}
+	port := u.Port()
+	if port == "" {
+		port = "587"
+	}
+
+	sender, err := mail.ParseAddress(from)
+	if err != nil {
+		return nil, fmt.Errorf("invalid MAIL_FROM: %w", err)
+	}
+
+	m := &SMTPMailer{
+		addr:   net.JoinHostPort(host, port),
+		from:   from,
+		sender: sender.Address,
+	}
+	if u.User != nil {
+		password, _ := u.User.Password()
+		m.auth = smtp.PlainAuth("", u.User.Username(), password, host)
+	}
+
+	return m, nil
+}
+
+func (m *SMTPMailer) Send(ctx context.Context, msg Message) error {
+	if !validHeader(msg.To) || !validHeader(msg.Subject) {
+		return fmt.Errorf("invalid mail header")
+	}
+
+	recipient, err := mail.ParseAddress(msg.To)
+	if err != nil {
+		return fmt.Errorf("invalid recipient: %w", err)
+	}
+
+	if err := smtp.SendMail(m.addr, m.auth, m.sender, []string{recipient.Address}, compose(m.from, msg)); err != nil {
+		return fmt.Errorf("failed to send mail: %w", err)
+	}
+
+	return nil
+}
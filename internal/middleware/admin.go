@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"crypto/subtle"
+
+	"wallet-service/internal/models"
+	"wallet-service/internal/problem"
+	"wallet-service/internal/session"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminTokenAuth guards the admin API with a shared operator token passed in
+// X-Admin-Token. The admin API is disabled when no token is configured.
+func AdminTokenAuth(adminToken string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if adminToken == "" {
+			problem.Respond(c, problem.CodeForbidden, "Admin API disabled")
+			c.Abort()
+			return
+		}
+
+		provided := c.GetHeader("X-Admin-Token")
+		if subtle.ConstantTimeCompare([]byte(provided), []byte(adminToken)) != 1 {
+			problem.Respond(c, problem.CodeUnauthenticated, "Invalid admin token")
+			c.Abort()
+			return
+		}
+
+		c.Set("audit_actor", "admin-token")
+		c.Set("role", string(models.RoleAdmin))
+		c.Next()
+	}
+}
+
+// StaffAuth guards the admin API. Staff log in like any user and are told
+// apart by the role in their access token; the shared X-Admin-Token still
+// works and acts as an admin. Routes then pick the roles they allow with
+// RequireRole.
+func StaffAuth(adminToken string, tokens TokenConfig, sessions *session.Store) gin.HandlerFunc {
+	adminTokenAuth := AdminTokenAuth(adminToken)
+	staffAuth := AuthMiddleware(tokens, sessions, nil)
+
+	return func(c *gin.Context) {
+		if c.GetHeader("X-Admin-Token") != "" {
+			adminTokenAuth(c)
+			return
+		}
+		staffAuth(c)
+	}
+}
@@ -0,0 +1,139 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+
+	"wallet-service/internal/audit"
+	"wallet-service/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+const maxAuditedBodySize = 1 << 20
+
+// auditedFields are the only request body fields copied into an audit record;
+// anything else (passwords, secrets) never reaches the log
+type auditedFields struct {
+	Amount           *decimal.Decimal `json:"amount"`
+	WalletID         *uuid.UUID       `json:"wallet_id"`
+	ReceiverWalletID *uuid.UUID       `json:"receiver_wallet_id"`
+	Email            string           `json:"email"`
+	Role             string           `json:"role"`
+	Status           string           `json:"status"`
+	Tier             string           `json:"tier"`
+	Direction        string           `json:"direction"`
+	TicketRef        string           `json:"ticket_ref"`
+	Reason           string           `json:"reason"`
+	SweepToWalletID  *uuid.UUID       `json:"sweep_to_wallet_id"`
+}
+
+// Audit records who called the route, what it touched and how it ended in the
+// hash-chained audit log once the handler has run
+func Audit(auditor *audit.Auditor, action string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var fields auditedFields
+		if c.Request.Body != nil {
+			body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxAuditedBodySize))
+			if err == nil {
+				// Hand the handler the whole body, not just the part read here
+				c.Request.Body = readCloser{io.MultiReader(bytes.NewReader(body), c.Request.Body), c.Request.Body}
+				json.Unmarshal(body, &fields)
+			}
+		}
+
+		c.Next()
+		renderErrors(c)
+
+		entry := &models.AuditLog{
+			IP:             c.ClientIP(),
+			UserAgent:      c.Request.UserAgent(),
+			Action:         action,
+			Method:         c.Request.Method,
+			Route:          c.FullPath(),
+			Amount:         fields.Amount,
+			IdempotencyKey: c.GetHeader("X-Idempotency-Key"),
+			StatusCode:     c.Writer.Status(),
+			Outcome:        audit.Outcome(c.Writer.Status()),
+		}
+
+		if userID, err := uuid.Parse(c.GetString("user_id")); err == nil {
+			entry.ActorID = &userID
+		}
+
+		if walletID, err := uuid.Parse(c.Param("wallet_id")); err == nil {
+			entry.WalletIDs = append(entry.WalletIDs, walletID)
+		}
+		if fields.WalletID != nil {
+			entry.WalletIDs = append(entry.WalletIDs, *fields.WalletID)
+		}
+		if fields.ReceiverWalletID != nil {
+			entry.WalletIDs = append(entry.WalletIDs, *fields.ReceiverWalletID)
+		}
+		if fields.SweepToWalletID != nil {
+			entry.WalletIDs = append(entry.WalletIDs, *fields.SweepToWalletID)
+		}
+
+		details := map[string]string{}
+		if fields.Email != "" {
+			details["email"] = fields.Email
+		}
+		if actor := c.GetString("audit_actor"); actor != "" {
+			details["actor"] = actor
+		}
+		// Staff calls name their role and the customer they touched
+		if role := c.GetString("role"); role != "" && role != string(models.RoleUser) {
+			details["role"] = role
+		}
+		if subject := c.Param("user_id"); subject != "" {
+			details["user_id"] = subject
+		}
+		if fields.Role != "" {
+			details["new_role"] = fields.Role
+		}
+		if fields.Status != "" {
+			details["new_status"] = fields.Status
+		}
+		if fields.Tier != "" {
+			details["new_tier"] = fields.Tier
+		}
+		if subject := c.Param("submission_id"); subject != "" {
+			details["submission_id"] = subject
+		}
+		if subject := c.Param("adjustment_id"); subject != "" {
+			details["adjustment_id"] = subject
+		}
+		if subject := c.Param("transaction_id"); subject != "" {
+			details["transaction_id"] = subject
+		}
+		if fields.Direction != "" {
+			details["direction"] = fields.Direction
+		}
+		if fields.TicketRef != "" {
+			details["ticket_ref"] = fields.TicketRef
+		}
+		if fields.Reason != "" {
+			details["reason"] = fields.Reason
+		}
+		if len(details) > 0 {
+			entry.Details, _ = json.Marshal(details)
+		}
+
+		// The request context may already be cancelled by a disconnecting client;
+		// the record must be written regardless
+		if err := auditor.Record(context.Background(), entry); err != nil {
+			log.Printf("Error: failed to write audit log for %s: %v", action, err)
+		}
+	}
+}
+
+// readCloser reads from Reader and closes the original body
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
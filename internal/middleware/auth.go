@@ -2,25 +2,35 @@ package middleware
 
 import (
 	"context"
-	"fmt"
-	"net/http"
+	"errors"
 	"strings"
 
+	"wallet-service/internal/apikey"
+	"wallet-service/internal/problem"
+	"wallet-service/internal/session"
+
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
-	"github.com/redis/go-redis/v9"
+	"github.com/google/uuid"
 )
 
-type Claims struct {
-	UserID string `json:"user_id"`
-	jwt.RegisteredClaims
-}
+var (
+	ErrTokenRevoked = errors.New("token revoked")
+	ErrInvalidToken = errors.New("invalid token")
+)
 
-func AuthMiddleware(jwtSecret string, redisClient *redis.Client) gin.HandlerFunc {
+// AuthMiddleware accepts a session JWT, or an API key either as the bearer
+// credential or in X-API-Key. API key requests only reach routes that declare
+// a scope with RequireScope. A nil apiKeys disables API keys.
+func AuthMiddleware(tokens TokenConfig, sessions *session.Store, apiKeys *apikey.Authenticator) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		if key := c.GetHeader("X-API-Key"); key != "" {
+			authenticateAPIKey(c, key, apiKeys)
+			return
+		}
+
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header required"})
+			problem.Respond(c, problem.CodeUnauthenticated, "Authorization header required")
 			c.Abort()
 			return
 		}
@@ -28,49 +38,111 @@ func AuthMiddleware(jwtSecret string, redisClient *redis.Client) gin.HandlerFunc
 		// Extract token from "Bearer <token>"
 		tokenParts := strings.Split(authHeader, " ")
 		if len(tokenParts) != 2 || tokenParts[0] != "Bearer" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid authorization header format"})
+			problem.Respond(c, problem.CodeUnauthenticated, "Invalid authorization header format")
 			c.Abort()
 			return
 		}
 
-		tokenString := tokenParts[1]
-
-		// Check if token is cached in Redis
-		ctx := context.Background()
-		redisKey := fmt.Sprintf("jwt:%s", tokenString)
-		_, err := redisClient.Get(ctx, redisKey).Result()
-		if err == redis.Nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Token not found in cache"})
-			c.Abort()
-			return
-		} else if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check token cache"})
-			c.Abort()
+		if apikey.IsKey(tokenParts[1]) {
+			authenticateAPIKey(c, tokenParts[1], apiKeys)
 			return
 		}
 
-		// Parse and validate JWT token
-		token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-			}
-			return []byte(jwtSecret), nil
-		})
+		authenticate(c, tokenParts[1], tokens, sessions)
+	}
+}
 
-		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
-			c.Abort()
+// StreamAuthMiddleware is AuthMiddleware for long-lived streaming endpoints.
+// Browsers' EventSource cannot set headers, so the token may also be passed
+// as the access_token query parameter.
+func StreamAuthMiddleware(tokens TokenConfig, sessions *session.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetHeader("Authorization") != "" {
+			AuthMiddleware(tokens, sessions, nil)(c)
 			return
 		}
 
-		if claims, ok := token.Claims.(*Claims); ok && token.Valid {
-			// Set user ID in context
-			c.Set("user_id", claims.UserID)
-			c.Next()
-		} else {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token claims"})
+		tokenString := c.Query("access_token")
+		if tokenString == "" {
+			problem.Respond(c, problem.CodeUnauthenticated, "Authorization header required")
 			c.Abort()
 			return
 		}
+
+		authenticate(c, tokenString, tokens, sessions)
+	}
+}
+
+func authenticate(c *gin.Context, tokenString string, tokens TokenConfig, sessions *session.Store) {
+	claims, err := ValidateToken(c.Request.Context(), tokenString, tokens, sessions)
+	switch {
+	case errors.Is(err, ErrTokenRevoked):
+		problem.Respond(c, problem.CodeUnauthenticated, "Token has been revoked")
+		c.Abort()
+		return
+	case errors.Is(err, ErrInvalidToken):
+		problem.Respond(c, problem.CodeUnauthenticated, "Invalid token")
+		c.Abort()
+		return
+	case err != nil:
+		problem.Respond(c, problem.CodeInternal, "Failed to check token cache")
+		c.Abort()
+		return
+	}
+
+	// Best effort: a failed touch only leaves last-used stale
+	sessionID, _ := uuid.Parse(claims.SessionID)
+	sessions.Touch(c.Request.Context(), sessionID, c.ClientIP())
+
+	// Set user ID in context
+	c.Set("user_id", claims.UserID)
+	c.Set("session_id", claims.SessionID)
+	c.Set("role", string(claims.Role))
+	c.Set("token", tokenString)
+	c.Next()
+}
+
+func authenticateAPIKey(c *gin.Context, presented string, apiKeys *apikey.Authenticator) {
+	if apiKeys == nil {
+		problem.Respond(c, problem.CodeUnauthenticated, "API keys are not accepted here")
+		c.Abort()
+		return
+	}
+
+	key, err := apiKeys.Authenticate(c.Request.Context(), presented)
+	switch {
+	case errors.Is(err, apikey.ErrInvalidKey):
+		problem.Respond(c, problem.CodeUnauthenticated, "Invalid API key")
+		c.Abort()
+		return
+	case err != nil:
+		problem.Respond(c, problem.CodeInternal, "Failed to check API key")
+		c.Abort()
+		return
+	}
+
+	c.Set("user_id", key.UserID.String())
+	c.Set(apikey.ContextKey, key)
+	c.Set("audit_actor", "api-key:"+key.ID.String())
+	c.Next()
+}
+
+// ValidateToken checks the token's signature and claims, and that it is still
+// the live access token of its session
+func ValidateToken(ctx context.Context, tokenString string, tokens TokenConfig, sessions *session.Store) (*Claims, error) {
+	// Parse and validate JWT token
+	claims, err := tokens.Parse(tokenString)
+	if err != nil {
+		return nil, err
+	}
+
+	sessionID, _ := uuid.Parse(claims.SessionID)
+	err = sessions.Validate(ctx, claims.ID, sessionID)
+	if errors.Is(err, session.ErrTokenRevoked) {
+		return nil, ErrTokenRevoked
+	} else if err != nil {
+		return nil, err
 	}
+
+	return claims, nil
 }
@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
+)
+
+// requestAmount peeks at the JSON "amount" of the request body, leaving the
+// body readable for the handler. ok is false when the body has no valid
+// amount, which the handler's binding then rejects.
+func requestAmount(c *gin.Context) (decimal.Decimal, bool, error) {
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		return decimal.Zero, false, err
+	}
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+	var req struct {
+		Amount *decimal.Decimal `json:"amount"`
+	}
+	if err := json.Unmarshal(body, &req); err != nil || req.Amount == nil {
+		return decimal.Zero, false, nil
+	}
+
+	return *req.Amount, true, nil
+}
@@ -0,0 +1,97 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"wallet-service/internal/idempotency"
+	"wallet-service/internal/problem"
+	"wallet-service/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// errorResponses maps the errors handlers report with c.Error to the problem
+// clients see. The first match wins.
+var errorResponses = []struct {
+	err    error
+	code   problem.Code
+	detail string
+}{
+	{service.ErrWalletNotFound, problem.CodeWalletNotFound, "Wallet not found"},
+	{service.ErrWithdrawalNotFound, problem.CodeNotFound, "Withdrawal not found"},
+	{service.ErrForbidden, problem.CodeForbidden, "Access denied"},
+	{service.ErrInvalidAmount, problem.CodeInvalidAmount, "Amount must be positive"},
+	{service.ErrInsufficientFunds, problem.CodeInsufficientFunds, "Insufficient balance"},
+	{service.ErrSameWallet, problem.CodeSameWallet, "Source and destination must be different wallets"},
+	{service.ErrCoinMismatch, problem.CodeCoinMismatch, "Wallets must hold the same coin"},
+	{service.ErrSweepTargetRequired, problem.CodeSweepTargetRequired, "Wallet has a balance; sweep_to_wallet_id is required"},
+	{service.ErrWalletExists, problem.CodeWalletExists, "A wallet in this coin is already open"},
+	{service.ErrWithdrawalNotPending, problem.CodeAlreadyReviewed, "Withdrawal is no longer pending"},
+	{idempotency.ErrKeyAlreadyUsed, problem.CodeIdempotencyKeyProcessed, "Request with this idempotency key was already processed"},
+}
+
+// HandleErrors answers the error a handler reported with c.Error when the
+// handler wrote no response itself. Known errors get a stable code; anything
+// else is INTERNAL_ERROR, whose details only reach the request log.
+func HandleErrors() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+		renderErrors(c)
+	}
+}
+
+// Recover answers a handler panic with a logged INTERNAL_ERROR problem
+// instead of an empty 500
+func Recover() gin.HandlerFunc {
+	return gin.CustomRecovery(func(c *gin.Context, _ interface{}) {
+		problem.Respond(c, problem.CodeInternal, "")
+		c.Abort()
+	})
+}
+
+// NotFound answers unknown routes and methods
+func NotFound(c *gin.Context) {
+	problem.Respond(c, problem.CodeNotFound, "No route for "+c.Request.Method+" "+c.Request.URL.Path)
+}
+
+// renderErrors writes the problem for the last reported error, once.
+// Middleware that read the response status after c.Next call it first, so
+// they see the mapped status rather than the default 200.
+func renderErrors(c *gin.Context) {
+	if len(c.Errors) == 0 || c.Writer.Written() {
+		return
+	}
+
+	problem.Write(c, ErrorProblem(c.Errors.Last().Err))
+}
+
+// ErrorProblem maps an error reported by the wallet service to the problem
+// clients see, for the REST and gRPC frontends alike
+func ErrorProblem(err error) *problem.Problem {
+	var notActive *service.WalletNotActiveError
+	if errors.As(err, &notActive) {
+		return problem.New(problem.CodeWalletNotActive, fmt.Sprintf("Wallet %s is %s", notActive.WalletID, strings.ToLower(string(notActive.Status)))).
+			With("wallet_id", notActive.WalletID).
+			With("wallet_status", notActive.Status)
+	}
+
+	var overLimit *service.KYCLimitError
+	if errors.As(err, &overLimit) {
+		return problem.New(problem.CodeKYCLimitExceeded, "Amount exceeds the limit for your verification tier").
+			With("kyc_tier", overLimit.Tier).
+			With("coin_type", overLimit.CoinType).
+			With("limit", overLimit.Limit).
+			With("used", overLimit.Used).
+			With("window", overLimit.Window.String())
+	}
+
+	for _, known := range errorResponses {
+		if errors.Is(err, known.err) {
+			return problem.New(known.code, known.detail)
+		}
+	}
+
+	return problem.New(problem.CodeInternal, "")
+}
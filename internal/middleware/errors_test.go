@@ -0,0 +1,82 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"wallet-service/internal/idempotency"
+	"wallet-service/internal/models"
+	"wallet-service/internal/problem"
+	"wallet-service/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+func TestErrorProblem(t *testing.T) {
+	tests := []struct {
+		err    error
+		status int
+	}{
+		{&service.WalletNotActiveError{WalletID: uuid.New(), Status: models.WalletStatusClosed}, http.StatusConflict},
+		{service.ErrInsufficientFunds, http.StatusBadRequest},
+		{fmt.Errorf("transfer: %w", service.ErrWalletNotFound), http.StatusNotFound},
+		{service.ErrForbidden, http.StatusForbidden},
+		{service.ErrCoinMismatch, http.StatusBadRequest},
+		{service.ErrSweepTargetRequired, http.StatusBadRequest},
+		{service.ErrWithdrawalNotFound, http.StatusNotFound},
+		{service.ErrWithdrawalNotPending, http.StatusConflict},
+		{fmt.Errorf("withdraw: %w", &service.KYCLimitError{Tier: models.KYCTierBasic, CoinType: models.CoinTypeBTC}), http.StatusForbidden},
+		{idempotency.ErrKeyAlreadyUsed, http.StatusConflict},
+		{errors.New("pq: connection reset"), http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		if p := ErrorProblem(tt.err); p.Status != tt.status {
+			t.Errorf("%v: expected %d, got %d", tt.err, tt.status, p.Status)
+		}
+	}
+
+	if p := ErrorProblem(service.ErrInsufficientFunds); p.Code != problem.CodeInsufficientFunds {
+		t.Errorf("Expected INSUFFICIENT_FUNDS, got %s", p.Code)
+	}
+
+	if p := ErrorProblem(&service.KYCLimitError{Tier: models.KYCTierBasic, CoinType: models.CoinTypeBTC}); p.Code != problem.CodeKYCLimitExceeded || p.Extensions["coin_type"] != models.CoinTypeBTC {
+		t.Errorf("Expected KYC_LIMIT_EXCEEDED naming the coin, got %+v", p)
+	}
+
+	// Unknown errors never reach the client
+	if p := ErrorProblem(errors.New("pq: connection reset")); p.Code != problem.CodeInternal || strings.Contains(p.Detail, "pq") {
+		t.Errorf("Expected a generic INTERNAL_ERROR, got %+v", p)
+	}
+}
+
+func TestHandleErrors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(HandleErrors())
+	router.GET("/failed", func(c *gin.Context) {
+		c.Error(service.ErrForbidden)
+	})
+	router.GET("/answered", func(c *gin.Context) {
+		c.Error(service.ErrForbidden)
+		c.JSON(http.StatusTeapot, gin.H{})
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/failed", nil))
+	if w.Code != http.StatusForbidden || w.Header().Get("Content-Type") != problem.ContentType || !strings.Contains(w.Body.String(), `"code":"FORBIDDEN"`) {
+		t.Errorf("Expected a 403 FORBIDDEN problem, got %d %s", w.Code, w.Body.String())
+	}
+
+	// A handler that answered itself keeps its response
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/answered", nil))
+	if w.Code != http.StatusTeapot {
+		t.Errorf("Expected the handler's own 418, got %d", w.Code)
+	}
+}
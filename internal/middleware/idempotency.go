@@ -1,52 +1,117 @@
 package middleware
 
 import (
+	"bytes"
 	"context"
-	"fmt"
-	"net/http"
-	"time"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+
+	"wallet-service/internal/idempotency"
+	"wallet-service/internal/problem"
 
 	"github.com/gin-gonic/gin"
-	"github.com/redis/go-redis/v9"
+	"github.com/google/uuid"
 )
 
-func IdempotencyGuard(redisClient *redis.Client) gin.HandlerFunc {
+// responseRecorder tees everything the handler writes so it can be stored
+type responseRecorder struct {
+	gin.ResponseWriter
+	body *bytes.Buffer
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
+
+func (r *responseRecorder) WriteString(s string) (int, error) {
+	r.body.WriteString(s)
+	return r.ResponseWriter.WriteString(s)
+}
+
+// IdempotencyGuard makes a mutating request safe to retry with the same
+// X-Idempotency-Key, scoped per user and endpoint. The first request's
+// response is stored (by the handler, inside its ledger transaction) and
+// replayed to every repeat. Reusing a key for a different request is rejected
+// with 422, a repeat arriving while the first is still running gets 409, and
+// 5xx outcomes leave nothing behind so the client can retry.
+func IdempotencyGuard(store *idempotency.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		userID, exists := c.Get("user_id")
-		if !exists {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+		userID, err := uuid.Parse(c.GetString("user_id"))
+		if err != nil {
+			problem.Respond(c, problem.CodeUnauthenticated, "User ID not found in context")
 			c.Abort()
 			return
 		}
 
 		idempotencyKey := c.GetHeader("X-Idempotency-Key")
 		if idempotencyKey == "" {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "X-Idempotency-Key header required"})
+			problem.Respond(c, problem.CodeIdempotencyKeyRequired, "X-Idempotency-Key header required")
 			c.Abort()
 			return
 		}
 
-		// Create Redis key for idempotency check
-		redisKey := fmt.Sprintf("txn_lock:%s:%s", userID, idempotencyKey)
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			problem.Respond(c, problem.CodeInvalidRequest, "Invalid request body")
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		endpoint := c.Request.Method + " " + c.FullPath()
+		fingerprint := requestFingerprint(c.Request.Method, c.Request.URL.Path, body)
 		ctx := context.Background()
 
-		// Try to set the key with 5 seconds TTL
-		// If key already exists, it means this request was already processed
-		success, err := redisClient.SetNX(ctx, redisKey, "1", 5*time.Second).Result()
+		record, inFlight, err := store.Lookup(ctx, userID, endpoint, idempotencyKey)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check idempotency"})
+			problem.Respond(c, problem.CodeInternal, "Failed to check idempotency")
 			c.Abort()
 			return
 		}
 
-		if !success {
-			c.JSON(http.StatusConflict, gin.H{"error": "Duplicate request detected"})
+		if record != nil {
+			if record.Fingerprint != fingerprint {
+				problem.Respond(c, problem.CodeIdempotencyKeyReused, "Idempotency key already used for a different request")
+				c.Abort()
+				return
+			}
+
+			c.Header("Idempotent-Replayed", "true")
+			c.Data(record.StatusCode, record.ContentType, record.ResponseBody)
 			c.Abort()
 			return
 		}
 
-		// Store the key in context for potential cleanup
-		c.Set("idempotency_key", redisKey)
+		var pending *idempotency.Pending
+		if !inFlight {
+			pending = store.Begin(ctx, userID, endpoint, idempotencyKey, fingerprint)
+		}
+		if pending == nil {
+			problem.Respond(c, problem.CodeIdempotencyKeyInProgress, "Request with this idempotency key is still in progress")
+			c.Abort()
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer, body: &bytes.Buffer{}}
+		c.Writer = recorder
+
+		c.Set(idempotency.ContextKey, pending)
 		c.Next()
+		renderErrors(c)
+
+		store.Finish(ctx, pending, recorder.Status(), recorder.Header().Get("Content-Type"), recorder.body.Bytes())
 	}
 }
+
+// requestFingerprint identifies the request a key was first used for
+func requestFingerprint(method, path string, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(method))
+	h.Write([]byte{0})
+	h.Write([]byte(path))
+	h.Write([]byte{0})
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,97 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+
+	"wallet-service/internal/openapi"
+	"wallet-service/internal/problem"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ValidateRequest checks path parameters, query parameters and JSON bodies
+// against the OpenAPI document before any other work is done for the
+// request. Violations are answered as VALIDATION_FAILED, with every rejected
+// field. Headers are left to the middleware that owns them, such as
+// IdempotencyGuard and RequireOTP, so their problems keep their own codes.
+func ValidateRequest(doc *openapi.Document) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		operation := doc.Operation(c.Request.Method, c.FullPath())
+		if operation == nil {
+			c.Next()
+			return
+		}
+
+		var violations []problem.FieldError
+		for _, parameter := range operation.Parameters {
+			parameter = doc.Parameter(parameter)
+			switch parameter.In {
+			case "path":
+				violations = append(violations, doc.ValidateParameter(parameter, c.Param(parameter.Name))...)
+			case "query":
+				raw, ok := c.GetQuery(parameter.Name)
+				if !ok {
+					if parameter.Required {
+						violations = append(violations, problem.FieldError{Field: parameter.Name, Rule: "required", Message: "is required"})
+					}
+					continue
+				}
+				violations = append(violations, doc.ValidateParameter(parameter, raw)...)
+			}
+		}
+
+		bodyViolations, ok := validateBody(c, doc, operation)
+		if !ok {
+			problem.Respond(c, problem.CodeInvalidRequest, "Invalid request body")
+			c.Abort()
+			return
+		}
+		violations = append(violations, bodyViolations...)
+
+		if len(violations) > 0 {
+			p := problem.New(problem.CodeValidationFailed, "Request does not match the API specification")
+			p.Errors = violations
+			problem.Write(c, p)
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// validateBody checks a JSON request body, leaving it readable for the
+// handler. ok is false when the body is not JSON at all.
+func validateBody(c *gin.Context, doc *openapi.Document, operation *openapi.Operation) ([]problem.FieldError, bool) {
+	if operation.RequestBody == nil || c.Request.Body == nil {
+		return nil, true
+	}
+	media, isJSON := operation.RequestBody.Content["application/json"]
+	if !isJSON {
+		return nil, true
+	}
+
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		return nil, false
+	}
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+	if len(bytes.TrimSpace(body)) == 0 {
+		if operation.RequestBody.Required {
+			return []problem.FieldError{{Field: "body", Rule: "required", Message: "is required"}}, true
+		}
+		return nil, true
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil || decoder.More() {
+		return nil, false
+	}
+
+	return doc.ValidateJSON(media.Schema, value), true
+}
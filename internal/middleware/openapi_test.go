@@ -0,0 +1,72 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"wallet-service/internal/openapi"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+func TestValidateRequest(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(ValidateRequest(openapi.Build("")))
+
+	// Handlers echo the body to show it is still readable
+	echo := func(c *gin.Context) {
+		body, _ := io.ReadAll(c.Request.Body)
+		c.String(http.StatusOK, string(body))
+	}
+	router.POST("/wallets/:wallet_id/deposit", echo)
+	router.POST("/wallets/:wallet_id/close", echo)
+	router.POST("/api-keys", echo)
+	router.GET("/admin/kyc/submissions", echo)
+	router.GET("/undocumented", echo)
+
+	walletPath := "/wallets/" + uuid.NewString()
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		status int
+		want   string
+	}{
+		{"valid deposit", http.MethodPost, walletPath + "/deposit", `{"amount": "10.50"}`, http.StatusOK, `{"amount": "10.50"}`},
+		{"numeric amount", http.MethodPost, walletPath + "/deposit", `{"amount": 10.5}`, http.StatusOK, ""},
+		{"bad wallet ID", http.MethodPost, "/wallets/42/deposit", `{"amount": "1"}`, http.StatusBadRequest, `"field":"wallet_id","rule":"format"`},
+		{"missing amount", http.MethodPost, walletPath + "/deposit", `{}`, http.StatusBadRequest, `"field":"amount","rule":"required"`},
+		{"amount not a decimal", http.MethodPost, walletPath + "/deposit", `{"amount": "ten"}`, http.StatusBadRequest, `"field":"amount","rule":"type"`},
+		{"missing body", http.MethodPost, walletPath + "/deposit", ``, http.StatusBadRequest, `"field":"body","rule":"required"`},
+		{"malformed JSON", http.MethodPost, walletPath + "/deposit", `{"amount":`, http.StatusBadRequest, `"code":"INVALID_REQUEST"`},
+		{"optional body", http.MethodPost, walletPath + "/close", ``, http.StatusOK, ""},
+		{"bad item", http.MethodPost, "/api-keys", `{"name": "ci", "scopes": ["wallets:read", "root"]}`, http.StatusBadRequest, `"field":"scopes[1]","rule":"enum"`},
+		{"null where not nullable", http.MethodPost, "/api-keys", `{"name": null, "scopes": ["wallets:read"]}`, http.StatusBadRequest, `"field":"name","rule":"nullable"`},
+		{"nullable field", http.MethodPost, "/api-keys", `{"name": "ci", "scopes": ["wallets:read"], "max_amount": null}`, http.StatusOK, ""},
+		{"bad query enum", http.MethodGet, "/admin/kyc/submissions?status=lost", ``, http.StatusBadRequest, `"field":"status","rule":"enum"`},
+		{"bad query integer", http.MethodGet, "/admin/kyc/submissions?limit=ten", ``, http.StatusBadRequest, `"field":"limit","rule":"type"`},
+		{"valid query", http.MethodGet, "/admin/kyc/submissions?status=pending&limit=10", ``, http.StatusOK, ""},
+		{"undocumented route", http.MethodGet, "/undocumented", ``, http.StatusOK, ""},
+	}
+
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+
+		if w.Code != tt.status {
+			t.Errorf("%s: expected %d, got %d %s", tt.name, tt.status, w.Code, w.Body.String())
+			continue
+		}
+		if !strings.Contains(w.Body.String(), tt.want) {
+			t.Errorf("%s: expected %s in %s", tt.name, tt.want, w.Body.String())
+		}
+	}
+}
@@ -0,0 +1,177 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"wallet-service/internal/apikey"
+	"wallet-service/internal/models"
+	"wallet-service/internal/problem"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+// tokenBucketScript takes one token from the bucket in KEYS[1], refilling
+// ARGV[1] tokens every ARGV[2] milliseconds. It reads the clock from Redis so
+// every API instance shares one notion of time. Returns whether the request is
+// allowed, the tokens left, the milliseconds until a token is available and
+// the milliseconds until the bucket is full again.
+var tokenBucketScript = redis.NewScript(`
+local capacity = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local rate = capacity / window
+
+local clock = redis.call('TIME')
+local now = tonumber(clock[1]) * 1000 + math.floor(tonumber(clock[2]) / 1000)
+
+local bucket = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(bucket[1])
+local ts = tonumber(bucket[2])
+if tokens == nil or ts == nil then
+	tokens = capacity
+	ts = now
+end
+tokens = math.min(capacity, tokens + math.max(0, now - ts) * rate)
+
+local allowed = 0
+local retry = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+else
+	retry = math.ceil((1 - tokens) / rate)
+end
+
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', now)
+redis.call('PEXPIRE', KEYS[1], window)
+
+return {allowed, math.floor(tokens), retry, math.ceil((capacity - tokens) / rate)}
+`)
+
+// RateLimitKey names who a request counts against
+type RateLimitKey func(c *gin.Context) string
+
+// ByIP counts requests per client IP, for routes without a caller identity
+func ByIP(c *gin.Context) string {
+	return IPKey(c.ClientIP())
+}
+
+// ByCaller counts requests per API key or user, falling back to the IP
+func ByCaller(c *gin.Context) string {
+	if key := CallerKey(apikey.FromContext(c), c.GetString("user_id")); key != "" {
+		return key
+	}
+	return ByIP(c)
+}
+
+// IPKey is the rate limit key of a client IP, shared by every frontend
+func IPKey(ip string) string {
+	return "ip:" + ip
+}
+
+// CallerKey is the rate limit key of an API key, or else of a user; it is
+// empty for an anonymous caller
+func CallerKey(key *models.APIKey, userID string) string {
+	if key != nil {
+		return "key:" + key.ID.String()
+	}
+	if userID != "" {
+		return "user:" + userID
+	}
+	return ""
+}
+
+// RateLimitPolicy allows Limit requests per Window for each key, refilled
+// continuously, so bursts up to Limit are allowed after a quiet period
+type RateLimitPolicy struct {
+	Name   string
+	Limit  int
+	Window time.Duration
+	Key    RateLimitKey
+}
+
+// RateLimiter enforces token bucket policies in Redis, shared by every API
+// instance
+type RateLimiter struct {
+	redisClient *redis.Client
+}
+
+func NewRateLimiter(redisClient *redis.Client) *RateLimiter {
+	return &RateLimiter{redisClient: redisClient}
+}
+
+// Enabled reports whether the policy limits anything
+func (p RateLimitPolicy) Enabled() bool {
+	return p.Limit > 0 && p.Window > 0
+}
+
+// RateLimitResult is the state of a caller's bucket after a request; times
+// are in whole seconds, rounded up, as the headers report them
+type RateLimitResult struct {
+	Allowed    bool
+	Remaining  int64
+	RetryAfter int64
+	Reset      int64
+}
+
+// Allow takes a token from key's bucket under the policy. It fails only when
+// Redis could not be asked, in which case callers let the request through,
+// since refusing all traffic would be worse than not limiting it.
+func (l *RateLimiter) Allow(ctx context.Context, policy RateLimitPolicy, key string) (*RateLimitResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer cancel()
+
+	result, err := tokenBucketScript.Run(ctx, l.redisClient, []string{"ratelimit:" + policy.Name + ":" + key}, policy.Limit, policy.Window.Milliseconds()).Int64Slice()
+	if err == nil && len(result) != 4 {
+		err = fmt.Errorf("unexpected token bucket result %v", result)
+	}
+	if err != nil {
+		log.Printf("Warning: rate limit check for %s failed: %v", policy.Name, err)
+		return nil, err
+	}
+
+	return &RateLimitResult{
+		Allowed:    result[0] == 1,
+		Remaining:  result[1],
+		RetryAfter: ceilSeconds(result[2]),
+		Reset:      ceilSeconds(result[3]),
+	}, nil
+}
+
+// Limit rejects requests over the policy with 429 and Retry-After, and reports
+// the caller's budget in X-RateLimit-* headers. A policy with no limit lets
+// everything through, as does an unreachable Redis.
+func (l *RateLimiter) Limit(policy RateLimitPolicy) gin.HandlerFunc {
+	if !policy.Enabled() {
+		return func(c *gin.Context) { c.Next() }
+	}
+
+	return func(c *gin.Context) {
+		result, err := l.Allow(c.Request.Context(), policy, policy.Key(c))
+		if err != nil {
+			c.Next()
+			return
+		}
+
+		c.Header("X-RateLimit-Limit", strconv.Itoa(policy.Limit))
+		c.Header("X-RateLimit-Remaining", strconv.FormatInt(result.Remaining, 10))
+		c.Header("X-RateLimit-Reset", strconv.FormatInt(result.Reset, 10))
+
+		if !result.Allowed {
+			c.Header("Retry-After", strconv.FormatInt(result.RetryAfter, 10))
+			problem.Respond(c, problem.CodeRateLimited, "Too many requests")
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+func ceilSeconds(milliseconds int64) int64 {
+	return (milliseconds + 999) / 1000
+}
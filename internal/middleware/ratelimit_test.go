@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"wallet-service/internal/apikey"
+	"wallet-service/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+func TestByCaller(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	keyID := uuid.New()
+	userID := uuid.NewString()
+
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodGet, "/wallets", nil)
+	c.Request.RemoteAddr = "203.0.113.7:4242"
+
+	if got := ByCaller(c); got != "ip:203.0.113.7" {
+		t.Errorf("Expected anonymous caller keyed by IP, got %s", got)
+	}
+
+	c.Set("user_id", userID)
+	if got := ByCaller(c); got != "user:"+userID {
+		t.Errorf("Expected user key, got %s", got)
+	}
+
+	// An API key counts separately from its owner's sessions
+	c.Set(apikey.ContextKey, &models.APIKey{ID: keyID})
+	if got := ByCaller(c); got != "key:"+keyID.String() {
+		t.Errorf("Expected API key, got %s", got)
+	}
+}
+
+func TestRateLimiter_DisabledPolicyPassesThrough(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	limiter := NewRateLimiter(nil)
+
+	router := gin.New()
+	router.GET("/", limiter.Limit(RateLimitPolicy{Name: "off", Limit: 0, Window: time.Minute, Key: ByIP}), func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+	if recorder.Code != http.StatusNoContent || recorder.Header().Get("X-RateLimit-Limit") != "" {
+		t.Errorf("Expected request through without headers, got %d %v", recorder.Code, recorder.Header())
+	}
+}
@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"wallet-service/internal/models"
+	"wallet-service/internal/problem"
+
+	"github.com/gin-gonic/gin"
+)
+
+// StaffRoles may look up other users' data under /admin
+var StaffRoles = []models.Role{models.RoleSupportReadOnly, models.RoleOperator, models.RoleAdmin}
+
+// IsRole reports whether role is one the service knows
+func IsRole(role models.Role) bool {
+	switch role {
+	case models.RoleUser, models.RoleSupportReadOnly, models.RoleOperator, models.RoleAdmin:
+		return true
+	}
+	return false
+}
+
+// RequireRole only lets callers holding one of roles through. The role comes
+// from the access token (or the admin token), so API keys never pass.
+func RequireRole(roles ...models.Role) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role := models.Role(c.GetString("role"))
+		for _, allowed := range roles {
+			if role == allowed {
+				c.Next()
+				return
+			}
+		}
+
+		problem.Respond(c, problem.CodeInsufficientRole, "Insufficient role")
+		c.Abort()
+	}
+}
@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"wallet-service/internal/apikey"
+	"wallet-service/internal/models"
+	"wallet-service/internal/problem"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// RequireScope admits API key requests whose key was granted scope, may act on
+// the route's :wallet_id and, for bodies with an amount, stays within the
+// key's per-operation limit. Requests with a user session pass untouched.
+func RequireScope(scope models.APIScope) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := apikey.FromContext(c)
+		if key == nil {
+			c.Next()
+			return
+		}
+
+		if !apikey.HasScope(key, scope) {
+			problem.Respond(c, problem.CodeInsufficientScope, "API key lacks scope "+string(scope))
+			c.Abort()
+			return
+		}
+
+		if walletID, err := uuid.Parse(c.Param("wallet_id")); err == nil && !apikey.AllowsWallet(key, walletID) {
+			problem.Respond(c, problem.CodeInsufficientScope, "API key is not allowed to use this wallet")
+			c.Abort()
+			return
+		}
+
+		if key.MaxAmount != nil {
+			amount, ok, err := requestAmount(c)
+			if err != nil {
+				problem.Respond(c, problem.CodeInvalidRequest, "Invalid request body")
+				c.Abort()
+				return
+			}
+			if ok && !apikey.AllowsAmount(key, amount) {
+				problem.Respond(c, problem.CodeAPIKeyLimitExceeded, "Amount exceeds the API key limit")
+				c.Abort()
+				return
+			}
+		}
+
+		c.Next()
+	}
+}
+
+// SessionOnly keeps API keys away from account and security routes, which
+// need a logged-in user
+func SessionOnly() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if apikey.FromContext(c) != nil {
+			problem.Respond(c, problem.CodeSessionRequired, "This endpoint requires a user session")
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
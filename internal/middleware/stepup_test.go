@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"context"
+	"testing"
+
+	"wallet-service/internal/models"
+	"wallet-service/internal/repository"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+func TestStepUpThresholds_Exempt(t *testing.T) {
+	walletRepo := repository.NewMockWalletRepository()
+	btc := &models.Wallet{ID: uuid.New(), UserID: uuid.New(), CoinType: models.CoinTypeBTC}
+	ada := &models.Wallet{ID: uuid.New(), UserID: uuid.New(), CoinType: models.CoinTypeADA}
+	eth := &models.Wallet{ID: uuid.New(), UserID: uuid.New(), CoinType: models.CoinTypeETH}
+	for _, wallet := range []*models.Wallet{btc, ada, eth} {
+		walletRepo.Create(context.Background(), nil, wallet)
+	}
+	thresholds := NewStepUpThresholds(walletRepo, map[models.CoinType]decimal.Decimal{
+		models.CoinTypeBTC: decimal.RequireFromString("0.05"),
+		models.CoinTypeADA: decimal.NewFromInt(2500),
+	})
+
+	tests := []struct {
+		walletID uuid.UUID
+		amount   string
+		exempt   bool
+	}{
+		{btc.ID, "0.05", true},
+		// The same amount is small in one coin and large in another
+		{btc.ID, "100", false},
+		{ada.ID, "100", true},
+		// A coin without a threshold always needs a code
+		{eth.ID, "0.001", false},
+		{uuid.New(), "0.001", false},
+	}
+
+	for _, tt := range tests {
+		exempt, err := thresholds.Exempt(tt.walletID, decimal.RequireFromString(tt.amount))
+		if err != nil || exempt != tt.exempt {
+			t.Errorf("%s %s: expected exempt %v, got %v (%v)", tt.walletID, tt.amount, tt.exempt, exempt, err)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package middleware
+
+import (
+	"fmt"
+	"time"
+
+	"wallet-service/internal/models"
+	"wallet-service/internal/signing"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+type Claims struct {
+	UserID    string      `json:"user_id"`
+	SessionID string      `json:"sid"`
+	Role      models.Role `json:"role"`
+	jwt.RegisteredClaims
+}
+
+// TokenConfig signs and verifies access tokens with the keyring's keys
+type TokenConfig struct {
+	Keys      *signing.Keyring
+	Issuer    string
+	Audience  string
+	AccessTTL time.Duration
+}
+
+// Issue mints a short-lived access token for the user's session with a fresh
+// token ID. The role is fixed for the token's lifetime.
+func (tc TokenConfig) Issue(userID, sessionID string, role models.Role) (string, *Claims, error) {
+	now := time.Now()
+	claims := &Claims{
+		UserID:    userID,
+		SessionID: sessionID,
+		Role:      role,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   userID,
+			ID:        uuid.NewString(),
+			Issuer:    tc.Issuer,
+			Audience:  jwt.ClaimStrings{tc.Audience},
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(tc.AccessTTL)),
+		},
+	}
+
+	key, err := tc.Keys.Current()
+	if err != nil {
+		return "", nil, err
+	}
+
+	token := jwt.NewWithClaims(key.Method(), claims)
+	token.Header["kid"] = key.ID
+	signed, err := token.SignedString(key.PrivateKey())
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to sign token: %w", err)
+	}
+
+	return signed, claims, nil
+}
+
+// Parse verifies the signature and the exp, iss, aud, sub, jti, sid and role
+// claims
+func (tc TokenConfig) Parse(tokenString string) (*Claims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		key, ok := tc.Keys.Lookup(kid)
+		if !ok {
+			return nil, fmt.Errorf("unknown or retired signing key %q", kid)
+		}
+		// The key decides the algorithm, never the token
+		if token.Method.Alg() != key.Algorithm {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return key.PublicKey(), nil
+	},
+		jwt.WithValidMethods([]string{signing.AlgorithmEdDSA, signing.AlgorithmRS256}),
+		jwt.WithIssuer(tc.Issuer),
+		jwt.WithAudience(tc.Audience),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+	)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	claims, ok := token.Claims.(*Claims)
+	if !ok || !token.Valid {
+		return nil, ErrInvalidToken
+	}
+
+	if claims.ID == "" || claims.Subject == "" || claims.Subject != claims.UserID {
+		return nil, ErrInvalidToken
+	}
+	if _, err := uuid.Parse(claims.Subject); err != nil {
+		return nil, ErrInvalidToken
+	}
+	if _, err := uuid.Parse(claims.SessionID); err != nil {
+		return nil, ErrInvalidToken
+	}
+	if !IsRole(claims.Role) {
+		return nil, ErrInvalidToken
+	}
+
+	return claims, nil
+}
@@ -0,0 +1,191 @@
+package middleware
+
+import (
+	"testing"
+	"time"
+
+	"wallet-service/internal/models"
+	"wallet-service/internal/signing"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+func testKeyring(t *testing.T, algorithm string) *signing.Keyring {
+	key, err := signing.GenerateKey(algorithm, time.Now().Add(-time.Hour))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	return signing.NewKeyring(key)
+}
+
+func testTokenConfig(t *testing.T) TokenConfig {
+	return TokenConfig{
+		Keys:      testKeyring(t, signing.AlgorithmEdDSA),
+		Issuer:    "wallet-service",
+		Audience:  "wallet-api",
+		AccessTTL: 15 * time.Minute,
+	}
+}
+
+func TestTokenConfig_IssueAndParse(t *testing.T) {
+	tokens := testTokenConfig(t)
+	userID := uuid.NewString()
+
+	tokenString, issued, err := tokens.Issue(userID, uuid.NewString(), models.RoleUser)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	claims, err := tokens.Parse(tokenString)
+	if err != nil {
+		t.Fatalf("Expected token to parse, got %v", err)
+	}
+	if claims.Subject != userID || claims.UserID != userID {
+		t.Errorf("Expected subject %s, got %s", userID, claims.Subject)
+	}
+	if claims.ID != issued.ID || claims.ID == "" {
+		t.Errorf("Expected jti %s, got %s", issued.ID, claims.ID)
+	}
+	if claims.Role != models.RoleUser {
+		t.Errorf("Expected role %s, got %s", models.RoleUser, claims.Role)
+	}
+}
+
+func TestTokenConfig_ParseRejectsForeignTokens(t *testing.T) {
+	tokens := testTokenConfig(t)
+	userID := uuid.NewString()
+
+	otherIssuer := tokens
+	otherIssuer.Issuer = "someone-else"
+	otherAudience := tokens
+	otherAudience.Audience = "another-api"
+	otherKey := tokens
+	otherKey.Keys = testKeyring(t, signing.AlgorithmEdDSA)
+	expired := tokens
+	expired.AccessTTL = -time.Minute
+
+	for name, issuer := range map[string]TokenConfig{
+		"issuer":   otherIssuer,
+		"audience": otherAudience,
+		"key":      otherKey,
+		"expired":  expired,
+	} {
+		tokenString, _, err := issuer.Issue(userID, uuid.NewString(), models.RoleUser)
+		if err != nil {
+			t.Fatalf("%s: expected no error, got %v", name, err)
+		}
+		if _, err := tokens.Parse(tokenString); err != ErrInvalidToken {
+			t.Errorf("%s: expected ErrInvalidToken, got %v", name, err)
+		}
+	}
+}
+
+func TestTokenConfig_ParseRequiresMatchingSubject(t *testing.T) {
+	tokens := testTokenConfig(t)
+	now := time.Now()
+
+	claims := &Claims{
+		UserID:    uuid.NewString(),
+		SessionID: uuid.NewString(),
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   uuid.NewString(),
+			ID:        uuid.NewString(),
+			Issuer:    tokens.Issuer,
+			Audience:  jwt.ClaimStrings{tokens.Audience},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(time.Minute)),
+		},
+	}
+	key, _ := tokens.Keys.Current()
+	token := jwt.NewWithClaims(key.Method(), claims)
+	token.Header["kid"] = key.ID
+	tokenString, _ := token.SignedString(key.PrivateKey())
+
+	if _, err := tokens.Parse(tokenString); err != ErrInvalidToken {
+		t.Errorf("Expected ErrInvalidToken for mismatched sub, got %v", err)
+	}
+}
+
+func TestTokenConfig_ParseRejectsUnknownRole(t *testing.T) {
+	tokens := testTokenConfig(t)
+
+	for _, role := range []models.Role{"", "superuser"} {
+		tokenString, _, err := tokens.Issue(uuid.NewString(), uuid.NewString(), role)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if _, err := tokens.Parse(tokenString); err != ErrInvalidToken {
+			t.Errorf("Expected ErrInvalidToken for role %q, got %v", role, err)
+		}
+	}
+}
+
+func TestTokenConfig_ParseRejectsSymmetricTokens(t *testing.T) {
+	tokens := testTokenConfig(t)
+	key, _ := tokens.Keys.Current()
+
+	// An HS256 token "signed" with the public key must not verify
+	now := time.Now()
+	userID := uuid.NewString()
+	claims := &Claims{
+		UserID:    userID,
+		SessionID: uuid.NewString(),
+		Role:      models.RoleAdmin,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   userID,
+			ID:        uuid.NewString(),
+			Issuer:    tokens.Issuer,
+			Audience:  jwt.ClaimStrings{tokens.Audience},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(time.Minute)),
+		},
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	token.Header["kid"] = key.ID
+	tokenString, _ := token.SignedString([]byte(key.JWK().X))
+
+	if _, err := tokens.Parse(tokenString); err != ErrInvalidToken {
+		t.Errorf("Expected ErrInvalidToken for HS256 token, got %v", err)
+	}
+}
+
+func TestTokenConfig_KeyRotation(t *testing.T) {
+	tokens := testTokenConfig(t)
+	tokens.Keys = testKeyring(t, signing.AlgorithmRS256)
+	oldKey, _ := tokens.Keys.Current()
+
+	oldToken, _, err := tokens.Issue(uuid.NewString(), uuid.NewString(), models.RoleUser)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	newKey, err := signing.GenerateKey(signing.AlgorithmEdDSA, time.Now().Add(-time.Minute))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// Within the grace period both keys verify and the new one signs
+	oldKey.RetiresAt = time.Now().Add(tokens.AccessTTL)
+	tokens.Keys.Set([]*signing.Key{oldKey, newKey})
+
+	newToken, _, err := tokens.Issue(uuid.NewString(), uuid.NewString(), models.RoleUser)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	for name, tokenString := range map[string]string{"old": oldToken, "new": newToken} {
+		if _, err := tokens.Parse(tokenString); err != nil {
+			t.Errorf("Expected %s token to parse, got %v", name, err)
+		}
+	}
+	if parsed, _, _ := jwt.NewParser().ParseUnverified(newToken, &Claims{}); parsed.Header["kid"] != newKey.ID {
+		t.Errorf("Expected new tokens to be signed by %s, got %v", newKey.ID, parsed.Header["kid"])
+	}
+
+	// Once retired, the old key no longer verifies
+	oldKey.RetiresAt = time.Now().Add(-time.Second)
+	tokens.Keys.Set([]*signing.Key{oldKey, newKey})
+	if _, err := tokens.Parse(oldToken); err != ErrInvalidToken {
+		t.Errorf("Expected ErrInvalidToken for retired key, got %v", err)
+	}
+}
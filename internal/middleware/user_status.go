@@ -0,0 +1,85 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"wallet-service/internal/accountstatus"
+	"wallet-service/internal/models"
+	"wallet-service/internal/problem"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// IsUserStatus reports whether status is one the service knows
+func IsUserStatus(status models.UserStatus) bool {
+	switch status {
+	case models.UserStatusActive, models.UserStatusSuspended, models.UserStatusLockedForReview:
+		return true
+	}
+	return false
+}
+
+// UserStatusGuard stops suspended and locked accounts after authentication.
+// Statuses in readAccess may still make safe (read-only) requests; any other
+// status that is not active is refused outright. It runs after AuthMiddleware,
+// so it covers access tokens and API keys alike.
+func UserStatusGuard(statuses *accountstatus.Cache, readAccess []models.UserStatus) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userIDStr := c.GetString("user_id")
+		// The admin token belongs to no user
+		if userIDStr == "" {
+			c.Next()
+			return
+		}
+
+		userID, err := uuid.Parse(userIDStr)
+		if err != nil {
+			problem.Respond(c, problem.CodeInternal, "Invalid user ID")
+			c.Abort()
+			return
+		}
+
+		status, err := statuses.Get(c.Request.Context(), userID)
+		if err != nil {
+			if errors.Is(err, accountstatus.ErrUserNotFound) {
+				problem.Respond(c, problem.CodeUnauthenticated, "User not found")
+			} else {
+				problem.Respond(c, problem.CodeInternal, "Failed to check account status")
+			}
+			c.Abort()
+			return
+		}
+
+		if !StatusAllows(status, c.Request.Method, readAccess) {
+			problem.Write(c, problem.New(problem.CodeAccountRestricted, "Account is "+strings.ReplaceAll(string(status), "-", " ")).With("account_status", status))
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// StatusAllows reports whether an account in status may make a request with
+// method. Frontends without HTTP methods pass GET for read-only calls.
+func StatusAllows(status models.UserStatus, method string, readAccess []models.UserStatus) bool {
+	if status == models.UserStatusActive {
+		return true
+	}
+
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+	default:
+		return false
+	}
+
+	for _, allowed := range readAccess {
+		if status == allowed {
+			return true
+		}
+	}
+	return false
+}
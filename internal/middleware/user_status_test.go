@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"net/http"
+	"testing"
+
+	"wallet-service/internal/models"
+)
+
+func TestStatusAllows(t *testing.T) {
+	readAccess := []models.UserStatus{models.UserStatusSuspended}
+
+	tests := []struct {
+		status  models.UserStatus
+		method  string
+		allowed bool
+	}{
+		{models.UserStatusActive, http.MethodPost, true},
+		{models.UserStatusActive, http.MethodGet, true},
+		{models.UserStatusSuspended, http.MethodGet, true},
+		{models.UserStatusSuspended, http.MethodPost, false},
+		{models.UserStatusSuspended, http.MethodDelete, false},
+		// Not granted read access, so even reads are refused
+		{models.UserStatusLockedForReview, http.MethodGet, false},
+		{models.UserStatusLockedForReview, http.MethodPut, false},
+	}
+
+	for _, tt := range tests {
+		if got := StatusAllows(tt.status, tt.method, readAccess); got != tt.allowed {
+			t.Errorf("%s %s: expected %v, got %v", tt.status, tt.method, tt.allowed, got)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
@@ -8,18 +9,40 @@ import (
 )
 
 type CoinType string
+type WalletStatus string
 type TransactionType string
 type TransactionStatus string
 type Direction string
+type EventType string
+type WebhookDeliveryStatus string
+type AuditOutcome string
+type APIScope string
+type Role string
+type UserStatus string
+type StatusReason string
+type KYCTier string
+type KYCDocumentType string
+type KYCSubmissionStatus string
+type AdjustmentStatus string
+type WithdrawalReviewStatus string
 
 const (
 	CoinTypeBTC CoinType = "BTC"
 	CoinTypeETH CoinType = "ETH"
 	CoinTypeADA CoinType = "ADA"
 
+	// Only ACTIVE wallets take part in deposits, withdrawals and transfers.
+	// CLOSING wallets wait for held funds to be released before they close.
+	WalletStatusActive  WalletStatus = "ACTIVE"
+	WalletStatusFrozen  WalletStatus = "FROZEN"
+	WalletStatusClosing WalletStatus = "CLOSING"
+	WalletStatusClosed  WalletStatus = "CLOSED"
+
 	TransactionTypeDeposit    TransactionType = "DEPOSIT"
 	TransactionTypeWithdrawal TransactionType = "WITHDRAWAL"
 	TransactionTypeTransfer   TransactionType = "TRANSFER"
+	// Staff corrections, booked once a second admin approves them
+	TransactionTypeAdjustment TransactionType = "ADJUSTMENT"
 
 	TransactionStatusPending TransactionStatus = "PENDING"
 	TransactionStatusDone    TransactionStatus = "DONE"
@@ -27,13 +50,86 @@ const (
 
 	DirectionIn  Direction = "IN"
 	DirectionOut Direction = "OUT"
+
+	EventTypeTransactionCompleted EventType = "TransactionCompleted"
+	EventTypeWalletDebited        EventType = "WalletDebited"
+	EventTypeWalletCredited       EventType = "WalletCredited"
+	EventTypeWebhookTest          EventType = "WebhookTest"
+	// A withdrawal held for review was requested, approved, rejected or
+	// cancelled
+	EventTypeWithdrawalStatusChanged EventType = "WithdrawalStatusChanged"
+
+	WebhookDeliveryStatusPending   WebhookDeliveryStatus = "PENDING"
+	WebhookDeliveryStatusDelivered WebhookDeliveryStatus = "DELIVERED"
+	WebhookDeliveryStatusDead      WebhookDeliveryStatus = "DEAD"
+
+	AuditOutcomeSuccess AuditOutcome = "SUCCESS"
+	AuditOutcomeDenied  AuditOutcome = "DENIED"
+	AuditOutcomeFailure AuditOutcome = "FAILURE"
+
+	ScopeWalletsRead      APIScope = "wallets:read"
+	ScopeTransactionsRead APIScope = "transactions:read"
+	ScopeDepositsWrite    APIScope = "deposits:write"
+	ScopeWithdrawalsWrite APIScope = "withdrawals:write"
+	ScopeTransfersWrite   APIScope = "transfers:write"
+	ScopeWebhooksRead     APIScope = "webhooks:read"
+	ScopeWebhooksWrite    APIScope = "webhooks:write"
+
+	RoleUser            Role = "user"
+	RoleSupportReadOnly Role = "support-readonly"
+	RoleOperator        Role = "operator"
+	RoleAdmin           Role = "admin"
+
+	// Users who are not active cannot move money or change their account;
+	// whether they keep read access is a configured policy
+	UserStatusActive          UserStatus = "active"
+	UserStatusSuspended       UserStatus = "suspended"
+	UserStatusLockedForReview UserStatus = "locked-for-review"
+
+	StatusReasonFraudSuspected   StatusReason = "fraud-suspected"
+	StatusReasonChargeback       StatusReason = "chargeback"
+	StatusReasonComplianceReview StatusReason = "compliance-review"
+	StatusReasonSanctionsHit     StatusReason = "sanctions-hit"
+	StatusReasonTermsViolation   StatusReason = "terms-violation"
+	StatusReasonCustomerRequest  StatusReason = "customer-request"
+	StatusReasonReviewCleared    StatusReason = "review-cleared"
+
+	// The KYC tier decides how much a user may withdraw or transfer at once
+	KYCTierUnverified KYCTier = "unverified"
+	KYCTierBasic      KYCTier = "basic"
+	KYCTierFull       KYCTier = "full"
+
+	KYCDocumentPassport       KYCDocumentType = "passport"
+	KYCDocumentNationalID     KYCDocumentType = "national-id"
+	KYCDocumentDriversLicense KYCDocumentType = "drivers-license"
+	KYCDocumentProofOfAddress KYCDocumentType = "proof-of-address"
+
+	KYCSubmissionPending  KYCSubmissionStatus = "pending"
+	KYCSubmissionApproved KYCSubmissionStatus = "approved"
+	KYCSubmissionRejected KYCSubmissionStatus = "rejected"
+
+	AdjustmentStatusPending  AdjustmentStatus = "PENDING"
+	AdjustmentStatusExecuted AdjustmentStatus = "EXECUTED"
+	AdjustmentStatusRejected AdjustmentStatus = "REJECTED"
+	AdjustmentStatusExpired  AdjustmentStatus = "EXPIRED"
+
+	WithdrawalReviewPending   WithdrawalReviewStatus = "PENDING"
+	WithdrawalReviewApproved  WithdrawalReviewStatus = "APPROVED"
+	WithdrawalReviewRejected  WithdrawalReviewStatus = "REJECTED"
+	WithdrawalReviewCancelled WithdrawalReviewStatus = "CANCELLED"
 )
 
 type User struct {
-	ID        uuid.UUID `json:"id" db:"id"`
-	Name      string    `json:"name" db:"name"`
-	Email     string    `json:"email" db:"email"`
-	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	ID              uuid.UUID    `json:"id" db:"id"`
+	Name            string       `json:"name" db:"name"`
+	Email           string       `json:"email" db:"email"`
+	PasswordHash    string       `json:"-" db:"password_hash"`
+	Role            Role         `json:"role" db:"role"`
+	Status          UserStatus   `json:"status" db:"status"`
+	StatusReason    StatusReason `json:"status_reason,omitempty" db:"status_reason"`
+	StatusChangedAt *time.Time   `json:"status_changed_at,omitempty" db:"status_changed_at"`
+	KYCTier         KYCTier      `json:"kyc_tier" db:"kyc_tier"`
+	CreatedAt       time.Time    `json:"created_at" db:"created_at"`
 }
 
 type Wallet struct {
@@ -42,7 +138,9 @@ type Wallet struct {
 	CoinType     CoinType        `json:"coin_type" db:"coin_type"`
 	Amount       decimal.Decimal `json:"amount" db:"amount"`
 	FrozenAmount decimal.Decimal `json:"frozen_amount" db:"frozen_amount"`
+	Status       WalletStatus    `json:"status" db:"status"`
 	CreatedAt    time.Time       `json:"created_at" db:"created_at"`
+	ClosedAt     *time.Time      `json:"closed_at,omitempty" db:"closed_at"`
 }
 
 type Transaction struct {
@@ -52,6 +150,16 @@ type Transaction struct {
 	CreatedAt time.Time         `json:"created_at" db:"created_at"`
 }
 
+type Price struct {
+	ID        uuid.UUID       `json:"id" db:"id"`
+	CoinType  CoinType        `json:"coin_type" db:"coin_type"`
+	Currency  string          `json:"currency" db:"currency"`
+	Price     decimal.Decimal `json:"price" db:"price"`
+	Source    string          `json:"source" db:"source"`
+	AsOf      time.Time       `json:"as_of" db:"as_of"`
+	CreatedAt time.Time       `json:"created_at" db:"created_at"`
+}
+
 type TransactionEntry struct {
 	ID                   uuid.UUID       `json:"id" db:"id"`
 	TxnID                uuid.UUID       `json:"txn_id" db:"txn_id"`
@@ -62,14 +170,404 @@ type TransactionEntry struct {
 	CreatedAt            time.Time       `json:"created_at" db:"created_at"`
 }
 
+// OutboxEvent is a domain event written in the same database transaction as
+// the ledger change it describes. Sequence is taken at insert and so is not
+// commit order; PublishSequence is assigned by the relay once the event has
+// committed, and is the order events are published and streams resume in.
+type OutboxEvent struct {
+	ID              uuid.UUID       `json:"id" db:"id"`
+	Sequence        int64           `json:"sequence" db:"seq"`
+	PublishSequence int64           `json:"publish_sequence,omitempty" db:"publish_seq"`
+	EventType       EventType       `json:"type" db:"event_type"`
+	AggregateID     uuid.UUID       `json:"aggregate_id" db:"aggregate_id"`
+	Payload         json.RawMessage `json:"payload" db:"payload"`
+	CreatedAt       time.Time       `json:"occurred_at" db:"created_at"`
+}
+
+type WebhookEndpoint struct {
+	ID         uuid.UUID   `json:"id" db:"id"`
+	UserID     uuid.UUID   `json:"user_id" db:"user_id"`
+	URL        string      `json:"url" db:"url"`
+	Secret     string      `json:"-" db:"secret"`
+	EventTypes []EventType `json:"event_types" db:"event_types"`
+	CreatedAt  time.Time   `json:"created_at" db:"created_at"`
+}
+
+// WebhookDelivery is one event queued for one endpoint, along with the outcome
+// of the latest attempt. Deliveries that exhaust their retries become DEAD.
+type WebhookDelivery struct {
+	ID             uuid.UUID             `json:"id" db:"id"`
+	EndpointID     uuid.UUID             `json:"endpoint_id" db:"endpoint_id"`
+	EventID        uuid.UUID             `json:"event_id" db:"event_id"`
+	EventType      EventType             `json:"event_type" db:"event_type"`
+	Payload        json.RawMessage       `json:"payload" db:"payload"`
+	Status         WebhookDeliveryStatus `json:"status" db:"status"`
+	Attempts       int                   `json:"attempts" db:"attempts"`
+	NextAttemptAt  *time.Time            `json:"next_attempt_at,omitempty" db:"next_attempt_at"`
+	LastStatusCode *int                  `json:"last_status_code,omitempty" db:"last_status_code"`
+	LastError      *string               `json:"last_error,omitempty" db:"last_error"`
+	DeliveredAt    *time.Time            `json:"delivered_at,omitempty" db:"delivered_at"`
+	CreatedAt      time.Time             `json:"created_at" db:"created_at"`
+}
+
+// AuditLog is one append-only record of a mutating or privileged API call.
+// Hash covers PrevHash and every other field, chaining each record to the one
+// before it so any edit or deletion is detectable.
+type AuditLog struct {
+	Sequence       int64            `json:"sequence" db:"seq"`
+	ID             uuid.UUID        `json:"id" db:"id"`
+	ActorID        *uuid.UUID       `json:"actor_id,omitempty" db:"actor_id"`
+	IP             string           `json:"ip" db:"ip"`
+	UserAgent      string           `json:"user_agent" db:"user_agent"`
+	Action         string           `json:"action" db:"action"`
+	Method         string           `json:"method" db:"method"`
+	Route          string           `json:"route" db:"route"`
+	WalletIDs      []uuid.UUID      `json:"wallet_ids" db:"wallet_ids"`
+	Amount         *decimal.Decimal `json:"amount,omitempty" db:"amount"`
+	IdempotencyKey string           `json:"idempotency_key,omitempty" db:"idempotency_key"`
+	Details        json.RawMessage  `json:"details,omitempty" db:"details"`
+	StatusCode     int              `json:"status_code" db:"status_code"`
+	Outcome        AuditOutcome     `json:"outcome" db:"outcome"`
+	CreatedAt      time.Time        `json:"created_at" db:"created_at"`
+	PrevHash       string           `json:"prev_hash" db:"prev_hash"`
+	Hash           string           `json:"hash" db:"hash"`
+}
+
+// IdempotencyRecord is the stored outcome of a request made with an
+// X-Idempotency-Key, unique per user, endpoint and key
+type IdempotencyRecord struct {
+	ID           uuid.UUID `json:"id" db:"id"`
+	UserID       uuid.UUID `json:"user_id" db:"user_id"`
+	Endpoint     string    `json:"endpoint" db:"endpoint"`
+	Key          string    `json:"key" db:"key"`
+	Fingerprint  string    `json:"fingerprint" db:"fingerprint"`
+	StatusCode   int       `json:"status_code" db:"status_code"`
+	ContentType  string    `json:"content_type" db:"content_type"`
+	ResponseBody []byte    `json:"response_body" db:"response_body"`
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+	ExpiresAt    time.Time `json:"expires_at" db:"expires_at"`
+}
+
+type PasswordResetToken struct {
+	ID        uuid.UUID  `json:"id" db:"id"`
+	UserID    uuid.UUID  `json:"user_id" db:"user_id"`
+	TokenHash string     `json:"-" db:"token_hash"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+	ExpiresAt time.Time  `json:"expires_at" db:"expires_at"`
+	UsedAt    *time.Time `json:"used_at,omitempty" db:"used_at"`
+}
+
+// Registration is a sign-up waiting for its email to be verified, by the link
+// token or by the code sent alongside it
+type Registration struct {
+	ID           uuid.UUID  `json:"id" db:"id"`
+	Email        string     `json:"email" db:"email"`
+	Name         string     `json:"name" db:"name"`
+	PasswordHash string     `json:"-" db:"password_hash"`
+	TokenHash    string     `json:"-" db:"token_hash"`
+	CodeHash     string     `json:"-" db:"code_hash"`
+	Attempts     int        `json:"attempts" db:"attempts"`
+	CreatedAt    time.Time  `json:"created_at" db:"created_at"`
+	ExpiresAt    time.Time  `json:"expires_at" db:"expires_at"`
+	UsedAt       *time.Time `json:"used_at,omitempty" db:"used_at"`
+}
+
+type RefreshToken struct {
+	ID        uuid.UUID  `json:"id" db:"id"`
+	FamilyID  uuid.UUID  `json:"family_id" db:"family_id"`
+	UserID    uuid.UUID  `json:"user_id" db:"user_id"`
+	TokenHash string     `json:"-" db:"token_hash"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+	ExpiresAt time.Time  `json:"expires_at" db:"expires_at"`
+	UsedAt    *time.Time `json:"used_at,omitempty" db:"used_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty" db:"revoked_at"`
+}
+
+type TOTPCredential struct {
+	UserID       uuid.UUID  `json:"user_id" db:"user_id"`
+	Secret       string     `json:"-" db:"secret"`
+	EnabledAt    *time.Time `json:"enabled_at,omitempty" db:"enabled_at"`
+	LastUsedStep int64      `json:"-" db:"last_used_step"`
+	CreatedAt    time.Time  `json:"created_at" db:"created_at"`
+}
+
+// SigningKey is a key pair for signing access tokens, identified in tokens by
+// its kid. PrivateKey is sealed and never leaves the service; PublicKey is the
+// PKIX DER encoding published in the JWKS.
+type SigningKey struct {
+	ID          string    `json:"kid" db:"kid"`
+	Algorithm   string    `json:"alg" db:"algorithm"`
+	PrivateKey  string    `json:"-" db:"private_key"`
+	PublicKey   []byte    `json:"-" db:"public_key"`
+	ActivatesAt time.Time `json:"activates_at" db:"activates_at"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+}
+
+// APIKey is a long-lived credential for scripts and services. WalletIDs, when
+// set, restricts the key to those wallets; MaxAmount caps a single operation.
+type APIKey struct {
+	ID         uuid.UUID        `json:"id" db:"id"`
+	UserID     uuid.UUID        `json:"user_id" db:"user_id"`
+	Name       string           `json:"name" db:"name"`
+	Prefix     string           `json:"prefix" db:"prefix"`
+	KeyHash    string           `json:"-" db:"key_hash"`
+	Scopes     []APIScope       `json:"scopes" db:"scopes"`
+	WalletIDs  []uuid.UUID      `json:"wallet_ids" db:"wallet_ids"`
+	MaxAmount  *decimal.Decimal `json:"max_amount,omitempty" db:"max_amount"`
+	ExpiresAt  *time.Time       `json:"expires_at,omitempty" db:"expires_at"`
+	LastUsedAt *time.Time       `json:"last_used_at,omitempty" db:"last_used_at"`
+	RevokedAt  *time.Time       `json:"revoked_at,omitempty" db:"revoked_at"`
+	CreatedAt  time.Time        `json:"created_at" db:"created_at"`
+}
+
+// Session is one login, identified by its refresh token family
+type Session struct {
+	ID         uuid.UUID `json:"id"`
+	UserID     uuid.UUID `json:"user_id"`
+	Device     string    `json:"device"`
+	IP         string    `json:"ip"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	Current    bool      `json:"current"`
+}
+
+// KYCSubmission is one identity document a user sent in to reach a tier. The
+// file itself is kept on local disk at StoragePath, which is never exposed;
+// SHA256 lets a reviewer confirm it is the file that was submitted.
+type KYCSubmission struct {
+	ID            uuid.UUID           `json:"id" db:"id"`
+	UserID        uuid.UUID           `json:"user_id" db:"user_id"`
+	RequestedTier KYCTier             `json:"requested_tier" db:"requested_tier"`
+	DocumentType  KYCDocumentType     `json:"document_type" db:"document_type"`
+	FileName      string              `json:"file_name" db:"file_name"`
+	ContentType   string              `json:"content_type" db:"content_type"`
+	SizeBytes     int64               `json:"size_bytes" db:"size_bytes"`
+	SHA256        string              `json:"sha256" db:"sha256"`
+	StoragePath   string              `json:"-" db:"storage_path"`
+	Provider      string              `json:"provider" db:"provider"`
+	Status        KYCSubmissionStatus `json:"status" db:"status"`
+	ReviewerID    *uuid.UUID          `json:"reviewer_id,omitempty" db:"reviewer_id"`
+	ReviewNote    string              `json:"review_note,omitempty" db:"review_note"`
+	CreatedAt     time.Time           `json:"created_at" db:"created_at"`
+	ReviewedAt    *time.Time          `json:"reviewed_at,omitempty" db:"reviewed_at"`
+}
+
+// BalanceAdjustment is a staff request to credit (IN) or debit (OUT) a
+// wallet. It only moves money once an admin other than RequestedBy approves
+// it before ExpiresAt; TransactionID is the ADJUSTMENT it was booked as.
+type BalanceAdjustment struct {
+	ID            uuid.UUID        `json:"id" db:"id"`
+	WalletID      uuid.UUID        `json:"wallet_id" db:"wallet_id"`
+	Direction     Direction        `json:"direction" db:"direction"`
+	Amount        decimal.Decimal  `json:"amount" db:"amount"`
+	Reason        string           `json:"reason" db:"reason"`
+	TicketRef     string           `json:"ticket_ref" db:"ticket_ref"`
+	Status        AdjustmentStatus `json:"status" db:"status"`
+	RequestedBy   uuid.UUID        `json:"requested_by" db:"requested_by"`
+	ReviewedBy    *uuid.UUID       `json:"reviewed_by,omitempty" db:"reviewed_by"`
+	ReviewNote    string           `json:"review_note,omitempty" db:"review_note"`
+	TransactionID *uuid.UUID       `json:"transaction_id,omitempty" db:"transaction_id"`
+	CreatedAt     time.Time        `json:"created_at" db:"created_at"`
+	ExpiresAt     time.Time        `json:"expires_at" db:"expires_at"`
+	ReviewedAt    *time.Time       `json:"reviewed_at,omitempty" db:"reviewed_at"`
+}
+
+// WithdrawalReview is a withdrawal above its coin's review threshold. Its
+// PENDING transaction has no entries yet: the amount sits in the wallet's
+// FrozenAmount until an operator approves it, which books the debit, or it is
+// rejected or cancelled, which releases the hold.
+type WithdrawalReview struct {
+	TransactionID uuid.UUID              `json:"transaction_id" db:"transaction_id"`
+	WalletID      uuid.UUID              `json:"wallet_id" db:"wallet_id"`
+	UserID        uuid.UUID              `json:"user_id" db:"user_id"`
+	CoinType      CoinType               `json:"coin_type" db:"coin_type"`
+	Amount        decimal.Decimal        `json:"amount" db:"amount"`
+	Status        WithdrawalReviewStatus `json:"status" db:"status"`
+	ReviewedBy    *uuid.UUID             `json:"reviewed_by,omitempty" db:"reviewed_by"`
+	ReviewNote    string                 `json:"review_note,omitempty" db:"review_note"`
+	CreatedAt     time.Time              `json:"created_at" db:"created_at"`
+	DecidedAt     *time.Time             `json:"decided_at,omitempty" db:"decided_at"`
+}
+
 // Request/Response models
 type LoginRequest struct {
+	Email    string `json:"email" binding:"required,email"`
+	Password string `json:"password" binding:"required"`
+	// OTP is a TOTP or recovery code, required once two-factor is enabled
+	OTP string `json:"otp"`
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required"`
+}
+
+type PasswordResetRequest struct {
 	Email string `json:"email" binding:"required,email"`
 }
 
+type PasswordResetConfirmRequest struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required"`
+}
+
+type RegisterRequest struct {
+	Name     string `json:"name" binding:"required,max=100"`
+	Email    string `json:"email" binding:"required,email,max=254"`
+	Password string `json:"password" binding:"required"`
+}
+
+// VerifyEmailRequest carries either the token from the verification link or
+// the email and the code from the message
+type VerifyEmailRequest struct {
+	Token string `json:"token" form:"token"`
+	Email string `json:"email"`
+	Code  string `json:"code"`
+}
+
+type RegistrationResponse struct {
+	User    User     `json:"user"`
+	Wallets []Wallet `json:"wallets"`
+}
+
+// UpdateUserStatusRequest changes a user's status; a reason code is always
+// required, including when reinstating
+type UpdateUserStatusRequest struct {
+	Status UserStatus   `json:"status" binding:"required,oneof=active suspended locked-for-review"`
+	Reason StatusReason `json:"reason" binding:"required,oneof=fraud-suspected chargeback compliance-review sanctions-hit terms-violation customer-request review-cleared"`
+}
+
+// SubmitKYCRequest is the form sent alongside the document file
+type SubmitKYCRequest struct {
+	Tier         KYCTier         `form:"tier" binding:"required,oneof=basic full"`
+	DocumentType KYCDocumentType `form:"document_type" binding:"required,oneof=passport national-id drivers-license proof-of-address"`
+}
+
+type KYCStatusResponse struct {
+	Tier        KYCTier         `json:"tier"`
+	Submissions []KYCSubmission `json:"submissions"`
+}
+
+// ApproveKYCRequest grants a tier, which may be lower than the one requested
+type ApproveKYCRequest struct {
+	Tier   KYCTier `json:"tier" binding:"required,oneof=basic full"`
+	Reason string  `json:"reason" binding:"max=500"`
+}
+
+type RejectKYCRequest struct {
+	Reason string `json:"reason" binding:"required,max=500"`
+}
+
+// UpdateKYCTierRequest sets a user's tier directly, e.g. to downgrade it when
+// a document expires; the reason is required for the audit log
+type UpdateKYCTierRequest struct {
+	Tier   KYCTier `json:"tier" binding:"required,oneof=unverified basic full"`
+	Reason string  `json:"reason" binding:"required,max=500"`
+}
+
+type KYCSubmissionListResponse struct {
+	Submissions []KYCSubmission `json:"submissions"`
+	Total       int             `json:"total"`
+}
+
+type CreateAdjustmentRequest struct {
+	WalletID  uuid.UUID       `json:"wallet_id" binding:"required"`
+	Amount    decimal.Decimal `json:"amount" binding:"required,gt=0"`
+	Direction Direction       `json:"direction" binding:"required,oneof=IN OUT"`
+	Reason    string          `json:"reason" binding:"required,max=500"`
+	TicketRef string          `json:"ticket_ref" binding:"required,max=100"`
+}
+
+// ReviewAdjustmentRequest is optional on approval and needs a reason to reject
+type ReviewAdjustmentRequest struct {
+	Reason string `json:"reason" binding:"max=500"`
+}
+
+type AdjustmentListResponse struct {
+	Adjustments []BalanceAdjustment `json:"adjustments"`
+	Total       int                 `json:"total"`
+}
+
+// ReviewWithdrawalRequest is optional on approval and needs a reason to reject
+type ReviewWithdrawalRequest struct {
+	Reason string `json:"reason" binding:"max=500"`
+}
+
+type WithdrawalReviewListResponse struct {
+	Withdrawals []WithdrawalReview `json:"withdrawals"`
+	Total       int                `json:"total"`
+}
+
+type UpdateRoleRequest struct {
+	Role Role `json:"role" binding:"required,oneof=user support-readonly operator admin"`
+}
+
+type TokenResponse struct {
+	Token        string `json:"token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+	RefreshToken string `json:"refresh_token"`
+}
+
 type LoginResponse struct {
-	Token string `json:"token"`
-	User  User   `json:"user"`
+	TokenResponse
+	User User `json:"user"`
+}
+
+// JWK is a public signing key in RFC 7517 form; Curve and X are set for
+// Ed25519 keys, N and E for RSA keys
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Algorithm string `json:"alg"`
+	Use       string `json:"use"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+}
+
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+type TwoFactorEnrollResponse struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+type CreateAPIKeyRequest struct {
+	Name      string           `json:"name" binding:"required,max=100"`
+	Scopes    []APIScope       `json:"scopes" binding:"required,min=1,dive,oneof=wallets:read transactions:read deposits:write withdrawals:write transfers:write webhooks:read webhooks:write"`
+	WalletIDs []uuid.UUID      `json:"wallet_ids"`
+	MaxAmount *decimal.Decimal `json:"max_amount"`
+	ExpiresAt *time.Time       `json:"expires_at"`
+}
+
+// CreateAPIKeyResponse is the only response that ever carries the key itself
+type CreateAPIKeyResponse struct {
+	APIKey
+	Key string `json:"key"`
+}
+
+type APIKeyListResponse struct {
+	APIKeys []APIKey `json:"api_keys"`
+}
+
+type SessionListResponse struct {
+	Sessions []Session `json:"sessions"`
 }
 
 type DepositRequest struct {
@@ -90,6 +588,22 @@ type BalanceResponse struct {
 	CoinType     CoinType        `json:"coin_type"`
 	Amount       decimal.Decimal `json:"amount"`
 	FrozenAmount decimal.Decimal `json:"frozen_amount"`
+	Status       WalletStatus    `json:"status"`
+}
+
+type OpenWalletRequest struct {
+	CoinType CoinType `json:"coin_type" binding:"required,oneof=BTC ETH ADA"`
+}
+
+// CloseWalletRequest names the wallet that receives the remaining balance; it
+// may be left out when the balance is zero
+type CloseWalletRequest struct {
+	SweepToWalletID *uuid.UUID `json:"sweep_to_wallet_id"`
+}
+
+// WalletStatusChangeRequest records why staff froze or unfroze a wallet
+type WalletStatusChangeRequest struct {
+	Reason string `json:"reason" binding:"required,max=500"`
 }
 
 type TransactionHistoryRequest struct {
@@ -105,7 +619,72 @@ type TransactionHistoryResponse struct {
 	Total        int                `json:"total"`
 }
 
+type UserWalletsRequest struct {
+	Currency string `form:"currency"`
+	// Closed wallets are archived and only listed on request
+	IncludeClosed bool `form:"include_closed"`
+}
+
 type UserWalletsResponse struct {
-	UserID  uuid.UUID `json:"user_id"`
-	Wallets []Wallet  `json:"wallets"`
+	UserID    uuid.UUID           `json:"user_id"`
+	Wallets   []Wallet            `json:"wallets"`
+	Valuation *PortfolioValuation `json:"valuation,omitempty"`
+}
+
+// WalletValuation is the fiat value of a single wallet. Price and Value are nil
+// when no price has ever been fetched for the coin; Stale is set when the price
+// used is older than the configured staleness window.
+type WalletValuation struct {
+	WalletID  uuid.UUID        `json:"wallet_id"`
+	CoinType  CoinType         `json:"coin_type"`
+	Price     *decimal.Decimal `json:"price"`
+	Value     *decimal.Decimal `json:"value"`
+	PriceAsOf *time.Time       `json:"price_as_of"`
+	Stale     bool             `json:"stale"`
+}
+
+type PortfolioValuation struct {
+	Currency   string            `json:"currency"`
+	TotalValue decimal.Decimal   `json:"total_value"`
+	Stale      bool              `json:"stale"`
+	Wallets    []WalletValuation `json:"wallets"`
+}
+
+type CreateWebhookRequest struct {
+	URL        string      `json:"url" binding:"required,url"`
+	Secret     string      `json:"secret" binding:"omitempty,min=16"`
+	EventTypes []EventType `json:"event_types" binding:"required,min=1,dive,oneof=WalletCredited WalletDebited TransactionCompleted WithdrawalStatusChanged"`
+}
+
+// CreateWebhookResponse is the only response that reveals the signing secret
+type CreateWebhookResponse struct {
+	WebhookEndpoint
+	Secret string `json:"secret"`
+}
+
+type WebhookDeliveryListResponse struct {
+	Deliveries []WebhookDelivery `json:"deliveries"`
+	Total      int               `json:"total"`
+}
+
+type AuditLogQuery struct {
+	ActorID  *uuid.UUID    `form:"actor_id"`
+	Action   string        `form:"action"`
+	WalletID *uuid.UUID    `form:"wallet_id"`
+	Outcome  *AuditOutcome `form:"outcome"`
+	From     *time.Time    `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To       *time.Time    `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+	Limit    int           `form:"limit"`
+	Offset   int           `form:"offset"`
+}
+
+type AuditLogListResponse struct {
+	AuditLogs []AuditLog `json:"audit_logs"`
+	Total     int        `json:"total"`
+}
+
+type AuditChainVerification struct {
+	Valid    bool   `json:"valid"`
+	Checked  int    `json:"checked"`
+	BrokenAt *int64 `json:"broken_at,omitempty"`
 }
@@ -0,0 +1,281 @@
+package openapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// decimalPattern matches the decimal strings amounts are written as
+const decimalPattern = `^-?[0-9]+(\.[0-9]+)?$`
+
+var (
+	uuidType    = reflect.TypeOf(uuid.UUID{})
+	decimalType = reflect.TypeOf(decimal.Decimal{})
+	timeType    = reflect.TypeOf(time.Time{})
+	rawType     = reflect.TypeOf(json.RawMessage{})
+	bytesType   = reflect.TypeOf([]byte{})
+)
+
+// generator derives schemas from Go types the way encoding/json and gin's
+// binding see them: fields are named by their json tag and constrained by
+// their binding tag. Named structs and enums become shared components.
+type generator struct {
+	schemas map[string]*Schema
+	types   map[string]reflect.Type
+	enums   map[reflect.Type][]string
+	// names overrides component names that read badly out of their package
+	names map[reflect.Type]string
+}
+
+func newGenerator(enums map[reflect.Type][]string, names map[reflect.Type]string) *generator {
+	g := &generator{
+		schemas: map[string]*Schema{},
+		types:   map[string]reflect.Type{},
+		enums:   enums,
+		names:   names,
+	}
+	g.schemas["Decimal"] = &Schema{
+		Description: "Decimal amount. Responses write it as a string to keep its precision; requests may also send a JSON number.",
+		AnyOf: []*Schema{
+			{Type: "string", Format: "decimal", Pattern: decimalPattern},
+			{Type: "number"},
+		},
+	}
+	return g
+}
+
+// schemaOf returns the schema of v's type, registering its components
+func (g *generator) schemaOf(v interface{}) *Schema {
+	return g.schema(reflect.TypeOf(v))
+}
+
+func (g *generator) schema(t reflect.Type) *Schema {
+	switch t {
+	case uuidType:
+		return &Schema{Type: "string", Format: "uuid"}
+	case decimalType:
+		return ref("Decimal")
+	case timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case rawType:
+		// Any JSON value
+		return &Schema{}
+	case bytesType:
+		return &Schema{Type: "string", Format: "byte"}
+	}
+
+	if values, ok := g.enums[t]; ok {
+		return g.component(t, func() *Schema {
+			return &Schema{Type: "string", Enum: values}
+		})
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		return nullable(g.schema(t.Elem()))
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.object(t)
+		}
+		return g.component(t, func() *Schema { return g.object(t) })
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: g.schema(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.schema(t.Elem())}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	default:
+		return &Schema{}
+	}
+}
+
+// component registers t under its type name once and refers to it
+func (g *generator) component(t reflect.Type, build func() *Schema) *Schema {
+	name, ok := g.names[t]
+	if !ok {
+		name = exported(t.Name())
+	}
+	if existing, ok := g.types[name]; ok && existing != t {
+		name = exported(pkgName(t)) + name
+	}
+	if _, ok := g.types[name]; !ok {
+		// Register first so recursive types refer to themselves
+		g.types[name] = t
+		g.schemas[name] = &Schema{}
+		*g.schemas[name] = *build()
+	}
+	return ref(name)
+}
+
+// object lists t's JSON fields, flattening embedded structs like
+// encoding/json does
+func (g *generator) object(t reflect.Type) *Schema {
+	schema := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name := fieldName(field)
+		if name == "-" {
+			continue
+		}
+
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			embedded := g.object(field.Type)
+			for property, fieldSchema := range embedded.Properties {
+				schema.Properties[property] = fieldSchema
+			}
+			schema.Required = append(schema.Required, embedded.Required...)
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		fieldSchema, required := g.field(field)
+		schema.Properties[name] = fieldSchema
+		if required {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+	return schema
+}
+
+// field builds a field's schema and applies its binding rules
+func (g *generator) field(field reflect.StructField) (*Schema, bool) {
+	schema := g.schema(field.Type)
+	rules := strings.Split(field.Tag.Get("binding"), ",")
+
+	required := false
+	target := schema
+	kind := field.Type.Kind()
+	for _, rule := range rules {
+		name, param, _ := strings.Cut(rule, "=")
+		switch name {
+		case "required":
+			required = true
+		case "omitempty":
+			// Empty values skip the remaining rules, so they say nothing
+			// about what the field must hold
+			return schema, required
+		case "dive":
+			if target.Items == nil {
+				return schema, required
+			}
+			target.Items = inline(target.Items)
+			target = target.Items
+			kind = field.Type.Elem().Kind()
+		case "oneof":
+			*target = Schema{Type: "string", Enum: strings.Fields(param), Nullable: target.Nullable}
+		case "email":
+			target.Format = "email"
+		case "url":
+			target.Format = "uri"
+		case "min", "max":
+			n, err := strconv.Atoi(param)
+			if err != nil {
+				continue
+			}
+			bound := &n
+			switch {
+			case kind == reflect.String && name == "min":
+				target.MinLength = bound
+			case kind == reflect.String:
+				target.MaxLength = bound
+			case (kind == reflect.Slice || kind == reflect.Array) && name == "min":
+				target.MinItems = bound
+			case kind == reflect.Slice || kind == reflect.Array:
+				target.MaxItems = bound
+			}
+		}
+	}
+	return schema, required
+}
+
+// queryParameters describes the query string a struct binds with its form
+// tags
+func (g *generator) queryParameters(v interface{}) []*Parameter {
+	t := reflect.TypeOf(v)
+	var parameters []*Parameter
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := strings.Split(field.Tag.Get("form"), ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+
+		schema, required := g.field(field)
+		parameters = append(parameters, &Parameter{
+			Name:     name,
+			In:       "query",
+			Required: required,
+			Schema:   unwrap(schema),
+		})
+	}
+	return parameters
+}
+
+func fieldName(field reflect.StructField) string {
+	return strings.Split(field.Tag.Get("json"), ",")[0]
+}
+
+func pkgName(t reflect.Type) string {
+	path := t.PkgPath()
+	return path[strings.LastIndex(path, "/")+1:]
+}
+
+func exported(name string) string {
+	if name == "" {
+		return name
+	}
+	runes := []rune(name)
+	runes[0] = unicode.ToUpper(runes[0])
+	return string(runes)
+}
+
+func ref(name string) *Schema {
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
+
+// nullable marks a schema as accepting null. A $ref takes no siblings in
+// OpenAPI 3.0, so references are wrapped in allOf.
+func nullable(schema *Schema) *Schema {
+	if schema.Ref != "" {
+		return &Schema{AllOf: []*Schema{schema}, Nullable: true}
+	}
+	schema.Nullable = true
+	return schema
+}
+
+// inline copies a schema so rules can narrow it without touching a shared
+// component
+func inline(schema *Schema) *Schema {
+	copied := *schema
+	return &copied
+}
+
+// unwrap drops the nullable wrapper of an optional query parameter, which
+// is simply left out rather than sent as null
+func unwrap(schema *Schema) *Schema {
+	if len(schema.AllOf) == 1 && schema.Nullable {
+		return schema.AllOf[0]
+	}
+	schema.Nullable = false
+	return schema
+}
@@ -0,0 +1,533 @@
+package openapi
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"wallet-service/internal/models"
+	"wallet-service/internal/problem"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// access is who may call a route, which decides its security schemes
+type access int
+
+const (
+	public access = iota
+	// A logged-in user; API keys and the admin token are refused
+	userSession
+	// A logged-in user, or an API key holding the route's scope
+	userOrAPIKey
+	// Staff holding one of the route's roles, or the X-Admin-Token
+	staff
+	// Like userSession, but browsers' EventSource may pass the access token
+	// in the query string
+	stream
+)
+
+// stepUp is when a route asks for a fresh one-time code in X-OTP
+type stepUp int
+
+const (
+	noStepUp stepUp = iota
+	// Whenever the user has two-factor authentication enabled
+	stepUpAlways
+	// Only for amounts above the configured threshold
+	stepUpAbove
+)
+
+// route documents one operation registered in main.go. Path parameters are
+// read from the path and are UUIDs, except an error code's slug.
+type route struct {
+	method  string
+	path    string
+	id      string
+	tag     string
+	summary string
+	notes   string
+
+	access access
+	scope  models.APIScope
+	roles  []models.Role
+
+	// query is a struct binding the query string with form tags; params are
+	// parameters the handler reads by hand
+	query  interface{}
+	params []*Parameter
+
+	body         interface{}
+	optionalBody bool
+	form         *Schema
+
+	idempotent bool
+	stepUp     stepUp
+
+	status int
+	// response is the body of status, or responseSchema when it is not a Go
+	// type, served as contentType
+	response       interface{}
+	responseSchema *Schema
+	contentType    string
+	// mayHold marks money movements answered with 202 when held for review
+	mayHold bool
+}
+
+// Shapes of the responses handlers build with gin.H
+type message struct {
+	Message string `json:"message"`
+}
+
+type revokedSessions struct {
+	Message string `json:"message"`
+	Revoked int    `json:"revoked"`
+}
+
+type movement struct {
+	Message string          `json:"message"`
+	Amount  decimal.Decimal `json:"amount"`
+	// Set when a withdrawal is held for review
+	TransactionID *uuid.UUID               `json:"transaction_id,omitempty"`
+	Status        models.TransactionStatus `json:"status,omitempty"`
+}
+
+type walletClosure struct {
+	Message         string              `json:"message"`
+	WalletID        uuid.UUID           `json:"wallet_id"`
+	Status          models.WalletStatus `json:"status"`
+	SweptAmount     *decimal.Decimal    `json:"swept_amount,omitempty"`
+	SweepToWalletID *uuid.UUID          `json:"sweep_to_wallet_id,omitempty"`
+}
+
+type webhookList struct {
+	Webhooks []models.WebhookEndpoint `json:"webhooks"`
+}
+
+type errorCatalog struct {
+	Errors []problem.Entry `json:"errors"`
+}
+
+type health struct {
+	Status string `json:"status"`
+}
+
+var (
+	staffRoles    = []models.Role{models.RoleSupportReadOnly, models.RoleOperator, models.RoleAdmin}
+	operatorRoles = []models.Role{models.RoleOperator, models.RoleAdmin}
+	adminRoles    = []models.Role{models.RoleAdmin}
+)
+
+var routes = []route{
+	// Service metadata
+	{method: http.MethodGet, path: "/health", id: "getHealth", tag: "meta", summary: "Health check", status: http.StatusOK, response: health{}},
+	{method: http.MethodGet, path: "/openapi.json", id: "getOpenAPI", tag: "meta", summary: "This OpenAPI document", status: http.StatusOK, responseSchema: &Schema{Type: "object"}},
+	{method: http.MethodGet, path: "/.well-known/jwks.json", id: "getJWKS", tag: "meta", summary: "Public keys that verify access tokens", status: http.StatusOK, response: models.JWKSet{}},
+	{method: http.MethodGet, path: "/errors", id: "listErrors", tag: "meta", summary: "Error code catalog", status: http.StatusOK, response: errorCatalog{}},
+	{method: http.MethodGet, path: "/errors/{code}", id: "getError", tag: "meta", summary: "Documentation of one error code", notes: "code is the slug in a problem's type URI, e.g. insufficient-funds.", status: http.StatusOK, response: problem.Entry{}},
+
+	// Registration, login and passwords
+	{method: http.MethodPost, path: "/auth/register", id: "register", tag: "auth", summary: "Start a sign-up", notes: "Mails a verification link and code. The answer is the same whether or not the email is registered.", body: models.RegisterRequest{}, status: http.StatusAccepted, response: message{}},
+	{method: http.MethodGet, path: "/auth/verify-email", id: "verifyEmailLink", tag: "auth", summary: "Verify an email by the mailed link", params: []*Parameter{{Name: "token", In: "query", Required: true, Schema: &Schema{Type: "string"}}}, status: http.StatusCreated, response: models.RegistrationResponse{}},
+	{method: http.MethodPost, path: "/auth/verify-email", id: "verifyEmail", tag: "auth", summary: "Verify an email by token, or by email and code", notes: "Creates the user and a wallet per coin.", body: models.VerifyEmailRequest{}, status: http.StatusCreated, response: models.RegistrationResponse{}},
+	{method: http.MethodPost, path: "/auth/login", id: "login", tag: "auth", summary: "Log in", notes: "Users with two-factor authentication enabled also send otp; without it the answer is an OTP_REQUIRED problem.", body: models.LoginRequest{}, status: http.StatusOK, response: models.LoginResponse{}},
+	{method: http.MethodPost, path: "/auth/refresh", id: "refreshToken", tag: "auth", summary: "Rotate a refresh token", body: models.RefreshRequest{}, status: http.StatusOK, response: models.TokenResponse{}},
+	{method: http.MethodPost, path: "/auth/password/reset-request", id: "requestPasswordReset", tag: "auth", summary: "Mail a password reset token", body: models.PasswordResetRequest{}, status: http.StatusAccepted, response: message{}},
+	{method: http.MethodPost, path: "/auth/password/reset", id: "resetPassword", tag: "auth", summary: "Reset a password with a mailed token", body: models.PasswordResetConfirmRequest{}, status: http.StatusOK, response: message{}},
+
+	// Sessions
+	{method: http.MethodPost, path: "/auth/logout", id: "logout", tag: "sessions", summary: "End the current session", access: userSession, status: http.StatusOK, response: message{}},
+	{method: http.MethodGet, path: "/auth/sessions", id: "listSessions", tag: "sessions", summary: "List the caller's sessions", access: userSession, status: http.StatusOK, response: models.SessionListResponse{}},
+	{method: http.MethodDelete, path: "/auth/sessions", id: "revokeAllSessions", tag: "sessions", summary: "End every session of the caller", access: userSession, status: http.StatusOK, response: revokedSessions{}},
+	{method: http.MethodDelete, path: "/auth/sessions/{session_id}", id: "revokeSession", tag: "sessions", summary: "End one session", access: userSession, status: http.StatusOK, response: message{}},
+
+	// Account security
+	{method: http.MethodPost, path: "/auth/password", id: "changePassword", tag: "account", summary: "Change the password", notes: "Ends every session.", access: userSession, body: models.ChangePasswordRequest{}, stepUp: stepUpAlways, status: http.StatusOK, response: message{}},
+	{method: http.MethodPost, path: "/auth/2fa/enroll", id: "enrollTwoFactor", tag: "two-factor", summary: "Start two-factor enrollment", access: userSession, status: http.StatusOK, response: models.TwoFactorEnrollResponse{}},
+	{method: http.MethodPost, path: "/auth/2fa/confirm", id: "confirmTwoFactor", tag: "two-factor", summary: "Enable two-factor authentication with a first code", access: userSession, body: models.TwoFactorCodeRequest{}, status: http.StatusOK, response: models.RecoveryCodesResponse{}},
+	{method: http.MethodDelete, path: "/auth/2fa", id: "disableTwoFactor", tag: "two-factor", summary: "Disable two-factor authentication", access: userSession, stepUp: stepUpAlways, status: http.StatusOK, response: message{}},
+	{method: http.MethodPost, path: "/auth/2fa/recovery-codes", id: "regenerateRecoveryCodes", tag: "two-factor", summary: "Replace the recovery codes", access: userSession, stepUp: stepUpAlways, status: http.StatusOK, response: models.RecoveryCodesResponse{}},
+
+	// Wallets
+	{method: http.MethodGet, path: "/wallets", id: "listWallets", tag: "wallets", summary: "List the caller's wallets", notes: "currency values them in a fiat currency; API keys restricted to wallets only see those.", access: userOrAPIKey, scope: models.ScopeWalletsRead, query: models.UserWalletsRequest{}, status: http.StatusOK, response: models.UserWalletsResponse{}},
+	{method: http.MethodPost, path: "/wallets", id: "openWallet", tag: "wallets", summary: "Open a wallet", access: userSession, body: models.OpenWalletRequest{}, status: http.StatusCreated, response: models.Wallet{}},
+	{method: http.MethodPost, path: "/wallets/{wallet_id}/close", id: "closeWallet", tag: "wallets", summary: "Close a wallet", notes: "A wallet with a balance needs sweep_to_wallet_id, and the sweep counts towards the KYC limit; a wallet with held funds becomes CLOSING.", access: userSession, body: models.CloseWalletRequest{}, optionalBody: true, idempotent: true, stepUp: stepUpAlways, status: http.StatusOK, response: walletClosure{}},
+	{method: http.MethodPost, path: "/wallets/{wallet_id}/deposit", id: "deposit", tag: "wallets", summary: "Deposit into a wallet", access: userOrAPIKey, scope: models.ScopeDepositsWrite, body: models.DepositRequest{}, idempotent: true, status: http.StatusOK, response: movement{}},
+	{method: http.MethodPost, path: "/wallets/{wallet_id}/withdraw", id: "withdraw", tag: "wallets", summary: "Withdraw from a wallet", notes: "Withdrawals above the coin's review threshold are held and answered with 202 and the PENDING transaction. The KYC tier caps what leaves the user's wallets in the coin over a rolling window, held withdrawals included.", access: userOrAPIKey, scope: models.ScopeWithdrawalsWrite, body: models.WithdrawRequest{}, idempotent: true, stepUp: stepUpAlways, status: http.StatusOK, response: movement{}, mayHold: true},
+	{method: http.MethodPost, path: "/wallets/{wallet_id}/transfer", id: "transfer", tag: "wallets", summary: "Transfer to another wallet of the same coin", notes: "The KYC tier caps what leaves the user's wallets in the coin over a rolling window.", access: userOrAPIKey, scope: models.ScopeTransfersWrite, body: models.TransferRequest{}, idempotent: true, stepUp: stepUpAbove, status: http.StatusOK, response: movement{}},
+	{method: http.MethodGet, path: "/wallets/{wallet_id}/balance", id: "getBalance", tag: "wallets", summary: "Get a wallet's balance", access: userOrAPIKey, scope: models.ScopeWalletsRead, status: http.StatusOK, response: models.BalanceResponse{}},
+	{method: http.MethodGet, path: "/wallets/{wallet_id}/transactions", id: "listTransactions", tag: "wallets", summary: "List a wallet's transaction entries", access: userOrAPIKey, scope: models.ScopeTransactionsRead, params: page(20, 100), status: http.StatusOK, response: models.TransactionHistoryResponse{}},
+
+	// Withdrawals held for review
+	{method: http.MethodGet, path: "/withdrawals", id: "listUserWithdrawals", tag: "withdrawals", summary: "List the caller's held withdrawals", access: userOrAPIKey, scope: models.ScopeTransactionsRead, params: withStatus(models.WithdrawalReviewStatus(""), page(20, 100)), status: http.StatusOK, response: models.WithdrawalReviewListResponse{}},
+	{method: http.MethodPost, path: "/withdrawals/{transaction_id}/cancel", id: "cancelWithdrawal", tag: "withdrawals", summary: "Cancel a pending withdrawal", notes: "Releases the held amount.", access: userOrAPIKey, scope: models.ScopeWithdrawalsWrite, status: http.StatusOK, response: models.WithdrawalReview{}},
+
+	// KYC
+	{method: http.MethodPost, path: "/kyc/submissions", id: "submitKYCDocument", tag: "kyc", summary: "Submit an identity document", access: userSession, form: kycForm(), status: http.StatusCreated, response: models.KYCSubmission{}},
+	{method: http.MethodGet, path: "/kyc", id: "getKYCStatus", tag: "kyc", summary: "Get the caller's KYC tier and submissions", access: userSession, status: http.StatusOK, response: models.KYCStatusResponse{}},
+
+	// API keys
+	{method: http.MethodPost, path: "/api-keys", id: "createAPIKey", tag: "api-keys", summary: "Create an API key", notes: "The response is the only one that carries the key.", access: userSession, body: models.CreateAPIKeyRequest{}, stepUp: stepUpAlways, status: http.StatusCreated, response: models.CreateAPIKeyResponse{}},
+	{method: http.MethodGet, path: "/api-keys", id: "listAPIKeys", tag: "api-keys", summary: "List the caller's API keys", access: userSession, status: http.StatusOK, response: models.APIKeyListResponse{}},
+	{method: http.MethodDelete, path: "/api-keys/{key_id}", id: "revokeAPIKey", tag: "api-keys", summary: "Revoke an API key", access: userSession, status: http.StatusOK, response: message{}},
+
+	// Webhooks
+	{method: http.MethodPost, path: "/webhooks", id: "createWebhook", tag: "webhooks", summary: "Register a webhook endpoint", notes: "The URL must be https and resolve to public addresses only. The response is the only one that reveals the signing secret.", access: userOrAPIKey, scope: models.ScopeWebhooksWrite, body: models.CreateWebhookRequest{}, status: http.StatusCreated, response: models.CreateWebhookResponse{}},
+	{method: http.MethodGet, path: "/webhooks", id: "listWebhooks", tag: "webhooks", summary: "List the caller's webhook endpoints", access: userOrAPIKey, scope: models.ScopeWebhooksRead, status: http.StatusOK, response: webhookList{}},
+	{method: http.MethodDelete, path: "/webhooks/{webhook_id}", id: "deleteWebhook", tag: "webhooks", summary: "Delete a webhook endpoint", access: userOrAPIKey, scope: models.ScopeWebhooksWrite, status: http.StatusNoContent},
+	{method: http.MethodPost, path: "/webhooks/{webhook_id}/test", id: "testWebhook", tag: "webhooks", summary: "Send a test event", access: userOrAPIKey, scope: models.ScopeWebhooksWrite, status: http.StatusOK, response: models.WebhookDelivery{}},
+	{method: http.MethodGet, path: "/webhooks/{webhook_id}/deliveries", id: "listWebhookDeliveries", tag: "webhooks", summary: "List an endpoint's deliveries", access: userOrAPIKey, scope: models.ScopeWebhooksRead, params: page(50, 200), status: http.StatusOK, response: models.WebhookDeliveryListResponse{}},
+	{method: http.MethodPost, path: "/webhooks/{webhook_id}/deliveries/{delivery_id}/retry", id: "retryWebhookDelivery", tag: "webhooks", summary: "Queue a delivery again", access: userOrAPIKey, scope: models.ScopeWebhooksWrite, status: http.StatusAccepted, response: models.WebhookDelivery{}},
+
+	// Live stream
+	{method: http.MethodGet, path: "/stream/events", id: "streamEvents", tag: "stream", summary: "Stream balance changes and transactions", notes: "Server-Sent Events; reconnecting clients resume after Last-Event-ID.", access: stream, params: []*Parameter{
+		{Name: "wallet_id", In: "query", Description: "Only events of this wallet", Schema: &Schema{Type: "string", Format: "uuid"}},
+		{Name: "last_event_id", In: "query", Description: "Resume after this event, for clients that cannot set Last-Event-ID", Schema: &Schema{Type: "integer", Format: "int64"}},
+		{Name: "Last-Event-ID", In: "header", Description: "Resume after this event", Schema: &Schema{Type: "string"}},
+	}, status: http.StatusOK, responseSchema: &Schema{Type: "string"}, contentType: "text/event-stream"},
+
+	// Admin: audit log
+	{method: http.MethodGet, path: "/admin/audit-logs", id: "listAuditLogs", tag: "admin", summary: "Search the audit log", access: staff, roles: adminRoles, query: models.AuditLogQuery{}, status: http.StatusOK, response: models.AuditLogListResponse{}},
+	{method: http.MethodGet, path: "/admin/audit-logs/verify", id: "verifyAuditChain", tag: "admin", summary: "Verify the audit log's hash chain", access: staff, roles: adminRoles, status: http.StatusOK, response: models.AuditChainVerification{}},
+
+	// Admin: customers
+	{method: http.MethodGet, path: "/admin/users/{user_id}", id: "adminGetUser", tag: "admin", summary: "Look up a user", access: staff, roles: staffRoles, status: http.StatusOK, response: models.User{}},
+	{method: http.MethodGet, path: "/admin/users/{user_id}/wallets", id: "adminListUserWallets", tag: "admin", summary: "List a user's wallets", access: staff, roles: staffRoles, status: http.StatusOK, response: models.UserWalletsResponse{}},
+	{method: http.MethodGet, path: "/admin/wallets/{wallet_id}/transactions", id: "adminListWalletTransactions", tag: "admin", summary: "List any wallet's transaction entries", access: staff, roles: staffRoles, params: page(20, 100), status: http.StatusOK, response: models.TransactionHistoryResponse{}},
+	{method: http.MethodPut, path: "/admin/users/{user_id}/role", id: "adminUpdateUserRole", tag: "admin", summary: "Change a user's role", access: staff, roles: adminRoles, body: models.UpdateRoleRequest{}, status: http.StatusOK, response: models.User{}},
+	{method: http.MethodPut, path: "/admin/users/{user_id}/status", id: "adminUpdateUserStatus", tag: "admin", summary: "Suspend, lock or reinstate a user", access: staff, roles: operatorRoles, body: models.UpdateUserStatusRequest{}, status: http.StatusOK, response: models.User{}},
+	{method: http.MethodDelete, path: "/admin/users/{user_id}/sessions", id: "adminRevokeUserSessions", tag: "admin", summary: "End every session of a user", access: staff, roles: operatorRoles, status: http.StatusOK, response: revokedSessions{}},
+
+	// Admin: KYC review
+	{method: http.MethodGet, path: "/admin/kyc/submissions", id: "adminListKYCSubmissions", tag: "admin", summary: "List KYC submissions", access: staff, roles: staffRoles, params: withStatus(models.KYCSubmissionStatus(""), page(20, 100)), status: http.StatusOK, response: models.KYCSubmissionListResponse{}},
+	{method: http.MethodGet, path: "/admin/kyc/submissions/{submission_id}/document", id: "adminGetKYCDocument", tag: "admin", summary: "Download a submitted document", access: staff, roles: operatorRoles, status: http.StatusOK, responseSchema: &Schema{Type: "string", Format: "binary"}, contentType: "application/octet-stream"},
+	{method: http.MethodPost, path: "/admin/kyc/submissions/{submission_id}/approve", id: "adminApproveKYCSubmission", tag: "admin", summary: "Approve a submission", access: staff, roles: operatorRoles, body: models.ApproveKYCRequest{}, status: http.StatusOK, response: models.KYCSubmission{}},
+	{method: http.MethodPost, path: "/admin/kyc/submissions/{submission_id}/reject", id: "adminRejectKYCSubmission", tag: "admin", summary: "Reject a submission", access: staff, roles: operatorRoles, body: models.RejectKYCRequest{}, status: http.StatusOK, response: models.KYCSubmission{}},
+	{method: http.MethodPut, path: "/admin/users/{user_id}/kyc-tier", id: "adminUpdateKYCTier", tag: "admin", summary: "Set a user's KYC tier", access: staff, roles: operatorRoles, body: models.UpdateKYCTierRequest{}, status: http.StatusOK, response: models.User{}},
+
+	// Admin: balance adjustments
+	{method: http.MethodGet, path: "/admin/adjustments", id: "adminListAdjustments", tag: "admin", summary: "List balance adjustments", access: staff, roles: staffRoles, params: withStatus(models.AdjustmentStatus(""), page(20, 100)), status: http.StatusOK, response: models.AdjustmentListResponse{}},
+	{method: http.MethodGet, path: "/admin/adjustments/{adjustment_id}", id: "adminGetAdjustment", tag: "admin", summary: "Get a balance adjustment", access: staff, roles: staffRoles, status: http.StatusOK, response: models.BalanceAdjustment{}},
+	{method: http.MethodPost, path: "/admin/adjustments", id: "adminCreateAdjustment", tag: "admin", summary: "File a balance adjustment", notes: "Nothing moves until another admin approves it.", access: staff, roles: operatorRoles, body: models.CreateAdjustmentRequest{}, status: http.StatusCreated, response: models.BalanceAdjustment{}},
+	{method: http.MethodPost, path: "/admin/adjustments/{adjustment_id}/approve", id: "adminApproveAdjustment", tag: "admin", summary: "Approve and book an adjustment", access: staff, roles: adminRoles, body: models.ReviewAdjustmentRequest{}, optionalBody: true, status: http.StatusOK, response: models.BalanceAdjustment{}},
+	{method: http.MethodPost, path: "/admin/adjustments/{adjustment_id}/reject", id: "adminRejectAdjustment", tag: "admin", summary: "Reject an adjustment", notes: "reason is required.", access: staff, roles: adminRoles, body: models.ReviewAdjustmentRequest{}, status: http.StatusOK, response: models.BalanceAdjustment{}},
+
+	// Admin: withdrawal review
+	{method: http.MethodGet, path: "/admin/withdrawals", id: "adminListWithdrawals", tag: "admin", summary: "List held withdrawals, oldest first", access: staff, roles: staffRoles, params: withStatus(models.WithdrawalReviewStatus(""), page(20, 100)), status: http.StatusOK, response: models.WithdrawalReviewListResponse{}},
+	{method: http.MethodPost, path: "/admin/withdrawals/{transaction_id}/approve", id: "adminApproveWithdrawal", tag: "admin", summary: "Approve a held withdrawal", access: staff, roles: operatorRoles, body: models.ReviewWithdrawalRequest{}, optionalBody: true, status: http.StatusOK, response: models.WithdrawalReview{}},
+	{method: http.MethodPost, path: "/admin/withdrawals/{transaction_id}/reject", id: "adminRejectWithdrawal", tag: "admin", summary: "Reject a held withdrawal", notes: "reason is required.", access: staff, roles: operatorRoles, body: models.ReviewWithdrawalRequest{}, status: http.StatusOK, response: models.WithdrawalReview{}},
+
+	// Admin: wallet holds
+	{method: http.MethodPost, path: "/admin/wallets/{wallet_id}/freeze", id: "adminFreezeWallet", tag: "admin", summary: "Freeze a wallet", access: staff, roles: operatorRoles, body: models.WalletStatusChangeRequest{}, status: http.StatusOK, response: models.Wallet{}},
+	{method: http.MethodPost, path: "/admin/wallets/{wallet_id}/unfreeze", id: "adminUnfreezeWallet", tag: "admin", summary: "Unfreeze a wallet", access: staff, roles: operatorRoles, body: models.WalletStatusChangeRequest{}, status: http.StatusOK, response: models.Wallet{}},
+}
+
+var tags = []Tag{
+	{Name: "meta", Description: "Health, keys, error codes and this document"},
+	{Name: "auth", Description: "Registration, login and passwords"},
+	{Name: "sessions", Description: "Logins of the caller"},
+	{Name: "account", Description: "Account security"},
+	{Name: "two-factor", Description: "TOTP two-factor authentication"},
+	{Name: "wallets", Description: "Wallets and money movements"},
+	{Name: "withdrawals", Description: "Withdrawals held for review"},
+	{Name: "kyc", Description: "Identity verification"},
+	{Name: "api-keys", Description: "Scoped keys for scripts and integrations"},
+	{Name: "webhooks", Description: "Signed event deliveries"},
+	{Name: "stream", Description: "Live Server-Sent Events"},
+	{Name: "admin", Description: "Staff API"},
+}
+
+// enums lists the values of the models' string enums
+func enums() map[reflect.Type][]string {
+	enums := map[reflect.Type][]string{}
+	for _, values := range [][]interface{}{
+		{models.CoinTypeBTC, models.CoinTypeETH, models.CoinTypeADA},
+		{models.WalletStatusActive, models.WalletStatusFrozen, models.WalletStatusClosing, models.WalletStatusClosed},
+		{models.TransactionTypeDeposit, models.TransactionTypeWithdrawal, models.TransactionTypeTransfer, models.TransactionTypeAdjustment},
+		{models.TransactionStatusPending, models.TransactionStatusDone, models.TransactionStatusFailed},
+		{models.DirectionIn, models.DirectionOut},
+		{models.EventTypeTransactionCompleted, models.EventTypeWalletDebited, models.EventTypeWalletCredited, models.EventTypeWebhookTest, models.EventTypeWithdrawalStatusChanged},
+		{models.WebhookDeliveryStatusPending, models.WebhookDeliveryStatusDelivered, models.WebhookDeliveryStatusDead},
+		{models.AuditOutcomeSuccess, models.AuditOutcomeDenied, models.AuditOutcomeFailure},
+		{models.ScopeWalletsRead, models.ScopeTransactionsRead, models.ScopeDepositsWrite, models.ScopeWithdrawalsWrite, models.ScopeTransfersWrite, models.ScopeWebhooksRead, models.ScopeWebhooksWrite},
+		{models.RoleUser, models.RoleSupportReadOnly, models.RoleOperator, models.RoleAdmin},
+		{models.UserStatusActive, models.UserStatusSuspended, models.UserStatusLockedForReview},
+		{models.StatusReasonFraudSuspected, models.StatusReasonChargeback, models.StatusReasonComplianceReview, models.StatusReasonSanctionsHit, models.StatusReasonTermsViolation, models.StatusReasonCustomerRequest, models.StatusReasonReviewCleared},
+		{models.KYCTierUnverified, models.KYCTierBasic, models.KYCTierFull},
+		{models.KYCDocumentPassport, models.KYCDocumentNationalID, models.KYCDocumentDriversLicense, models.KYCDocumentProofOfAddress},
+		{models.KYCSubmissionPending, models.KYCSubmissionApproved, models.KYCSubmissionRejected},
+		{models.AdjustmentStatusPending, models.AdjustmentStatusExecuted, models.AdjustmentStatusRejected, models.AdjustmentStatusExpired},
+		{models.WithdrawalReviewPending, models.WithdrawalReviewApproved, models.WithdrawalReviewRejected, models.WithdrawalReviewCancelled},
+	} {
+		names := make([]string, 0, len(values))
+		for _, value := range values {
+			names = append(names, fmt.Sprint(value))
+		}
+		enums[reflect.TypeOf(values[0])] = names
+	}
+
+	// Error codes come from the catalog
+	codes := []string{}
+	for _, entry := range problem.Catalog() {
+		codes = append(codes, string(entry.Code))
+	}
+	enums[reflect.TypeOf(problem.Code(""))] = codes
+	return enums
+}
+
+// page documents the limit and offset read by the handlers' pagination
+func page(defaultLimit, maxLimit int) []*Parameter {
+	return []*Parameter{
+		{Name: "limit", In: "query", Description: fmt.Sprintf("Page size; defaults to %d, at most %d", defaultLimit, maxLimit), Schema: &Schema{Type: "integer", Format: "int32"}},
+		{Name: "offset", In: "query", Description: "Entries to skip", Schema: &Schema{Type: "integer", Format: "int32"}},
+	}
+}
+
+// withStatus adds a status filter taking the values of the status enum
+func withStatus(status interface{}, parameters []*Parameter) []*Parameter {
+	return append([]*Parameter{{Name: "status", In: "query", Description: "Only entries in this status", Schema: &Schema{Type: "string", Enum: enums()[reflect.TypeOf(status)]}}}, parameters...)
+}
+
+func kycForm() *Schema {
+	return &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"tier":          {Type: "string", Enum: []string{string(models.KYCTierBasic), string(models.KYCTierFull)}},
+			"document_type": {Type: "string", Enum: enums()[reflect.TypeOf(models.KYCDocumentType(""))]},
+			"file":          {Type: "string", Format: "binary", Description: "PDF, JPEG or PNG"},
+		},
+		Required: []string{"tier", "document_type", "file"},
+	}
+}
+
+// Build assembles the document of every route, served from serverURL
+func Build(serverURL string) *Document {
+	g := newGenerator(enums(), map[reflect.Type]string{
+		reflect.TypeOf(problem.Code("")): "ErrorCode",
+		reflect.TypeOf(problem.Entry{}):  "ErrorCodeEntry",
+	})
+	problemSchema := g.schemaOf(problem.Problem{})
+	// Problems may carry extension members such as otp_required
+	g.schemas["Problem"].AdditionalProperties = &Schema{}
+
+	doc := &Document{
+		OpenAPI: Version,
+		Info: Info{
+			Title:       "Wallet Service API",
+			Version:     "1.0.0",
+			Description: "Amounts are decimal strings. Errors are RFC 7807 problems (application/problem+json) whose code is listed under /errors.",
+		},
+		Tags:  tags,
+		Paths: map[string]*PathItem{},
+		Components: Components{
+			Schemas:         g.schemas,
+			Parameters:      sharedParameters(),
+			Responses:       problemResponses(problemSchema),
+			SecuritySchemes: securitySchemes(),
+		},
+	}
+	if serverURL != "" {
+		doc.Servers = []Server{{URL: serverURL}}
+	}
+
+	for _, r := range routes {
+		item := doc.Paths[r.path]
+		if item == nil {
+			item = &PathItem{}
+			doc.Paths[r.path] = item
+		}
+		item.set(r.method, r.operation(g))
+	}
+	return doc
+}
+
+func (r route) operation(g *generator) *Operation {
+	op := &Operation{
+		OperationID: r.id,
+		Summary:     r.summary,
+		Tags:        []string{r.tag},
+		Responses:   map[string]*Response{},
+		Security:    r.security(),
+	}
+
+	var notes []string
+	if r.notes != "" {
+		notes = append(notes, r.notes)
+	}
+	if r.scope != "" {
+		notes = append(notes, fmt.Sprintf("API keys need the `%s` scope.", r.scope))
+	}
+	if len(r.roles) > 0 {
+		names := make([]string, 0, len(r.roles))
+		for _, role := range r.roles {
+			names = append(names, string(role))
+		}
+		notes = append(notes, "Staff roles: "+strings.Join(names, ", ")+". The X-Admin-Token acts as admin.")
+	}
+	op.Description = strings.Join(notes, " ")
+
+	// Parameters: the path's, then the query's, then headers
+	for _, segment := range strings.Split(r.path, "/") {
+		if strings.HasPrefix(segment, "{") {
+			name := strings.Trim(segment, "{}")
+			schema := &Schema{Type: "string", Format: "uuid"}
+			if name == "code" {
+				schema = &Schema{Type: "string"}
+			}
+			op.Parameters = append(op.Parameters, &Parameter{Name: name, In: "path", Required: true, Schema: schema})
+		}
+	}
+	if r.query != nil {
+		op.Parameters = append(op.Parameters, g.queryParameters(r.query)...)
+	}
+	op.Parameters = append(op.Parameters, r.params...)
+	if r.idempotent {
+		op.Parameters = append(op.Parameters, &Parameter{Ref: "#/components/parameters/IdempotencyKey"})
+	}
+	switch r.stepUp {
+	case stepUpAlways:
+		op.Parameters = append(op.Parameters, &Parameter{Ref: "#/components/parameters/OTP"})
+	case stepUpAbove:
+		op.Parameters = append(op.Parameters, &Parameter{Ref: "#/components/parameters/OTPAboveThreshold"})
+	}
+	op.Parameters = append(op.Parameters, &Parameter{Ref: "#/components/parameters/RequestID"})
+
+	switch {
+	case r.body != nil:
+		op.RequestBody = &RequestBody{
+			Required: !r.optionalBody,
+			Content:  map[string]*MediaType{"application/json": {Schema: g.schemaOf(r.body)}},
+		}
+	case r.form != nil:
+		op.RequestBody = &RequestBody{
+			Required: true,
+			Content:  map[string]*MediaType{"multipart/form-data": {Schema: r.form}},
+		}
+	}
+
+	op.Responses[strconv.Itoa(r.status)] = r.success(g, http.StatusText(r.status))
+	if r.mayHold {
+		op.Responses[strconv.Itoa(http.StatusAccepted)] = r.success(g, "Held for review")
+	}
+
+	errors := []string{"default"}
+	if len(op.Parameters) > 1 || op.RequestBody != nil {
+		errors = append(errors, "400")
+	}
+	if r.access != public {
+		errors = append(errors, "401", "403")
+	}
+	if strings.Contains(r.path, "{") {
+		errors = append(errors, "404")
+	}
+	if r.idempotent {
+		errors = append(errors, "409", "422")
+	}
+	if r.rateLimited() {
+		errors = append(errors, "429")
+	}
+	for _, status := range errors {
+		op.Responses[status] = &Response{Ref: "#/components/responses/" + problemResponseNames[status]}
+	}
+	return op
+}
+
+func (r route) success(g *generator, description string) *Response {
+	response := &Response{Description: description}
+	schema := r.responseSchema
+	if r.response != nil {
+		schema = g.schemaOf(r.response)
+	}
+	if schema != nil {
+		contentType := r.contentType
+		if contentType == "" {
+			contentType = "application/json"
+		}
+		response.Content = map[string]*MediaType{contentType: {Schema: schema}}
+	}
+	return response
+}
+
+func (r route) security() []SecurityRequirement {
+	switch r.access {
+	case userSession:
+		return []SecurityRequirement{{"bearerAuth": {}}}
+	case userOrAPIKey:
+		return []SecurityRequirement{{"bearerAuth": {}}, {"apiKey": {}}}
+	case staff:
+		return []SecurityRequirement{{"bearerAuth": {}}, {"adminToken": {}}}
+	case stream:
+		return []SecurityRequirement{{"bearerAuth": {}}, {"accessTokenQuery": {}}}
+	default:
+		return []SecurityRequirement{}
+	}
+}
+
+// rateLimited is true of the routes behind a rate limit: the public auth
+// routes and every authenticated route
+func (r route) rateLimited() bool {
+	return r.access != public || strings.HasPrefix(r.path, "/auth/")
+}
+
+var problemResponseNames = map[string]string{
+	"default": "Error",
+	"400":     "BadRequest",
+	"401":     "Unauthorized",
+	"403":     "Forbidden",
+	"404":     "NotFound",
+	"409":     "Conflict",
+	"422":     "IdempotencyKeyReused",
+	"429":     "TooManyRequests",
+}
+
+func problemResponses(problemSchema *Schema) map[string]*Response {
+	descriptions := map[string]string{
+		"Error":                "Unexpected error",
+		"BadRequest":           "INVALID_REQUEST or VALIDATION_FAILED, with the rejected fields",
+		"Unauthorized":         "Missing or invalid credentials, or a one-time code is required",
+		"Forbidden":            "The caller lacks the role, scope, KYC tier or account status",
+		"NotFound":             "The resource does not exist or is not the caller's",
+		"Conflict":             "A request with this idempotency key is in progress or was processed, or the resource's state forbids the change",
+		"IdempotencyKeyReused": "The idempotency key was used for a different request",
+		"TooManyRequests":      "Rate limited; retry after the seconds in Retry-After",
+	}
+
+	responses := map[string]*Response{}
+	names := make([]string, 0, len(descriptions))
+	for name := range descriptions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		responses[name] = &Response{
+			Description: descriptions[name],
+			Headers: map[string]*Header{
+				"X-Request-ID": {Description: "ID of the request, also in the problem's request_id", Schema: &Schema{Type: "string"}},
+			},
+			Content: map[string]*MediaType{problem.ContentType: {Schema: problemSchema}},
+		}
+	}
+	return responses
+}
+
+func sharedParameters() map[string]*Parameter {
+	return map[string]*Parameter{
+		"IdempotencyKey":    {Name: "X-Idempotency-Key", In: "header", Required: true, Description: "Unique per request; a repeat with the same key and body replays the stored response", Schema: &Schema{Type: "string"}},
+		"OTP":               {Name: "X-OTP", In: "header", Description: "Fresh TOTP or recovery code, required once two-factor authentication is enabled", Schema: &Schema{Type: "string"}},
+		"OTPAboveThreshold": {Name: "X-OTP", In: "header", Description: "Fresh TOTP or recovery code, required for amounts above the step-up threshold of the wallet's coin once two-factor authentication is enabled", Schema: &Schema{Type: "string"}},
+		"RequestID":         {Name: "X-Request-ID", In: "header", Description: "Caller's request ID, up to 128 of A-Z a-z 0-9 . _ -; generated when missing", Schema: &Schema{Type: "string", Pattern: `^[A-Za-z0-9._-]{1,128}$`}},
+	}
+}
+
+func securitySchemes() map[string]*SecurityScheme {
+	return map[string]*SecurityScheme{
+		"bearerAuth":       {Type: "http", Scheme: "bearer", BearerFormat: "JWT", Description: "Access token from /auth/login; keys are published at /.well-known/jwks.json"},
+		"apiKey":           {Type: "apiKey", In: "header", Name: "X-API-Key", Description: "API key from /api-keys, limited to its scopes and wallets"},
+		"adminToken":       {Type: "apiKey", In: "header", Name: "X-Admin-Token", Description: "Shared operator token; acts as an admin"},
+		"accessTokenQuery": {Type: "apiKey", In: "query", Name: "access_token", Description: "Access token in the query string, for EventSource clients that cannot set headers"},
+	}
+}
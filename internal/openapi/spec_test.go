@@ -0,0 +1,93 @@
+package openapi
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestBuildResolvesEveryReference(t *testing.T) {
+	doc := Build("https://wallet.example.com")
+
+	body, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+
+	for _, match := range regexp.MustCompile(`"\$ref":"#/components/(\w+)/(\w+)"`).FindAllStringSubmatch(string(body), -1) {
+		var found bool
+		switch match[1] {
+		case "schemas":
+			_, found = doc.Components.Schemas[match[2]]
+		case "parameters":
+			_, found = doc.Components.Parameters[match[2]]
+		case "responses":
+			_, found = doc.Components.Responses[match[2]]
+		}
+		if !found {
+			t.Errorf("Unresolved reference %s", match[0])
+		}
+	}
+}
+
+func TestBuildOperations(t *testing.T) {
+	doc := Build("")
+
+	ids := map[string]string{}
+	for key, operation := range doc.Operations() {
+		if previous, ok := ids[operation.OperationID]; ok {
+			t.Errorf("%s and %s share the operationId %s", previous, key, operation.OperationID)
+		}
+		ids[operation.OperationID] = key
+
+		if len(operation.Responses) == 0 {
+			t.Errorf("%s documents no responses", key)
+		}
+	}
+
+	deposit := doc.Operation("POST", "/wallets/:wallet_id/deposit")
+	if deposit == nil {
+		t.Fatal("Expected the deposit operation")
+	}
+	var refs []string
+	for _, parameter := range deposit.Parameters {
+		refs = append(refs, parameter.Ref)
+	}
+	if !strings.Contains(strings.Join(refs, " "), "IdempotencyKey") {
+		t.Errorf("Expected deposits to require an idempotency key, got %v", refs)
+	}
+	if len(deposit.Security) != 2 {
+		t.Errorf("Expected deposits to accept an access token or an API key, got %v", deposit.Security)
+	}
+
+	if health := doc.Operation("GET", "/health"); health == nil || len(health.Security) != 0 {
+		t.Error("Expected a public health check")
+	}
+}
+
+func TestSchemaFromBindingTags(t *testing.T) {
+	doc := Build("")
+
+	register := doc.Components.Schemas["RegisterRequest"]
+	if strings.Join(register.Required, ",") != "name,email,password" {
+		t.Errorf("Expected name, email and password to be required, got %v", register.Required)
+	}
+	if email := register.Properties["email"]; email.Format != "email" || email.MaxLength == nil || *email.MaxLength != 254 {
+		t.Errorf("Expected an email of at most 254 characters, got %+v", email)
+	}
+
+	wallet := doc.Components.Schemas["Wallet"]
+	if wallet.Properties["amount"].Ref != "#/components/schemas/Decimal" || wallet.Properties["closed_at"].Nullable != true {
+		t.Errorf("Unexpected wallet schema %+v", wallet.Properties)
+	}
+	if coin := doc.Components.Schemas["CoinType"]; strings.Join(coin.Enum, ",") != "BTC,ETH,ADA" {
+		t.Errorf("Expected the coin enum, got %v", coin.Enum)
+	}
+
+	// Embedded structs are flattened like encoding/json does
+	login := doc.Components.Schemas["LoginResponse"]
+	if _, ok := login.Properties["refresh_token"]; !ok {
+		t.Errorf("Expected the embedded token fields, got %v", login.Properties)
+	}
+}
@@ -0,0 +1,265 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"wallet-service/internal/problem"
+
+	"github.com/google/uuid"
+)
+
+// patterns caches compiled schema patterns
+var patterns sync.Map
+
+// ValidateJSON checks a decoded JSON value, decoded with UseNumber, against
+// schema. Every violation is reported, each under the field's JSON path.
+func (d *Document) ValidateJSON(schema *Schema, value interface{}) []problem.FieldError {
+	var violations []problem.FieldError
+	d.validate(schema, value, "", &violations)
+	return violations
+}
+
+// ValidateParameter checks the raw string of a path or query parameter
+func (d *Document) ValidateParameter(parameter *Parameter, raw string) []problem.FieldError {
+	schema := d.resolve(parameter.Schema)
+	value, err := parseParameter(schema, raw)
+	if err != nil {
+		return []problem.FieldError{{Field: parameter.Name, Rule: "type", Message: "must be " + typeName(schema)}}
+	}
+
+	var violations []problem.FieldError
+	d.validate(schema, value, parameter.Name, &violations)
+	return violations
+}
+
+// Parameter resolves a parameter that may refer to a shared one
+func (d *Document) Parameter(parameter *Parameter) *Parameter {
+	if parameter.Ref == "" {
+		return parameter
+	}
+	return d.Components.Parameters[strings.TrimPrefix(parameter.Ref, "#/components/parameters/")]
+}
+
+func (d *Document) resolve(schema *Schema) *Schema {
+	for schema != nil && schema.Ref != "" {
+		schema = d.Components.Schemas[strings.TrimPrefix(schema.Ref, "#/components/schemas/")]
+	}
+	return schema
+}
+
+func (d *Document) validate(schema *Schema, value interface{}, field string, violations *[]problem.FieldError) {
+	schema = d.resolve(schema)
+	if schema == nil {
+		return
+	}
+	fail := func(rule, message string) {
+		*violations = append(*violations, problem.FieldError{Field: field, Rule: rule, Message: message})
+	}
+
+	if value == nil {
+		if !schema.Nullable && (schema.Type != "" || len(schema.AllOf) > 0 || len(schema.AnyOf) > 0) {
+			fail("nullable", "must not be null")
+		}
+		return
+	}
+
+	for _, part := range schema.AllOf {
+		d.validate(part, value, field, violations)
+	}
+	if len(schema.AnyOf) > 0 {
+		matched := false
+		for _, option := range schema.AnyOf {
+			var optionViolations []problem.FieldError
+			d.validate(option, value, field, &optionViolations)
+			if len(optionViolations) == 0 {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			fail("type", "must be "+typeName(schema))
+		}
+	}
+
+	switch schema.Type {
+	case "string":
+		s, ok := value.(string)
+		if !ok {
+			fail("type", "must be a string")
+			return
+		}
+		d.validateString(schema, s, fail)
+	case "integer", "number":
+		n, ok := value.(json.Number)
+		if !ok {
+			fail("type", "must be "+typeName(schema))
+			return
+		}
+		f, err := n.Float64()
+		if err != nil || (schema.Type == "integer" && strings.ContainsAny(n.String(), ".eE")) {
+			fail("type", "must be "+typeName(schema))
+			return
+		}
+		if schema.Minimum != nil && f < *schema.Minimum {
+			fail("minimum", fmt.Sprintf("must be at least %v", *schema.Minimum))
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			fail("type", "must be a boolean")
+		}
+	case "array":
+		items, ok := value.([]interface{})
+		if !ok {
+			fail("type", "must be an array")
+			return
+		}
+		if schema.MinItems != nil && len(items) < *schema.MinItems {
+			fail("minItems", fmt.Sprintf("must have at least %d items", *schema.MinItems))
+		}
+		if schema.MaxItems != nil && len(items) > *schema.MaxItems {
+			fail("maxItems", fmt.Sprintf("must have at most %d items", *schema.MaxItems))
+		}
+		for i, item := range items {
+			d.validate(schema.Items, item, fmt.Sprintf("%s[%d]", field, i), violations)
+		}
+	case "object":
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			fail("type", "must be an object")
+			return
+		}
+		for _, name := range schema.Required {
+			if _, ok := object[name]; !ok {
+				*violations = append(*violations, problem.FieldError{Field: join(field, name), Rule: "required", Message: "is required"})
+			}
+		}
+		// Unknown members are ignored, as binding ignores them
+		for name, member := range object {
+			if property, ok := schema.Properties[name]; ok {
+				d.validate(property, member, join(field, name), violations)
+			} else if schema.AdditionalProperties != nil {
+				d.validate(schema.AdditionalProperties, member, join(field, name), violations)
+			}
+		}
+	}
+}
+
+func (d *Document) validateString(schema *Schema, s string, fail func(rule, message string)) {
+	if len(schema.Enum) > 0 && !contains(schema.Enum, s) {
+		fail("enum", "must be one of "+strings.Join(schema.Enum, ", "))
+		return
+	}
+
+	length := utf8.RuneCountInString(s)
+	if schema.MinLength != nil && length < *schema.MinLength {
+		fail("minLength", fmt.Sprintf("must be at least %d characters long", *schema.MinLength))
+	}
+	if schema.MaxLength != nil && length > *schema.MaxLength {
+		fail("maxLength", fmt.Sprintf("must be at most %d characters long", *schema.MaxLength))
+	}
+	if schema.Pattern != "" && !matches(schema.Pattern, s) {
+		fail("pattern", "must match "+schema.Pattern)
+	}
+
+	switch schema.Format {
+	case "uuid":
+		if _, err := uuid.Parse(s); err != nil {
+			fail("format", "must be a UUID")
+		}
+	case "date-time":
+		if _, err := time.Parse(time.RFC3339, s); err != nil {
+			fail("format", "must be an RFC 3339 date-time")
+		}
+	case "email":
+		if _, err := mail.ParseAddress(s); err != nil {
+			fail("format", "must be a valid email address")
+		}
+	case "uri":
+		if u, err := url.ParseRequestURI(s); err != nil || u.Scheme == "" || u.Host == "" {
+			fail("format", "must be a URL")
+		}
+	}
+}
+
+// parseParameter converts a parameter's string to the JSON value its
+// schema describes
+func parseParameter(schema *Schema, raw string) (interface{}, error) {
+	switch schema.Type {
+	case "integer":
+		if _, err := strconv.ParseInt(raw, 10, 64); err != nil {
+			return nil, err
+		}
+		return json.Number(raw), nil
+	case "number":
+		if _, err := strconv.ParseFloat(raw, 64); err != nil {
+			return nil, err
+		}
+		return json.Number(raw), nil
+	case "boolean":
+		return strconv.ParseBool(raw)
+	default:
+		return raw, nil
+	}
+}
+
+func typeName(schema *Schema) string {
+	if len(schema.AnyOf) > 0 {
+		names := make([]string, 0, len(schema.AnyOf))
+		for _, option := range schema.AnyOf {
+			names = append(names, typeName(option))
+		}
+		return strings.Join(names, " or ")
+	}
+	switch schema.Type {
+	case "integer":
+		return "an integer"
+	case "array", "object":
+		return "an " + schema.Type
+	case "string":
+		if schema.Format == "decimal" {
+			return "a decimal string"
+		}
+		return "a string"
+	case "":
+		return "a value"
+	default:
+		return "a " + schema.Type
+	}
+}
+
+func matches(pattern, s string) bool {
+	compiled, ok := patterns.Load(pattern)
+	if !ok {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return true
+		}
+		compiled, _ = patterns.LoadOrStore(pattern, re)
+	}
+	return compiled.(*regexp.Regexp).MatchString(s)
+}
+
+func join(field, name string) string {
+	if field == "" {
+		return name
+	}
+	return field + "." + name
+}
+
+func contains(values []string, s string) bool {
+	for _, value := range values {
+		if value == s {
+			return true
+		}
+	}
+	return false
+}
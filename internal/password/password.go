@@ -0,0 +1,146 @@
+package password
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// argon2id parameters (RFC 9106 second recommended option)
+const (
+	argonTime    uint32 = 3
+	argonMemory  uint32 = 64 * 1024
+	argonThreads uint8  = 2
+	argonKeyLen  uint32 = 32
+	saltLen             = 16
+
+	MinLength = 12
+	MaxLength = 128
+)
+
+var (
+	ErrMismatch      = errors.New("password does not match")
+	ErrInvalidHash   = errors.New("invalid password hash")
+	ErrTooShort      = fmt.Errorf("password must be at least %d characters", MinLength)
+	ErrTooLong       = fmt.Errorf("password must be at most %d characters", MaxLength)
+	ErrTooSimple     = errors.New("password must mix at least three of: lowercase, uppercase, digits, symbols")
+	ErrContainsEmail = errors.New("password must not contain the email address")
+)
+
+// dummyHash is verified against when a login names an unknown user, so both
+// paths cost one argon2id derivation and timing does not reveal the email
+var dummyHash, _ = Hash("dummy-password-for-timing")
+
+// Hash derives an argon2id hash and encodes it in PHC string format:
+// $argon2id$v=19$m=65536,t=3,p=2$<salt>$<key>
+func Hash(plain string) (string, error) {
+	salt := make([]byte, saltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("failed to generate salt: %w", err)
+	}
+
+	key := argon2.IDKey([]byte(plain), salt, argonTime, argonMemory, argonThreads, argonKeyLen)
+
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, argonMemory, argonTime, argonThreads,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+// Verify checks plain against an encoded hash in constant time. An empty hash
+// (user without a password) is checked against a dummy hash and never matches.
+func Verify(encoded, plain string) error {
+	if encoded == "" {
+		verify(dummyHash, plain)
+		return ErrMismatch
+	}
+	return verify(encoded, plain)
+}
+
+// VerifyDummy burns the same time as Verify for callers that found no user
+func VerifyDummy(plain string) {
+	verify(dummyHash, plain)
+}
+
+func verify(encoded, plain string) error {
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return ErrInvalidHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return ErrInvalidHash
+	}
+
+	var memory, time uint32
+	var threads uint8
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &memory, &time, &threads); err != nil {
+		return ErrInvalidHash
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return ErrInvalidHash
+	}
+
+	expected, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return ErrInvalidHash
+	}
+
+	actual := argon2.IDKey([]byte(plain), salt, time, memory, threads, uint32(len(expected)))
+	if subtle.ConstantTimeCompare(actual, expected) != 1 {
+		return ErrMismatch
+	}
+
+	return nil
+}
+
+// Validate enforces the password strength rules
+func Validate(plain, email string) error {
+	length := len([]rune(plain))
+	if length < MinLength {
+		return ErrTooShort
+	}
+	if length > MaxLength {
+		return ErrTooLong
+	}
+
+	var lower, upper, digit, symbol bool
+	for _, r := range plain {
+		switch {
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		default:
+			symbol = true
+		}
+	}
+
+	classes := 0
+	for _, present := range []bool{lower, upper, digit, symbol} {
+		if present {
+			classes++
+		}
+	}
+	if classes < 3 {
+		return ErrTooSimple
+	}
+
+	if local, _, _ := strings.Cut(strings.ToLower(email), "@"); len(local) >= 4 && strings.Contains(strings.ToLower(plain), local) {
+		return ErrContainsEmail
+	}
+
+	return nil
+}
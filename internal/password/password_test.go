@@ -0,0 +1,44 @@
+package password
+
+import "testing"
+
+func TestHashAndVerify(t *testing.T) {
+	hash, err := Hash("Correct-Horse-42")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if err := Verify(hash, "Correct-Horse-42"); err != nil {
+		t.Errorf("Expected password to verify, got %v", err)
+	}
+	if err := Verify(hash, "Correct-Horse-43"); err != ErrMismatch {
+		t.Errorf("Expected ErrMismatch, got %v", err)
+	}
+	if err := Verify("", "anything"); err != ErrMismatch {
+		t.Errorf("Expected ErrMismatch for user without password, got %v", err)
+	}
+
+	other, _ := Hash("Correct-Horse-42")
+	if other == hash {
+		t.Error("Expected hashes of the same password to use different salts")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	cases := []struct {
+		password string
+		want     error
+	}{
+		{"Short-1", ErrTooShort},
+		{"alllowercaseletters", ErrTooSimple},
+		{"lowercase-and-symbols", ErrTooSimple},
+		{"Mixed-Case-Password", nil},
+		{"user_001-Secret-9", ErrContainsEmail},
+	}
+
+	for _, tc := range cases {
+		if got := Validate(tc.password, "user_001@example.com"); got != tc.want {
+			t.Errorf("Validate(%q) = %v, want %v", tc.password, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,28 @@
+package password
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+)
+
+// NewResetToken returns a random reset token for the user and the SHA-256
+// digest that is stored in its place
+func NewResetToken() (string, string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", "", fmt.Errorf("failed to generate reset token: %w", err)
+	}
+
+	token := base64.RawURLEncoding.EncodeToString(raw)
+	return token, HashResetToken(token), nil
+}
+
+// HashResetToken digests a reset token for lookup; tokens carry 256 bits of
+// entropy so an unsalted hash is sufficient
+func HashResetToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
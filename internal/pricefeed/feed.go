@@ -0,0 +1,196 @@
+package pricefeed
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"wallet-service/internal/models"
+	"wallet-service/internal/repository"
+
+	"github.com/shopspring/decimal"
+)
+
+type priceKey struct {
+	coinType models.CoinType
+	currency string
+}
+
+// Feed keeps the latest price per coin/currency in memory, refreshes it from a
+// PriceSource on an interval and records every new quote in the price history
+type Feed struct {
+	source     PriceSource
+	priceRepo  repository.IPriceRepository
+	currencies map[string]bool
+	interval   time.Duration
+	staleAfter time.Duration
+
+	mu     sync.RWMutex
+	latest map[priceKey]models.Price
+}
+
+func NewFeed(source PriceSource, priceRepo repository.IPriceRepository, currencies []string, interval, staleAfter time.Duration) *Feed {
+	supported := make(map[string]bool, len(currencies))
+	for _, currency := range currencies {
+		supported[strings.ToUpper(strings.TrimSpace(currency))] = true
+	}
+
+	return &Feed{
+		source:     source,
+		priceRepo:  priceRepo,
+		currencies: supported,
+		interval:   interval,
+		staleAfter: staleAfter,
+		latest:     make(map[priceKey]models.Price),
+	}
+}
+
+// Start refreshes prices immediately and then on every interval until ctx is done
+func (f *Feed) Start(ctx context.Context) {
+	go func() {
+		if err := f.Refresh(ctx); err != nil {
+			log.Printf("Warning: initial price refresh failed: %v", err)
+		}
+
+		ticker := time.NewTicker(f.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := f.Refresh(ctx); err != nil {
+					log.Printf("Warning: price refresh failed: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+// Refresh pulls prices from the source and updates the in-memory cache with
+// quotes newer than the ones already known. Only quotes whose price changed
+// are added to the history; a newer quote at the same price just keeps the
+// cached one fresh.
+func (f *Feed) Refresh(ctx context.Context) error {
+	prices, err := f.source.Fetch(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, price := range prices {
+		if !f.currencies[price.Currency] {
+			continue
+		}
+
+		key := priceKey{coinType: price.CoinType, currency: price.Currency}
+
+		f.mu.RLock()
+		current, known := f.latest[key]
+		f.mu.RUnlock()
+
+		// After a restart, compare against the stored history instead
+		if !known {
+			stored, err := f.priceRepo.GetLatest(key.coinType, key.currency)
+			if err != nil {
+				return fmt.Errorf("failed to get stored %s/%s price: %w", price.CoinType, price.Currency, err)
+			}
+			if stored != nil {
+				current, known = *stored, true
+			}
+		}
+
+		if known && !price.AsOf.After(current.AsOf) {
+			continue
+		}
+
+		price := price
+		if known && price.Price.Equal(current.Price) {
+			// Keep the stored row's identity; only the quote's age moves
+			asOf := price.AsOf
+			price = current
+			price.AsOf = asOf
+		} else if err := f.priceRepo.Create(ctx, &price); err != nil {
+			return fmt.Errorf("failed to store %s/%s price: %w", price.CoinType, price.Currency, err)
+		}
+
+		f.mu.Lock()
+		f.latest[key] = price
+		f.mu.Unlock()
+	}
+
+	return nil
+}
+
+// Supports reports whether currency is one of the configured fiat currencies
+func (f *Feed) Supports(currency string) bool {
+	return f.currencies[strings.ToUpper(currency)]
+}
+
+// Quote returns the latest known price for a coin, falling back to the stored
+// history when nothing has been fetched since startup. The boolean reports
+// whether the price is older than the staleness window.
+func (f *Feed) Quote(coinType models.CoinType, currency string) (*models.Price, bool, error) {
+	key := priceKey{coinType: coinType, currency: strings.ToUpper(currency)}
+
+	f.mu.RLock()
+	price, ok := f.latest[key]
+	f.mu.RUnlock()
+
+	if !ok {
+		stored, err := f.priceRepo.GetLatest(key.coinType, key.currency)
+		if err != nil {
+			return nil, false, err
+		}
+		if stored == nil {
+			return nil, false, nil
+		}
+		price = *stored
+	}
+
+	return &price, time.Since(price.AsOf) > f.staleAfter, nil
+}
+
+// Valuate prices every wallet in currency. Wallets without any known price are
+// left unvalued and, like stale prices, mark the whole portfolio as stale.
+func (f *Feed) Valuate(currency string, wallets []models.Wallet) (*models.PortfolioValuation, error) {
+	currency = strings.ToUpper(currency)
+
+	valuation := &models.PortfolioValuation{
+		Currency:   currency,
+		TotalValue: decimal.Zero,
+		Wallets:    make([]models.WalletValuation, 0, len(wallets)),
+	}
+
+	for _, wallet := range wallets {
+		price, stale, err := f.Quote(wallet.CoinType, currency)
+		if err != nil {
+			return nil, err
+		}
+
+		walletValuation := models.WalletValuation{
+			WalletID: wallet.ID,
+			CoinType: wallet.CoinType,
+			Stale:    price == nil || stale,
+		}
+
+		if price != nil {
+			value := wallet.Amount.Mul(price.Price).Round(2)
+			walletValuation.Price = &price.Price
+			walletValuation.Value = &value
+			walletValuation.PriceAsOf = &price.AsOf
+			valuation.TotalValue = valuation.TotalValue.Add(value)
+		}
+
+		if walletValuation.Stale {
+			valuation.Stale = true
+		}
+
+		valuation.Wallets = append(valuation.Wallets, walletValuation)
+	}
+
+	return valuation, nil
+}
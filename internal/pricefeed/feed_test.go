@@ -0,0 +1,133 @@
+package pricefeed
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"wallet-service/internal/models"
+	"wallet-service/internal/repository"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+type staticSource struct {
+	prices []models.Price
+}
+
+func (s *staticSource) Name() string { return "static" }
+
+func (s *staticSource) Fetch(ctx context.Context) ([]models.Price, error) {
+	return s.prices, nil
+}
+
+func TestFeed_ValuateFlagsStalePrices(t *testing.T) {
+	source := &staticSource{prices: []models.Price{
+		{ID: uuid.New(), CoinType: models.CoinTypeBTC, Currency: "USD", Price: decimal.NewFromInt(60000), AsOf: time.Now()},
+		{ID: uuid.New(), CoinType: models.CoinTypeETH, Currency: "USD", Price: decimal.NewFromInt(3000), AsOf: time.Now().Add(-time.Hour)},
+	}}
+	priceRepo := repository.NewMockPriceRepository()
+	feed := NewFeed(source, priceRepo, []string{"USD"}, time.Minute, 5*time.Minute)
+
+	if err := feed.Refresh(context.Background()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	wallets := []models.Wallet{
+		{ID: uuid.New(), CoinType: models.CoinTypeBTC, Amount: decimal.RequireFromString("0.5")},
+		{ID: uuid.New(), CoinType: models.CoinTypeETH, Amount: decimal.NewFromInt(2)},
+		{ID: uuid.New(), CoinType: models.CoinTypeADA, Amount: decimal.NewFromInt(100)},
+	}
+
+	valuation, err := feed.Valuate("usd", wallets)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if !valuation.TotalValue.Equal(decimal.NewFromInt(36000)) {
+		t.Errorf("Expected total 36000, got %s", valuation.TotalValue)
+	}
+	if !valuation.Stale {
+		t.Error("Expected portfolio to be flagged stale")
+	}
+	if valuation.Wallets[0].Stale {
+		t.Error("Expected fresh BTC price not to be stale")
+	}
+	if !valuation.Wallets[1].Stale {
+		t.Error("Expected hour-old ETH price to be stale")
+	}
+	if valuation.Wallets[2].Value != nil || !valuation.Wallets[2].Stale {
+		t.Error("Expected unpriced ADA wallet to have no value and be stale")
+	}
+}
+
+func TestFeed_RefreshSkipsUnchangedQuotes(t *testing.T) {
+	asOf := time.Now()
+	source := &staticSource{prices: []models.Price{
+		{ID: uuid.New(), CoinType: models.CoinTypeBTC, Currency: "USD", Price: decimal.NewFromInt(60000), AsOf: asOf},
+		{ID: uuid.New(), CoinType: models.CoinTypeBTC, Currency: "JPY", Price: decimal.NewFromInt(9000000), AsOf: asOf},
+	}}
+	priceRepo := repository.NewMockPriceRepository()
+	feed := NewFeed(source, priceRepo, []string{"USD"}, time.Minute, 5*time.Minute)
+
+	for i := 0; i < 2; i++ {
+		if err := feed.Refresh(context.Background()); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+
+	history, _ := priceRepo.GetHistory(models.CoinTypeBTC, "USD", asOf.Add(-time.Minute))
+	if len(history) != 1 {
+		t.Errorf("Expected 1 stored USD quote, got %d", len(history))
+	}
+	if feed.Supports("JPY") {
+		t.Error("Expected JPY to be unsupported")
+	}
+}
+
+func TestFeed_RefreshStoresOnlyChangedPrices(t *testing.T) {
+	asOf := time.Now().Add(-time.Minute)
+	source := &staticSource{}
+	priceRepo := repository.NewMockPriceRepository()
+	feed := NewFeed(source, priceRepo, []string{"USD"}, time.Minute, 5*time.Minute)
+
+	quotes := []decimal.Decimal{decimal.NewFromInt(60000), decimal.NewFromInt(60000), decimal.NewFromInt(61000)}
+	for i, quote := range quotes {
+		source.prices = []models.Price{{ID: uuid.New(), CoinType: models.CoinTypeBTC, Currency: "USD", Price: quote, AsOf: asOf.Add(time.Duration(i) * time.Second)}}
+		if err := feed.Refresh(context.Background()); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+
+	history, _ := priceRepo.GetHistory(models.CoinTypeBTC, "USD", asOf.Add(-time.Minute))
+	if len(history) != 2 {
+		t.Errorf("Expected 2 stored quotes, got %d", len(history))
+	}
+
+	price, _, _ := feed.Quote(models.CoinTypeBTC, "USD")
+	if !price.AsOf.Equal(asOf.Add(2 * time.Second)) {
+		t.Errorf("Expected the newest quote to be cached, got %v", price.AsOf)
+	}
+}
+
+func TestDecodePrices(t *testing.T) {
+	document := `{"rates": {"BTC": {"usd": "64000"}, "DOGE": {"USD": "0.1"}}}`
+
+	if _, err := decodePrices(strings.NewReader(document), "test", nil); err == nil {
+		t.Error("Expected a document without as_of to be rejected")
+	}
+
+	modified := time.Date(2025, 7, 14, 0, 0, 0, 0, time.UTC)
+	prices, err := decodePrices(strings.NewReader(document), "test", &modified)
+	if err != nil {
+		t.Fatalf("Expected unknown coins to be skipped, got %v", err)
+	}
+	if len(prices) != 1 || prices[0].CoinType != models.CoinTypeBTC || prices[0].Currency != "USD" {
+		t.Fatalf("Expected only the BTC/USD quote, got %+v", prices)
+	}
+	if !prices[0].AsOf.Equal(modified) {
+		t.Errorf("Expected the fallback as_of, got %v", prices[0].AsOf)
+	}
+}
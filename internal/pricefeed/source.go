@@ -0,0 +1,165 @@
+package pricefeed
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"wallet-service/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// PriceSource fetches the current coin prices from an upstream provider
+type PriceSource interface {
+	Name() string
+	Fetch(ctx context.Context) ([]models.Price, error)
+}
+
+// pricePayload is the document served by file and HTTP sources:
+//
+//	{"as_of": "2025-07-14T00:00:00Z", "rates": {"BTC": {"USD": "64000.00"}}}
+//
+// as_of dates the quotes and drives the staleness check. HTTP sources must
+// send it; a file without it is dated by its modification time, so an
+// unchanged file reads as the same, ageing quotes.
+type pricePayload struct {
+	AsOf  *time.Time                            `json:"as_of"`
+	Rates map[models.CoinType]map[string]string `json:"rates"`
+}
+
+// NewPriceSource builds a source from a location, either an http(s) URL or a
+// local file path (optionally prefixed with file://)
+func NewPriceSource(location string) (PriceSource, error) {
+	switch {
+	case location == "":
+		return nil, fmt.Errorf("price source location is empty")
+	case strings.HasPrefix(location, "http://"), strings.HasPrefix(location, "https://"):
+		return NewHTTPSource(location, 10*time.Second), nil
+	default:
+		return NewFileSource(strings.TrimPrefix(location, "file://")), nil
+	}
+}
+
+// FileSource reads prices from a JSON file on disk
+type FileSource struct {
+	path string
+}
+
+func NewFileSource(path string) *FileSource {
+	return &FileSource{path: path}
+}
+
+func (s *FileSource) Name() string {
+	return "file:" + s.path
+}
+
+func (s *FileSource) Fetch(ctx context.Context) ([]models.Price, error) {
+	f, err := os.Open(s.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open price file: %w", err)
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat price file: %w", err)
+	}
+	modified := info.ModTime()
+
+	return decodePrices(f, s.Name(), &modified)
+}
+
+// HTTPSource fetches prices from an HTTP endpoint serving the same JSON document
+type HTTPSource struct {
+	url    string
+	client *http.Client
+}
+
+func NewHTTPSource(url string, timeout time.Duration) *HTTPSource {
+	return &HTTPSource{
+		url:    url,
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+func (s *HTTPSource) Name() string {
+	return s.url
+}
+
+func (s *HTTPSource) Fetch(ctx context.Context) ([]models.Price, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build price request: %w", err)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch prices: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("price source returned status %d", resp.StatusCode)
+	}
+
+	return decodePrices(resp.Body, s.Name(), nil)
+}
+
+// decodePrices reads a price document, dating it with defaultAsOf when it
+// has no as_of; without either the document is rejected. Coins the service
+// does not know are skipped so one new listing cannot stop every refresh.
+func decodePrices(r io.Reader, source string, defaultAsOf *time.Time) ([]models.Price, error) {
+	var payload pricePayload
+	if err := json.NewDecoder(r).Decode(&payload); err != nil {
+		return nil, fmt.Errorf("failed to decode prices: %w", err)
+	}
+
+	var asOf time.Time
+	switch {
+	case payload.AsOf != nil:
+		asOf = payload.AsOf.UTC()
+	case defaultAsOf != nil:
+		asOf = defaultAsOf.UTC()
+	default:
+		return nil, fmt.Errorf("price document from %s has no as_of", source)
+	}
+
+	var prices []models.Price
+	for coinType, rates := range payload.Rates {
+		switch coinType {
+		case models.CoinTypeBTC, models.CoinTypeETH, models.CoinTypeADA:
+		default:
+			log.Printf("Warning: skipping prices for unknown coin %q from %s", coinType, source)
+			continue
+		}
+
+		for currency, raw := range rates {
+			value, err := decimal.NewFromString(raw)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s/%s price %q: %w", coinType, currency, raw, err)
+			}
+			if !value.IsPositive() {
+				return nil, fmt.Errorf("invalid %s/%s price %q: must be positive", coinType, currency, raw)
+			}
+
+			prices = append(prices, models.Price{
+				ID:       uuid.New(),
+				CoinType: coinType,
+				Currency: strings.ToUpper(currency),
+				Price:    value,
+				Source:   source,
+				AsOf:     asOf,
+			})
+		}
+	}
+
+	return prices, nil
+}
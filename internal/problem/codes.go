@@ -0,0 +1,139 @@
+package problem
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Code identifies a kind of problem. Codes are part of the API contract:
+// clients branch on them, so they are never renamed or reused.
+type Code string
+
+const (
+	// Requests
+	CodeInvalidRequest       Code = "INVALID_REQUEST"
+	CodeValidationFailed     Code = "VALIDATION_FAILED"
+	CodePayloadTooLarge      Code = "PAYLOAD_TOO_LARGE"
+	CodeUnsupportedMediaType Code = "UNSUPPORTED_MEDIA_TYPE"
+	CodeNotFound             Code = "NOT_FOUND"
+	CodeConflict             Code = "CONFLICT"
+	CodeRateLimited          Code = "RATE_LIMITED"
+	CodeInternal             Code = "INTERNAL_ERROR"
+
+	// Authentication and access
+	CodeUnauthenticated        Code = "UNAUTHENTICATED"
+	CodeInvalidCredentials     Code = "INVALID_CREDENTIALS"
+	CodeWeakPassword           Code = "WEAK_PASSWORD"
+	CodeOTPRequired            Code = "OTP_REQUIRED"
+	CodeOTPInvalid             Code = "OTP_INVALID"
+	CodeForbidden              Code = "FORBIDDEN"
+	CodeSessionRequired        Code = "SESSION_REQUIRED"
+	CodeInsufficientRole       Code = "INSUFFICIENT_ROLE"
+	CodeInsufficientScope      Code = "INSUFFICIENT_SCOPE"
+	CodeAPIKeyLimitExceeded    Code = "API_KEY_LIMIT_EXCEEDED"
+	CodeAccountRestricted      Code = "ACCOUNT_RESTRICTED"
+	CodeKYCLimitExceeded       Code = "KYC_LIMIT_EXCEEDED"
+	CodeEmailAlreadyRegistered Code = "EMAIL_ALREADY_REGISTERED"
+
+	// Wallets and money movements
+	CodeWalletNotFound      Code = "WALLET_NOT_FOUND"
+	CodeWalletNotActive     Code = "WALLET_NOT_ACTIVE"
+	CodeWalletExists        Code = "WALLET_EXISTS"
+	CodeInvalidAmount       Code = "INVALID_AMOUNT"
+	CodeInsufficientFunds   Code = "INSUFFICIENT_FUNDS"
+	CodeSameWallet          Code = "SAME_WALLET"
+	CodeCoinMismatch        Code = "COIN_MISMATCH"
+	CodeSweepTargetRequired Code = "SWEEP_TARGET_REQUIRED"
+
+	// Staff reviews
+	CodeAlreadyReviewed   Code = "ALREADY_REVIEWED"
+	CodeAdjustmentExpired Code = "ADJUSTMENT_EXPIRED"
+	CodeSelfApproval      Code = "SELF_APPROVAL"
+
+	// Idempotency
+	CodeIdempotencyKeyRequired   Code = "IDEMPOTENCY_KEY_REQUIRED"
+	CodeIdempotencyKeyReused     Code = "IDEMPOTENCY_KEY_REUSED"
+	CodeIdempotencyKeyInProgress Code = "IDEMPOTENCY_KEY_IN_PROGRESS"
+	CodeIdempotencyKeyProcessed  Code = "IDEMPOTENCY_KEY_ALREADY_PROCESSED"
+)
+
+// Slug is the code's path segment under the error catalog
+func (c Code) Slug() string {
+	return strings.ReplaceAll(strings.ToLower(string(c)), "_", "-")
+}
+
+// Entry documents a code
+type Entry struct {
+	Code        Code   `json:"code"`
+	Status      int    `json:"status"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+}
+
+var catalog = []Entry{
+	{CodeInvalidRequest, http.StatusBadRequest, "Invalid request", "The request is malformed: unparsable JSON, a bad path parameter or a missing required input."},
+	{CodeValidationFailed, http.StatusBadRequest, "Validation failed", "One or more fields break their rules; errors lists each field, the rule and why."},
+	{CodePayloadTooLarge, http.StatusRequestEntityTooLarge, "Payload too large", "The uploaded document is larger than the configured limit."},
+	{CodeUnsupportedMediaType, http.StatusUnsupportedMediaType, "Unsupported media type", "The uploaded document is not a PDF, JPEG or PNG."},
+	{CodeNotFound, http.StatusNotFound, "Not found", "The resource does not exist or is not visible to the caller."},
+	{CodeConflict, http.StatusConflict, "Conflict", "The request conflicts with the resource's current state."},
+	{CodeRateLimited, http.StatusTooManyRequests, "Too many requests", "A rate limit was hit; retry after the number of seconds in Retry-After."},
+	{CodeInternal, http.StatusInternalServerError, "Internal server error", "The server failed to complete the request. Quote the request_id when reporting it."},
+
+	{CodeUnauthenticated, http.StatusUnauthorized, "Authentication required", "The credentials are missing, invalid, expired or revoked."},
+	{CodeInvalidCredentials, http.StatusUnauthorized, "Invalid credentials", "The email and password, or the current password, do not match."},
+	{CodeWeakPassword, http.StatusBadRequest, "Weak password", "The new password is too short, too long, too simple or contains the email."},
+	{CodeOTPRequired, http.StatusUnauthorized, "One-time code required", "Two-factor authentication is enabled; repeat the request with a one-time code."},
+	{CodeOTPInvalid, http.StatusUnauthorized, "Invalid one-time code", "The one-time code is wrong or was already used."},
+	{CodeForbidden, http.StatusForbidden, "Forbidden", "The caller may not act on this resource."},
+	{CodeSessionRequired, http.StatusForbidden, "User session required", "The endpoint is not available to API keys or the admin token."},
+	{CodeInsufficientRole, http.StatusForbidden, "Insufficient role", "The caller's staff role is below the one the endpoint requires."},
+	{CodeInsufficientScope, http.StatusForbidden, "Insufficient scope", "The API key lacks the scope or wallet the request needs."},
+	{CodeAPIKeyLimitExceeded, http.StatusForbidden, "API key limit exceeded", "The amount is above the API key's per-request limit."},
+	{CodeAccountRestricted, http.StatusForbidden, "Account restricted", "The account is suspended or locked for review."},
+	{CodeKYCLimitExceeded, http.StatusForbidden, "Verification limit exceeded", "The amount would take what the account sent of the coin within the window, plus withdrawals still held for review, above its KYC tier's limit."},
+	{CodeEmailAlreadyRegistered, http.StatusConflict, "Email already registered", "Another account uses this email."},
+
+	{CodeWalletNotFound, http.StatusNotFound, "Wallet not found", "The wallet does not exist."},
+	{CodeWalletNotActive, http.StatusConflict, "Wallet not active", "The wallet is frozen, closing or closed and cannot move money."},
+	{CodeWalletExists, http.StatusConflict, "Wallet exists", "The user already has an open wallet in this coin."},
+	{CodeInvalidAmount, http.StatusBadRequest, "Invalid amount", "Amounts must be positive."},
+	{CodeInsufficientFunds, http.StatusBadRequest, "Insufficient funds", "The wallet's available balance, excluding held withdrawals, is below the amount."},
+	{CodeSameWallet, http.StatusBadRequest, "Same wallet", "Source and destination must be different wallets."},
+	{CodeCoinMismatch, http.StatusBadRequest, "Coin mismatch", "Both wallets must hold the same coin."},
+	{CodeSweepTargetRequired, http.StatusBadRequest, "Sweep target required", "The wallet still has a balance; name a sweep_to_wallet_id to move it to."},
+
+	{CodeAlreadyReviewed, http.StatusConflict, "Already reviewed", "The submission, adjustment or withdrawal was already decided."},
+	{CodeAdjustmentExpired, http.StatusConflict, "Adjustment expired", "The adjustment was not approved in time and has expired."},
+	{CodeSelfApproval, http.StatusForbidden, "Self-approval", "A different admin than the requester must approve the adjustment."},
+
+	{CodeIdempotencyKeyRequired, http.StatusBadRequest, "Idempotency key required", "Mutating money endpoints need an X-Idempotency-Key header."},
+	{CodeIdempotencyKeyReused, http.StatusUnprocessableEntity, "Idempotency key reused", "The key was already used for a different request; use a new key."},
+	{CodeIdempotencyKeyInProgress, http.StatusConflict, "Idempotency key in progress", "A request with this key is still running; retry shortly to get its response."},
+	{CodeIdempotencyKeyProcessed, http.StatusConflict, "Idempotency key already processed", "A concurrent request with this key committed first; retry to get its response."},
+}
+
+// Catalog lists every code
+func Catalog() []Entry {
+	return append([]Entry(nil), catalog...)
+}
+
+// Lookup returns the entry of code, or INTERNAL_ERROR's for an unknown code
+func Lookup(code Code) Entry {
+	for _, entry := range catalog {
+		if entry.Code == code {
+			return entry
+		}
+	}
+	return Lookup(CodeInternal)
+}
+
+// LookupSlug finds the entry documented under slug
+func LookupSlug(slug string) (Entry, bool) {
+	for _, entry := range catalog {
+		if entry.Code.Slug() == slug {
+			return entry, true
+		}
+	}
+	return Entry{}, false
+}
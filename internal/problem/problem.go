@@ -0,0 +1,125 @@
+// Package problem writes error responses as RFC 7807 problem details. Every
+// problem carries a stable machine-readable code from the catalog, a link to
+// the code's documentation, the request ID and, for rejected input, the
+// offending fields.
+package problem
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ContentType is the media type of problem details
+const ContentType = "application/problem+json"
+
+// RequestIDKey is the gin context key holding the ID of the current request
+const RequestIDKey = "request_id"
+
+// docsBaseURL prefixes the type URI of every problem; see SetDocsBaseURL
+var docsBaseURL = "/errors"
+
+// SetDocsBaseURL makes problem types absolute links to the error catalog
+// served under baseURL, e.g. https://wallet.example.com/errors
+func SetDocsBaseURL(baseURL string) {
+	docsBaseURL = strings.TrimRight(baseURL, "/")
+}
+
+// TypeURI is the documentation link of a code
+func TypeURI(code Code) string {
+	return docsBaseURL + "/" + code.Slug()
+}
+
+// FieldError names one rejected request field
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// Problem is an RFC 7807 problem details object. Title and Status come from
+// the code's catalog entry; Detail explains this occurrence.
+type Problem struct {
+	Type      string       `json:"type"`
+	Title     string       `json:"title"`
+	Status    int          `json:"status"`
+	Detail    string       `json:"detail,omitempty"`
+	Instance  string       `json:"instance,omitempty"`
+	Code      Code         `json:"code"`
+	RequestID string       `json:"request_id,omitempty"`
+	Errors    []FieldError `json:"errors,omitempty"`
+	// Extensions are extra members, such as otp_required, written next to
+	// the standard ones
+	Extensions map[string]interface{} `json:"-"`
+}
+
+// New builds the problem for code; Respond or Write fills in the request
+func New(code Code, detail string) *Problem {
+	entry := Lookup(code)
+	return &Problem{
+		Type:   TypeURI(entry.Code),
+		Title:  entry.Title,
+		Status: entry.Status,
+		Detail: detail,
+		Code:   entry.Code,
+	}
+}
+
+// With adds an extension member
+func (p *Problem) With(key string, value interface{}) *Problem {
+	if p.Extensions == nil {
+		p.Extensions = map[string]interface{}{}
+	}
+	p.Extensions[key] = value
+	return p
+}
+
+// Field adds a rejected field, for checks binding tags cannot express
+func (p *Problem) Field(field, rule, message string) *Problem {
+	p.Errors = append(p.Errors, FieldError{Field: field, Rule: rule, Message: message})
+	return p
+}
+
+func (p *Problem) MarshalJSON() ([]byte, error) {
+	type standard Problem
+	body, err := json.Marshal((*standard)(p))
+	if err != nil || len(p.Extensions) == 0 {
+		return body, err
+	}
+
+	members := map[string]interface{}{}
+	for key, value := range p.Extensions {
+		members[key] = value
+	}
+	// Standard members win over extensions of the same name
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return nil, err
+	}
+	for key, value := range fields {
+		members[key] = value
+	}
+	return json.Marshal(members)
+}
+
+// Respond writes the problem for code. Middleware still call c.Abort
+// themselves.
+func Respond(c *gin.Context, code Code, detail string) {
+	Write(c, New(code, detail))
+}
+
+// Write sends p, stamped with the request path and ID
+func Write(c *gin.Context, p *Problem) {
+	if c.Request != nil {
+		p.Instance = c.Request.URL.Path
+	}
+	p.RequestID = c.GetString(RequestIDKey)
+
+	body, err := json.Marshal(p)
+	if err != nil {
+		p = New(CodeInternal, "")
+		body, _ = json.Marshal(p)
+	}
+	c.Data(p.Status, ContentType, body)
+}
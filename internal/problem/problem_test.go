@@ -0,0 +1,109 @@
+package problem
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+func TestMarshalExtensions(t *testing.T) {
+	p := New(CodeOTPRequired, "One-time code required").With("otp_required", true).With("code", "OVERRIDDEN")
+
+	body, err := json.Marshal(p)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+
+	var members map[string]interface{}
+	if err := json.Unmarshal(body, &members); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if members["otp_required"] != true {
+		t.Errorf("Expected the otp_required extension, got %s", body)
+	}
+	if members["code"] != string(CodeOTPRequired) {
+		t.Errorf("Expected the standard code to win, got %v", members["code"])
+	}
+	if members["type"] != "/errors/otp-required" || members["status"] != float64(http.StatusUnauthorized) {
+		t.Errorf("Expected the catalog's type and status, got %s", body)
+	}
+}
+
+func TestFromBindingError(t *testing.T) {
+	var req struct {
+		Email    string `json:"email" binding:"required,email"`
+		Password string `json:"password" binding:"required,min=8"`
+	}
+	err := binding.JSON.BindBody([]byte(`{"email":"not-an-email","password":"short"}`), &req)
+
+	p := FromBindingError("Invalid request body", err)
+	if p.Code != CodeValidationFailed || p.Status != http.StatusBadRequest {
+		t.Fatalf("Expected VALIDATION_FAILED, got %s %d", p.Code, p.Status)
+	}
+	if len(p.Errors) != 2 {
+		t.Fatalf("Expected 2 field errors, got %+v", p.Errors)
+	}
+	if p.Errors[0].Field != "email" || p.Errors[0].Rule != "email" {
+		t.Errorf("Expected the email field by its JSON name, got %+v", p.Errors[0])
+	}
+	if p.Errors[1].Field != "password" || p.Errors[1].Message != "must be at least 8 characters long" {
+		t.Errorf("Unexpected password error %+v", p.Errors[1])
+	}
+
+	// Wrong JSON types name the field too
+	err = binding.JSON.BindBody([]byte(`{"email":1}`), &req)
+	if p := FromBindingError("Invalid request body", err); p.Code != CodeValidationFailed || len(p.Errors) != 1 || p.Errors[0].Rule != "type" {
+		t.Errorf("Expected a type field error, got %+v", p)
+	}
+
+	// Malformed JSON is not about any field
+	err = binding.JSON.BindBody([]byte(`{`), &req)
+	if p := FromBindingError("Invalid request body", err); p.Code != CodeInvalidRequest || len(p.Errors) != 0 {
+		t.Errorf("Expected INVALID_REQUEST, got %+v", p)
+	}
+}
+
+func TestWrite(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/wallets/:id", func(c *gin.Context) {
+		c.Set(RequestIDKey, "req-1")
+		Respond(c, CodeWalletNotFound, "Wallet not found")
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/wallets/42", nil))
+
+	if w.Code != http.StatusNotFound || w.Header().Get("Content-Type") != ContentType {
+		t.Fatalf("Expected a 404 problem, got %d %s", w.Code, w.Header().Get("Content-Type"))
+	}
+	body := w.Body.String()
+	for _, want := range []string{`"code":"WALLET_NOT_FOUND"`, `"instance":"/wallets/42"`, `"request_id":"req-1"`} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Expected %s in %s", want, body)
+		}
+	}
+}
+
+func TestCatalog(t *testing.T) {
+	seen := map[Code]bool{}
+	for _, entry := range Catalog() {
+		if seen[entry.Code] {
+			t.Errorf("Duplicate code %s", entry.Code)
+		}
+		seen[entry.Code] = true
+
+		if found, ok := LookupSlug(entry.Code.Slug()); !ok || found.Code != entry.Code {
+			t.Errorf("Slug %s does not find %s", entry.Code.Slug(), entry.Code)
+		}
+	}
+
+	if Lookup("NO_SUCH_CODE").Code != CodeInternal {
+		t.Error("Expected unknown codes to fall back to INTERNAL_ERROR")
+	}
+}
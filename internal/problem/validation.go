@@ -0,0 +1,130 @@
+package problem
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+func init() {
+	// Name fields the way clients send them, not by their Go names
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterTagNameFunc(fieldName)
+	}
+}
+
+func fieldName(field reflect.StructField) string {
+	for _, tag := range []string{"json", "form", "uri"} {
+		name := strings.Split(field.Tag.Get(tag), ",")[0]
+		if name == "-" {
+			return ""
+		}
+		if name != "" {
+			return name
+		}
+	}
+	return field.Name
+}
+
+// Invalid answers a request whose body or query failed to bind. Rule
+// violations become VALIDATION_FAILED with one entry per field; anything
+// else, such as malformed JSON, is INVALID_REQUEST with detail.
+func Invalid(c *gin.Context, detail string, err error) {
+	Write(c, FromBindingError(detail, err))
+}
+
+// FromBindingError builds the problem for a binding error
+func FromBindingError(detail string, err error) *Problem {
+	var validationErrors validator.ValidationErrors
+	if errors.As(err, &validationErrors) {
+		p := New(CodeValidationFailed, detail)
+		for _, fieldErr := range validationErrors {
+			p.Errors = append(p.Errors, FieldError{
+				Field:   fieldPath(fieldErr),
+				Rule:    fieldErr.Tag(),
+				Message: ruleMessage(fieldErr),
+			})
+		}
+		return p
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		p := New(CodeValidationFailed, detail)
+		p.Errors = []FieldError{{
+			Field:   typeErr.Field,
+			Rule:    "type",
+			Message: "must be " + article(typeErr.Type.Kind().String()),
+		}}
+		return p
+	}
+
+	return New(CodeInvalidRequest, detail)
+}
+
+// fieldPath drops the struct name from the field's namespace, so nested
+// fields read like their JSON path
+func fieldPath(fieldErr validator.FieldError) string {
+	namespace := fieldErr.Namespace()
+	if i := strings.Index(namespace, "."); i >= 0 {
+		return namespace[i+1:]
+	}
+	return fieldErr.Field()
+}
+
+func ruleMessage(fieldErr validator.FieldError) string {
+	param := fieldErr.Param()
+	switch fieldErr.Tag() {
+	case "required":
+		return "is required"
+	case "email":
+		return "must be a valid email address"
+	case "uuid", "uuid4":
+		return "must be a UUID"
+	case "url":
+		return "must be a URL"
+	case "oneof":
+		return "must be one of " + strings.Join(strings.Fields(param), ", ")
+	case "gt":
+		return "must be greater than " + param
+	case "gte":
+		return "must be at least " + param
+	case "lt":
+		return "must be less than " + param
+	case "lte":
+		return "must be at most " + param
+	case "min":
+		return "must be at least " + param + lengthUnit(fieldErr.Kind())
+	case "max":
+		return "must be at most " + param + lengthUnit(fieldErr.Kind())
+	case "len":
+		return "must have length " + param
+	default:
+		return fmt.Sprintf("fails the %s rule", fieldErr.Tag())
+	}
+}
+
+// lengthUnit says what min and max count for strings and lists
+func lengthUnit(kind reflect.Kind) string {
+	switch kind {
+	case reflect.String:
+		return " characters long"
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return " items long"
+	default:
+		return ""
+	}
+}
+
+func article(kind string) string {
+	if strings.ContainsAny(kind[:1], "aeiou") {
+		return "an " + kind
+	}
+	return "a " + kind
+}
@@ -0,0 +1,170 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"wallet-service/internal/models"
+
+	"github.com/google/uuid"
+)
+
+const adjustmentColumns = `id, wallet_id, direction, amount, reason, ticket_ref, status, requested_by, reviewed_by, review_note, transaction_id, created_at, expires_at, reviewed_at`
+
+type AdjustmentRepository struct {
+	db *sql.DB
+}
+
+func NewAdjustmentRepository(db *sql.DB) *AdjustmentRepository {
+	return &AdjustmentRepository{db: db}
+}
+
+func (r *AdjustmentRepository) Create(ctx context.Context, adjustment *models.BalanceAdjustment) error {
+	if adjustment.Status == "" {
+		adjustment.Status = models.AdjustmentStatusPending
+	}
+
+	query := `INSERT INTO balance_adjustments (id, wallet_id, direction, amount, reason, ticket_ref, status, requested_by, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING created_at`
+
+	err := r.db.QueryRowContext(ctx, query,
+		adjustment.ID,
+		adjustment.WalletID,
+		adjustment.Direction,
+		adjustment.Amount,
+		adjustment.Reason,
+		adjustment.TicketRef,
+		adjustment.Status,
+		adjustment.RequestedBy,
+		adjustment.ExpiresAt,
+	).Scan(&adjustment.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create adjustment: %w", err)
+	}
+
+	return nil
+}
+
+func (r *AdjustmentRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.BalanceAdjustment, error) {
+	query := `SELECT ` + adjustmentColumns + ` FROM balance_adjustments WHERE id = $1`
+
+	adjustment, err := scanAdjustment(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get adjustment: %w", err)
+	}
+
+	return adjustment, nil
+}
+
+func (r *AdjustmentRepository) List(ctx context.Context, status models.AdjustmentStatus, limit, offset int) ([]models.BalanceAdjustment, int, error) {
+	where := ` WHERE 1 = 1`
+	var args []interface{}
+	if status != "" {
+		args = append(args, status)
+		where += ` AND status = $1`
+	}
+
+	var total int
+	if err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM balance_adjustments`+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to get adjustment count: %w", err)
+	}
+
+	args = append(args, limit, offset)
+	query := `SELECT ` + adjustmentColumns + ` FROM balance_adjustments` + where + fmt.Sprintf(" ORDER BY created_at DESC LIMIT $%d OFFSET $%d", len(args)-1, len(args))
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list adjustments: %w", err)
+	}
+	defer rows.Close()
+
+	adjustments := []models.BalanceAdjustment{}
+	for rows.Next() {
+		adjustment, err := scanAdjustment(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan adjustment: %w", err)
+		}
+		adjustments = append(adjustments, *adjustment)
+	}
+
+	return adjustments, total, rows.Err()
+}
+
+func (r *AdjustmentRepository) GetOverdue(ctx context.Context, limit int) ([]models.BalanceAdjustment, error) {
+	query := `SELECT ` + adjustmentColumns + ` FROM balance_adjustments
+		WHERE status = 'PENDING' AND expires_at <= NOW()
+		ORDER BY expires_at LIMIT $1`
+
+	rows, err := r.db.QueryContext(ctx, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get overdue adjustments: %w", err)
+	}
+	defer rows.Close()
+
+	adjustments := []models.BalanceAdjustment{}
+	for rows.Next() {
+		adjustment, err := scanAdjustment(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan adjustment: %w", err)
+		}
+		adjustments = append(adjustments, *adjustment)
+	}
+
+	return adjustments, rows.Err()
+}
+
+func (r *AdjustmentRepository) GetByIDForUpdate(ctx context.Context, tx *sql.Tx, id uuid.UUID) (*models.BalanceAdjustment, error) {
+	query := `SELECT ` + adjustmentColumns + ` FROM balance_adjustments WHERE id = $1 FOR UPDATE`
+
+	adjustment, err := scanAdjustment(tx.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to lock adjustment: %w", err)
+	}
+
+	return adjustment, nil
+}
+
+func (r *AdjustmentRepository) Review(ctx context.Context, tx *sql.Tx, id uuid.UUID, status models.AdjustmentStatus, reviewerID *uuid.UUID, note string, transactionID *uuid.UUID) error {
+	query := `UPDATE balance_adjustments
+		SET status = $1, reviewed_by = $2, review_note = $3, transaction_id = $4, reviewed_at = NOW()
+		WHERE id = $5`
+
+	_, err := tx.ExecContext(ctx, query, status, reviewerID, note, transactionID, id)
+	if err != nil {
+		return fmt.Errorf("failed to review adjustment: %w", err)
+	}
+
+	return nil
+}
+
+func scanAdjustment(row rowScanner) (*models.BalanceAdjustment, error) {
+	var adjustment models.BalanceAdjustment
+	err := row.Scan(
+		&adjustment.ID,
+		&adjustment.WalletID,
+		&adjustment.Direction,
+		&adjustment.Amount,
+		&adjustment.Reason,
+		&adjustment.TicketRef,
+		&adjustment.Status,
+		&adjustment.RequestedBy,
+		&adjustment.ReviewedBy,
+		&adjustment.ReviewNote,
+		&adjustment.TransactionID,
+		&adjustment.CreatedAt,
+		&adjustment.ExpiresAt,
+		&adjustment.ReviewedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &adjustment, nil
+}
@@ -0,0 +1,191 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"wallet-service/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/shopspring/decimal"
+)
+
+// auditChainLockKey serializes appends so every record links to its predecessor
+const auditChainLockKey = 727002
+
+const auditLogColumns = `seq, id, actor_id, ip, user_agent, action, method, route, wallet_ids, amount, idempotency_key, details, status_code, outcome, created_at, prev_hash, hash`
+
+type AuditRepository struct {
+	db *sql.DB
+}
+
+func NewAuditRepository(db *sql.DB) *AuditRepository {
+	return &AuditRepository{db: db}
+}
+
+func (r *AuditRepository) LockChain(ctx context.Context, tx *sql.Tx) error {
+	_, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1)`, auditChainLockKey)
+	if err != nil {
+		return fmt.Errorf("failed to lock audit chain: %w", err)
+	}
+
+	return nil
+}
+
+func (r *AuditRepository) GetLastHash(ctx context.Context, tx *sql.Tx) (string, error) {
+	var hash string
+	err := tx.QueryRowContext(ctx, `SELECT hash FROM audit_logs ORDER BY seq DESC LIMIT 1`).Scan(&hash)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to get last audit hash: %w", err)
+	}
+
+	return hash, nil
+}
+
+func (r *AuditRepository) Append(ctx context.Context, tx *sql.Tx, entry *models.AuditLog) error {
+	query := `INSERT INTO audit_logs (id, actor_id, ip, user_agent, action, method, route, wallet_ids, amount, idempotency_key, details, status_code, outcome, created_at, prev_hash, hash)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16) RETURNING seq`
+
+	// details is stored as TEXT rather than JSONB so it reads back byte for
+	// byte and the record still hashes the same
+	var details sql.NullString
+	if len(entry.Details) > 0 {
+		details = sql.NullString{String: string(entry.Details), Valid: true}
+	}
+
+	return tx.QueryRowContext(ctx, query,
+		entry.ID,
+		entry.ActorID,
+		entry.IP,
+		entry.UserAgent,
+		entry.Action,
+		entry.Method,
+		entry.Route,
+		pq.Array(entry.WalletIDs),
+		entry.Amount,
+		entry.IdempotencyKey,
+		details,
+		entry.StatusCode,
+		entry.Outcome,
+		entry.CreatedAt,
+		entry.PrevHash,
+		entry.Hash,
+	).Scan(&entry.Sequence)
+}
+
+func (r *AuditRepository) Query(query *models.AuditLogQuery) ([]models.AuditLog, int, error) {
+	where := ` WHERE 1 = 1`
+	var args []interface{}
+
+	addFilter := func(clause string, value interface{}) {
+		args = append(args, value)
+		where += fmt.Sprintf(clause, len(args))
+	}
+
+	if query.ActorID != nil {
+		addFilter(" AND actor_id = $%d", *query.ActorID)
+	}
+	if query.Action != "" {
+		addFilter(" AND action = $%d", query.Action)
+	}
+	if query.WalletID != nil {
+		addFilter(" AND $%d = ANY(wallet_ids)", *query.WalletID)
+	}
+	if query.Outcome != nil {
+		addFilter(" AND outcome = $%d", *query.Outcome)
+	}
+	if query.From != nil {
+		addFilter(" AND created_at >= $%d", query.From.UTC())
+	}
+	if query.To != nil {
+		addFilter(" AND created_at <= $%d", query.To.UTC())
+	}
+
+	var total int
+	if err := r.db.QueryRow(`SELECT COUNT(*) FROM audit_logs`+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to get audit log count: %w", err)
+	}
+
+	args = append(args, query.Limit, query.Offset)
+	selectQuery := `SELECT ` + auditLogColumns + ` FROM audit_logs` + where + fmt.Sprintf(" ORDER BY seq DESC LIMIT $%d OFFSET $%d", len(args)-1, len(args))
+
+	rows, err := r.db.Query(selectQuery, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query audit logs: %w", err)
+	}
+	defer rows.Close()
+
+	logs, err := scanAuditLogs(rows)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return logs, total, nil
+}
+
+func (r *AuditRepository) GetChain(afterSeq int64, limit int) ([]models.AuditLog, error) {
+	rows, err := r.db.Query(`SELECT `+auditLogColumns+` FROM audit_logs WHERE seq > $1 ORDER BY seq LIMIT $2`, afterSeq, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get audit chain: %w", err)
+	}
+	defer rows.Close()
+
+	return scanAuditLogs(rows)
+}
+
+func scanAuditLogs(rows *sql.Rows) ([]models.AuditLog, error) {
+	var logs []models.AuditLog
+	for rows.Next() {
+		var entry models.AuditLog
+		var walletIDs []string
+		var amount decimal.NullDecimal
+		var details sql.NullString
+		err := rows.Scan(
+			&entry.Sequence,
+			&entry.ID,
+			&entry.ActorID,
+			&entry.IP,
+			&entry.UserAgent,
+			&entry.Action,
+			&entry.Method,
+			&entry.Route,
+			pq.Array(&walletIDs),
+			&amount,
+			&entry.IdempotencyKey,
+			&details,
+			&entry.StatusCode,
+			&entry.Outcome,
+			&entry.CreatedAt,
+			&entry.PrevHash,
+			&entry.Hash,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan audit log: %w", err)
+		}
+
+		entry.WalletIDs = make([]uuid.UUID, 0, len(walletIDs))
+		for _, raw := range walletIDs {
+			walletID, err := uuid.Parse(raw)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse audit wallet ID: %w", err)
+			}
+			entry.WalletIDs = append(entry.WalletIDs, walletID)
+		}
+		if amount.Valid {
+			entry.Amount = &amount.Decimal
+		}
+		if details.Valid {
+			entry.Details = json.RawMessage(details.String)
+		}
+
+		logs = append(logs, entry)
+	}
+
+	return logs, nil
+}
@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"wallet-service/internal/models"
+
+	"github.com/google/uuid"
+)
+
+type IdempotencyRepository struct {
+	db *sql.DB
+}
+
+func NewIdempotencyRepository(db *sql.DB) *IdempotencyRepository {
+	return &IdempotencyRepository{db: db}
+}
+
+// Create claims the key, replacing a row that has expired but was not yet
+// cleaned up: Get no longer returns such a row, so it must not block reuse
+func (r *IdempotencyRepository) Create(ctx context.Context, tx *sql.Tx, record *models.IdempotencyRecord) (bool, error) {
+	query := `INSERT INTO idempotency_keys (id, user_id, endpoint, key, fingerprint, status_code, content_type, response_body, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (user_id, endpoint, key) DO UPDATE SET
+			id = EXCLUDED.id,
+			fingerprint = EXCLUDED.fingerprint,
+			status_code = EXCLUDED.status_code,
+			content_type = EXCLUDED.content_type,
+			response_body = EXCLUDED.response_body,
+			created_at = NOW(),
+			expires_at = EXCLUDED.expires_at
+		WHERE idempotency_keys.expires_at <= NOW()
+		RETURNING created_at`
+
+	err := tx.QueryRowContext(ctx, query,
+		record.ID,
+		record.UserID,
+		record.Endpoint,
+		record.Key,
+		record.Fingerprint,
+		record.StatusCode,
+		record.ContentType,
+		record.ResponseBody,
+		record.ExpiresAt,
+	).Scan(&record.CreatedAt)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to create idempotency key: %w", err)
+	}
+
+	return true, nil
+}
+
+func (r *IdempotencyRepository) Get(ctx context.Context, userID uuid.UUID, endpoint, key string) (*models.IdempotencyRecord, error) {
+	query := `SELECT id, user_id, endpoint, key, fingerprint, status_code, content_type, response_body, created_at, expires_at
+		FROM idempotency_keys WHERE user_id = $1 AND endpoint = $2 AND key = $3 AND expires_at > NOW()`
+
+	var record models.IdempotencyRecord
+	err := r.db.QueryRowContext(ctx, query, userID, endpoint, key).Scan(
+		&record.ID,
+		&record.UserID,
+		&record.Endpoint,
+		&record.Key,
+		&record.Fingerprint,
+		&record.StatusCode,
+		&record.ContentType,
+		&record.ResponseBody,
+		&record.CreatedAt,
+		&record.ExpiresAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get idempotency key: %w", err)
+	}
+
+	return &record, nil
+}
+
+func (r *IdempotencyRepository) DeleteExpired(ctx context.Context, limit int) (int64, error) {
+	query := `DELETE FROM idempotency_keys WHERE id IN (SELECT id FROM idempotency_keys WHERE expires_at <= NOW() LIMIT $1)`
+
+	result, err := r.db.ExecContext(ctx, query, limit)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired idempotency keys: %w", err)
+	}
+
+	return result.RowsAffected()
+}
@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"time"
 
 	"wallet-service/internal/models"
 
@@ -14,20 +15,39 @@ import (
 type IUserRepository interface {
 	GetByEmail(email string) (*models.User, error)
 	GetByID(id uuid.UUID) (*models.User, error)
-	Create(user *models.User) error
 	GetAll() ([]models.User, error)
+
+	// Transaction methods - 接受事务上下文
+	// Create reports false, without error, when the email is already taken
+	Create(ctx context.Context, tx *sql.Tx, user *models.User) (bool, error)
+	UpdatePassword(ctx context.Context, tx *sql.Tx, userID uuid.UUID, passwordHash string) error
+	UpdateRole(ctx context.Context, tx *sql.Tx, userID uuid.UUID, role models.Role) error
+	UpdateStatus(ctx context.Context, tx *sql.Tx, userID uuid.UUID, status models.UserStatus, reason models.StatusReason) error
+	UpdateKYCTier(ctx context.Context, tx *sql.Tx, userID uuid.UUID, tier models.KYCTier) error
 }
 
 // IWalletRepository defines the interface for wallet data operations
 type IWalletRepository interface {
 	GetByID(id uuid.UUID) (*models.Wallet, error)
 	GetByUserID(userID uuid.UUID) ([]models.Wallet, error)
-	Create(wallet *models.Wallet) error
+	// GetByUserIDAndCoinType returns the user's open wallet in the coin
 	GetByUserIDAndCoinType(userID uuid.UUID, coinType models.CoinType) (*models.Wallet, error)
 
 	// Transaction methods - 接受事务上下文
+	// Create reports false, without error, when the user already has an open
+	// wallet in the coin
+	Create(ctx context.Context, tx *sql.Tx, wallet *models.Wallet) (bool, error)
+	// GetByIDForUpdate locks the wallet row until the transaction ends
+	GetByIDForUpdate(ctx context.Context, tx *sql.Tx, id uuid.UUID) (*models.Wallet, error)
 	UpdateAmount(ctx context.Context, tx *sql.Tx, walletID uuid.UUID, amount decimal.Decimal) error
 	UpdateFrozenAmount(ctx context.Context, tx *sql.Tx, walletID uuid.UUID, frozenAmount decimal.Decimal) error
+	UpdateStatus(ctx context.Context, tx *sql.Tx, walletID uuid.UUID, status models.WalletStatus) error
+}
+
+// ITransactionManager runs a function inside one database transaction; see
+// TransactionManager
+type ITransactionManager interface {
+	ExecuteTransaction(ctx context.Context, fn TransactionFunc) error
 }
 
 // ITransactionRepository defines the interface for transaction data operations
@@ -39,4 +59,198 @@ type ITransactionRepository interface {
 	// Transaction methods - 接受事务上下文
 	CreateTransaction(ctx context.Context, tx *sql.Tx, transaction *models.Transaction) error
 	CreateTransactionEntry(ctx context.Context, tx *sql.Tx, entry *models.TransactionEntry) error
+	UpdateStatus(ctx context.Context, tx *sql.Tx, id uuid.UUID, status models.TransactionStatus) error
+	// SumOutflows totals the completed withdrawals and outgoing transfers of
+	// walletIDs booked within the last window
+	SumOutflows(ctx context.Context, tx *sql.Tx, walletIDs []uuid.UUID, window time.Duration) (decimal.Decimal, error)
+}
+
+// IPriceRepository defines the interface for historical price data operations
+type IPriceRepository interface {
+	Create(ctx context.Context, price *models.Price) error
+	GetLatest(coinType models.CoinType, currency string) (*models.Price, error)
+	GetHistory(coinType models.CoinType, currency string, since time.Time) ([]models.Price, error)
+}
+
+// IOutboxRepository defines the interface for transactional outbox operations
+type IOutboxRepository interface {
+	// GetRange returns events with fromSeq <= seq <= toSeq, optionally limited
+	// to the given aggregates
+	GetRange(fromSeq, toSeq int64, aggregateIDs []uuid.UUID, limit int) ([]models.OutboxEvent, error)
+	// GetAfter returns events with a publish sequence above afterPublishSeq in
+	// publish order, optionally limited to the given aggregates
+	GetAfter(afterPublishSeq int64, aggregateIDs []uuid.UUID, limit int) ([]models.OutboxEvent, error)
+
+	// Transaction methods - 接受事务上下文
+	Create(ctx context.Context, tx *sql.Tx, event *models.OutboxEvent) error
+	LockRelay(ctx context.Context, tx *sql.Tx) (bool, error)
+	AssignPublishSequence(ctx context.Context, tx *sql.Tx, limit int) (int, error)
+	LockCursor(ctx context.Context, tx *sql.Tx, consumer string) (int64, bool, error)
+	SaveCursor(ctx context.Context, tx *sql.Tx, consumer string, publishSeq int64) error
+}
+
+// IWebhookRepository defines the interface for webhook endpoint and delivery operations
+type IWebhookRepository interface {
+	CreateEndpoint(endpoint *models.WebhookEndpoint) error
+	GetEndpointByID(id uuid.UUID) (*models.WebhookEndpoint, error)
+	GetEndpointsByUserID(userID uuid.UUID) ([]models.WebhookEndpoint, error)
+	DeleteEndpoint(id uuid.UUID) error
+
+	// CreateDelivery ignores a delivery whose endpoint already has the event queued
+	CreateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error
+	// ClaimDueDeliveries leases due PENDING deliveries to the caller by pushing
+	// their next attempt out by lease, so other instances skip them meanwhile
+	ClaimDueDeliveries(ctx context.Context, limit int, lease time.Duration) ([]models.WebhookDelivery, error)
+	UpdateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error
+	GetDeliveryByID(id uuid.UUID) (*models.WebhookDelivery, error)
+	GetDeliveriesByEndpointID(endpointID uuid.UUID, limit, offset int) ([]models.WebhookDelivery, int, error)
+}
+
+// IAuditRepository defines the interface for append-only audit log operations
+type IAuditRepository interface {
+	Query(query *models.AuditLogQuery) ([]models.AuditLog, int, error)
+	// GetChain returns records in sequence order starting after afterSeq
+	GetChain(afterSeq int64, limit int) ([]models.AuditLog, error)
+
+	// Transaction methods - 接受事务上下文
+	LockChain(ctx context.Context, tx *sql.Tx) error
+	GetLastHash(ctx context.Context, tx *sql.Tx) (string, error)
+	Append(ctx context.Context, tx *sql.Tx, entry *models.AuditLog) error
+}
+
+// ISigningKeyRepository defines the interface for access token signing keys
+type ISigningKeyRepository interface {
+	// List returns keys in activation order
+	List(ctx context.Context) ([]models.SigningKey, error)
+
+	// Transaction methods - 接受事务上下文
+	Lock(ctx context.Context, tx *sql.Tx) error
+	ListForRotation(ctx context.Context, tx *sql.Tx) ([]models.SigningKey, error)
+	Create(ctx context.Context, tx *sql.Tx, key *models.SigningKey) error
+	Delete(ctx context.Context, tx *sql.Tx, kids []string) error
+}
+
+// IIdempotencyRepository defines the interface for stored idempotent responses
+type IIdempotencyRepository interface {
+	Get(ctx context.Context, userID uuid.UUID, endpoint, key string) (*models.IdempotencyRecord, error)
+	DeleteExpired(ctx context.Context, limit int) (int64, error)
+
+	// Transaction methods - 接受事务上下文
+	// Create reports false when the user already used the key on the endpoint
+	// and the stored response has not expired; an expired one is replaced.
+	// A concurrent transaction holding the same key blocks it until that
+	// transaction ends.
+	Create(ctx context.Context, tx *sql.Tx, record *models.IdempotencyRecord) (bool, error)
+}
+
+// IRegistrationRepository defines the interface for sign-ups awaiting email
+// verification
+type IRegistrationRepository interface {
+	Create(ctx context.Context, registration *models.Registration) error
+
+	// Transaction methods - 接受事务上下文
+	// ConsumeByToken marks an unused, unexpired registration as used and
+	// returns it, or nil when no such registration exists
+	ConsumeByToken(ctx context.Context, tx *sql.Tx, tokenHash string) (*models.Registration, error)
+	GetPendingByEmailForUpdate(ctx context.Context, tx *sql.Tx, email string) ([]models.Registration, error)
+	// RecordFailedAttempt counts a wrong code, using up registrations that
+	// reach maxAttempts
+	RecordFailedAttempt(ctx context.Context, tx *sql.Tx, ids []uuid.UUID, maxAttempts int) error
+	InvalidateForEmail(ctx context.Context, tx *sql.Tx, email string) error
+}
+
+// IKYCRepository defines the interface for KYC document submissions
+type IKYCRepository interface {
+	Create(ctx context.Context, submission *models.KYCSubmission) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.KYCSubmission, error)
+	// GetByUserID returns the user's submissions, newest first
+	GetByUserID(ctx context.Context, userID uuid.UUID) ([]models.KYCSubmission, error)
+	// List returns submissions oldest first, all of them when status is empty
+	List(ctx context.Context, status models.KYCSubmissionStatus, limit, offset int) ([]models.KYCSubmission, int, error)
+
+	// Transaction methods - 接受事务上下文
+	GetByIDForUpdate(ctx context.Context, tx *sql.Tx, id uuid.UUID) (*models.KYCSubmission, error)
+	Review(ctx context.Context, tx *sql.Tx, id uuid.UUID, status models.KYCSubmissionStatus, reviewerID *uuid.UUID, note string) error
+}
+
+// IAdjustmentRepository defines the interface for maker-checker balance
+// adjustments
+type IAdjustmentRepository interface {
+	Create(ctx context.Context, adjustment *models.BalanceAdjustment) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.BalanceAdjustment, error)
+	// List returns adjustments newest first, all of them when status is empty
+	List(ctx context.Context, status models.AdjustmentStatus, limit, offset int) ([]models.BalanceAdjustment, int, error)
+	// GetOverdue returns up to limit pending adjustments past their expiry,
+	// longest overdue first
+	GetOverdue(ctx context.Context, limit int) ([]models.BalanceAdjustment, error)
+
+	// Transaction methods - 接受事务上下文
+	GetByIDForUpdate(ctx context.Context, tx *sql.Tx, id uuid.UUID) (*models.BalanceAdjustment, error)
+	Review(ctx context.Context, tx *sql.Tx, id uuid.UUID, status models.AdjustmentStatus, reviewerID *uuid.UUID, note string, transactionID *uuid.UUID) error
+}
+
+// IWithdrawalReviewRepository defines the interface for withdrawals held for
+// operator review
+type IWithdrawalReviewRepository interface {
+	GetByTransactionID(ctx context.Context, transactionID uuid.UUID) (*models.WithdrawalReview, error)
+	// List returns withdrawals oldest first, all of them when status is
+	// empty, only userID's when it is set, and only those of walletIDs when
+	// any are given
+	List(ctx context.Context, status models.WithdrawalReviewStatus, userID *uuid.UUID, walletIDs []uuid.UUID, limit, offset int) ([]models.WithdrawalReview, int, error)
+
+	// Transaction methods - 接受事务上下文
+	Create(ctx context.Context, tx *sql.Tx, review *models.WithdrawalReview) error
+	GetByTransactionIDForUpdate(ctx context.Context, tx *sql.Tx, transactionID uuid.UUID) (*models.WithdrawalReview, error)
+	Decide(ctx context.Context, tx *sql.Tx, transactionID uuid.UUID, status models.WithdrawalReviewStatus, reviewerID *uuid.UUID, note string) error
+	// SumPending totals the withdrawals of walletIDs still held for review
+	SumPending(ctx context.Context, tx *sql.Tx, walletIDs []uuid.UUID) (decimal.Decimal, error)
+}
+
+// IPasswordResetRepository defines the interface for password reset token operations
+type IPasswordResetRepository interface {
+	Create(ctx context.Context, token *models.PasswordResetToken) error
+
+	// Transaction methods - 接受事务上下文
+	// Consume marks an unused, unexpired token as used and returns it, or nil
+	// when no such token exists, so each token works exactly once
+	Consume(ctx context.Context, tx *sql.Tx, tokenHash string) (*models.PasswordResetToken, error)
+	InvalidateForUser(ctx context.Context, tx *sql.Tx, userID uuid.UUID) error
+}
+
+// IRefreshTokenRepository defines the interface for rotating refresh token operations
+type IRefreshTokenRepository interface {
+	// Transaction methods - 接受事务上下文
+	Create(ctx context.Context, tx *sql.Tx, token *models.RefreshToken) error
+	// GetByHashForUpdate locks the token row so concurrent refreshes with the
+	// same token serialise and the second one sees it as used
+	GetByHashForUpdate(ctx context.Context, tx *sql.Tx, tokenHash string) (*models.RefreshToken, error)
+	MarkUsed(ctx context.Context, tx *sql.Tx, id uuid.UUID) error
+	RevokeFamily(ctx context.Context, tx *sql.Tx, familyID uuid.UUID) error
+	RevokeForUser(ctx context.Context, tx *sql.Tx, userID uuid.UUID) error
+}
+
+// ITwoFactorRepository defines the interface for TOTP credential and recovery code operations
+type ITwoFactorRepository interface {
+	Get(ctx context.Context, userID uuid.UUID) (*models.TOTPCredential, error)
+	// SavePending stores a new secret awaiting confirmation; it never replaces
+	// an enabled credential
+	SavePending(ctx context.Context, credential *models.TOTPCredential) error
+	// UseStep records step as the last accepted code, reporting false when a
+	// code at or after it was already used
+	UseStep(ctx context.Context, userID uuid.UUID, step int64) (bool, error)
+	UseRecoveryCode(ctx context.Context, userID uuid.UUID, codeHash string) (bool, error)
+
+	// Transaction methods - 接受事务上下文
+	Enable(ctx context.Context, tx *sql.Tx, userID uuid.UUID, step int64) error
+	Delete(ctx context.Context, tx *sql.Tx, userID uuid.UUID) error
+	ReplaceRecoveryCodes(ctx context.Context, tx *sql.Tx, userID uuid.UUID, codeHashes []string) error
+}
+
+// IAPIKeyRepository defines the interface for API key operations
+type IAPIKeyRepository interface {
+	Create(ctx context.Context, key *models.APIKey) error
+	GetByHash(ctx context.Context, keyHash string) (*models.APIKey, error)
+	GetByUserID(ctx context.Context, userID uuid.UUID) ([]models.APIKey, error)
+	Revoke(ctx context.Context, userID, id uuid.UUID) (bool, error)
+	TouchLastUsed(ctx context.Context, id uuid.UUID) error
 }
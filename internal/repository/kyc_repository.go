@@ -0,0 +1,170 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"wallet-service/internal/models"
+
+	"github.com/google/uuid"
+)
+
+const kycSubmissionColumns = `id, user_id, requested_tier, document_type, file_name, content_type, size_bytes, sha256, storage_path, provider, status, reviewer_id, review_note, created_at, reviewed_at`
+
+type KYCRepository struct {
+	db *sql.DB
+}
+
+func NewKYCRepository(db *sql.DB) *KYCRepository {
+	return &KYCRepository{db: db}
+}
+
+func (r *KYCRepository) Create(ctx context.Context, submission *models.KYCSubmission) error {
+	if submission.Status == "" {
+		submission.Status = models.KYCSubmissionPending
+	}
+
+	query := `INSERT INTO kyc_submissions (id, user_id, requested_tier, document_type, file_name, content_type, size_bytes, sha256, storage_path, provider, status)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11) RETURNING created_at`
+
+	err := r.db.QueryRowContext(ctx, query,
+		submission.ID,
+		submission.UserID,
+		submission.RequestedTier,
+		submission.DocumentType,
+		submission.FileName,
+		submission.ContentType,
+		submission.SizeBytes,
+		submission.SHA256,
+		submission.StoragePath,
+		submission.Provider,
+		submission.Status,
+	).Scan(&submission.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create KYC submission: %w", err)
+	}
+
+	return nil
+}
+
+func (r *KYCRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.KYCSubmission, error) {
+	query := `SELECT ` + kycSubmissionColumns + ` FROM kyc_submissions WHERE id = $1`
+
+	submission, err := scanKYCSubmission(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get KYC submission: %w", err)
+	}
+
+	return submission, nil
+}
+
+func (r *KYCRepository) GetByUserID(ctx context.Context, userID uuid.UUID) ([]models.KYCSubmission, error) {
+	query := `SELECT ` + kycSubmissionColumns + ` FROM kyc_submissions WHERE user_id = $1 ORDER BY created_at DESC`
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get KYC submissions: %w", err)
+	}
+	defer rows.Close()
+
+	return scanKYCSubmissions(rows)
+}
+
+func (r *KYCRepository) List(ctx context.Context, status models.KYCSubmissionStatus, limit, offset int) ([]models.KYCSubmission, int, error) {
+	where := ` WHERE 1 = 1`
+	var args []interface{}
+	if status != "" {
+		args = append(args, status)
+		where += ` AND status = $1`
+	}
+
+	var total int
+	if err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM kyc_submissions`+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to get KYC submission count: %w", err)
+	}
+
+	// Oldest first, so the review queue is worked in order
+	args = append(args, limit, offset)
+	query := `SELECT ` + kycSubmissionColumns + ` FROM kyc_submissions` + where + fmt.Sprintf(" ORDER BY created_at LIMIT $%d OFFSET $%d", len(args)-1, len(args))
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list KYC submissions: %w", err)
+	}
+	defer rows.Close()
+
+	submissions, err := scanKYCSubmissions(rows)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return submissions, total, nil
+}
+
+func (r *KYCRepository) GetByIDForUpdate(ctx context.Context, tx *sql.Tx, id uuid.UUID) (*models.KYCSubmission, error) {
+	query := `SELECT ` + kycSubmissionColumns + ` FROM kyc_submissions WHERE id = $1 FOR UPDATE`
+
+	submission, err := scanKYCSubmission(tx.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to lock KYC submission: %w", err)
+	}
+
+	return submission, nil
+}
+
+func (r *KYCRepository) Review(ctx context.Context, tx *sql.Tx, id uuid.UUID, status models.KYCSubmissionStatus, reviewerID *uuid.UUID, note string) error {
+	query := `UPDATE kyc_submissions SET status = $1, reviewer_id = $2, review_note = $3, reviewed_at = NOW() WHERE id = $4`
+
+	_, err := tx.ExecContext(ctx, query, status, reviewerID, note, id)
+	if err != nil {
+		return fmt.Errorf("failed to review KYC submission: %w", err)
+	}
+
+	return nil
+}
+
+func scanKYCSubmissions(rows *sql.Rows) ([]models.KYCSubmission, error) {
+	submissions := []models.KYCSubmission{}
+	for rows.Next() {
+		submission, err := scanKYCSubmission(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan KYC submission: %w", err)
+		}
+		submissions = append(submissions, *submission)
+	}
+
+	return submissions, rows.Err()
+}
+
+func scanKYCSubmission(row rowScanner) (*models.KYCSubmission, error) {
+	var submission models.KYCSubmission
+	err := row.Scan(
+		&submission.ID,
+		&submission.UserID,
+		&submission.RequestedTier,
+		&submission.DocumentType,
+		&submission.FileName,
+		&submission.ContentType,
+		&submission.SizeBytes,
+		&submission.SHA256,
+		&submission.StoragePath,
+		&submission.Provider,
+		&submission.Status,
+		&submission.ReviewerID,
+		&submission.ReviewNote,
+		&submission.CreatedAt,
+		&submission.ReviewedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &submission, nil
+}
@@ -3,6 +3,9 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"fmt"
+	"sort"
+	"time"
 
 	"wallet-service/internal/models"
 
@@ -37,9 +40,18 @@ func (m *MockUserRepository) GetByID(id uuid.UUID) (*models.User, error) {
 	return nil, nil
 }
 
-func (m *MockUserRepository) Create(user *models.User) error {
+func (m *MockUserRepository) Create(ctx context.Context, tx *sql.Tx, user *models.User) (bool, error) {
+	if _, exists := m.users[user.Email]; exists {
+		return false, nil
+	}
+	if user.Status == "" {
+		user.Status = models.UserStatusActive
+	}
+	if user.KYCTier == "" {
+		user.KYCTier = models.KYCTierUnverified
+	}
 	m.users[user.Email] = user
-	return nil
+	return true, nil
 }
 
 func (m *MockUserRepository) GetAll() ([]models.User, error) {
@@ -50,6 +62,49 @@ func (m *MockUserRepository) GetAll() ([]models.User, error) {
 	return users, nil
 }
 
+func (m *MockUserRepository) UpdatePassword(ctx context.Context, tx *sql.Tx, userID uuid.UUID, passwordHash string) error {
+	for _, user := range m.users {
+		if user.ID == userID {
+			user.PasswordHash = passwordHash
+			return nil
+		}
+	}
+	return fmt.Errorf("user not found")
+}
+
+func (m *MockUserRepository) UpdateRole(ctx context.Context, tx *sql.Tx, userID uuid.UUID, role models.Role) error {
+	for _, user := range m.users {
+		if user.ID == userID {
+			user.Role = role
+			return nil
+		}
+	}
+	return fmt.Errorf("user not found")
+}
+
+func (m *MockUserRepository) UpdateStatus(ctx context.Context, tx *sql.Tx, userID uuid.UUID, status models.UserStatus, reason models.StatusReason) error {
+	for _, user := range m.users {
+		if user.ID == userID {
+			now := time.Now()
+			user.Status = status
+			user.StatusReason = reason
+			user.StatusChangedAt = &now
+			return nil
+		}
+	}
+	return fmt.Errorf("user not found")
+}
+
+func (m *MockUserRepository) UpdateKYCTier(ctx context.Context, tx *sql.Tx, userID uuid.UUID, tier models.KYCTier) error {
+	for _, user := range m.users {
+		if user.ID == userID {
+			user.KYCTier = tier
+			return nil
+		}
+	}
+	return fmt.Errorf("user not found")
+}
+
 // MockWalletRepository implements IWalletRepository for testing
 type MockWalletRepository struct {
 	wallets map[uuid.UUID]*models.Wallet
@@ -79,9 +134,19 @@ func (m *MockWalletRepository) GetByUserID(userID uuid.UUID) ([]models.Wallet, e
 	return wallets, nil
 }
 
-func (m *MockWalletRepository) Create(wallet *models.Wallet) error {
+func (m *MockWalletRepository) Create(ctx context.Context, tx *sql.Tx, wallet *models.Wallet) (bool, error) {
+	if existing, _ := m.GetByUserIDAndCoinType(wallet.UserID, wallet.CoinType); existing != nil {
+		return false, nil
+	}
+	if wallet.Status == "" {
+		wallet.Status = models.WalletStatusActive
+	}
 	m.wallets[wallet.ID] = wallet
-	return nil
+	return true, nil
+}
+
+func (m *MockWalletRepository) GetByIDForUpdate(ctx context.Context, tx *sql.Tx, id uuid.UUID) (*models.Wallet, error) {
+	return m.GetByID(id)
 }
 
 func (m *MockWalletRepository) UpdateAmount(ctx context.Context, tx *sql.Tx, walletID uuid.UUID, amount decimal.Decimal) error {
@@ -98,9 +163,22 @@ func (m *MockWalletRepository) UpdateFrozenAmount(ctx context.Context, tx *sql.T
 	return nil
 }
 
+func (m *MockWalletRepository) UpdateStatus(ctx context.Context, tx *sql.Tx, walletID uuid.UUID, status models.WalletStatus) error {
+	if wallet, exists := m.wallets[walletID]; exists {
+		wallet.Status = status
+		if status == models.WalletStatusClosed {
+			now := time.Now()
+			wallet.ClosedAt = &now
+		} else {
+			wallet.ClosedAt = nil
+		}
+	}
+	return nil
+}
+
 func (m *MockWalletRepository) GetByUserIDAndCoinType(userID uuid.UUID, coinType models.CoinType) (*models.Wallet, error) {
 	for _, wallet := range m.wallets {
-		if wallet.UserID == userID && wallet.CoinType == coinType {
+		if wallet.UserID == userID && wallet.CoinType == coinType && wallet.Status != models.WalletStatusClosed {
 			return wallet, nil
 		}
 	}
@@ -130,10 +208,37 @@ func (m *MockTransactionRepository) CreateTransaction(ctx context.Context, tx *s
 }
 
 func (m *MockTransactionRepository) CreateTransactionEntry(ctx context.Context, tx *sql.Tx, entry *models.TransactionEntry) error {
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now()
+	}
 	m.entries[entry.ID] = entry
 	return nil
 }
 
+func (m *MockTransactionRepository) SumOutflows(ctx context.Context, tx *sql.Tx, walletIDs []uuid.UUID, window time.Duration) (decimal.Decimal, error) {
+	total := decimal.Zero
+	for _, entry := range m.entries {
+		transaction := m.transactions[entry.TxnID]
+		if entry.Direction != models.DirectionOut || !containsUUID(walletIDs, entry.WalletID) || transaction == nil ||
+			transaction.Status != models.TransactionStatusDone || entry.CreatedAt.Before(time.Now().Add(-window)) {
+			continue
+		}
+		if transaction.Type == models.TransactionTypeWithdrawal || transaction.Type == models.TransactionTypeTransfer {
+			total = total.Add(entry.Amount)
+		}
+	}
+	return total, nil
+}
+
+func (m *MockTransactionRepository) UpdateStatus(ctx context.Context, tx *sql.Tx, id uuid.UUID, status models.TransactionStatus) error {
+	transaction, exists := m.transactions[id]
+	if !exists {
+		return fmt.Errorf("transaction not found")
+	}
+	transaction.Status = status
+	return nil
+}
+
 func (m *MockTransactionRepository) GetTransactionHistory(walletID uuid.UUID, req *models.TransactionHistoryRequest) (*models.TransactionHistoryResponse, error) {
 	var entries []models.TransactionEntry
 	for _, entry := range m.entries {
@@ -163,3 +268,903 @@ func (m *MockTransactionRepository) GetTransactionEntriesByTxnID(txnID uuid.UUID
 	}
 	return entries, nil
 }
+
+// MockPriceRepository implements IPriceRepository for testing
+type MockPriceRepository struct {
+	prices []models.Price
+}
+
+func NewMockPriceRepository() *MockPriceRepository {
+	return &MockPriceRepository{}
+}
+
+func (m *MockPriceRepository) Create(ctx context.Context, price *models.Price) error {
+	price.CreatedAt = time.Now()
+	m.prices = append(m.prices, *price)
+	return nil
+}
+
+func (m *MockPriceRepository) GetLatest(coinType models.CoinType, currency string) (*models.Price, error) {
+	var latest *models.Price
+	for i := range m.prices {
+		price := &m.prices[i]
+		if price.CoinType == coinType && price.Currency == currency && (latest == nil || price.AsOf.After(latest.AsOf)) {
+			latest = price
+		}
+	}
+	return latest, nil
+}
+
+func (m *MockPriceRepository) GetHistory(coinType models.CoinType, currency string, since time.Time) ([]models.Price, error) {
+	var prices []models.Price
+	for _, price := range m.prices {
+		if price.CoinType == coinType && price.Currency == currency && !price.AsOf.Before(since) {
+			prices = append(prices, price)
+		}
+	}
+	return prices, nil
+}
+
+// MockOutboxRepository implements IOutboxRepository for testing
+type MockOutboxRepository struct {
+	events     []models.OutboxEvent
+	publishSeq int64
+	cursors    map[string]int64
+}
+
+func NewMockOutboxRepository() *MockOutboxRepository {
+	return &MockOutboxRepository{cursors: make(map[string]int64)}
+}
+
+func (m *MockOutboxRepository) Create(ctx context.Context, tx *sql.Tx, event *models.OutboxEvent) error {
+	event.Sequence = int64(len(m.events) + 1)
+	event.CreatedAt = time.Now()
+	m.events = append(m.events, *event)
+	return nil
+}
+
+func (m *MockOutboxRepository) LockRelay(ctx context.Context, tx *sql.Tx) (bool, error) {
+	return true, nil
+}
+
+// AssignPublishSequence numbers events in seq order; every mock event counts
+// as committed
+func (m *MockOutboxRepository) AssignPublishSequence(ctx context.Context, tx *sql.Tx, limit int) (int, error) {
+	assigned := 0
+	for i := range m.events {
+		if m.events[i].PublishSequence == 0 && assigned < limit {
+			m.publishSeq++
+			m.events[i].PublishSequence = m.publishSeq
+			assigned++
+		}
+	}
+	return assigned, nil
+}
+
+func (m *MockOutboxRepository) LockCursor(ctx context.Context, tx *sql.Tx, consumer string) (int64, bool, error) {
+	return m.cursors[consumer], true, nil
+}
+
+func (m *MockOutboxRepository) SaveCursor(ctx context.Context, tx *sql.Tx, consumer string, publishSeq int64) error {
+	m.cursors[consumer] = publishSeq
+	return nil
+}
+
+// Cursor returns how far the named consumer has published
+func (m *MockOutboxRepository) Cursor(consumer string) int64 {
+	return m.cursors[consumer]
+}
+
+func (m *MockOutboxRepository) GetRange(fromSeq, toSeq int64, aggregateIDs []uuid.UUID, limit int) ([]models.OutboxEvent, error) {
+	var events []models.OutboxEvent
+	for _, event := range m.events {
+		if event.Sequence < fromSeq || event.Sequence > toSeq || len(events) >= limit {
+			continue
+		}
+		if aggregateIDs != nil && !containsUUID(aggregateIDs, event.AggregateID) {
+			continue
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+func (m *MockOutboxRepository) GetAfter(afterPublishSeq int64, aggregateIDs []uuid.UUID, limit int) ([]models.OutboxEvent, error) {
+	var events []models.OutboxEvent
+	for _, event := range m.published() {
+		if event.PublishSequence <= afterPublishSeq || len(events) >= limit {
+			continue
+		}
+		if aggregateIDs != nil && !containsUUID(aggregateIDs, event.AggregateID) {
+			continue
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+// published returns the events that have a publish sequence, in that order
+func (m *MockOutboxRepository) published() []models.OutboxEvent {
+	events := make([]models.OutboxEvent, 0, len(m.events))
+	for _, event := range m.events {
+		if event.PublishSequence != 0 {
+			events = append(events, event)
+		}
+	}
+	sort.Slice(events, func(i, j int) bool { return events[i].PublishSequence < events[j].PublishSequence })
+	return events
+}
+
+func containsUUID(ids []uuid.UUID, id uuid.UUID) bool {
+	for _, candidate := range ids {
+		if candidate == id {
+			return true
+		}
+	}
+	return false
+}
+
+// MockWebhookRepository implements IWebhookRepository for testing
+type MockWebhookRepository struct {
+	endpoints  map[uuid.UUID]*models.WebhookEndpoint
+	deliveries map[uuid.UUID]*models.WebhookDelivery
+}
+
+func NewMockWebhookRepository() *MockWebhookRepository {
+	return &MockWebhookRepository{
+		endpoints:  make(map[uuid.UUID]*models.WebhookEndpoint),
+		deliveries: make(map[uuid.UUID]*models.WebhookDelivery),
+	}
+}
+
+func (m *MockWebhookRepository) CreateEndpoint(endpoint *models.WebhookEndpoint) error {
+	endpoint.CreatedAt = time.Now()
+	m.endpoints[endpoint.ID] = endpoint
+	return nil
+}
+
+func (m *MockWebhookRepository) GetEndpointByID(id uuid.UUID) (*models.WebhookEndpoint, error) {
+	if endpoint, exists := m.endpoints[id]; exists {
+		return endpoint, nil
+	}
+	return nil, nil
+}
+
+func (m *MockWebhookRepository) GetEndpointsByUserID(userID uuid.UUID) ([]models.WebhookEndpoint, error) {
+	var endpoints []models.WebhookEndpoint
+	for _, endpoint := range m.endpoints {
+		if endpoint.UserID == userID {
+			endpoints = append(endpoints, *endpoint)
+		}
+	}
+	return endpoints, nil
+}
+
+func (m *MockWebhookRepository) DeleteEndpoint(id uuid.UUID) error {
+	delete(m.endpoints, id)
+	return nil
+}
+
+func (m *MockWebhookRepository) CreateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	for _, existing := range m.deliveries {
+		if existing.EndpointID == delivery.EndpointID && existing.EventID == delivery.EventID {
+			return nil
+		}
+	}
+	now := time.Now()
+	delivery.CreatedAt = now
+	if delivery.NextAttemptAt == nil {
+		delivery.NextAttemptAt = &now
+	}
+	m.deliveries[delivery.ID] = delivery
+	return nil
+}
+
+func (m *MockWebhookRepository) ClaimDueDeliveries(ctx context.Context, limit int, lease time.Duration) ([]models.WebhookDelivery, error) {
+	now := time.Now()
+	leasedUntil := now.Add(lease)
+	var deliveries []models.WebhookDelivery
+	for _, delivery := range m.deliveries {
+		if len(deliveries) >= limit || delivery.Status != models.WebhookDeliveryStatusPending {
+			continue
+		}
+		if delivery.NextAttemptAt != nil && delivery.NextAttemptAt.After(now) {
+			continue
+		}
+		delivery.NextAttemptAt = &leasedUntil
+		deliveries = append(deliveries, *delivery)
+	}
+	return deliveries, nil
+}
+
+func (m *MockWebhookRepository) UpdateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	updated := *delivery
+	m.deliveries[delivery.ID] = &updated
+	return nil
+}
+
+func (m *MockWebhookRepository) GetDeliveryByID(id uuid.UUID) (*models.WebhookDelivery, error) {
+	if delivery, exists := m.deliveries[id]; exists {
+		return delivery, nil
+	}
+	return nil, nil
+}
+
+func (m *MockWebhookRepository) GetDeliveriesByEndpointID(endpointID uuid.UUID, limit, offset int) ([]models.WebhookDelivery, int, error) {
+	var deliveries []models.WebhookDelivery
+	for _, delivery := range m.deliveries {
+		if delivery.EndpointID == endpointID {
+			deliveries = append(deliveries, *delivery)
+		}
+	}
+	total := len(deliveries)
+	if offset >= total {
+		return nil, total, nil
+	}
+	end := offset + limit
+	if end > total {
+		end = total
+	}
+	return deliveries[offset:end], total, nil
+}
+
+// MockAuditRepository implements IAuditRepository for testing
+type MockAuditRepository struct {
+	logs []models.AuditLog
+}
+
+func NewMockAuditRepository() *MockAuditRepository {
+	return &MockAuditRepository{}
+}
+
+func (m *MockAuditRepository) LockChain(ctx context.Context, tx *sql.Tx) error {
+	return nil
+}
+
+func (m *MockAuditRepository) GetLastHash(ctx context.Context, tx *sql.Tx) (string, error) {
+	if len(m.logs) == 0 {
+		return "", nil
+	}
+	return m.logs[len(m.logs)-1].Hash, nil
+}
+
+func (m *MockAuditRepository) Append(ctx context.Context, tx *sql.Tx, entry *models.AuditLog) error {
+	entry.Sequence = int64(len(m.logs) + 1)
+	m.logs = append(m.logs, *entry)
+	return nil
+}
+
+func (m *MockAuditRepository) Query(query *models.AuditLogQuery) ([]models.AuditLog, int, error) {
+	var logs []models.AuditLog
+	for _, entry := range m.logs {
+		if query.ActorID != nil && (entry.ActorID == nil || *entry.ActorID != *query.ActorID) {
+			continue
+		}
+		if query.Action != "" && entry.Action != query.Action {
+			continue
+		}
+		if query.WalletID != nil && !containsUUID(entry.WalletIDs, *query.WalletID) {
+			continue
+		}
+		if query.Outcome != nil && entry.Outcome != *query.Outcome {
+			continue
+		}
+		logs = append(logs, entry)
+	}
+	return logs, len(logs), nil
+}
+
+func (m *MockAuditRepository) GetChain(afterSeq int64, limit int) ([]models.AuditLog, error) {
+	var logs []models.AuditLog
+	for _, entry := range m.logs {
+		if entry.Sequence > afterSeq && len(logs) < limit {
+			logs = append(logs, entry)
+		}
+	}
+	return logs, nil
+}
+
+// MockIdempotencyRepository implements IIdempotencyRepository for testing
+type MockIdempotencyRepository struct {
+	records map[string]*models.IdempotencyRecord
+}
+
+func NewMockIdempotencyRepository() *MockIdempotencyRepository {
+	return &MockIdempotencyRepository{
+		records: make(map[string]*models.IdempotencyRecord),
+	}
+}
+
+func (m *MockIdempotencyRepository) Create(ctx context.Context, tx *sql.Tx, record *models.IdempotencyRecord) (bool, error) {
+	scope := record.UserID.String() + "|" + record.Endpoint + "|" + record.Key
+	if existing, exists := m.records[scope]; exists && existing.ExpiresAt.After(time.Now()) {
+		return false, nil
+	}
+	record.CreatedAt = time.Now()
+	m.records[scope] = record
+	return true, nil
+}
+
+func (m *MockIdempotencyRepository) Get(ctx context.Context, userID uuid.UUID, endpoint, key string) (*models.IdempotencyRecord, error) {
+	record, exists := m.records[userID.String()+"|"+endpoint+"|"+key]
+	if !exists || !record.ExpiresAt.After(time.Now()) {
+		return nil, nil
+	}
+	return record, nil
+}
+
+func (m *MockIdempotencyRepository) DeleteExpired(ctx context.Context, limit int) (int64, error) {
+	var deleted int64
+	for scope, record := range m.records {
+		if deleted < int64(limit) && !record.ExpiresAt.After(time.Now()) {
+			delete(m.records, scope)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// MockPasswordResetRepository implements IPasswordResetRepository for testing
+type MockPasswordResetRepository struct {
+	tokens map[string]*models.PasswordResetToken
+}
+
+func NewMockPasswordResetRepository() *MockPasswordResetRepository {
+	return &MockPasswordResetRepository{
+		tokens: make(map[string]*models.PasswordResetToken),
+	}
+}
+
+func (m *MockPasswordResetRepository) Create(ctx context.Context, token *models.PasswordResetToken) error {
+	token.CreatedAt = time.Now()
+	m.tokens[token.TokenHash] = token
+	return nil
+}
+
+func (m *MockPasswordResetRepository) Consume(ctx context.Context, tx *sql.Tx, tokenHash string) (*models.PasswordResetToken, error) {
+	token, exists := m.tokens[tokenHash]
+	if !exists || token.UsedAt != nil || !token.ExpiresAt.After(time.Now()) {
+		return nil, nil
+	}
+	now := time.Now()
+	token.UsedAt = &now
+	return token, nil
+}
+
+func (m *MockPasswordResetRepository) InvalidateForUser(ctx context.Context, tx *sql.Tx, userID uuid.UUID) error {
+	now := time.Now()
+	for _, token := range m.tokens {
+		if token.UserID == userID && token.UsedAt == nil {
+			token.UsedAt = &now
+		}
+	}
+	return nil
+}
+
+// MockRefreshTokenRepository implements IRefreshTokenRepository for testing
+type MockRefreshTokenRepository struct {
+	tokens map[string]*models.RefreshToken
+}
+
+func NewMockRefreshTokenRepository() *MockRefreshTokenRepository {
+	return &MockRefreshTokenRepository{
+		tokens: make(map[string]*models.RefreshToken),
+	}
+}
+
+func (m *MockRefreshTokenRepository) Create(ctx context.Context, tx *sql.Tx, token *models.RefreshToken) error {
+	token.CreatedAt = time.Now()
+	m.tokens[token.TokenHash] = token
+	return nil
+}
+
+func (m *MockRefreshTokenRepository) GetByHashForUpdate(ctx context.Context, tx *sql.Tx, tokenHash string) (*models.RefreshToken, error) {
+	if token, exists := m.tokens[tokenHash]; exists {
+		return token, nil
+	}
+	return nil, nil
+}
+
+func (m *MockRefreshTokenRepository) MarkUsed(ctx context.Context, tx *sql.Tx, id uuid.UUID) error {
+	now := time.Now()
+	for _, token := range m.tokens {
+		if token.ID == id {
+			token.UsedAt = &now
+		}
+	}
+	return nil
+}
+
+func (m *MockRefreshTokenRepository) RevokeFamily(ctx context.Context, tx *sql.Tx, familyID uuid.UUID) error {
+	now := time.Now()
+	for _, token := range m.tokens {
+		if token.FamilyID == familyID && token.RevokedAt == nil {
+			token.RevokedAt = &now
+		}
+	}
+	return nil
+}
+
+func (m *MockRefreshTokenRepository) RevokeForUser(ctx context.Context, tx *sql.Tx, userID uuid.UUID) error {
+	now := time.Now()
+	for _, token := range m.tokens {
+		if token.UserID == userID && token.RevokedAt == nil {
+			token.RevokedAt = &now
+		}
+	}
+	return nil
+}
+
+// MockTwoFactorRepository implements ITwoFactorRepository for testing
+type MockTwoFactorRepository struct {
+	credentials   map[uuid.UUID]*models.TOTPCredential
+	recoveryCodes map[uuid.UUID]map[string]bool
+}
+
+func NewMockTwoFactorRepository() *MockTwoFactorRepository {
+	return &MockTwoFactorRepository{
+		credentials:   make(map[uuid.UUID]*models.TOTPCredential),
+		recoveryCodes: make(map[uuid.UUID]map[string]bool),
+	}
+}
+
+func (m *MockTwoFactorRepository) Get(ctx context.Context, userID uuid.UUID) (*models.TOTPCredential, error) {
+	if credential, exists := m.credentials[userID]; exists {
+		copied := *credential
+		return &copied, nil
+	}
+	return nil, nil
+}
+
+func (m *MockTwoFactorRepository) SavePending(ctx context.Context, credential *models.TOTPCredential) error {
+	if existing, exists := m.credentials[credential.UserID]; exists && existing.EnabledAt != nil {
+		return nil
+	}
+	credential.CreatedAt = time.Now()
+	credential.LastUsedStep = 0
+	m.credentials[credential.UserID] = credential
+	return nil
+}
+
+func (m *MockTwoFactorRepository) UseStep(ctx context.Context, userID uuid.UUID, step int64) (bool, error) {
+	credential, exists := m.credentials[userID]
+	if !exists || credential.LastUsedStep >= step {
+		return false, nil
+	}
+	credential.LastUsedStep = step
+	return true, nil
+}
+
+func (m *MockTwoFactorRepository) UseRecoveryCode(ctx context.Context, userID uuid.UUID, codeHash string) (bool, error) {
+	codes := m.recoveryCodes[userID]
+	if unused, exists := codes[codeHash]; !exists || !unused {
+		return false, nil
+	}
+	codes[codeHash] = false
+	return true, nil
+}
+
+func (m *MockTwoFactorRepository) Enable(ctx context.Context, tx *sql.Tx, userID uuid.UUID, step int64) error {
+	credential, exists := m.credentials[userID]
+	if !exists || credential.EnabledAt != nil {
+		return fmt.Errorf("no pending TOTP credential")
+	}
+	now := time.Now()
+	credential.EnabledAt = &now
+	credential.LastUsedStep = step
+	return nil
+}
+
+func (m *MockTwoFactorRepository) Delete(ctx context.Context, tx *sql.Tx, userID uuid.UUID) error {
+	delete(m.credentials, userID)
+	delete(m.recoveryCodes, userID)
+	return nil
+}
+
+func (m *MockTwoFactorRepository) ReplaceRecoveryCodes(ctx context.Context, tx *sql.Tx, userID uuid.UUID, codeHashes []string) error {
+	codes := make(map[string]bool, len(codeHashes))
+	for _, codeHash := range codeHashes {
+		codes[codeHash] = true
+	}
+	m.recoveryCodes[userID] = codes
+	return nil
+}
+
+// MockAPIKeyRepository implements IAPIKeyRepository for testing
+type MockAPIKeyRepository struct {
+	keys map[uuid.UUID]*models.APIKey
+}
+
+func NewMockAPIKeyRepository() *MockAPIKeyRepository {
+	return &MockAPIKeyRepository{
+		keys: make(map[uuid.UUID]*models.APIKey),
+	}
+}
+
+func (m *MockAPIKeyRepository) Create(ctx context.Context, key *models.APIKey) error {
+	key.CreatedAt = time.Now()
+	m.keys[key.ID] = key
+	return nil
+}
+
+func (m *MockAPIKeyRepository) GetByHash(ctx context.Context, keyHash string) (*models.APIKey, error) {
+	for _, key := range m.keys {
+		if key.KeyHash == keyHash {
+			return key, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *MockAPIKeyRepository) GetByUserID(ctx context.Context, userID uuid.UUID) ([]models.APIKey, error) {
+	keys := []models.APIKey{}
+	for _, key := range m.keys {
+		if key.UserID == userID && key.RevokedAt == nil {
+			keys = append(keys, *key)
+		}
+	}
+	return keys, nil
+}
+
+func (m *MockAPIKeyRepository) Revoke(ctx context.Context, userID, id uuid.UUID) (bool, error) {
+	key, exists := m.keys[id]
+	if !exists || key.UserID != userID || key.RevokedAt != nil {
+		return false, nil
+	}
+	now := time.Now()
+	key.RevokedAt = &now
+	return true, nil
+}
+
+func (m *MockAPIKeyRepository) TouchLastUsed(ctx context.Context, id uuid.UUID) error {
+	if key, exists := m.keys[id]; exists {
+		now := time.Now()
+		key.LastUsedAt = &now
+	}
+	return nil
+}
+
+// MockSigningKeyRepository implements ISigningKeyRepository for testing
+type MockSigningKeyRepository struct {
+	keys []models.SigningKey
+}
+
+func NewMockSigningKeyRepository() *MockSigningKeyRepository {
+	return &MockSigningKeyRepository{}
+}
+
+func (m *MockSigningKeyRepository) List(ctx context.Context) ([]models.SigningKey, error) {
+	keys := make([]models.SigningKey, len(m.keys))
+	copy(keys, m.keys)
+	return keys, nil
+}
+
+func (m *MockSigningKeyRepository) Lock(ctx context.Context, tx *sql.Tx) error {
+	return nil
+}
+
+func (m *MockSigningKeyRepository) ListForRotation(ctx context.Context, tx *sql.Tx) ([]models.SigningKey, error) {
+	return m.List(ctx)
+}
+
+func (m *MockSigningKeyRepository) Create(ctx context.Context, tx *sql.Tx, key *models.SigningKey) error {
+	key.CreatedAt = time.Now()
+	m.keys = append(m.keys, *key)
+	return nil
+}
+
+func (m *MockSigningKeyRepository) Delete(ctx context.Context, tx *sql.Tx, kids []string) error {
+	deleted := make(map[string]bool, len(kids))
+	for _, kid := range kids {
+		deleted[kid] = true
+	}
+
+	kept := m.keys[:0]
+	for _, key := range m.keys {
+		if !deleted[key.ID] {
+			kept = append(kept, key)
+		}
+	}
+	m.keys = kept
+	return nil
+}
+
+// MockRegistrationRepository implements IRegistrationRepository for testing
+type MockRegistrationRepository struct {
+	registrations map[uuid.UUID]*models.Registration
+}
+
+func NewMockRegistrationRepository() *MockRegistrationRepository {
+	return &MockRegistrationRepository{
+		registrations: make(map[uuid.UUID]*models.Registration),
+	}
+}
+
+func (m *MockRegistrationRepository) Create(ctx context.Context, registration *models.Registration) error {
+	registration.CreatedAt = time.Now()
+	m.registrations[registration.ID] = registration
+	return nil
+}
+
+func (m *MockRegistrationRepository) ConsumeByToken(ctx context.Context, tx *sql.Tx, tokenHash string) (*models.Registration, error) {
+	for _, registration := range m.registrations {
+		if registration.TokenHash == tokenHash && registration.UsedAt == nil && registration.ExpiresAt.After(time.Now()) {
+			now := time.Now()
+			registration.UsedAt = &now
+			return registration, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *MockRegistrationRepository) GetPendingByEmailForUpdate(ctx context.Context, tx *sql.Tx, email string) ([]models.Registration, error) {
+	var registrations []models.Registration
+	for _, registration := range m.registrations {
+		if registration.Email == email && registration.UsedAt == nil && registration.ExpiresAt.After(time.Now()) {
+			registrations = append(registrations, *registration)
+		}
+	}
+	return registrations, nil
+}
+
+func (m *MockRegistrationRepository) RecordFailedAttempt(ctx context.Context, tx *sql.Tx, ids []uuid.UUID, maxAttempts int) error {
+	for _, id := range ids {
+		if registration, exists := m.registrations[id]; exists {
+			registration.Attempts++
+			if registration.Attempts >= maxAttempts {
+				now := time.Now()
+				registration.UsedAt = &now
+			}
+		}
+	}
+	return nil
+}
+
+func (m *MockRegistrationRepository) InvalidateForEmail(ctx context.Context, tx *sql.Tx, email string) error {
+	now := time.Now()
+	for _, registration := range m.registrations {
+		if registration.Email == email && registration.UsedAt == nil {
+			registration.UsedAt = &now
+		}
+	}
+	return nil
+}
+
+// MockKYCRepository implements IKYCRepository for testing
+type MockKYCRepository struct {
+	submissions map[uuid.UUID]*models.KYCSubmission
+}
+
+func NewMockKYCRepository() *MockKYCRepository {
+	return &MockKYCRepository{
+		submissions: make(map[uuid.UUID]*models.KYCSubmission),
+	}
+}
+
+func (m *MockKYCRepository) Create(ctx context.Context, submission *models.KYCSubmission) error {
+	if submission.Status == "" {
+		submission.Status = models.KYCSubmissionPending
+	}
+	submission.CreatedAt = time.Now()
+	m.submissions[submission.ID] = submission
+	return nil
+}
+
+func (m *MockKYCRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.KYCSubmission, error) {
+	if submission, exists := m.submissions[id]; exists {
+		return submission, nil
+	}
+	return nil, nil
+}
+
+func (m *MockKYCRepository) GetByUserID(ctx context.Context, userID uuid.UUID) ([]models.KYCSubmission, error) {
+	submissions := []models.KYCSubmission{}
+	for _, submission := range m.submissions {
+		if submission.UserID == userID {
+			submissions = append(submissions, *submission)
+		}
+	}
+	return submissions, nil
+}
+
+func (m *MockKYCRepository) List(ctx context.Context, status models.KYCSubmissionStatus, limit, offset int) ([]models.KYCSubmission, int, error) {
+	var matched []models.KYCSubmission
+	for _, submission := range m.submissions {
+		if status == "" || submission.Status == status {
+			matched = append(matched, *submission)
+		}
+	}
+
+	total := len(matched)
+	if offset >= total {
+		return []models.KYCSubmission{}, total, nil
+	}
+	end := offset + limit
+	if end > total {
+		end = total
+	}
+	return matched[offset:end], total, nil
+}
+
+func (m *MockKYCRepository) GetByIDForUpdate(ctx context.Context, tx *sql.Tx, id uuid.UUID) (*models.KYCSubmission, error) {
+	return m.GetByID(ctx, id)
+}
+
+func (m *MockKYCRepository) Review(ctx context.Context, tx *sql.Tx, id uuid.UUID, status models.KYCSubmissionStatus, reviewerID *uuid.UUID, note string) error {
+	submission, exists := m.submissions[id]
+	if !exists {
+		return fmt.Errorf("KYC submission not found")
+	}
+	now := time.Now()
+	submission.Status = status
+	submission.ReviewerID = reviewerID
+	submission.ReviewNote = note
+	submission.ReviewedAt = &now
+	return nil
+}
+
+// MockAdjustmentRepository implements IAdjustmentRepository for testing
+type MockAdjustmentRepository struct {
+	adjustments map[uuid.UUID]*models.BalanceAdjustment
+}
+
+func NewMockAdjustmentRepository() *MockAdjustmentRepository {
+	return &MockAdjustmentRepository{
+		adjustments: make(map[uuid.UUID]*models.BalanceAdjustment),
+	}
+}
+
+func (m *MockAdjustmentRepository) Create(ctx context.Context, adjustment *models.BalanceAdjustment) error {
+	if adjustment.Status == "" {
+		adjustment.Status = models.AdjustmentStatusPending
+	}
+	adjustment.CreatedAt = time.Now()
+	m.adjustments[adjustment.ID] = adjustment
+	return nil
+}
+
+func (m *MockAdjustmentRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.BalanceAdjustment, error) {
+	if adjustment, exists := m.adjustments[id]; exists {
+		return adjustment, nil
+	}
+	return nil, nil
+}
+
+func (m *MockAdjustmentRepository) List(ctx context.Context, status models.AdjustmentStatus, limit, offset int) ([]models.BalanceAdjustment, int, error) {
+	var matched []models.BalanceAdjustment
+	for _, adjustment := range m.adjustments {
+		if status == "" || adjustment.Status == status {
+			matched = append(matched, *adjustment)
+		}
+	}
+
+	total := len(matched)
+	if offset >= total {
+		return []models.BalanceAdjustment{}, total, nil
+	}
+	end := offset + limit
+	if end > total {
+		end = total
+	}
+	return matched[offset:end], total, nil
+}
+
+func (m *MockAdjustmentRepository) GetOverdue(ctx context.Context, limit int) ([]models.BalanceAdjustment, error) {
+	overdue := []models.BalanceAdjustment{}
+	for _, adjustment := range m.adjustments {
+		if adjustment.Status == models.AdjustmentStatusPending && !adjustment.ExpiresAt.After(time.Now()) {
+			overdue = append(overdue, *adjustment)
+		}
+	}
+	sort.Slice(overdue, func(i, j int) bool { return overdue[i].ExpiresAt.Before(overdue[j].ExpiresAt) })
+	if len(overdue) > limit {
+		overdue = overdue[:limit]
+	}
+	return overdue, nil
+}
+
+func (m *MockAdjustmentRepository) GetByIDForUpdate(ctx context.Context, tx *sql.Tx, id uuid.UUID) (*models.BalanceAdjustment, error) {
+	return m.GetByID(ctx, id)
+}
+
+func (m *MockAdjustmentRepository) Review(ctx context.Context, tx *sql.Tx, id uuid.UUID, status models.AdjustmentStatus, reviewerID *uuid.UUID, note string, transactionID *uuid.UUID) error {
+	adjustment, exists := m.adjustments[id]
+	if !exists {
+		return fmt.Errorf("adjustment not found")
+	}
+	now := time.Now()
+	adjustment.Status = status
+	adjustment.ReviewedBy = reviewerID
+	adjustment.ReviewNote = note
+	adjustment.TransactionID = transactionID
+	adjustment.ReviewedAt = &now
+	return nil
+}
+
+// MockWithdrawalReviewRepository implements IWithdrawalReviewRepository for
+// testing
+type MockWithdrawalReviewRepository struct {
+	reviews map[uuid.UUID]*models.WithdrawalReview
+}
+
+func NewMockWithdrawalReviewRepository() *MockWithdrawalReviewRepository {
+	return &MockWithdrawalReviewRepository{
+		reviews: make(map[uuid.UUID]*models.WithdrawalReview),
+	}
+}
+
+func (m *MockWithdrawalReviewRepository) Create(ctx context.Context, tx *sql.Tx, review *models.WithdrawalReview) error {
+	if review.Status == "" {
+		review.Status = models.WithdrawalReviewPending
+	}
+	review.CreatedAt = time.Now()
+	m.reviews[review.TransactionID] = review
+	return nil
+}
+
+func (m *MockWithdrawalReviewRepository) GetByTransactionID(ctx context.Context, transactionID uuid.UUID) (*models.WithdrawalReview, error) {
+	if review, exists := m.reviews[transactionID]; exists {
+		return review, nil
+	}
+	return nil, nil
+}
+
+func (m *MockWithdrawalReviewRepository) List(ctx context.Context, status models.WithdrawalReviewStatus, userID *uuid.UUID, walletIDs []uuid.UUID, limit, offset int) ([]models.WithdrawalReview, int, error) {
+	var matched []models.WithdrawalReview
+	for _, review := range m.reviews {
+		if (status == "" || review.Status == status) && (userID == nil || review.UserID == *userID) &&
+			(len(walletIDs) == 0 || containsUUID(walletIDs, review.WalletID)) {
+			matched = append(matched, *review)
+		}
+	}
+
+	total := len(matched)
+	if offset >= total {
+		return []models.WithdrawalReview{}, total, nil
+	}
+	end := offset + limit
+	if end > total {
+		end = total
+	}
+	return matched[offset:end], total, nil
+}
+
+func (m *MockWithdrawalReviewRepository) GetByTransactionIDForUpdate(ctx context.Context, tx *sql.Tx, transactionID uuid.UUID) (*models.WithdrawalReview, error) {
+	return m.GetByTransactionID(ctx, transactionID)
+}
+
+func (m *MockWithdrawalReviewRepository) Decide(ctx context.Context, tx *sql.Tx, transactionID uuid.UUID, status models.WithdrawalReviewStatus, reviewerID *uuid.UUID, note string) error {
+	review, exists := m.reviews[transactionID]
+	if !exists {
+		return fmt.Errorf("withdrawal review not found")
+	}
+	now := time.Now()
+	review.Status = status
+	review.ReviewedBy = reviewerID
+	review.ReviewNote = note
+	review.DecidedAt = &now
+	return nil
+}
+
+func (m *MockWithdrawalReviewRepository) SumPending(ctx context.Context, tx *sql.Tx, walletIDs []uuid.UUID) (decimal.Decimal, error) {
+	total := decimal.Zero
+	for _, review := range m.reviews {
+		if review.Status == models.WithdrawalReviewPending && containsUUID(walletIDs, review.WalletID) {
+			total = total.Add(review.Amount)
+		}
+	}
+	return total, nil
+}
+
+// MockTransactionManager implements ITransactionManager for testing. It runs
+// the function with a nil transaction, which the mock repositories ignore,
+// and cannot roll back what a failing function already changed.
+type MockTransactionManager struct{}
+
+func NewMockTransactionManager() *MockTransactionManager {
+	return &MockTransactionManager{}
+}
+
+func (m *MockTransactionManager) ExecuteTransaction(ctx context.Context, fn TransactionFunc) error {
+	return fn(ctx, nil)
+}
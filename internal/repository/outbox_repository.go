@@ -0,0 +1,169 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"wallet-service/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// outboxRelayLockKey is the advisory lock held by whichever instance is
+// currently relaying, so publish sequences are handed out by one transaction
+// at a time and events leave the outbox in that order. seq is assigned at
+// insert, not commit, so it is not commit order; see Relay.
+const outboxRelayLockKey = 727001
+
+type OutboxRepository struct {
+	db *sql.DB
+}
+
+func NewOutboxRepository(db *sql.DB) *OutboxRepository {
+	return &OutboxRepository{db: db}
+}
+
+func (r *OutboxRepository) Create(ctx context.Context, tx *sql.Tx, event *models.OutboxEvent) error {
+	query := `INSERT INTO outbox (id, event_type, aggregate_id, payload) VALUES ($1, $2, $3, $4) RETURNING seq, created_at`
+
+	return tx.QueryRowContext(ctx, query, event.ID, event.EventType, event.AggregateID, []byte(event.Payload)).Scan(&event.Sequence, &event.CreatedAt)
+}
+
+func (r *OutboxRepository) LockRelay(ctx context.Context, tx *sql.Tx) (bool, error) {
+	var locked bool
+	err := tx.QueryRowContext(ctx, `SELECT pg_try_advisory_xact_lock($1)`, outboxRelayLockKey).Scan(&locked)
+	if err != nil {
+		return false, fmt.Errorf("failed to acquire outbox relay lock: %w", err)
+	}
+
+	return locked, nil
+}
+
+// AssignPublishSequence numbers up to limit committed events that have no
+// publish sequence yet, in seq order. Events still uncommitted are invisible
+// here and get a later number once they commit, so publish sequence follows
+// commit order.
+func (r *OutboxRepository) AssignPublishSequence(ctx context.Context, tx *sql.Tx, limit int) (int, error) {
+	query := `
+		UPDATE outbox o SET publish_seq = numbered.publish_seq
+		FROM (
+			SELECT seq, nextval('outbox_publish_seq') AS publish_seq
+			FROM (SELECT seq FROM outbox WHERE publish_seq IS NULL ORDER BY seq LIMIT $1 FOR UPDATE) pending
+			ORDER BY seq
+		) numbered
+		WHERE o.seq = numbered.seq`
+
+	result, err := tx.ExecContext(ctx, query, limit)
+	if err != nil {
+		return 0, fmt.Errorf("failed to assign publish sequence: %w", err)
+	}
+
+	assigned, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to assign publish sequence: %w", err)
+	}
+
+	return int(assigned), nil
+}
+
+// LockCursor returns the publish sequence the named consumer has published
+// up to, holding its cursor until the transaction ends. It returns false
+// while another instance is publishing to the same consumer.
+func (r *OutboxRepository) LockCursor(ctx context.Context, tx *sql.Tx, consumer string) (int64, bool, error) {
+	_, err := tx.ExecContext(ctx, `INSERT INTO outbox_cursors (consumer) VALUES ($1) ON CONFLICT (consumer) DO NOTHING`, consumer)
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to create outbox cursor: %w", err)
+	}
+
+	var publishSeq int64
+	err = tx.QueryRowContext(ctx, `SELECT publish_seq FROM outbox_cursors WHERE consumer = $1 FOR UPDATE SKIP LOCKED`, consumer).Scan(&publishSeq)
+	if err == sql.ErrNoRows {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to lock outbox cursor: %w", err)
+	}
+
+	return publishSeq, true, nil
+}
+
+func (r *OutboxRepository) SaveCursor(ctx context.Context, tx *sql.Tx, consumer string, publishSeq int64) error {
+	query := `UPDATE outbox_cursors SET publish_seq = $2, updated_at = NOW() WHERE consumer = $1`
+
+	_, err := tx.ExecContext(ctx, query, consumer, publishSeq)
+	if err != nil {
+		return fmt.Errorf("failed to save outbox cursor: %w", err)
+	}
+
+	return nil
+}
+
+func (r *OutboxRepository) GetRange(fromSeq, toSeq int64, aggregateIDs []uuid.UUID, limit int) ([]models.OutboxEvent, error) {
+	query := `SELECT seq, publish_seq, id, event_type, aggregate_id, payload, created_at FROM outbox WHERE seq >= $1 AND seq <= $2`
+	args := []interface{}{fromSeq, toSeq}
+
+	if aggregateIDs != nil {
+		query += ` AND aggregate_id = ANY($3)`
+		args = append(args, pq.Array(aggregateIDs))
+	}
+
+	query += fmt.Sprintf(" ORDER BY seq LIMIT $%d", len(args)+1)
+	args = append(args, limit)
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get outbox events: %w", err)
+	}
+	defer rows.Close()
+
+	return scanOutboxEvents(rows)
+}
+
+func (r *OutboxRepository) GetAfter(afterPublishSeq int64, aggregateIDs []uuid.UUID, limit int) ([]models.OutboxEvent, error) {
+	query := `SELECT seq, publish_seq, id, event_type, aggregate_id, payload, created_at FROM outbox WHERE publish_seq > $1`
+	args := []interface{}{afterPublishSeq}
+
+	if aggregateIDs != nil {
+		query += ` AND aggregate_id = ANY($2)`
+		args = append(args, pq.Array(aggregateIDs))
+	}
+
+	query += fmt.Sprintf(" ORDER BY publish_seq LIMIT $%d", len(args)+1)
+	args = append(args, limit)
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get outbox events: %w", err)
+	}
+	defer rows.Close()
+
+	return scanOutboxEvents(rows)
+}
+
+func scanOutboxEvents(rows *sql.Rows) ([]models.OutboxEvent, error) {
+	var events []models.OutboxEvent
+	for rows.Next() {
+		var event models.OutboxEvent
+		var payload []byte
+		var publishSeq sql.NullInt64
+		err := rows.Scan(
+			&event.Sequence,
+			&publishSeq,
+			&event.ID,
+			&event.EventType,
+			&event.AggregateID,
+			&payload,
+			&event.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan outbox event: %w", err)
+		}
+		event.Payload = payload
+		event.PublishSequence = publishSeq.Int64
+		events = append(events, event)
+	}
+
+	return events, nil
+}
@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"wallet-service/internal/models"
+)
+
+type PriceRepository struct {
+	db *sql.DB
+}
+
+func NewPriceRepository(db *sql.DB) *PriceRepository {
+	return &PriceRepository{db: db}
+}
+
+func (r *PriceRepository) Create(ctx context.Context, price *models.Price) error {
+	query := `INSERT INTO prices (id, coin_type, currency, price, source, as_of) VALUES ($1, $2, $3, $4, $5, $6) RETURNING created_at`
+
+	return r.db.QueryRowContext(ctx, query, price.ID, price.CoinType, price.Currency, price.Price, price.Source, price.AsOf).Scan(&price.CreatedAt)
+}
+
+func (r *PriceRepository) GetLatest(coinType models.CoinType, currency string) (*models.Price, error) {
+	query := `SELECT id, coin_type, currency, price, source, as_of, created_at FROM prices WHERE coin_type = $1 AND currency = $2 ORDER BY as_of DESC LIMIT 1`
+
+	var price models.Price
+	err := r.db.QueryRow(query, coinType, currency).Scan(
+		&price.ID,
+		&price.CoinType,
+		&price.Currency,
+		&price.Price,
+		&price.Source,
+		&price.AsOf,
+		&price.CreatedAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get latest price: %w", err)
+	}
+
+	return &price, nil
+}
+
+func (r *PriceRepository) GetHistory(coinType models.CoinType, currency string, since time.Time) ([]models.Price, error) {
+	query := `SELECT id, coin_type, currency, price, source, as_of, created_at FROM prices WHERE coin_type = $1 AND currency = $2 AND as_of >= $3 ORDER BY as_of DESC`
+
+	rows, err := r.db.Query(query, coinType, currency, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get price history: %w", err)
+	}
+	defer rows.Close()
+
+	var prices []models.Price
+	for rows.Next() {
+		var price models.Price
+		err := rows.Scan(
+			&price.ID,
+			&price.CoinType,
+			&price.Currency,
+			&price.Price,
+			&price.Source,
+			&price.AsOf,
+			&price.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan price: %w", err)
+		}
+		prices = append(prices, price)
+	}
+
+	return prices, nil
+}
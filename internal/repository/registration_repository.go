@@ -0,0 +1,126 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"wallet-service/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const registrationColumns = `id, email, name, password_hash, token_hash, code_hash, attempts, created_at, expires_at, used_at`
+
+type RegistrationRepository struct {
+	db *sql.DB
+}
+
+func NewRegistrationRepository(db *sql.DB) *RegistrationRepository {
+	return &RegistrationRepository{db: db}
+}
+
+func (r *RegistrationRepository) Create(ctx context.Context, registration *models.Registration) error {
+	query := `INSERT INTO registrations (id, email, name, password_hash, token_hash, code_hash, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING created_at`
+
+	err := r.db.QueryRowContext(ctx, query,
+		registration.ID,
+		registration.Email,
+		registration.Name,
+		registration.PasswordHash,
+		registration.TokenHash,
+		registration.CodeHash,
+		registration.ExpiresAt,
+	).Scan(&registration.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create registration: %w", err)
+	}
+
+	return nil
+}
+
+func (r *RegistrationRepository) ConsumeByToken(ctx context.Context, tx *sql.Tx, tokenHash string) (*models.Registration, error) {
+	query := `UPDATE registrations SET used_at = NOW()
+		WHERE token_hash = $1 AND used_at IS NULL AND expires_at > NOW()
+		RETURNING ` + registrationColumns
+
+	registration, err := scanRegistration(tx.QueryRowContext(ctx, query, tokenHash))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to consume registration: %w", err)
+	}
+
+	return registration, nil
+}
+
+func (r *RegistrationRepository) GetPendingByEmailForUpdate(ctx context.Context, tx *sql.Tx, email string) ([]models.Registration, error) {
+	query := `SELECT ` + registrationColumns + ` FROM registrations
+		WHERE email = $1 AND used_at IS NULL AND expires_at > NOW()
+		ORDER BY created_at
+		FOR UPDATE`
+
+	rows, err := tx.QueryContext(ctx, query, email)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pending registrations: %w", err)
+	}
+	defer rows.Close()
+
+	var registrations []models.Registration
+	for rows.Next() {
+		registration, err := scanRegistration(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan registration: %w", err)
+		}
+		registrations = append(registrations, *registration)
+	}
+
+	return registrations, rows.Err()
+}
+
+func (r *RegistrationRepository) RecordFailedAttempt(ctx context.Context, tx *sql.Tx, ids []uuid.UUID, maxAttempts int) error {
+	query := `UPDATE registrations
+		SET attempts = attempts + 1,
+			used_at = CASE WHEN attempts + 1 >= $2 THEN NOW() ELSE used_at END
+		WHERE id = ANY($1)`
+
+	if _, err := tx.ExecContext(ctx, query, pq.Array(ids), maxAttempts); err != nil {
+		return fmt.Errorf("failed to record verification attempt: %w", err)
+	}
+
+	return nil
+}
+
+func (r *RegistrationRepository) InvalidateForEmail(ctx context.Context, tx *sql.Tx, email string) error {
+	query := `UPDATE registrations SET used_at = NOW() WHERE email = $1 AND used_at IS NULL`
+
+	if _, err := tx.ExecContext(ctx, query, email); err != nil {
+		return fmt.Errorf("failed to invalidate registrations: %w", err)
+	}
+
+	return nil
+}
+
+func scanRegistration(row rowScanner) (*models.Registration, error) {
+	var registration models.Registration
+	err := row.Scan(
+		&registration.ID,
+		&registration.Email,
+		&registration.Name,
+		&registration.PasswordHash,
+		&registration.TokenHash,
+		&registration.CodeHash,
+		&registration.Attempts,
+		&registration.CreatedAt,
+		&registration.ExpiresAt,
+		&registration.UsedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &registration, nil
+}
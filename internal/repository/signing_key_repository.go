@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"wallet-service/internal/models"
+
+	"github.com/lib/pq"
+)
+
+// signingKeyLockKey serializes rotation across instances
+const signingKeyLockKey = 727003
+
+const signingKeyColumns = `kid, algorithm, private_key, public_key, activates_at, created_at`
+
+type SigningKeyRepository struct {
+	db *sql.DB
+}
+
+func NewSigningKeyRepository(db *sql.DB) *SigningKeyRepository {
+	return &SigningKeyRepository{db: db}
+}
+
+func (r *SigningKeyRepository) List(ctx context.Context) ([]models.SigningKey, error) {
+	query := `SELECT ` + signingKeyColumns + ` FROM signing_keys ORDER BY activates_at, kid`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list signing keys: %w", err)
+	}
+	defer rows.Close()
+
+	return scanSigningKeys(rows)
+}
+
+func (r *SigningKeyRepository) Lock(ctx context.Context, tx *sql.Tx) error {
+	_, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1)`, signingKeyLockKey)
+	if err != nil {
+		return fmt.Errorf("failed to lock signing keys: %w", err)
+	}
+
+	return nil
+}
+
+func (r *SigningKeyRepository) ListForRotation(ctx context.Context, tx *sql.Tx) ([]models.SigningKey, error) {
+	query := `SELECT ` + signingKeyColumns + ` FROM signing_keys ORDER BY activates_at, kid`
+
+	rows, err := tx.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list signing keys: %w", err)
+	}
+	defer rows.Close()
+
+	return scanSigningKeys(rows)
+}
+
+func (r *SigningKeyRepository) Create(ctx context.Context, tx *sql.Tx, key *models.SigningKey) error {
+	query := `
+		INSERT INTO signing_keys (kid, algorithm, private_key, public_key, activates_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING created_at`
+
+	err := tx.QueryRowContext(ctx, query, key.ID, key.Algorithm, key.PrivateKey, key.PublicKey, key.ActivatesAt).Scan(&key.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create signing key: %w", err)
+	}
+
+	return nil
+}
+
+func (r *SigningKeyRepository) Delete(ctx context.Context, tx *sql.Tx, kids []string) error {
+	_, err := tx.ExecContext(ctx, `DELETE FROM signing_keys WHERE kid = ANY($1)`, pq.Array(kids))
+	if err != nil {
+		return fmt.Errorf("failed to delete signing keys: %w", err)
+	}
+
+	return nil
+}
+
+func scanSigningKeys(rows *sql.Rows) ([]models.SigningKey, error) {
+	var keys []models.SigningKey
+	for rows.Next() {
+		var key models.SigningKey
+		err := rows.Scan(&key.ID, &key.Algorithm, &key.PrivateKey, &key.PublicKey, &key.ActivatesAt, &key.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan signing key: %w", err)
+		}
+		keys = append(keys, key)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate signing keys: %w", err)
+	}
+
+	return keys, nil
+}
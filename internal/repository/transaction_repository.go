@@ -4,10 +4,13 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"time"
 
 	"wallet-service/internal/models"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/shopspring/decimal"
 )
 
 type TransactionRepository struct {
@@ -30,6 +33,33 @@ func (r *TransactionRepository) CreateTransactionEntry(ctx context.Context, tx *
 	return tx.QueryRowContext(ctx, query, entry.ID, entry.TxnID, entry.WalletID, entry.Direction, entry.Amount, entry.CounterpartyWalletID).Scan(&entry.CreatedAt)
 }
 
+func (r *TransactionRepository) UpdateStatus(ctx context.Context, tx *sql.Tx, id uuid.UUID, status models.TransactionStatus) error {
+	query := `UPDATE transactions SET status = $1 WHERE id = $2`
+
+	_, err := tx.ExecContext(ctx, query, status, id)
+	if err != nil {
+		return fmt.Errorf("failed to update transaction status: %w", err)
+	}
+
+	return nil
+}
+
+func (r *TransactionRepository) SumOutflows(ctx context.Context, tx *sql.Tx, walletIDs []uuid.UUID, window time.Duration) (decimal.Decimal, error) {
+	// The window is applied with the database's clock, which set created_at
+	query := `SELECT COALESCE(SUM(e.amount), 0)
+		FROM transaction_entries e JOIN transactions t ON t.id = e.txn_id
+		WHERE e.wallet_id = ANY($1) AND e.direction = 'OUT'
+			AND t.type IN ('WITHDRAWAL', 'TRANSFER') AND t.status = 'DONE'
+			AND e.created_at > NOW() - make_interval(secs => $2)`
+
+	var total decimal.Decimal
+	if err := tx.QueryRowContext(ctx, query, pq.Array(walletIDs), window.Seconds()).Scan(&total); err != nil {
+		return decimal.Zero, fmt.Errorf("failed to sum outflows: %w", err)
+	}
+
+	return total, nil
+}
+
 func (r *TransactionRepository) GetTransactionHistory(walletID uuid.UUID, req *models.TransactionHistoryRequest) (*models.TransactionHistoryResponse, error) {
 	baseQuery := `SELECT id, txn_id, wallet_id, direction, amount, counterparty_wallet_id, created_at FROM transaction_entries WHERE wallet_id = $1`
 	countQuery := `SELECT COUNT(*) FROM transaction_entries WHERE wallet_id = $1`
@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 
@@ -9,6 +10,8 @@ import (
 	"github.com/google/uuid"
 )
 
+const userColumns = `id, name, email, password_hash, role, status, status_reason, status_changed_at, kyc_tier, created_at`
+
 type UserRepository struct {
 	db *sql.DB
 }
@@ -18,16 +21,9 @@ func NewUserRepository(db *sql.DB) *UserRepository {
 }
 
 func (r *UserRepository) GetByEmail(email string) (*models.User, error) {
-	query := `SELECT id, name, email, created_at FROM users WHERE email = $1`
-
-	var user models.User
-	err := r.db.QueryRow(query, email).Scan(
-		&user.ID,
-		&user.Name,
-		&user.Email,
-		&user.CreatedAt,
-	)
+	query := `SELECT ` + userColumns + ` FROM users WHERE email = $1`
 
+	user, err := scanUser(r.db.QueryRow(query, email))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
@@ -35,20 +31,13 @@ func (r *UserRepository) GetByEmail(email string) (*models.User, error) {
 		return nil, fmt.Errorf("failed to get user by email: %w", err)
 	}
 
-	return &user, nil
+	return user, nil
 }
 
 func (r *UserRepository) GetByID(id uuid.UUID) (*models.User, error) {
-	query := `SELECT id, name, email, created_at FROM users WHERE id = $1`
-
-	var user models.User
-	err := r.db.QueryRow(query, id).Scan(
-		&user.ID,
-		&user.Name,
-		&user.Email,
-		&user.CreatedAt,
-	)
+	query := `SELECT ` + userColumns + ` FROM users WHERE id = $1`
 
+	user, err := scanUser(r.db.QueryRow(query, id))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
@@ -56,17 +45,37 @@ func (r *UserRepository) GetByID(id uuid.UUID) (*models.User, error) {
 		return nil, fmt.Errorf("failed to get user by ID: %w", err)
 	}
 
-	return &user, nil
+	return user, nil
 }
 
-func (r *UserRepository) Create(user *models.User) error {
-	query := `INSERT INTO users (id, name, email) VALUES ($1, $2, $3) RETURNING created_at`
+func (r *UserRepository) Create(ctx context.Context, tx *sql.Tx, user *models.User) (bool, error) {
+	if user.Role == "" {
+		user.Role = models.RoleUser
+	}
+	if user.Status == "" {
+		user.Status = models.UserStatusActive
+	}
+	if user.KYCTier == "" {
+		user.KYCTier = models.KYCTierUnverified
+	}
+
+	query := `INSERT INTO users (id, name, email, password_hash, role, status, kyc_tier) VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (email) DO NOTHING
+		RETURNING created_at`
 
-	return r.db.QueryRow(query, user.ID, user.Name, user.Email).Scan(&user.CreatedAt)
+	err := tx.QueryRowContext(ctx, query, user.ID, user.Name, user.Email, user.PasswordHash, user.Role, user.Status, user.KYCTier).Scan(&user.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to create user: %w", err)
+	}
+
+	return true, nil
 }
 
 func (r *UserRepository) GetAll() ([]models.User, error) {
-	query := `SELECT id, name, email, created_at FROM users ORDER BY created_at`
+	query := `SELECT id, name, email, role, status, status_reason, status_changed_at, kyc_tier, created_at FROM users ORDER BY created_at`
 
 	rows, err := r.db.Query(query)
 	if err != nil {
@@ -77,7 +86,7 @@ func (r *UserRepository) GetAll() ([]models.User, error) {
 	var users []models.User
 	for rows.Next() {
 		var user models.User
-		err := rows.Scan(&user.ID, &user.Name, &user.Email, &user.CreatedAt)
+		err := rows.Scan(&user.ID, &user.Name, &user.Email, &user.Role, &user.Status, &user.StatusReason, &user.StatusChangedAt, &user.KYCTier, &user.CreatedAt)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan user: %w", err)
 		}
@@ -86,3 +95,104 @@ func (r *UserRepository) GetAll() ([]models.User, error) {
 
 	return users, nil
 }
+
+func (r *UserRepository) UpdatePassword(ctx context.Context, tx *sql.Tx, userID uuid.UUID, passwordHash string) error {
+	query := `UPDATE users SET password_hash = $1, password_changed_at = NOW() WHERE id = $2`
+
+	result, err := tx.ExecContext(ctx, query, passwordHash, userID)
+	if err != nil {
+		return fmt.Errorf("failed to update password: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("user not found")
+	}
+
+	return nil
+}
+
+func (r *UserRepository) UpdateRole(ctx context.Context, tx *sql.Tx, userID uuid.UUID, role models.Role) error {
+	query := `UPDATE users SET role = $1 WHERE id = $2`
+
+	result, err := tx.ExecContext(ctx, query, role, userID)
+	if err != nil {
+		return fmt.Errorf("failed to update role: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("user not found")
+	}
+
+	return nil
+}
+
+func (r *UserRepository) UpdateStatus(ctx context.Context, tx *sql.Tx, userID uuid.UUID, status models.UserStatus, reason models.StatusReason) error {
+	query := `UPDATE users SET status = $1, status_reason = $2, status_changed_at = NOW() WHERE id = $3`
+
+	result, err := tx.ExecContext(ctx, query, status, reason, userID)
+	if err != nil {
+		return fmt.Errorf("failed to update status: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("user not found")
+	}
+
+	return nil
+}
+
+func (r *UserRepository) UpdateKYCTier(ctx context.Context, tx *sql.Tx, userID uuid.UUID, tier models.KYCTier) error {
+	query := `UPDATE users SET kyc_tier = $1 WHERE id = $2`
+
+	result, err := tx.ExecContext(ctx, query, tier, userID)
+	if err != nil {
+		return fmt.Errorf("failed to update KYC tier: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("user not found")
+	}
+
+	return nil
+}
+
+func scanUser(row rowScanner) (*models.User, error) {
+	var user models.User
+	err := row.Scan(
+		&user.ID,
+		&user.Name,
+		&user.Email,
+		&user.PasswordHash,
+		&user.Role,
+		&user.Status,
+		&user.StatusReason,
+		&user.StatusChangedAt,
+		&user.KYCTier,
+		&user.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
@@ -11,6 +11,8 @@ import (
 	"github.com/shopspring/decimal"
 )
 
+const walletColumns = `id, user_id, coin_type, amount, frozen_amount, status, created_at, closed_at`
+
 type WalletRepository struct {
 	db *sql.DB
 }
@@ -20,18 +22,9 @@ func NewWalletRepository(db *sql.DB) *WalletRepository {
 }
 
 func (r *WalletRepository) GetByID(id uuid.UUID) (*models.Wallet, error) {
-	query := `SELECT id, user_id, coin_type, amount, frozen_amount, created_at FROM wallets WHERE id = $1`
-
-	var wallet models.Wallet
-	err := r.db.QueryRow(query, id).Scan(
-		&wallet.ID,
-		&wallet.UserID,
-		&wallet.CoinType,
-		&wallet.Amount,
-		&wallet.FrozenAmount,
-		&wallet.CreatedAt,
-	)
+	query := `SELECT ` + walletColumns + ` FROM wallets WHERE id = $1`
 
+	wallet, err := scanWallet(r.db.QueryRow(query, id))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
@@ -39,11 +32,11 @@ func (r *WalletRepository) GetByID(id uuid.UUID) (*models.Wallet, error) {
 		return nil, fmt.Errorf("failed to get wallet by ID: %w", err)
 	}
 
-	return &wallet, nil
+	return wallet, nil
 }
 
 func (r *WalletRepository) GetByUserID(userID uuid.UUID) ([]models.Wallet, error) {
-	query := `SELECT id, user_id, coin_type, amount, frozen_amount, created_at FROM wallets WHERE user_id = $1`
+	query := `SELECT ` + walletColumns + ` FROM wallets WHERE user_id = $1`
 
 	rows, err := r.db.Query(query, userID)
 	if err != nil {
@@ -53,28 +46,48 @@ func (r *WalletRepository) GetByUserID(userID uuid.UUID) ([]models.Wallet, error
 
 	var wallets []models.Wallet
 	for rows.Next() {
-		var wallet models.Wallet
-		err := rows.Scan(
-			&wallet.ID,
-			&wallet.UserID,
-			&wallet.CoinType,
-			&wallet.Amount,
-			&wallet.FrozenAmount,
-			&wallet.CreatedAt,
-		)
+		wallet, err := scanWallet(rows)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan wallet: %w", err)
 		}
-		wallets = append(wallets, wallet)
+		wallets = append(wallets, *wallet)
 	}
 
 	return wallets, nil
 }
 
-func (r *WalletRepository) Create(wallet *models.Wallet) error {
-	query := `INSERT INTO wallets (id, user_id, coin_type, amount, frozen_amount) VALUES ($1, $2, $3, $4, $5) RETURNING created_at`
+func (r *WalletRepository) Create(ctx context.Context, tx *sql.Tx, wallet *models.Wallet) (bool, error) {
+	if wallet.Status == "" {
+		wallet.Status = models.WalletStatusActive
+	}
+
+	query := `INSERT INTO wallets (id, user_id, coin_type, amount, frozen_amount, status) VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (user_id, coin_type) WHERE status <> 'CLOSED' DO NOTHING
+		RETURNING created_at`
+
+	err := tx.QueryRowContext(ctx, query, wallet.ID, wallet.UserID, wallet.CoinType, wallet.Amount, wallet.FrozenAmount, wallet.Status).Scan(&wallet.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to create wallet: %w", err)
+	}
+
+	return true, nil
+}
+
+func (r *WalletRepository) GetByIDForUpdate(ctx context.Context, tx *sql.Tx, id uuid.UUID) (*models.Wallet, error) {
+	query := `SELECT ` + walletColumns + ` FROM wallets WHERE id = $1 FOR UPDATE`
+
+	wallet, err := scanWallet(tx.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to lock wallet: %w", err)
+	}
 
-	return r.db.QueryRow(query, wallet.ID, wallet.UserID, wallet.CoinType, wallet.Amount, wallet.FrozenAmount).Scan(&wallet.CreatedAt)
+	return wallet, nil
 }
 
 func (r *WalletRepository) UpdateAmount(ctx context.Context, tx *sql.Tx, walletID uuid.UUID, amount decimal.Decimal) error {
@@ -99,24 +112,47 @@ func (r *WalletRepository) UpdateFrozenAmount(ctx context.Context, tx *sql.Tx, w
 	return nil
 }
 
+func (r *WalletRepository) UpdateStatus(ctx context.Context, tx *sql.Tx, walletID uuid.UUID, status models.WalletStatus) error {
+	query := `UPDATE wallets
+		SET status = $1::wallet_status, closed_at = CASE WHEN $1::wallet_status = 'CLOSED' THEN NOW() END
+		WHERE id = $2`
+
+	_, err := tx.ExecContext(ctx, query, status, walletID)
+	if err != nil {
+		return fmt.Errorf("failed to update wallet status: %w", err)
+	}
+
+	return nil
+}
+
 func (r *WalletRepository) GetByUserIDAndCoinType(userID uuid.UUID, coinType models.CoinType) (*models.Wallet, error) {
-	query := `SELECT id, user_id, coin_type, amount, frozen_amount, created_at FROM wallets WHERE user_id = $1 AND coin_type = $2`
+	query := `SELECT ` + walletColumns + ` FROM wallets WHERE user_id = $1 AND coin_type = $2 AND status <> 'CLOSED'`
+
+	wallet, err := scanWallet(r.db.QueryRow(query, userID, coinType))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get wallet by user ID and coin type: %w", err)
+	}
+
+	return wallet, nil
+}
 
+func scanWallet(row rowScanner) (*models.Wallet, error) {
 	var wallet models.Wallet
-	err := r.db.QueryRow(query, userID, coinType).Scan(
+	err := row.Scan(
 		&wallet.ID,
 		&wallet.UserID,
 		&wallet.CoinType,
 		&wallet.Amount,
 		&wallet.FrozenAmount,
+		&wallet.Status,
 		&wallet.CreatedAt,
+		&wallet.ClosedAt,
 	)
-
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, nil
-		}
-		return nil, fmt.Errorf("failed to get wallet by user ID and coin type: %w", err)
+		return nil, err
 	}
 
 	return &wallet, nil
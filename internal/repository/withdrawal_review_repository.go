@@ -0,0 +1,161 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"wallet-service/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/shopspring/decimal"
+)
+
+const withdrawalReviewColumns = `transaction_id, wallet_id, user_id, coin_type, amount, status, reviewed_by, review_note, created_at, decided_at`
+
+type WithdrawalReviewRepository struct {
+	db *sql.DB
+}
+
+func NewWithdrawalReviewRepository(db *sql.DB) *WithdrawalReviewRepository {
+	return &WithdrawalReviewRepository{db: db}
+}
+
+func (r *WithdrawalReviewRepository) Create(ctx context.Context, tx *sql.Tx, review *models.WithdrawalReview) error {
+	if review.Status == "" {
+		review.Status = models.WithdrawalReviewPending
+	}
+
+	query := `INSERT INTO withdrawal_reviews (transaction_id, wallet_id, user_id, coin_type, amount, status)
+		VALUES ($1, $2, $3, $4, $5, $6) RETURNING created_at`
+
+	err := tx.QueryRowContext(ctx, query,
+		review.TransactionID,
+		review.WalletID,
+		review.UserID,
+		review.CoinType,
+		review.Amount,
+		review.Status,
+	).Scan(&review.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create withdrawal review: %w", err)
+	}
+
+	return nil
+}
+
+func (r *WithdrawalReviewRepository) GetByTransactionID(ctx context.Context, transactionID uuid.UUID) (*models.WithdrawalReview, error) {
+	query := `SELECT ` + withdrawalReviewColumns + ` FROM withdrawal_reviews WHERE transaction_id = $1`
+
+	review, err := scanWithdrawalReview(r.db.QueryRowContext(ctx, query, transactionID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get withdrawal review: %w", err)
+	}
+
+	return review, nil
+}
+
+func (r *WithdrawalReviewRepository) List(ctx context.Context, status models.WithdrawalReviewStatus, userID *uuid.UUID, walletIDs []uuid.UUID, limit, offset int) ([]models.WithdrawalReview, int, error) {
+	where := ` WHERE 1 = 1`
+	var args []interface{}
+	if status != "" {
+		args = append(args, status)
+		where += fmt.Sprintf(" AND status = $%d", len(args))
+	}
+	if userID != nil {
+		args = append(args, *userID)
+		where += fmt.Sprintf(" AND user_id = $%d", len(args))
+	}
+	if len(walletIDs) > 0 {
+		args = append(args, pq.Array(walletIDs))
+		where += fmt.Sprintf(" AND wallet_id = ANY($%d)", len(args))
+	}
+
+	var total int
+	if err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM withdrawal_reviews`+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to get withdrawal review count: %w", err)
+	}
+
+	args = append(args, limit, offset)
+	query := `SELECT ` + withdrawalReviewColumns + ` FROM withdrawal_reviews` + where + fmt.Sprintf(" ORDER BY created_at LIMIT $%d OFFSET $%d", len(args)-1, len(args))
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list withdrawal reviews: %w", err)
+	}
+	defer rows.Close()
+
+	reviews := []models.WithdrawalReview{}
+	for rows.Next() {
+		review, err := scanWithdrawalReview(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan withdrawal review: %w", err)
+		}
+		reviews = append(reviews, *review)
+	}
+
+	return reviews, total, rows.Err()
+}
+
+func (r *WithdrawalReviewRepository) GetByTransactionIDForUpdate(ctx context.Context, tx *sql.Tx, transactionID uuid.UUID) (*models.WithdrawalReview, error) {
+	query := `SELECT ` + withdrawalReviewColumns + ` FROM withdrawal_reviews WHERE transaction_id = $1 FOR UPDATE`
+
+	review, err := scanWithdrawalReview(tx.QueryRowContext(ctx, query, transactionID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to lock withdrawal review: %w", err)
+	}
+
+	return review, nil
+}
+
+func (r *WithdrawalReviewRepository) Decide(ctx context.Context, tx *sql.Tx, transactionID uuid.UUID, status models.WithdrawalReviewStatus, reviewerID *uuid.UUID, note string) error {
+	query := `UPDATE withdrawal_reviews
+		SET status = $1, reviewed_by = $2, review_note = $3, decided_at = NOW()
+		WHERE transaction_id = $4`
+
+	_, err := tx.ExecContext(ctx, query, status, reviewerID, note, transactionID)
+	if err != nil {
+		return fmt.Errorf("failed to decide withdrawal review: %w", err)
+	}
+
+	return nil
+}
+
+func (r *WithdrawalReviewRepository) SumPending(ctx context.Context, tx *sql.Tx, walletIDs []uuid.UUID) (decimal.Decimal, error) {
+	query := `SELECT COALESCE(SUM(amount), 0) FROM withdrawal_reviews WHERE wallet_id = ANY($1) AND status = 'PENDING'`
+
+	var total decimal.Decimal
+	if err := tx.QueryRowContext(ctx, query, pq.Array(walletIDs)).Scan(&total); err != nil {
+		return decimal.Zero, fmt.Errorf("failed to sum pending withdrawals: %w", err)
+	}
+
+	return total, nil
+}
+
+func scanWithdrawalReview(row rowScanner) (*models.WithdrawalReview, error) {
+	var review models.WithdrawalReview
+	err := row.Scan(
+		&review.TransactionID,
+		&review.WalletID,
+		&review.UserID,
+		&review.CoinType,
+		&review.Amount,
+		&review.Status,
+		&review.ReviewedBy,
+		&review.ReviewNote,
+		&review.CreatedAt,
+		&review.DecidedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &review, nil
+}
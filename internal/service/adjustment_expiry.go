@@ -0,0 +1,128 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"wallet-service/internal/audit"
+	"wallet-service/internal/models"
+	"wallet-service/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// adjustmentExpiryBatchSize is how many overdue adjustments one sweep expires
+const adjustmentExpiryBatchSize = 100
+
+// AdjustmentExpiry closes balance adjustments nobody reviewed in time. It is
+// the only place an adjustment becomes EXPIRED, and each expiry is written to
+// the audit log in the same transaction as the status change.
+type AdjustmentExpiry struct {
+	adjustmentRepo repository.IAdjustmentRepository
+	txManager      repository.ITransactionManager
+	auditor        *audit.Auditor
+}
+
+func NewAdjustmentExpiry(adjustmentRepo repository.IAdjustmentRepository, txManager repository.ITransactionManager, auditor *audit.Auditor) *AdjustmentExpiry {
+	return &AdjustmentExpiry{
+		adjustmentRepo: adjustmentRepo,
+		txManager:      txManager,
+		auditor:        auditor,
+	}
+}
+
+// Start expires overdue adjustments on every interval until ctx is done
+func (e *AdjustmentExpiry) Start(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if _, err := e.Sweep(ctx); err != nil {
+					log.Printf("Warning: adjustment expiry failed: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+// Sweep expires every pending adjustment past its expiry, one transaction
+// each. An adjustment that cannot be expired does not hold up the rest; the
+// errors are returned together at the end.
+func (e *AdjustmentExpiry) Sweep(ctx context.Context) (int, error) {
+	expired := 0
+	var errs []error
+	for {
+		overdue, err := e.adjustmentRepo.GetOverdue(ctx, adjustmentExpiryBatchSize)
+		if err != nil {
+			return expired, errors.Join(append(errs, err)...)
+		}
+
+		progressed := false
+		for i := range overdue {
+			done := false
+			err := e.txManager.ExecuteTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+				// Lock and re-read: a reviewer may have decided it meanwhile
+				adjustment, err := e.adjustmentRepo.GetByIDForUpdate(ctx, tx, overdue[i].ID)
+				if err != nil || adjustment == nil || adjustment.Status != models.AdjustmentStatusPending {
+					return err
+				}
+				done = true
+				return e.Expire(ctx, tx, adjustment)
+			})
+			if err != nil {
+				log.Printf("Warning: failed to expire adjustment %s: %v", overdue[i].ID, err)
+				errs = append(errs, err)
+				continue
+			}
+			progressed = true
+			if done {
+				expired++
+			}
+		}
+
+		// Stop on a short batch, or when nothing in a full one could be
+		// expired and the next read would return it again
+		if len(overdue) < adjustmentExpiryBatchSize || !progressed {
+			return expired, errors.Join(errs...)
+		}
+	}
+}
+
+// Expire marks a locked pending adjustment EXPIRED and records it in the
+// audit log, both inside tx
+func (e *AdjustmentExpiry) Expire(ctx context.Context, tx *sql.Tx, adjustment *models.BalanceAdjustment) error {
+	if err := e.adjustmentRepo.Review(ctx, tx, adjustment.ID, models.AdjustmentStatusExpired, nil, "", nil); err != nil {
+		return err
+	}
+
+	amount := adjustment.Amount
+	entry := &models.AuditLog{
+		Action:    "adjustment.expire",
+		Method:    "SYSTEM",
+		Route:     "adjustment-expiry",
+		WalletIDs: []uuid.UUID{adjustment.WalletID},
+		Amount:    &amount,
+		Outcome:   models.AuditOutcomeSuccess,
+	}
+	entry.Details, _ = json.Marshal(map[string]string{
+		"adjustment_id": adjustment.ID.String(),
+		"direction":     string(adjustment.Direction),
+		"ticket_ref":    adjustment.TicketRef,
+		"requested_by":  adjustment.RequestedBy.String(),
+		"expires_at":    adjustment.ExpiresAt.UTC().Format(time.RFC3339),
+	})
+	if err := e.auditor.RecordTx(ctx, tx, entry); err != nil {
+		return fmt.Errorf("failed to audit adjustment expiry: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"wallet-service/internal/audit"
+	"wallet-service/internal/models"
+	"wallet-service/internal/repository"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+func TestAdjustmentExpiry_Sweep(t *testing.T) {
+	adjustmentRepo := repository.NewMockAdjustmentRepository()
+	auditRepo := repository.NewMockAuditRepository()
+	expiry := NewAdjustmentExpiry(adjustmentRepo, repository.NewMockTransactionManager(), audit.NewAuditor(auditRepo, nil))
+
+	overdue := &models.BalanceAdjustment{ID: uuid.New(), WalletID: uuid.New(), Direction: models.DirectionIn, Amount: decimal.NewFromInt(3), TicketRef: "OPS-7", ExpiresAt: time.Now().Add(-time.Minute)}
+	open := &models.BalanceAdjustment{ID: uuid.New(), WalletID: uuid.New(), Direction: models.DirectionOut, Amount: decimal.NewFromInt(1), ExpiresAt: time.Now().Add(time.Hour)}
+	adjustmentRepo.Create(context.Background(), overdue)
+	adjustmentRepo.Create(context.Background(), open)
+
+	expired, err := expiry.Sweep(context.Background())
+	if err != nil || expired != 1 {
+		t.Fatalf("Expected 1 adjustment expired, got %d (%v)", expired, err)
+	}
+	if overdue.Status != models.AdjustmentStatusExpired || open.Status != models.AdjustmentStatusPending {
+		t.Errorf("Unexpected statuses %s, %s", overdue.Status, open.Status)
+	}
+
+	logs, total, _ := auditRepo.Query(&models.AuditLogQuery{Limit: 10})
+	if total != 1 || logs[0].Action != "adjustment.expire" || len(logs[0].WalletIDs) != 1 || logs[0].WalletIDs[0] != overdue.WalletID {
+		t.Fatalf("Expected one audit record for the expiry, got %+v", logs)
+	}
+	var details map[string]string
+	json.Unmarshal(logs[0].Details, &details)
+	if details["adjustment_id"] != overdue.ID.String() || details["ticket_ref"] != "OPS-7" {
+		t.Errorf("Unexpected audit details %v", details)
+	}
+
+	// Nothing is left to expire
+	if expired, _ := expiry.Sweep(context.Background()); expired != 0 {
+		t.Errorf("Expected nothing expired on a second sweep, got %d", expired)
+	}
+}
@@ -0,0 +1,289 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+
+	"wallet-service/internal/events"
+	"wallet-service/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// Ledger primitives. They run inside the caller's database transaction, on
+// wallets the caller has locked, so a freeze or close that commits first is
+// always seen.
+
+// lockWallets locks the wallets for the rest of the transaction and returns
+// their current state in the order given. Rows are locked in ID order so two
+// transfers between the same wallets cannot deadlock.
+func (s *WalletService) lockWallets(ctx context.Context, tx *sql.Tx, ids ...uuid.UUID) ([]*models.Wallet, error) {
+	order := append([]uuid.UUID(nil), ids...)
+	sort.Slice(order, func(i, j int) bool { return order[i].String() < order[j].String() })
+
+	locked := make(map[uuid.UUID]*models.Wallet, len(order))
+	for _, id := range order {
+		if _, ok := locked[id]; ok {
+			continue
+		}
+		wallet, err := s.walletRepo.GetByIDForUpdate(ctx, tx, id)
+		if err != nil {
+			return nil, err
+		}
+		if wallet == nil {
+			return nil, ErrWalletNotFound
+		}
+		locked[id] = wallet
+	}
+
+	wallets := make([]*models.Wallet, len(ids))
+	for i, id := range ids {
+		wallets[i] = locked[id]
+	}
+	return wallets, nil
+}
+
+// requireActive refuses wallets that are frozen, closing or closed
+func requireActive(wallets ...*models.Wallet) error {
+	for _, wallet := range wallets {
+		if wallet.Status != models.WalletStatusActive {
+			return &WalletNotActiveError{WalletID: wallet.ID, Status: wallet.Status}
+		}
+	}
+	return nil
+}
+
+// checkKYCLimit refuses an outflow of amount from a locked wallet that would
+// take its owner past their tier's limit for the coin: completed withdrawals
+// and transfers within the window, and withdrawals still held, count towards
+// it. A user has one open wallet per coin, so holding its lock keeps a
+// concurrent outflow from counting the same headroom.
+func (s *WalletService) checkKYCLimit(ctx context.Context, tx *sql.Tx, wallet *models.Wallet, amount decimal.Decimal) error {
+	if s.kycPolicy == nil {
+		return nil
+	}
+
+	user, err := s.userRepo.GetByID(wallet.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+	if user == nil {
+		return ErrForbidden
+	}
+	limit, capped := s.kycPolicy.Limit(user.KYCTier, wallet.CoinType)
+	if !capped {
+		return nil
+	}
+
+	// Closed wallets in the coin count too, so closing one frees no headroom
+	wallets, err := s.walletRepo.GetByUserID(wallet.UserID)
+	if err != nil {
+		return err
+	}
+	var walletIDs []uuid.UUID
+	for i := range wallets {
+		if wallets[i].CoinType == wallet.CoinType {
+			walletIDs = append(walletIDs, wallets[i].ID)
+		}
+	}
+
+	sent, err := s.transactionRepo.SumOutflows(ctx, tx, walletIDs, s.kycPolicy.Window())
+	if err != nil {
+		return err
+	}
+	held, err := s.withdrawalReviewRepo.SumPending(ctx, tx, walletIDs)
+	if err != nil {
+		return err
+	}
+
+	used := sent.Add(held)
+	if !s.kycPolicy.Allows(user.KYCTier, wallet.CoinType, used, amount) {
+		return &KYCLimitError{Tier: user.KYCTier, CoinType: wallet.CoinType, Limit: limit, Used: used, Window: s.kycPolicy.Window()}
+	}
+	return nil
+}
+
+// post books the receipt's single-entry movement against a locked wallet
+func (s *WalletService) post(ctx context.Context, tx *sql.Tx, receipt *Receipt, wallet *models.Wallet, direction models.Direction) error {
+	transaction := &models.Transaction{
+		ID:     receipt.TransactionID,
+		Type:   receipt.Type,
+		Status: models.TransactionStatusDone,
+	}
+	if err := s.transactionRepo.CreateTransaction(ctx, tx, transaction); err != nil {
+		return fmt.Errorf("failed to create transaction: %w", err)
+	}
+
+	return s.book(ctx, tx, transaction, wallet, direction, receipt.Amount)
+}
+
+// book writes one entry of an existing transaction, updates the wallet's
+// balance and records the events
+func (s *WalletService) book(ctx context.Context, tx *sql.Tx, transaction *models.Transaction, wallet *models.Wallet, direction models.Direction, amount decimal.Decimal) error {
+	newAmount := wallet.Amount.Add(amount)
+	if direction == models.DirectionOut {
+		newAmount = wallet.Amount.Sub(amount)
+	}
+	if err := s.walletRepo.UpdateAmount(ctx, tx, wallet.ID, newAmount); err != nil {
+		return fmt.Errorf("failed to update wallet amount: %w", err)
+	}
+
+	entry := &models.TransactionEntry{
+		ID:        uuid.New(),
+		TxnID:     transaction.ID,
+		WalletID:  wallet.ID,
+		Direction: direction,
+		Amount:    amount,
+	}
+	if err := s.transactionRepo.CreateTransactionEntry(ctx, tx, entry); err != nil {
+		return fmt.Errorf("failed to create transaction entry: %w", err)
+	}
+
+	return s.recordEvents(ctx, tx, transaction, []events.BalanceChange{
+		{Wallet: wallet, Entry: entry, Balance: newAmount},
+	})
+}
+
+// hold creates the receipt's PENDING withdrawal and moves its amount into the
+// wallet's frozen balance. Nothing is debited until an operator approves it.
+func (s *WalletService) hold(ctx context.Context, tx *sql.Tx, receipt *Receipt, wallet *models.Wallet) error {
+	transaction := &models.Transaction{
+		ID:     receipt.TransactionID,
+		Type:   models.TransactionTypeWithdrawal,
+		Status: models.TransactionStatusPending,
+	}
+	if err := s.transactionRepo.CreateTransaction(ctx, tx, transaction); err != nil {
+		return fmt.Errorf("failed to create transaction: %w", err)
+	}
+
+	if err := s.walletRepo.UpdateFrozenAmount(ctx, tx, wallet.ID, wallet.FrozenAmount.Add(receipt.Amount)); err != nil {
+		return fmt.Errorf("failed to update wallet frozen amount: %w", err)
+	}
+
+	review := &models.WithdrawalReview{
+		TransactionID: transaction.ID,
+		WalletID:      wallet.ID,
+		UserID:        wallet.UserID,
+		CoinType:      wallet.CoinType,
+		Amount:        receipt.Amount,
+	}
+	if err := s.withdrawalReviewRepo.Create(ctx, tx, review); err != nil {
+		return err
+	}
+
+	return s.recordWithdrawalReview(ctx, tx, review)
+}
+
+// transfer moves amount between two wallets locked by the caller's transaction
+func (s *WalletService) transfer(ctx context.Context, tx *sql.Tx, transactionID uuid.UUID, senderWallet, receiverWallet *models.Wallet, amount decimal.Decimal) error {
+	transaction := &models.Transaction{
+		ID:     transactionID,
+		Type:   models.TransactionTypeTransfer,
+		Status: models.TransactionStatusDone,
+	}
+	if err := s.transactionRepo.CreateTransaction(ctx, tx, transaction); err != nil {
+		return fmt.Errorf("failed to create transaction: %w", err)
+	}
+
+	senderNewAmount := senderWallet.Amount.Sub(amount)
+	if err := s.walletRepo.UpdateAmount(ctx, tx, senderWallet.ID, senderNewAmount); err != nil {
+		return fmt.Errorf("failed to update sender wallet amount: %w", err)
+	}
+
+	receiverNewAmount := receiverWallet.Amount.Add(amount)
+	if err := s.walletRepo.UpdateAmount(ctx, tx, receiverWallet.ID, receiverNewAmount); err != nil {
+		return fmt.Errorf("failed to update receiver wallet amount: %w", err)
+	}
+
+	senderEntry := &models.TransactionEntry{
+		ID:                   uuid.New(),
+		TxnID:                transaction.ID,
+		WalletID:             senderWallet.ID,
+		Direction:            models.DirectionOut,
+		Amount:               amount,
+		CounterpartyWalletID: &receiverWallet.ID,
+	}
+	receiverEntry := &models.TransactionEntry{
+		ID:                   uuid.New(),
+		TxnID:                transaction.ID,
+		WalletID:             receiverWallet.ID,
+		Direction:            models.DirectionIn,
+		Amount:               amount,
+		CounterpartyWalletID: &senderWallet.ID,
+	}
+
+	if err := s.transactionRepo.CreateTransactionEntry(ctx, tx, senderEntry); err != nil {
+		return fmt.Errorf("failed to create sender transaction entry: %w", err)
+	}
+	if err := s.transactionRepo.CreateTransactionEntry(ctx, tx, receiverEntry); err != nil {
+		return fmt.Errorf("failed to create receiver transaction entry: %w", err)
+	}
+
+	return s.recordEvents(ctx, tx, transaction, []events.BalanceChange{
+		{Wallet: senderWallet, Entry: senderEntry, Balance: senderNewAmount},
+		{Wallet: receiverWallet, Entry: receiverEntry, Balance: receiverNewAmount},
+	})
+}
+
+// BookAdjustment applies a staff adjustment to a wallet inside the caller's
+// transaction and returns its ADJUSTMENT transaction. Only closed wallets are
+// refused: correcting a frozen wallet is often the point.
+func (s *WalletService) BookAdjustment(ctx context.Context, tx *sql.Tx, walletID uuid.UUID, direction models.Direction, amount decimal.Decimal) (uuid.UUID, error) {
+	locked, err := s.lockWallets(ctx, tx, walletID)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	wallet := locked[0]
+	if wallet.Status == models.WalletStatusClosed {
+		return uuid.Nil, &WalletNotActiveError{WalletID: wallet.ID, Status: wallet.Status}
+	}
+	if direction == models.DirectionOut && available(wallet).LessThan(amount) {
+		return uuid.Nil, ErrInsufficientFunds
+	}
+
+	receipt := &Receipt{
+		TransactionID: uuid.New(),
+		Type:          models.TransactionTypeAdjustment,
+		Status:        models.TransactionStatusDone,
+		Amount:        amount,
+	}
+	if err := s.post(ctx, tx, receipt, wallet, direction); err != nil {
+		return uuid.Nil, err
+	}
+	return receipt.TransactionID, nil
+}
+
+// recordEvents writes the domain events of a ledger change to the outbox
+// within the same database transaction
+func (s *WalletService) recordEvents(ctx context.Context, tx *sql.Tx, transaction *models.Transaction, changes []events.BalanceChange) error {
+	outboxEvents, err := events.ForTransaction(transaction, changes)
+	if err != nil {
+		return err
+	}
+
+	for _, event := range outboxEvents {
+		if err := s.outboxRepo.Create(ctx, tx, event); err != nil {
+			return fmt.Errorf("failed to write %s event: %w", event.EventType, err)
+		}
+	}
+
+	return nil
+}
+
+// recordWithdrawalReview writes a WithdrawalStatusChanged event for the
+// review's current status to the outbox
+func (s *WalletService) recordWithdrawalReview(ctx context.Context, tx *sql.Tx, review *models.WithdrawalReview) error {
+	event, err := events.ForWithdrawalReview(review)
+	if err != nil {
+		return err
+	}
+
+	if err := s.outboxRepo.Create(ctx, tx, event); err != nil {
+		return fmt.Errorf("failed to write %s event: %w", event.EventType, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,476 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"wallet-service/internal/kyc"
+	"wallet-service/internal/models"
+	"wallet-service/internal/repository"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// Errors returned by WalletService. Frontends map them to their own status
+// codes; anything else is an internal failure whose details stay in the logs.
+var (
+	ErrWalletNotFound       = errors.New("wallet not found")
+	ErrForbidden            = errors.New("access denied")
+	ErrInvalidAmount        = errors.New("amount must be positive")
+	ErrInsufficientFunds    = errors.New("insufficient funds")
+	ErrSameWallet           = errors.New("source and destination are the same wallet")
+	ErrCoinMismatch         = errors.New("wallets hold different coins")
+	ErrWalletExists         = errors.New("a wallet in this coin is already open")
+	ErrWalletNotActive      = errors.New("wallet not active")
+	ErrSweepTargetRequired  = errors.New("sweep target required")
+	ErrWithdrawalNotFound   = errors.New("withdrawal not found")
+	ErrWithdrawalNotPending = errors.New("withdrawal no longer pending")
+	ErrKYCLimitExceeded     = errors.New("kyc limit exceeded")
+)
+
+// WalletNotActiveError reports a wallet whose status keeps it from moving
+// money. It matches ErrWalletNotActive with errors.Is.
+type WalletNotActiveError struct {
+	WalletID uuid.UUID
+	Status   models.WalletStatus
+}
+
+func (e *WalletNotActiveError) Error() string {
+	return fmt.Sprintf("wallet %s is %s", e.WalletID, strings.ToLower(string(e.Status)))
+}
+
+func (e *WalletNotActiveError) Is(target error) bool {
+	return target == ErrWalletNotActive
+}
+
+// KYCLimitError reports an outflow that would take the user past their
+// tier's limit for the coin. Used is what already left within the window.
+// It matches ErrKYCLimitExceeded with errors.Is.
+type KYCLimitError struct {
+	Tier     models.KYCTier
+	CoinType models.CoinType
+	Limit    decimal.Decimal
+	Used     decimal.Decimal
+	Window   time.Duration
+}
+
+func (e *KYCLimitError) Error() string {
+	return fmt.Sprintf("%s limit of %s %s per %s exceeded", e.Tier, e.Limit, e.CoinType, e.Window)
+}
+
+func (e *KYCLimitError) Is(target error) bool {
+	return target == ErrKYCLimitExceeded
+}
+
+// Receipt describes a money movement. A withdrawal held for review has a
+// PENDING status and has not moved anything yet.
+type Receipt struct {
+	TransactionID uuid.UUID
+	Type          models.TransactionType
+	Status        models.TransactionStatus
+	Amount        decimal.Decimal
+}
+
+// Claim runs first inside a money movement's database transaction, before
+// anything moves, e.g. to record the response under an idempotency key. An
+// error rolls the movement back. A nil Claim does nothing.
+type Claim func(ctx context.Context, tx *sql.Tx, receipt *Receipt) error
+
+func (c Claim) run(ctx context.Context, tx *sql.Tx, receipt *Receipt) error {
+	if c == nil {
+		return nil
+	}
+	return c(ctx, tx, receipt)
+}
+
+// Closure describes a wallet close: CLOSED, with the remainder swept to
+// SweepToWalletID, or CLOSING while frozen funds are still held
+type Closure struct {
+	WalletID        uuid.UUID
+	Status          models.WalletStatus
+	SweptAmount     decimal.Decimal
+	SweepToWalletID *uuid.UUID
+}
+
+// CloseClaim is the Claim of a wallet close
+type CloseClaim func(ctx context.Context, tx *sql.Tx, closure *Closure) error
+
+// WalletService holds the business rules of wallets: ownership, balances,
+// wallet status and the review of large withdrawals. Every money movement
+// locks its wallets and writes its ledger rows and outbox events in one
+// database transaction. It knows nothing of HTTP, so other frontends can
+// reuse it.
+type WalletService struct {
+	walletRepo           repository.IWalletRepository
+	transactionRepo      repository.ITransactionRepository
+	outboxRepo           repository.IOutboxRepository
+	withdrawalReviewRepo repository.IWithdrawalReviewRepository
+	userRepo             repository.IUserRepository
+	txManager            repository.ITransactionManager
+	// Withdrawals above their coin's threshold are held for review
+	reviewThresholds map[models.CoinType]decimal.Decimal
+	// kycPolicy caps each user's outflows by tier; nil leaves them uncapped
+	kycPolicy *kyc.Policy
+}
+
+func NewWalletService(walletRepo repository.IWalletRepository, transactionRepo repository.ITransactionRepository, outboxRepo repository.IOutboxRepository, withdrawalReviewRepo repository.IWithdrawalReviewRepository, userRepo repository.IUserRepository, txManager repository.ITransactionManager, reviewThresholds map[models.CoinType]decimal.Decimal, kycPolicy *kyc.Policy) *WalletService {
+	return &WalletService{
+		walletRepo:           walletRepo,
+		transactionRepo:      transactionRepo,
+		outboxRepo:           outboxRepo,
+		withdrawalReviewRepo: withdrawalReviewRepo,
+		userRepo:             userRepo,
+		txManager:            txManager,
+		reviewThresholds:     reviewThresholds,
+		kycPolicy:            kycPolicy,
+	}
+}
+
+// Wallet returns any wallet, for staff
+func (s *WalletService) Wallet(walletID uuid.UUID) (*models.Wallet, error) {
+	wallet, err := s.walletRepo.GetByID(walletID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get wallet: %w", err)
+	}
+	if wallet == nil {
+		return nil, ErrWalletNotFound
+	}
+	return wallet, nil
+}
+
+// OwnedWallet returns one of userID's wallets
+func (s *WalletService) OwnedWallet(userID, walletID uuid.UUID) (*models.Wallet, error) {
+	wallet, err := s.Wallet(walletID)
+	if err != nil {
+		return nil, err
+	}
+	if wallet.UserID != userID {
+		return nil, ErrForbidden
+	}
+	return wallet, nil
+}
+
+// ListWallets returns the user's wallets; closed wallets are archived out of
+// the list unless includeClosed is set
+func (s *WalletService) ListWallets(userID uuid.UUID, includeClosed bool) ([]models.Wallet, error) {
+	wallets, err := s.walletRepo.GetByUserID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user wallets: %w", err)
+	}
+
+	if !includeClosed {
+		open := make([]models.Wallet, 0, len(wallets))
+		for _, wallet := range wallets {
+			if wallet.Status != models.WalletStatusClosed {
+				open = append(open, wallet)
+			}
+		}
+		wallets = open
+	}
+
+	return wallets, nil
+}
+
+func (s *WalletService) Balance(userID, walletID uuid.UUID) (*models.BalanceResponse, error) {
+	wallet, err := s.OwnedWallet(userID, walletID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.BalanceResponse{
+		WalletID:     wallet.ID,
+		CoinType:     wallet.CoinType,
+		Amount:       wallet.Amount,
+		FrozenAmount: wallet.FrozenAmount,
+		Status:       wallet.Status,
+	}, nil
+}
+
+func (s *WalletService) History(userID, walletID uuid.UUID, req *models.TransactionHistoryRequest) (*models.TransactionHistoryResponse, error) {
+	if _, err := s.OwnedWallet(userID, walletID); err != nil {
+		return nil, err
+	}
+
+	history, err := s.transactionRepo.GetTransactionHistory(walletID, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transaction history: %w", err)
+	}
+	return history, nil
+}
+
+// OpenWallet opens a wallet in a coin the user has no open wallet in
+func (s *WalletService) OpenWallet(ctx context.Context, userID uuid.UUID, coinType models.CoinType) (*models.Wallet, error) {
+	wallet := &models.Wallet{
+		ID:           uuid.New(),
+		UserID:       userID,
+		CoinType:     coinType,
+		Amount:       decimal.Zero,
+		FrozenAmount: decimal.Zero,
+		Status:       models.WalletStatusActive,
+	}
+
+	var created bool
+	err := s.txManager.ExecuteTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		var err error
+		created, err = s.walletRepo.Create(ctx, tx, wallet)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open wallet: %w", err)
+	}
+	if !created {
+		return nil, ErrWalletExists
+	}
+
+	return wallet, nil
+}
+
+func (s *WalletService) Deposit(ctx context.Context, userID, walletID uuid.UUID, amount decimal.Decimal, claim Claim) (*Receipt, error) {
+	if !amount.IsPositive() {
+		return nil, ErrInvalidAmount
+	}
+	wallet, err := s.OwnedWallet(userID, walletID)
+	if err != nil {
+		return nil, err
+	}
+
+	receipt := &Receipt{
+		TransactionID: uuid.New(),
+		Type:          models.TransactionTypeDeposit,
+		Status:        models.TransactionStatusDone,
+		Amount:        amount,
+	}
+	err = s.txManager.ExecuteTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		// Claim first: a concurrent duplicate blocks here until this
+		// transaction ends, then fails instead of moving money twice
+		if err := claim.run(ctx, tx, receipt); err != nil {
+			return err
+		}
+
+		locked, err := s.lockWallets(ctx, tx, wallet.ID)
+		if err != nil {
+			return err
+		}
+		if err := requireActive(locked...); err != nil {
+			return err
+		}
+
+		return s.post(ctx, tx, receipt, locked[0], models.DirectionIn)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return receipt, nil
+}
+
+// Withdraw debits the wallet, or, above the coin's review threshold, holds
+// the amount in the frozen balance and returns a PENDING receipt
+func (s *WalletService) Withdraw(ctx context.Context, userID, walletID uuid.UUID, amount decimal.Decimal, claim Claim) (*Receipt, error) {
+	if !amount.IsPositive() {
+		return nil, ErrInvalidAmount
+	}
+	wallet, err := s.OwnedWallet(userID, walletID)
+	if err != nil {
+		return nil, err
+	}
+	if available(wallet).LessThan(amount) {
+		return nil, ErrInsufficientFunds
+	}
+
+	receipt := &Receipt{
+		TransactionID: uuid.New(),
+		Type:          models.TransactionTypeWithdrawal,
+		Status:        models.TransactionStatusDone,
+		Amount:        amount,
+	}
+	held := s.needsReview(wallet.CoinType, amount)
+	if held {
+		receipt.Status = models.TransactionStatusPending
+	}
+
+	err = s.txManager.ExecuteTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		// Claim first: a concurrent duplicate blocks here until this
+		// transaction ends, then fails instead of moving money twice
+		if err := claim.run(ctx, tx, receipt); err != nil {
+			return err
+		}
+
+		locked, err := s.lockWallets(ctx, tx, wallet.ID)
+		if err != nil {
+			return err
+		}
+		wallet := locked[0]
+		if err := requireActive(wallet); err != nil {
+			return err
+		}
+		if available(wallet).LessThan(amount) {
+			return ErrInsufficientFunds
+		}
+		if err := s.checkKYCLimit(ctx, tx, wallet, amount); err != nil {
+			return err
+		}
+
+		if held {
+			return s.hold(ctx, tx, receipt, wallet)
+		}
+		return s.post(ctx, tx, receipt, wallet, models.DirectionOut)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return receipt, nil
+}
+
+// Transfer moves amount from one of the user's wallets to any active wallet
+// in the same coin
+func (s *WalletService) Transfer(ctx context.Context, userID, senderWalletID, receiverWalletID uuid.UUID, amount decimal.Decimal, claim Claim) (*Receipt, error) {
+	if !amount.IsPositive() {
+		return nil, ErrInvalidAmount
+	}
+	if senderWalletID == receiverWalletID {
+		return nil, ErrSameWallet
+	}
+	senderWallet, err := s.OwnedWallet(userID, senderWalletID)
+	if err != nil {
+		return nil, err
+	}
+	receiverWallet, err := s.Wallet(receiverWalletID)
+	if err != nil {
+		return nil, err
+	}
+	if receiverWallet.CoinType != senderWallet.CoinType {
+		return nil, ErrCoinMismatch
+	}
+	if available(senderWallet).LessThan(amount) {
+		return nil, ErrInsufficientFunds
+	}
+
+	receipt := &Receipt{
+		TransactionID: uuid.New(),
+		Type:          models.TransactionTypeTransfer,
+		Status:        models.TransactionStatusDone,
+		Amount:        amount,
+	}
+	err = s.txManager.ExecuteTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		// Claim first: a concurrent duplicate blocks here until this
+		// transaction ends, then fails instead of moving money twice
+		if err := claim.run(ctx, tx, receipt); err != nil {
+			return err
+		}
+
+		locked, err := s.lockWallets(ctx, tx, senderWallet.ID, receiverWallet.ID)
+		if err != nil {
+			return err
+		}
+		if err := requireActive(locked...); err != nil {
+			return err
+		}
+		if available(locked[0]).LessThan(amount) {
+			return ErrInsufficientFunds
+		}
+		if err := s.checkKYCLimit(ctx, tx, locked[0], amount); err != nil {
+			return err
+		}
+
+		return s.transfer(ctx, tx, receipt.TransactionID, locked[0], locked[1], amount)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return receipt, nil
+}
+
+// CloseWallet closes one of the user's wallets. A remaining balance is swept
+// to sweepTo, an active wallet in the same coin, in the same transaction. A
+// wallet still holding frozen funds becomes CLOSING instead: it stops moving
+// money, and closing it again once nothing is held finishes.
+func (s *WalletService) CloseWallet(ctx context.Context, userID, walletID uuid.UUID, sweepTo *uuid.UUID, claim CloseClaim) (*Closure, error) {
+	wallet, err := s.OwnedWallet(userID, walletID)
+	if err != nil {
+		return nil, err
+	}
+	if sweepTo != nil && *sweepTo == wallet.ID {
+		return nil, ErrSameWallet
+	}
+
+	var closure *Closure
+	err = s.txManager.ExecuteTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		ids := []uuid.UUID{wallet.ID}
+		if sweepTo != nil {
+			ids = append(ids, *sweepTo)
+		}
+		locked, err := s.lockWallets(ctx, tx, ids...)
+		if err != nil {
+			return err
+		}
+
+		source := locked[0]
+		if source.Status != models.WalletStatusActive && source.Status != models.WalletStatusClosing {
+			return &WalletNotActiveError{WalletID: source.ID, Status: source.Status}
+		}
+
+		closure = &Closure{WalletID: source.ID, Status: models.WalletStatusClosed}
+		if source.FrozenAmount.IsPositive() {
+			closure.Status = models.WalletStatusClosing
+		}
+
+		var target *models.Wallet
+		if closure.Status == models.WalletStatusClosed && source.Amount.IsPositive() {
+			if len(locked) < 2 {
+				return ErrSweepTargetRequired
+			}
+			target = locked[1]
+			if target.CoinType != source.CoinType {
+				return ErrCoinMismatch
+			}
+			if err := requireActive(target); err != nil {
+				return err
+			}
+			// The sweep leaves the user like any transfer
+			if err := s.checkKYCLimit(ctx, tx, source, source.Amount); err != nil {
+				return err
+			}
+			closure.SweptAmount = source.Amount
+			closure.SweepToWalletID = &target.ID
+		}
+
+		// Claim before any money moves
+		if claim != nil {
+			if err := claim(ctx, tx, closure); err != nil {
+				return err
+			}
+		}
+
+		if target != nil {
+			if err := s.transfer(ctx, tx, uuid.New(), source, target, source.Amount); err != nil {
+				return err
+			}
+		}
+
+		return s.walletRepo.UpdateStatus(ctx, tx, source.ID, closure.Status)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return closure, nil
+}
+
+// available is the part of a wallet's balance not held by a pending withdrawal
+func available(wallet *models.Wallet) decimal.Decimal {
+	return wallet.Amount.Sub(wallet.FrozenAmount)
+}
+
+// needsReview reports whether a withdrawal of amount is held for review
+func (s *WalletService) needsReview(coinType models.CoinType, amount decimal.Decimal) bool {
+	threshold, ok := s.reviewThresholds[coinType]
+	return ok && amount.GreaterThan(threshold)
+}
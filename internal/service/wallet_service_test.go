@@ -0,0 +1,254 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"wallet-service/internal/kyc"
+	"wallet-service/internal/models"
+	"wallet-service/internal/repository"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+type testService struct {
+	*WalletService
+	walletRepo *repository.MockWalletRepository
+}
+
+func newTestService() *testService {
+	walletRepo := repository.NewMockWalletRepository()
+	thresholds := map[models.CoinType]decimal.Decimal{models.CoinTypeBTC: decimal.NewFromInt(1)}
+	return &testService{
+		WalletService: NewWalletService(walletRepo, repository.NewMockTransactionRepository(), repository.NewMockOutboxRepository(), repository.NewMockWithdrawalReviewRepository(), repository.NewMockUserRepository(), repository.NewMockTransactionManager(), thresholds, nil),
+		walletRepo:    walletRepo,
+	}
+}
+
+func (s *testService) wallet(userID uuid.UUID, coinType models.CoinType, amount int64) *models.Wallet {
+	wallet := &models.Wallet{ID: uuid.New(), UserID: userID, CoinType: coinType, Amount: decimal.NewFromInt(amount)}
+	s.walletRepo.Create(context.Background(), nil, wallet)
+	return wallet
+}
+
+func TestWalletService_LockWallets(t *testing.T) {
+	s := newTestService()
+
+	userID := uuid.New()
+	active := s.wallet(userID, models.CoinTypeBTC, 5)
+	frozen := s.wallet(userID, models.CoinTypeETH, 0)
+	frozen.Status = models.WalletStatusFrozen
+
+	locked, err := s.lockWallets(context.Background(), nil, frozen.ID, active.ID)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if locked[0].ID != frozen.ID || locked[1].ID != active.ID {
+		t.Error("Expected wallets in the order asked for")
+	}
+
+	var notActive *WalletNotActiveError
+	if err := requireActive(locked...); !errors.As(err, &notActive) || notActive.WalletID != frozen.ID {
+		t.Errorf("Expected frozen wallet refused, got %v", err)
+	}
+	if !errors.Is(requireActive(locked...), ErrWalletNotActive) {
+		t.Error("Expected WalletNotActiveError to match ErrWalletNotActive")
+	}
+	if err := requireActive(locked[1]); err != nil {
+		t.Errorf("Expected active wallet accepted, got %v", err)
+	}
+
+	if _, err := s.lockWallets(context.Background(), nil, active.ID, uuid.New()); !errors.Is(err, ErrWalletNotFound) {
+		t.Errorf("Expected ErrWalletNotFound, got %v", err)
+	}
+}
+
+func TestWalletService_NeedsReview(t *testing.T) {
+	s := newTestService()
+
+	tests := []struct {
+		coinType models.CoinType
+		amount   string
+		held     bool
+	}{
+		{models.CoinTypeBTC, "1", false},
+		{models.CoinTypeBTC, "1.000001", true},
+		// Coins without a threshold are never held
+		{models.CoinTypeETH, "1000000", false},
+	}
+
+	for _, tt := range tests {
+		if got := s.needsReview(tt.coinType, decimal.RequireFromString(tt.amount)); got != tt.held {
+			t.Errorf("%s %s: expected held=%v, got %v", tt.amount, tt.coinType, tt.held, got)
+		}
+	}
+}
+
+func TestAvailable(t *testing.T) {
+	wallet := &models.Wallet{Amount: decimal.NewFromInt(10), FrozenAmount: decimal.NewFromInt(4)}
+	if got := available(wallet); !got.Equal(decimal.NewFromInt(6)) {
+		t.Errorf("Expected 6 available, got %s", got)
+	}
+}
+
+func TestWalletService_Deposit(t *testing.T) {
+	s := newTestService()
+	owner := uuid.New()
+	wallet := s.wallet(owner, models.CoinTypeETH, 5)
+
+	if _, err := s.Deposit(context.Background(), uuid.New(), wallet.ID, decimal.NewFromInt(1), nil); !errors.Is(err, ErrForbidden) {
+		t.Errorf("Expected ErrForbidden depositing into another user's wallet, got %v", err)
+	}
+	if _, err := s.Deposit(context.Background(), owner, uuid.New(), decimal.NewFromInt(1), nil); !errors.Is(err, ErrWalletNotFound) {
+		t.Errorf("Expected ErrWalletNotFound, got %v", err)
+	}
+	if _, err := s.Deposit(context.Background(), owner, wallet.ID, decimal.Zero, nil); !errors.Is(err, ErrInvalidAmount) {
+		t.Errorf("Expected ErrInvalidAmount, got %v", err)
+	}
+
+	// A failing claim stops the deposit before anything moves
+	errClaimed := errors.New("claimed")
+	refuse := func(ctx context.Context, tx *sql.Tx, receipt *Receipt) error { return errClaimed }
+	if _, err := s.Deposit(context.Background(), owner, wallet.ID, decimal.NewFromInt(1), refuse); !errors.Is(err, errClaimed) {
+		t.Errorf("Expected the claim's error, got %v", err)
+	}
+	if !wallet.Amount.Equal(decimal.NewFromInt(5)) {
+		t.Errorf("Expected balance untouched after a failed claim, got %s", wallet.Amount)
+	}
+
+	var claimed *Receipt
+	receipt, err := s.Deposit(context.Background(), owner, wallet.ID, decimal.NewFromInt(2), func(ctx context.Context, tx *sql.Tx, receipt *Receipt) error {
+		claimed = receipt
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if claimed != receipt || receipt.Status != models.TransactionStatusDone {
+		t.Errorf("Expected the claim to see the DONE receipt, got %+v", claimed)
+	}
+	if !wallet.Amount.Equal(decimal.NewFromInt(7)) {
+		t.Errorf("Expected balance 7, got %s", wallet.Amount)
+	}
+
+	wallet.Status = models.WalletStatusFrozen
+	if _, err := s.Deposit(context.Background(), owner, wallet.ID, decimal.NewFromInt(1), nil); !errors.Is(err, ErrWalletNotActive) {
+		t.Errorf("Expected ErrWalletNotActive for a frozen wallet, got %v", err)
+	}
+}
+
+func TestWalletService_WithdrawHoldsLargeAmounts(t *testing.T) {
+	s := newTestService()
+	owner := uuid.New()
+	wallet := s.wallet(owner, models.CoinTypeBTC, 5)
+
+	receipt, err := s.Withdraw(context.Background(), owner, wallet.ID, decimal.NewFromInt(3), nil)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if receipt.Status != models.TransactionStatusPending {
+		t.Errorf("Expected a PENDING receipt, got %s", receipt.Status)
+	}
+	if !wallet.Amount.Equal(decimal.NewFromInt(5)) || !wallet.FrozenAmount.Equal(decimal.NewFromInt(3)) {
+		t.Errorf("Expected 3 of 5 held, got amount %s frozen %s", wallet.Amount, wallet.FrozenAmount)
+	}
+
+	// Held funds are not available to anything else
+	if _, err := s.Withdraw(context.Background(), owner, wallet.ID, decimal.RequireFromString("2.5"), nil); !errors.Is(err, ErrInsufficientFunds) {
+		t.Errorf("Expected ErrInsufficientFunds, got %v", err)
+	}
+
+	if _, err := s.CancelWithdrawal(context.Background(), uuid.New(), receipt.TransactionID, nil); !errors.Is(err, ErrForbidden) {
+		t.Errorf("Expected ErrForbidden cancelling another user's withdrawal, got %v", err)
+	}
+	if _, err := s.CancelWithdrawal(context.Background(), owner, receipt.TransactionID, []uuid.UUID{uuid.New()}); !errors.Is(err, ErrForbidden) {
+		t.Errorf("Expected ErrForbidden cancelling with a key restricted to another wallet, got %v", err)
+	}
+	if withdrawals, _, err := s.ListWithdrawals(context.Background(), "", &owner, []uuid.UUID{uuid.New()}, 10, 0); err != nil || len(withdrawals) != 0 {
+		t.Errorf("Expected no withdrawals for a key restricted to another wallet, got %d (%v)", len(withdrawals), err)
+	}
+	if _, err := s.ApproveWithdrawal(context.Background(), receipt.TransactionID, nil, ""); err != nil {
+		t.Fatalf("Expected approval, got %v", err)
+	}
+	if !wallet.Amount.Equal(decimal.NewFromInt(2)) || !wallet.FrozenAmount.IsZero() {
+		t.Errorf("Expected 3 debited and the hold released, got amount %s frozen %s", wallet.Amount, wallet.FrozenAmount)
+	}
+	if _, err := s.RejectWithdrawal(context.Background(), receipt.TransactionID, nil, "late"); !errors.Is(err, ErrWithdrawalNotPending) {
+		t.Errorf("Expected ErrWithdrawalNotPending, got %v", err)
+	}
+}
+
+func TestWalletService_Transfer(t *testing.T) {
+	s := newTestService()
+	owner := uuid.New()
+	sender := s.wallet(owner, models.CoinTypeBTC, 5)
+	receiver := s.wallet(uuid.New(), models.CoinTypeBTC, 0)
+	other := s.wallet(uuid.New(), models.CoinTypeETH, 0)
+
+	if _, err := s.Transfer(context.Background(), owner, sender.ID, sender.ID, decimal.NewFromInt(1), nil); !errors.Is(err, ErrSameWallet) {
+		t.Errorf("Expected ErrSameWallet, got %v", err)
+	}
+	if _, err := s.Transfer(context.Background(), owner, sender.ID, other.ID, decimal.NewFromInt(1), nil); !errors.Is(err, ErrCoinMismatch) {
+		t.Errorf("Expected ErrCoinMismatch, got %v", err)
+	}
+	if _, err := s.Transfer(context.Background(), owner, sender.ID, receiver.ID, decimal.NewFromInt(6), nil); !errors.Is(err, ErrInsufficientFunds) {
+		t.Errorf("Expected ErrInsufficientFunds, got %v", err)
+	}
+
+	if _, err := s.Transfer(context.Background(), owner, sender.ID, receiver.ID, decimal.NewFromInt(2), nil); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !sender.Amount.Equal(decimal.NewFromInt(3)) || !receiver.Amount.Equal(decimal.NewFromInt(2)) {
+		t.Errorf("Expected balances 3 and 2, got %s and %s", sender.Amount, receiver.Amount)
+	}
+}
+
+func TestWalletService_KYCLimitCountsRecentOutflows(t *testing.T) {
+	s := newTestService()
+	userRepo := repository.NewMockUserRepository()
+	s.userRepo = userRepo
+	s.kycPolicy = kyc.NewPolicy(map[models.KYCTier]map[models.CoinType]decimal.Decimal{
+		models.KYCTierUnverified: {models.CoinTypeBTC: decimal.NewFromInt(5)},
+	}, 24*time.Hour)
+
+	owner := &models.User{ID: uuid.New(), Email: "owner@example.com", KYCTier: models.KYCTierUnverified}
+	userRepo.Create(context.Background(), nil, owner)
+	sender := s.wallet(owner.ID, models.CoinTypeBTC, 10)
+	receiver := s.wallet(uuid.New(), models.CoinTypeBTC, 0)
+	eth := s.wallet(owner.ID, models.CoinTypeETH, 1000)
+
+	// Each request is under the cap; together they are not
+	if _, err := s.Transfer(context.Background(), owner.ID, sender.ID, receiver.ID, decimal.NewFromInt(1), nil); err != nil {
+		t.Fatalf("Expected first transfer allowed, got %v", err)
+	}
+	if _, err := s.Withdraw(context.Background(), owner.ID, sender.ID, decimal.NewFromInt(1), nil); err != nil {
+		t.Fatalf("Expected withdrawal allowed, got %v", err)
+	}
+	// Above the review threshold: held, and counted while pending
+	if _, err := s.Withdraw(context.Background(), owner.ID, sender.ID, decimal.RequireFromString("1.5"), nil); err != nil {
+		t.Fatalf("Expected withdrawal allowed, got %v", err)
+	}
+	_, err := s.Transfer(context.Background(), owner.ID, sender.ID, receiver.ID, decimal.NewFromInt(2), nil)
+	var overLimit *KYCLimitError
+	if !errors.As(err, &overLimit) || !errors.Is(err, ErrKYCLimitExceeded) {
+		t.Fatalf("Expected KYCLimitError, got %v", err)
+	}
+	if !overLimit.Used.Equal(decimal.RequireFromString("3.5")) || !overLimit.Limit.Equal(decimal.NewFromInt(5)) || overLimit.CoinType != models.CoinTypeBTC {
+		t.Errorf("Unexpected limit error %+v", overLimit)
+	}
+	if !sender.Amount.Equal(decimal.NewFromInt(8)) {
+		t.Errorf("Expected the refused transfer to move nothing, got %s", sender.Amount)
+	}
+
+	// Deposits are not capped, and other coins have their own limits
+	if _, err := s.Deposit(context.Background(), owner.ID, sender.ID, decimal.NewFromInt(100), nil); err != nil {
+		t.Errorf("Expected deposit allowed, got %v", err)
+	}
+	if _, err := s.Withdraw(context.Background(), owner.ID, eth.ID, decimal.NewFromInt(500), nil); err != nil {
+		t.Errorf("Expected uncapped ETH withdrawal allowed, got %v", err)
+	}
+}
@@ -0,0 +1,130 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"wallet-service/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// ListWithdrawals returns withdrawals held for review, oldest first; a nil
+// userID lists everyone's, and non-empty walletIDs keep only those wallets'
+func (s *WalletService) ListWithdrawals(ctx context.Context, status models.WithdrawalReviewStatus, userID *uuid.UUID, walletIDs []uuid.UUID, limit, offset int) ([]models.WithdrawalReview, int, error) {
+	withdrawals, total, err := s.withdrawalReviewRepo.List(ctx, status, userID, walletIDs, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list withdrawals: %w", err)
+	}
+	return withdrawals, total, nil
+}
+
+// CancelWithdrawal lets the owner withdraw a pending withdrawal, releasing the
+// held amount. Non-empty walletIDs, the wallets a restricted caller may use,
+// must include the withdrawal's wallet.
+func (s *WalletService) CancelWithdrawal(ctx context.Context, userID, transactionID uuid.UUID, walletIDs []uuid.UUID) (*models.WithdrawalReview, error) {
+	review, err := s.withdrawalReviewRepo.GetByTransactionID(ctx, transactionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get withdrawal: %w", err)
+	}
+	if review == nil {
+		return nil, ErrWithdrawalNotFound
+	}
+	if review.UserID != userID || !allowsWallet(walletIDs, review.WalletID) {
+		return nil, ErrForbidden
+	}
+
+	return s.decide(ctx, transactionID, models.WithdrawalReviewCancelled, nil, "")
+}
+
+// ApproveWithdrawal books the held withdrawal: the amount leaves both the
+// wallet's balance and its frozen balance, and the transaction becomes DONE
+func (s *WalletService) ApproveWithdrawal(ctx context.Context, transactionID uuid.UUID, reviewerID *uuid.UUID, note string) (*models.WithdrawalReview, error) {
+	return s.decide(ctx, transactionID, models.WithdrawalReviewApproved, reviewerID, note)
+}
+
+// RejectWithdrawal releases the held amount and fails the transaction
+func (s *WalletService) RejectWithdrawal(ctx context.Context, transactionID uuid.UUID, reviewerID *uuid.UUID, reason string) (*models.WithdrawalReview, error) {
+	return s.decide(ctx, transactionID, models.WithdrawalReviewRejected, reviewerID, reason)
+}
+
+// decide settles a pending withdrawal. The hold is released in every case;
+// only approval also debits the wallet. The decision, the balance change and
+// their events commit together.
+func (s *WalletService) decide(ctx context.Context, transactionID uuid.UUID, status models.WithdrawalReviewStatus, reviewerID *uuid.UUID, note string) (*models.WithdrawalReview, error) {
+	var review *models.WithdrawalReview
+	err := s.txManager.ExecuteTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		var err error
+		review, err = s.withdrawalReviewRepo.GetByTransactionIDForUpdate(ctx, tx, transactionID)
+		if err != nil {
+			return err
+		}
+		if review == nil {
+			return ErrWithdrawalNotFound
+		}
+		if review.Status != models.WithdrawalReviewPending {
+			return ErrWithdrawalNotPending
+		}
+
+		locked, err := s.lockWallets(ctx, tx, review.WalletID)
+		if err != nil {
+			return err
+		}
+		wallet := locked[0]
+
+		transactionStatus := models.TransactionStatusFailed
+		if status == models.WithdrawalReviewApproved {
+			// A staff freeze holds the payout too; a closing wallet is only
+			// waiting for this decision
+			if wallet.Status == models.WalletStatusFrozen {
+				return &WalletNotActiveError{WalletID: wallet.ID, Status: wallet.Status}
+			}
+			transactionStatus = models.TransactionStatusDone
+		}
+
+		if err := s.walletRepo.UpdateFrozenAmount(ctx, tx, wallet.ID, wallet.FrozenAmount.Sub(review.Amount)); err != nil {
+			return fmt.Errorf("failed to update wallet frozen amount: %w", err)
+		}
+		if err := s.transactionRepo.UpdateStatus(ctx, tx, review.TransactionID, transactionStatus); err != nil {
+			return err
+		}
+		if transactionStatus == models.TransactionStatusDone {
+			transaction := &models.Transaction{
+				ID:     review.TransactionID,
+				Type:   models.TransactionTypeWithdrawal,
+				Status: models.TransactionStatusDone,
+			}
+			if err := s.book(ctx, tx, transaction, wallet, models.DirectionOut, review.Amount); err != nil {
+				return err
+			}
+		}
+
+		if err := s.withdrawalReviewRepo.Decide(ctx, tx, review.TransactionID, status, reviewerID, note); err != nil {
+			return err
+		}
+		review.Status = status
+		review.ReviewedBy = reviewerID
+		review.ReviewNote = note
+		return s.recordWithdrawalReview(ctx, tx, review)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return review, nil
+}
+
+// allowsWallet reports whether walletID is among walletIDs; an empty list
+// allows every wallet
+func allowsWallet(walletIDs []uuid.UUID, walletID uuid.UUID) bool {
+	if len(walletIDs) == 0 {
+		return true
+	}
+	for _, allowed := range walletIDs {
+		if allowed == walletID {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,246 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"wallet-service/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+var (
+	ErrSessionNotFound = errors.New("session not found")
+	ErrTokenRevoked    = errors.New("token revoked")
+)
+
+// Store keeps login sessions in Redis. A session shares its ID with the
+// refresh token family of the login that created it and owns exactly one live
+// access token at a time:
+//
+//	session:<session_id>     hash of user, device, IP, times and current token ID
+//	user_sessions:<user_id>  set of the user's session IDs
+//	jwt:<token_id>           session ID, expiring with the access token
+type Store struct {
+	redisClient *redis.Client
+	ttl         time.Duration
+}
+
+// NewStore keeps idle sessions for ttl, which should match the refresh token
+// lifetime since a session cannot outlive its refresh token
+func NewStore(redisClient *redis.Client, ttl time.Duration) *Store {
+	return &Store{redisClient: redisClient, ttl: ttl}
+}
+
+func sessionKey(sessionID uuid.UUID) string {
+	return fmt.Sprintf("session:%s", sessionID)
+}
+
+func userSessionsKey(userID uuid.UUID) string {
+	return fmt.Sprintf("user_sessions:%s", userID)
+}
+
+func tokenKey(tokenID string) string {
+	return fmt.Sprintf("jwt:%s", tokenID)
+}
+
+// Create records a new session for a login
+func (s *Store) Create(ctx context.Context, session *models.Session) error {
+	now := time.Now().UTC()
+	session.CreatedAt = now
+	session.LastUsedAt = now
+
+	_, err := s.redisClient.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, sessionKey(session.ID), map[string]interface{}{
+			"user_id":      session.UserID.String(),
+			"device":       session.Device,
+			"ip":           session.IP,
+			"created_at":   now.Format(time.RFC3339Nano),
+			"last_used_at": now.Format(time.RFC3339Nano),
+		})
+		pipe.Expire(ctx, sessionKey(session.ID), s.ttl)
+		pipe.SAdd(ctx, userSessionsKey(session.UserID), session.ID.String())
+		pipe.Expire(ctx, userSessionsKey(session.UserID), s.ttl)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create session: %w", err)
+	}
+
+	return nil
+}
+
+// Activate makes tokenID the session's access token for accessTTL, revoking
+// the one it replaces, and extends the session's idle lifetime
+func (s *Store) Activate(ctx context.Context, sessionID uuid.UUID, tokenID string, accessTTL time.Duration) error {
+	values, err := s.redisClient.HMGet(ctx, sessionKey(sessionID), "user_id", "token_id").Result()
+	if err != nil {
+		return fmt.Errorf("failed to get session: %w", err)
+	}
+	if values[0] == nil {
+		return ErrSessionNotFound
+	}
+	userID, _ := uuid.Parse(values[0].(string))
+
+	_, err = s.redisClient.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		if previous, ok := values[1].(string); ok && previous != "" {
+			pipe.Del(ctx, tokenKey(previous))
+		}
+		pipe.Set(ctx, tokenKey(tokenID), sessionID.String(), accessTTL)
+		pipe.HSet(ctx, sessionKey(sessionID), "token_id", tokenID)
+		pipe.Expire(ctx, sessionKey(sessionID), s.ttl)
+		pipe.Expire(ctx, userSessionsKey(userID), s.ttl)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to activate session token: %w", err)
+	}
+
+	return nil
+}
+
+// Validate reports ErrTokenRevoked unless tokenID is the live access token of
+// sessionID
+func (s *Store) Validate(ctx context.Context, tokenID string, sessionID uuid.UUID) error {
+	value, err := s.redisClient.Get(ctx, tokenKey(tokenID)).Result()
+	if err == redis.Nil {
+		return ErrTokenRevoked
+	} else if err != nil {
+		return fmt.Errorf("failed to check token cache: %w", err)
+	}
+
+	if value != sessionID.String() {
+		return ErrTokenRevoked
+	}
+
+	return nil
+}
+
+// touchScript updates a session's last use only while the session exists, so
+// a request racing a revoke or expiry cannot recreate the hash without a TTL
+var touchScript = redis.NewScript(`
+if redis.call("HEXISTS", KEYS[1], "user_id") == 1 then
+	return redis.call("HSET", KEYS[1], "last_used_at", ARGV[1], "ip", ARGV[2])
+end
+return 0`)
+
+// Touch records that the session was just used from ip. A session that is
+// gone is left alone.
+func (s *Store) Touch(ctx context.Context, sessionID uuid.UUID, ip string) error {
+	return touchScript.Run(ctx, s.redisClient, []string{sessionKey(sessionID)},
+		time.Now().UTC().Format(time.RFC3339Nano),
+		ip,
+	).Err()
+}
+
+// Get returns the session if it belongs to userID and is still active
+func (s *Store) Get(ctx context.Context, userID, sessionID uuid.UUID) (*models.Session, error) {
+	fields, err := s.redisClient.HGetAll(ctx, sessionKey(sessionID)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+	if len(fields) == 0 || fields["user_id"] != userID.String() {
+		return nil, ErrSessionNotFound
+	}
+
+	session := &models.Session{
+		ID:     sessionID,
+		UserID: userID,
+		Device: fields["device"],
+		IP:     fields["ip"],
+	}
+	session.CreatedAt, _ = time.Parse(time.RFC3339Nano, fields["created_at"])
+	session.LastUsedAt, _ = time.Parse(time.RFC3339Nano, fields["last_used_at"])
+
+	return session, nil
+}
+
+// List returns the user's active sessions, most recently used first
+func (s *Store) List(ctx context.Context, userID uuid.UUID) ([]models.Session, error) {
+	ids, err := s.redisClient.SMembers(ctx, userSessionsKey(userID)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+
+	sessions := make([]models.Session, 0, len(ids))
+	for _, id := range ids {
+		sessionID, err := uuid.Parse(id)
+		if err != nil {
+			continue
+		}
+
+		session, err := s.Get(ctx, userID, sessionID)
+		if errors.Is(err, ErrSessionNotFound) {
+			// Expired on its own; drop it from the index
+			s.redisClient.SRem(ctx, userSessionsKey(userID), id)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, *session)
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LastUsedAt.After(sessions[j].LastUsedAt)
+	})
+
+	return sessions, nil
+}
+
+// Revoke ends one session of userID and its live access token
+func (s *Store) Revoke(ctx context.Context, userID, sessionID uuid.UUID) error {
+	values, err := s.redisClient.HMGet(ctx, sessionKey(sessionID), "user_id", "token_id").Result()
+	if err != nil {
+		return fmt.Errorf("failed to get session: %w", err)
+	}
+	if values[0] == nil || values[0].(string) != userID.String() {
+		return ErrSessionNotFound
+	}
+
+	_, err = s.redisClient.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		if tokenID, ok := values[1].(string); ok && tokenID != "" {
+			pipe.Del(ctx, tokenKey(tokenID))
+		}
+		pipe.Del(ctx, sessionKey(sessionID))
+		pipe.SRem(ctx, userSessionsKey(userID), sessionID.String())
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to revoke session: %w", err)
+	}
+
+	return nil
+}
+
+// RevokeAll ends every session of userID and returns how many were active
+func (s *Store) RevokeAll(ctx context.Context, userID uuid.UUID) (int, error) {
+	ids, err := s.redisClient.SMembers(ctx, userSessionsKey(userID)).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to list sessions: %w", err)
+	}
+
+	revoked := 0
+	for _, id := range ids {
+		sessionID, err := uuid.Parse(id)
+		if err != nil {
+			continue
+		}
+
+		err = s.Revoke(ctx, userID, sessionID)
+		if errors.Is(err, ErrSessionNotFound) {
+			continue
+		}
+		if err != nil {
+			return revoked, err
+		}
+		revoked++
+	}
+
+	s.redisClient.Del(ctx, userSessionsKey(userID))
+	return revoked, nil
+}
@@ -0,0 +1,86 @@
+package signing
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"wallet-service/internal/models"
+)
+
+var ErrNoSigningKey = errors.New("no active signing key")
+
+// Keyring holds the keys every instance signs and verifies with. The newest
+// key that has activated signs; every key that has not retired verifies.
+type Keyring struct {
+	mu   sync.RWMutex
+	keys []*Key
+}
+
+func NewKeyring(keys ...*Key) *Keyring {
+	keyring := &Keyring{}
+	keyring.Set(keys)
+	return keyring
+}
+
+// Set replaces the keys, e.g. after a reload from the database
+func (k *Keyring) Set(keys []*Key) {
+	sorted := make([]*Key, len(keys))
+	copy(sorted, keys)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].ActivatesAt.Before(sorted[j].ActivatesAt)
+	})
+
+	k.mu.Lock()
+	k.keys = sorted
+	k.mu.Unlock()
+}
+
+// Current returns the key new tokens are signed with
+func (k *Keyring) Current() (*Key, error) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	now := time.Now()
+	for i := len(k.keys) - 1; i >= 0; i-- {
+		if !k.keys[i].ActivatesAt.After(now) {
+			return k.keys[i], nil
+		}
+	}
+	return nil, ErrNoSigningKey
+}
+
+// Lookup returns the key with the given kid while it may still verify tokens
+func (k *Keyring) Lookup(kid string) (*Key, bool) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	now := time.Now()
+	for _, key := range k.keys {
+		if key.ID == kid {
+			return key, !retired(key, now)
+		}
+	}
+	return nil, false
+}
+
+// JWKS publishes every key that verifies now or will sign next, so other
+// services learn a key well before the first token signed with it
+func (k *Keyring) JWKS() models.JWKSet {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	now := time.Now()
+	set := models.JWKSet{Keys: []models.JWK{}}
+	for _, key := range k.keys {
+		if !retired(key, now) {
+			set.Keys = append(set.Keys, key.JWK())
+		}
+	}
+	return set
+}
+
+func retired(key *Key, now time.Time) bool {
+	return !key.RetiresAt.IsZero() && !key.RetiresAt.After(now)
+}
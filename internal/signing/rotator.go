@@ -0,0 +1,236 @@
+package signing
+
+import (
+	"context"
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"database/sql"
+	"encoding/base64"
+	"fmt"
+	"log"
+	"time"
+
+	"wallet-service/internal/models"
+	"wallet-service/internal/repository"
+)
+
+// Rotator keeps signing_keys on schedule and the keyring in sync with it.
+// There is always one upcoming key besides the one signing, created when its
+// predecessor activates, so it is published for a full rotation period before
+// it signs anything.
+type Rotator struct {
+	signingKeyRepo repository.ISigningKeyRepository
+	txManager      *repository.TransactionManager
+	keyring        *Keyring
+	aead           cipher.AEAD
+	algorithm      string
+	rotation       time.Duration
+	grace          time.Duration
+}
+
+// NewRotator seals private keys with encryptionKey. grace is how long a key
+// keeps verifying after the next one takes over; it must cover the access
+// token lifetime.
+func NewRotator(signingKeyRepo repository.ISigningKeyRepository, txManager *repository.TransactionManager, keyring *Keyring, encryptionKey, algorithm string, rotation, grace time.Duration) (*Rotator, error) {
+	if !IsAlgorithm(algorithm) {
+		return nil, fmt.Errorf("unsupported signing algorithm %q", algorithm)
+	}
+	if rotation <= grace {
+		return nil, fmt.Errorf("key rotation period %s must be longer than %s", rotation, grace)
+	}
+
+	key := sha256.Sum256([]byte(encryptionKey))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create AEAD: %w", err)
+	}
+
+	return &Rotator{
+		signingKeyRepo: signingKeyRepo,
+		txManager:      txManager,
+		keyring:        keyring,
+		aead:           aead,
+		algorithm:      algorithm,
+		rotation:       rotation,
+		grace:          grace,
+	}, nil
+}
+
+// Start rotates and reloads the keyring on every interval until ctx is done
+func (r *Rotator) Start(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := r.Rotate(ctx); err != nil {
+					log.Printf("Warning: signing key rotation failed: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+// Rotate creates the keys the schedule calls for, deletes retired ones and
+// reloads the keyring. Instances serialize on a lock so only one creates keys.
+func (r *Rotator) Rotate(ctx context.Context) error {
+	err := r.txManager.ExecuteTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		if err := r.signingKeyRepo.Lock(ctx, tx); err != nil {
+			return err
+		}
+
+		keys, err := r.signingKeyRepo.ListForRotation(ctx, tx)
+		if err != nil {
+			return err
+		}
+
+		activations, retiredKIDs := plan(keys, time.Now(), r.rotation, r.grace)
+		for _, activatesAt := range activations {
+			key, err := GenerateKey(r.algorithm, activatesAt)
+			if err != nil {
+				return err
+			}
+
+			record, err := r.seal(key)
+			if err != nil {
+				return err
+			}
+			if err := r.signingKeyRepo.Create(ctx, tx, record); err != nil {
+				return err
+			}
+			log.Printf("Created %s signing key %s, active from %s", key.Algorithm, key.ID, key.ActivatesAt.Format(time.RFC3339))
+		}
+
+		if len(retiredKIDs) > 0 {
+			if err := r.signingKeyRepo.Delete(ctx, tx, retiredKIDs); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return r.Load(ctx)
+}
+
+// Load reads every stored key into the keyring
+func (r *Rotator) Load(ctx context.Context) error {
+	records, err := r.signingKeyRepo.List(ctx)
+	if err != nil {
+		return err
+	}
+
+	keys := make([]*Key, 0, len(records))
+	for i, record := range records {
+		key, err := r.open(record)
+		if err != nil {
+			return err
+		}
+		if i+1 < len(records) {
+			key.RetiresAt = records[i+1].ActivatesAt.Add(r.grace)
+		}
+		keys = append(keys, key)
+	}
+
+	r.keyring.Set(keys)
+	return nil
+}
+
+// plan returns when new keys must activate so one is always upcoming, and the
+// keys whose successor has been signing for longer than grace
+func plan(keys []models.SigningKey, now time.Time, rotation, grace time.Duration) ([]time.Time, []string) {
+	var activations []time.Time
+	var retiredKIDs []string
+
+	for i := 0; i+1 < len(keys); i++ {
+		if !keys[i+1].ActivatesAt.Add(grace).After(now) {
+			retiredKIDs = append(retiredKIDs, keys[i].ID)
+		}
+	}
+
+	// The first key signs at once; after a long outage the overdue key is
+	// replaced at once as well
+	latest := now.Add(-rotation)
+	if len(keys) > 0 {
+		latest = keys[len(keys)-1].ActivatesAt
+	}
+	for !latest.After(now) {
+		next := latest.Add(rotation)
+		if !next.After(now) && latest.Before(now) {
+			next = now
+		}
+		activations = append(activations, next)
+		latest = next
+	}
+
+	return activations, retiredKIDs
+}
+
+func (r *Rotator) seal(key *Key) (*models.SigningKey, error) {
+	private, err := x509.MarshalPKCS8PrivateKey(key.private)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode private key: %w", err)
+	}
+	public, err := x509.MarshalPKIXPublicKey(key.PublicKey())
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode public key: %w", err)
+	}
+
+	nonce := make([]byte, r.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	// The kid is bound in as associated data so sealed keys cannot be swapped
+	sealed := r.aead.Seal(nonce, nonce, private, []byte(key.ID))
+
+	return &models.SigningKey{
+		ID:          key.ID,
+		Algorithm:   key.Algorithm,
+		PrivateKey:  base64.StdEncoding.EncodeToString(sealed),
+		PublicKey:   public,
+		ActivatesAt: key.ActivatesAt,
+	}, nil
+}
+
+func (r *Rotator) open(record models.SigningKey) (*Key, error) {
+	raw, err := base64.StdEncoding.DecodeString(record.PrivateKey)
+	if err != nil || len(raw) < r.aead.NonceSize() {
+		return nil, fmt.Errorf("invalid sealed signing key %s", record.ID)
+	}
+	nonce, ciphertext := raw[:r.aead.NonceSize()], raw[r.aead.NonceSize():]
+	der, err := r.aead.Open(nil, nonce, ciphertext, []byte(record.ID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open signing key %s, was JWT_SECRET changed? %w", record.ID, err)
+	}
+
+	parsed, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse signing key %s: %w", record.ID, err)
+	}
+	private, ok := parsed.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("signing key %s is not a signer", record.ID)
+	}
+
+	return &Key{
+		ID:          record.ID,
+		Algorithm:   record.Algorithm,
+		ActivatesAt: record.ActivatesAt,
+		private:     private,
+	}, nil
+}
@@ -0,0 +1,146 @@
+package signing
+
+import (
+	"testing"
+	"time"
+
+	"wallet-service/internal/models"
+)
+
+func TestPlan_FirstRun(t *testing.T) {
+	now := time.Now()
+	rotation := 30 * 24 * time.Hour
+
+	activations, retired := plan(nil, now, rotation, 15*time.Minute)
+	if len(activations) != 2 || !activations[0].Equal(now) || !activations[1].Equal(now.Add(rotation)) {
+		t.Errorf("Expected a key now and one a rotation later, got %v", activations)
+	}
+	if len(retired) != 0 {
+		t.Errorf("Expected nothing retired, got %v", retired)
+	}
+}
+
+func TestPlan_Schedule(t *testing.T) {
+	now := time.Now()
+	rotation := 30 * 24 * time.Hour
+	grace := 15 * time.Minute
+
+	keys := func(activations ...time.Time) []models.SigningKey {
+		var keys []models.SigningKey
+		for i, activatesAt := range activations {
+			keys = append(keys, models.SigningKey{ID: string(rune('a' + i)), ActivatesAt: activatesAt})
+		}
+		return keys
+	}
+
+	tests := []struct {
+		name        string
+		keys        []models.SigningKey
+		activations []time.Time
+		retired     []string
+	}{
+		{
+			name: "upcoming key already published",
+			keys: keys(now.Add(-time.Hour), now.Add(rotation-time.Hour)),
+		},
+		{
+			name:        "upcoming key just activated",
+			keys:        keys(now.Add(-rotation), now.Add(-time.Minute)),
+			activations: []time.Time{now.Add(rotation - time.Minute)},
+		},
+		{
+			name:        "predecessor past grace",
+			keys:        keys(now.Add(-rotation), now.Add(-time.Hour)),
+			activations: []time.Time{now.Add(rotation - time.Hour)},
+			retired:     []string{"a"},
+		},
+		{
+			name:        "overdue after an outage",
+			keys:        keys(now.Add(-3 * rotation)),
+			activations: []time.Time{now, now.Add(rotation)},
+		},
+	}
+
+	for _, tt := range tests {
+		activations, retired := plan(tt.keys, now, rotation, grace)
+		if len(activations) != len(tt.activations) {
+			t.Errorf("%s: expected activations %v, got %v", tt.name, tt.activations, activations)
+			continue
+		}
+		for i := range activations {
+			if !activations[i].Equal(tt.activations[i]) {
+				t.Errorf("%s: expected activations %v, got %v", tt.name, tt.activations, activations)
+			}
+		}
+		if len(retired) != len(tt.retired) || (len(retired) > 0 && retired[0] != tt.retired[0]) {
+			t.Errorf("%s: expected retired %v, got %v", tt.name, tt.retired, retired)
+		}
+	}
+}
+
+func TestRotator_SealAndOpen(t *testing.T) {
+	rotator, err := NewRotator(nil, nil, NewKeyring(), "test-encryption-key", AlgorithmEdDSA, time.Hour, time.Minute)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	for _, algorithm := range []string{AlgorithmEdDSA, AlgorithmRS256} {
+		key, err := GenerateKey(algorithm, time.Now())
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		record, err := rotator.seal(key)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		opened, err := rotator.open(*record)
+		if err != nil {
+			t.Fatalf("Expected %s key to open, got %v", algorithm, err)
+		}
+		if opened.JWK() != key.JWK() {
+			t.Errorf("Expected opened %s key to match", algorithm)
+		}
+
+		// A sealed key moved to another kid does not open
+		record.ID = "another-kid"
+		if _, err := rotator.open(*record); err == nil {
+			t.Errorf("Expected %s key under another kid to fail", algorithm)
+		}
+	}
+}
+
+func TestKeyring_CurrentAndJWKS(t *testing.T) {
+	now := time.Now()
+	retiredKey, _ := GenerateKey(AlgorithmEdDSA, now.Add(-2*time.Hour))
+	retiredKey.RetiresAt = now.Add(-time.Minute)
+	current, _ := GenerateKey(AlgorithmRS256, now.Add(-time.Hour))
+	upcoming, _ := GenerateKey(AlgorithmEdDSA, now.Add(time.Hour))
+
+	keyring := NewKeyring(upcoming, current, retiredKey)
+
+	key, err := keyring.Current()
+	if err != nil || key.ID != current.ID {
+		t.Errorf("Expected current key %s, got %v (%v)", current.ID, key, err)
+	}
+
+	if _, ok := keyring.Lookup(retiredKey.ID); ok {
+		t.Error("Expected retired key not to verify")
+	}
+
+	jwks := keyring.JWKS()
+	if len(jwks.Keys) != 2 || jwks.Keys[0].KeyID != current.ID || jwks.Keys[1].KeyID != upcoming.ID {
+		t.Errorf("Expected current and upcoming keys published, got %+v", jwks.Keys)
+	}
+	if jwks.Keys[0].KeyType != "RSA" || jwks.Keys[0].N == "" || jwks.Keys[0].E != "AQAB" {
+		t.Errorf("Expected RSA JWK, got %+v", jwks.Keys[0])
+	}
+	if jwks.Keys[1].KeyType != "OKP" || jwks.Keys[1].Curve != "Ed25519" || jwks.Keys[1].X == "" {
+		t.Errorf("Expected Ed25519 JWK, got %+v", jwks.Keys[1])
+	}
+
+	if _, err := NewKeyring(upcoming).Current(); err != ErrNoSigningKey {
+		t.Errorf("Expected ErrNoSigningKey before any key activates, got %v", err)
+	}
+}
@@ -0,0 +1,139 @@
+package stream
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"strings"
+	"sync"
+
+	"wallet-service/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// Hub holds a single Redis pattern subscription per API instance and fans the
+// per-user wallet events published by events.PubSubSink out to local streams
+type Hub struct {
+	redisClient *redis.Client
+	prefix      string
+	bufferSize  int
+
+	mu          sync.Mutex
+	subscribers map[uuid.UUID]map[*Subscription]struct{}
+}
+
+// Subscription receives the events of one user. Events is closed when the
+// subscriber falls too far behind, after which it should resume from the
+// outbox using the last publish sequence it saw.
+type Subscription struct {
+	UserID uuid.UUID
+	Events chan models.OutboxEvent
+}
+
+func NewHub(redisClient *redis.Client, prefix string) *Hub {
+	return &Hub{
+		redisClient: redisClient,
+		prefix:      prefix,
+		bufferSize:  64,
+		subscribers: make(map[uuid.UUID]map[*Subscription]struct{}),
+	}
+}
+
+// Start consumes the Redis subscription until ctx is done
+func (h *Hub) Start(ctx context.Context) {
+	pubsub := h.redisClient.PSubscribe(ctx, h.prefix+":*")
+
+	go func() {
+		defer pubsub.Close()
+
+		messages := pubsub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case message, ok := <-messages:
+				if !ok {
+					return
+				}
+				h.dispatch(message)
+			}
+		}
+	}()
+}
+
+func (h *Hub) dispatch(message *redis.Message) {
+	userID, err := uuid.Parse(strings.TrimPrefix(message.Channel, h.prefix+":"))
+	if err != nil {
+		return
+	}
+
+	var event models.OutboxEvent
+	if err := json.Unmarshal([]byte(message.Payload), &event); err != nil {
+		log.Printf("Warning: dropping malformed stream event on %s: %v", message.Channel, err)
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for sub := range h.subscribers[userID] {
+		select {
+		case sub.Events <- event:
+		default:
+			// Slow consumer: cut it off rather than silently skipping events
+			h.remove(sub)
+		}
+	}
+}
+
+func (h *Hub) Subscribe(userID uuid.UUID) *Subscription {
+	sub := &Subscription{
+		UserID: userID,
+		Events: make(chan models.OutboxEvent, h.bufferSize),
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.subscribers[userID] == nil {
+		h.subscribers[userID] = make(map[*Subscription]struct{})
+	}
+	h.subscribers[userID][sub] = struct{}{}
+
+	return sub
+}
+
+func (h *Hub) Unsubscribe(sub *Subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.remove(sub)
+}
+
+// remove must be called with h.mu held
+func (h *Hub) remove(sub *Subscription) {
+	subs, ok := h.subscribers[sub.UserID]
+	if !ok {
+		return
+	}
+	if _, ok := subs[sub]; !ok {
+		return
+	}
+
+	delete(subs, sub)
+	close(sub.Events)
+	if len(subs) == 0 {
+		delete(h.subscribers, sub.UserID)
+	}
+}
+
+// IsWalletEvent reports whether event belongs on a customer's live stream
+func IsWalletEvent(event *models.OutboxEvent) bool {
+	switch event.EventType {
+	case models.EventTypeWalletCredited, models.EventTypeWalletDebited, models.EventTypeWithdrawalStatusChanged:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,82 @@
+package stream
+
+import (
+	"wallet-service/internal/models"
+	"wallet-service/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// replayBatchSize is how many outbox events a replay reads at a time
+const replayBatchSize = 200
+
+// dedupeWindow is how many recently sent event IDs a Cursor remembers. Live
+// events can only repeat what was sent shortly before them: the tail of the
+// replay, or a relay retry of the last few events.
+const dedupeWindow = 1024
+
+// Cursor tracks what a live stream has sent so that an event arriving both
+// from the replay and from the Hub, or twice from the Hub, goes out once.
+// Events are told apart by ID; publish sequence is only the resume point.
+type Cursor struct {
+	after  int64
+	sent   map[uuid.UUID]struct{}
+	recent []uuid.UUID
+	next   int
+}
+
+// NewCursor starts a stream that resumes after the given publish sequence
+func NewCursor(after int64) *Cursor {
+	return &Cursor{
+		after: after,
+		sent:  make(map[uuid.UUID]struct{}),
+	}
+}
+
+// Admit reports whether event should be sent, and remembers it if so.
+// Events at or before the resume point were delivered on an earlier
+// connection.
+func (c *Cursor) Admit(event *models.OutboxEvent) bool {
+	if event.PublishSequence != 0 && event.PublishSequence <= c.after {
+		return false
+	}
+	if _, sent := c.sent[event.ID]; sent {
+		return false
+	}
+
+	if len(c.recent) < dedupeWindow {
+		c.recent = append(c.recent, event.ID)
+	} else {
+		delete(c.sent, c.recent[c.next])
+		c.recent[c.next] = event.ID
+		c.next = (c.next + 1) % dedupeWindow
+	}
+	c.sent[event.ID] = struct{}{}
+	return true
+}
+
+// Replay sends the wallet events of walletIDs published after the cursor's
+// resume point, in publish order. Subscribe to the Hub first so nothing
+// published during the replay is lost; the cursor drops the overlap.
+func Replay(outboxRepo repository.IOutboxRepository, cursor *Cursor, walletIDs []uuid.UUID, send func(*models.OutboxEvent) error) error {
+	after := cursor.after
+	for {
+		batch, err := outboxRepo.GetAfter(after, walletIDs, replayBatchSize)
+		if err != nil {
+			return err
+		}
+
+		for i := range batch {
+			if IsWalletEvent(&batch[i]) && cursor.Admit(&batch[i]) {
+				if err := send(&batch[i]); err != nil {
+					return err
+				}
+			}
+			after = batch[i].PublishSequence
+		}
+
+		if len(batch) < replayBatchSize {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package stream
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"wallet-service/internal/models"
+	"wallet-service/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+func TestCursor_DedupesByEventID(t *testing.T) {
+	cursor := NewCursor(0)
+	event := models.OutboxEvent{ID: uuid.New(), PublishSequence: 7}
+
+	if !cursor.Admit(&event) {
+		t.Fatal("Expected the first copy to be sent")
+	}
+	if cursor.Admit(&event) {
+		t.Error("Expected a second copy of the same event to be dropped")
+	}
+
+	// A distinct event is sent even if it carries an earlier publish sequence
+	if !cursor.Admit(&models.OutboxEvent{ID: uuid.New(), PublishSequence: 5}) {
+		t.Error("Expected a different event to be sent")
+	}
+}
+
+func TestCursor_DropsEventsBeforeResumePoint(t *testing.T) {
+	cursor := NewCursor(10)
+
+	if cursor.Admit(&models.OutboxEvent{ID: uuid.New(), PublishSequence: 10}) {
+		t.Error("Expected an event at the resume point to be dropped")
+	}
+	if !cursor.Admit(&models.OutboxEvent{ID: uuid.New(), PublishSequence: 11}) {
+		t.Error("Expected an event after the resume point to be sent")
+	}
+}
+
+func TestCursor_ForgetsBeyondWindow(t *testing.T) {
+	cursor := NewCursor(0)
+	first := models.OutboxEvent{ID: uuid.New()}
+	cursor.Admit(&first)
+	for i := 0; i < dedupeWindow; i++ {
+		cursor.Admit(&models.OutboxEvent{ID: uuid.New()})
+	}
+
+	if len(cursor.sent) != dedupeWindow {
+		t.Errorf("Expected %d remembered IDs, got %d", dedupeWindow, len(cursor.sent))
+	}
+	if _, ok := cursor.sent[first.ID]; ok {
+		t.Error("Expected the oldest ID to be forgotten")
+	}
+}
+
+func TestReplay_SendsWalletEventsAfterResumePoint(t *testing.T) {
+	ctx := context.Background()
+	outboxRepo := repository.NewMockOutboxRepository()
+	walletID := uuid.New()
+
+	var created []models.OutboxEvent
+	for _, eventType := range []models.EventType{
+		models.EventTypeWalletCredited,
+		models.EventTypeTransactionCompleted,
+		models.EventTypeWalletDebited,
+		models.EventTypeWalletCredited,
+	} {
+		event := models.OutboxEvent{ID: uuid.New(), EventType: eventType, AggregateID: walletID, Payload: json.RawMessage(`{}`)}
+		if err := outboxRepo.Create(ctx, nil, &event); err != nil {
+			t.Fatalf("Failed to create event: %v", err)
+		}
+		created = append(created, event)
+	}
+	// Another user's wallet, which the replay must not send
+	other := models.OutboxEvent{ID: uuid.New(), EventType: models.EventTypeWalletCredited, AggregateID: uuid.New(), Payload: json.RawMessage(`{}`)}
+	outboxRepo.Create(ctx, nil, &other)
+	outboxRepo.AssignPublishSequence(ctx, nil, 100)
+
+	cursor := NewCursor(1)
+	var sent []uuid.UUID
+	err := Replay(outboxRepo, cursor, []uuid.UUID{walletID}, func(event *models.OutboxEvent) error {
+		sent = append(sent, event.ID)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(sent) != 2 || sent[0] != created[2].ID || sent[1] != created[3].ID {
+		t.Errorf("Expected the two wallet events after publish sequence 1, got %v", sent)
+	}
+
+	// The same event arriving live afterwards is not sent again
+	live, _ := outboxRepo.GetAfter(3, nil, 1)
+	if len(live) != 1 || cursor.Admit(&live[0]) {
+		t.Error("Expected a replayed event arriving live to be dropped")
+	}
+}
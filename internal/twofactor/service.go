@@ -0,0 +1,324 @@
+package twofactor
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"wallet-service/internal/models"
+	"wallet-service/internal/repository"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+const recoveryCodeCount = 10
+
+var (
+	ErrNotEnrolled       = errors.New("two-factor authentication is not enabled")
+	ErrAlreadyEnabled    = errors.New("two-factor authentication is already enabled")
+	ErrInvalidCode       = errors.New("invalid one-time code")
+	ErrTooManyAttempts   = errors.New("too many one-time code attempts")
+	recoveryCodeEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+)
+
+// Service enrols users in TOTP and verifies their codes. Each TOTP code is
+// accepted once, and attempts are counted per user in Redis before the code
+// is checked, so a code cannot be brute-forced within its validity window,
+// not even by guessing concurrently.
+type Service struct {
+	twoFactorRepo repository.ITwoFactorRepository
+	txManager     *repository.TransactionManager
+	redisClient   *redis.Client
+	issuer        string
+	aead          cipher.AEAD
+	maxAttempts   int64
+	lockout       time.Duration
+}
+
+// NewService seals secrets at rest with AES-256-GCM under a key derived from
+// encryptionKey
+func NewService(twoFactorRepo repository.ITwoFactorRepository, txManager *repository.TransactionManager, redisClient *redis.Client, issuer, encryptionKey string, maxAttempts int, lockout time.Duration) (*Service, error) {
+	key := sha256.Sum256([]byte(encryptionKey))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+
+	return &Service{
+		twoFactorRepo: twoFactorRepo,
+		txManager:     txManager,
+		redisClient:   redisClient,
+		issuer:        issuer,
+		aead:          aead,
+		maxAttempts:   int64(maxAttempts),
+		lockout:       lockout,
+	}, nil
+}
+
+// Enabled reports whether the user has confirmed a TOTP enrolment
+func (s *Service) Enabled(ctx context.Context, userID uuid.UUID) (bool, error) {
+	credential, err := s.twoFactorRepo.Get(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	return credential != nil && credential.EnabledAt != nil, nil
+}
+
+// Enroll starts (or restarts) an enrolment with a fresh secret. It has no
+// effect until Confirm sees a valid code for the secret.
+func (s *Service) Enroll(ctx context.Context, user *models.User) (*models.TwoFactorEnrollResponse, error) {
+	enabled, err := s.Enabled(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if enabled {
+		return nil, ErrAlreadyEnabled
+	}
+
+	secret, err := GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	sealed, err := s.seal(secret)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.twoFactorRepo.SavePending(ctx, &models.TOTPCredential{UserID: user.ID, Secret: sealed}); err != nil {
+		return nil, err
+	}
+
+	return &models.TwoFactorEnrollResponse{
+		Secret:          secret,
+		ProvisioningURI: ProvisioningURI(s.issuer, user.Email, secret),
+	}, nil
+}
+
+// Confirm enables a pending enrolment with a code from the authenticator and
+// returns the recovery codes, which are shown only this once
+func (s *Service) Confirm(ctx context.Context, userID uuid.UUID, code string) ([]string, error) {
+	if err := s.reserveAttempt(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	credential, err := s.twoFactorRepo.Get(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if credential == nil {
+		return nil, ErrNotEnrolled
+	}
+	if credential.EnabledAt != nil {
+		return nil, ErrAlreadyEnabled
+	}
+
+	secret, err := s.open(credential.Secret)
+	if err != nil {
+		return nil, err
+	}
+
+	step, ok := Match(secret, code, time.Now())
+	if !ok {
+		return nil, ErrInvalidCode
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.txManager.ExecuteTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		if err := s.twoFactorRepo.Enable(ctx, tx, userID, step); err != nil {
+			return err
+		}
+		return s.twoFactorRepo.ReplaceRecoveryCodes(ctx, tx, userID, hashes)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.clearFailures(ctx, userID)
+	return codes, nil
+}
+
+// Disable removes the user's TOTP credential and recovery codes
+func (s *Service) Disable(ctx context.Context, userID uuid.UUID) error {
+	enabled, err := s.Enabled(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !enabled {
+		return ErrNotEnrolled
+	}
+
+	return s.txManager.ExecuteTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		return s.twoFactorRepo.Delete(ctx, tx, userID)
+	})
+}
+
+// RegenerateRecoveryCodes replaces every recovery code of the user
+func (s *Service) RegenerateRecoveryCodes(ctx context.Context, userID uuid.UUID) ([]string, error) {
+	enabled, err := s.Enabled(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !enabled {
+		return nil, ErrNotEnrolled
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.txManager.ExecuteTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		return s.twoFactorRepo.ReplaceRecoveryCodes(ctx, tx, userID, hashes)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// Verify accepts a current TOTP code that has not been used yet, or an unused
+// recovery code, for a user with two-factor enabled
+func (s *Service) Verify(ctx context.Context, userID uuid.UUID, code string) error {
+	if err := s.reserveAttempt(ctx, userID); err != nil {
+		return err
+	}
+
+	credential, err := s.twoFactorRepo.Get(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if credential == nil || credential.EnabledAt == nil {
+		return ErrNotEnrolled
+	}
+
+	code = strings.TrimSpace(code)
+	var ok bool
+	if len(code) == digits {
+		ok, err = s.verifyTOTP(ctx, credential, code)
+	} else {
+		ok, err = s.twoFactorRepo.UseRecoveryCode(ctx, userID, hashRecoveryCode(code))
+	}
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		return ErrInvalidCode
+	}
+
+	s.clearFailures(ctx, userID)
+	return nil
+}
+
+func (s *Service) verifyTOTP(ctx context.Context, credential *models.TOTPCredential, code string) (bool, error) {
+	secret, err := s.open(credential.Secret)
+	if err != nil {
+		return false, err
+	}
+
+	step, ok := Match(secret, code, time.Now())
+	if !ok {
+		return false, nil
+	}
+
+	// Rejects the same code twice, and any code older than the last one used
+	return s.twoFactorRepo.UseStep(ctx, credential.UserID, step)
+}
+
+func attemptsKey(userID uuid.UUID) string {
+	return fmt.Sprintf("otp_attempts:%s", userID)
+}
+
+// reserveAttemptScript counts an attempt and starts the lockout window at
+// the first one, in one step so concurrent attempts each see their own count
+var reserveAttemptScript = redis.NewScript(`
+local attempts = redis.call("INCR", KEYS[1])
+redis.call("PEXPIRE", KEYS[1], ARGV[1], "NX")
+return attempts`)
+
+// reserveAttempt counts an attempt before the code is checked, refusing it
+// once the user has used up their attempts for the window. Only a successful
+// attempt gives them back, through clearFailures.
+func (s *Service) reserveAttempt(ctx context.Context, userID uuid.UUID) error {
+	attempts, err := reserveAttemptScript.Run(ctx, s.redisClient, []string{attemptsKey(userID)}, s.lockout.Milliseconds()).Int64()
+	if err != nil {
+		return fmt.Errorf("failed to count OTP attempt: %w", err)
+	}
+	if attempts > s.maxAttempts {
+		return ErrTooManyAttempts
+	}
+	return nil
+}
+
+func (s *Service) clearFailures(ctx context.Context, userID uuid.UUID) {
+	s.redisClient.Del(ctx, attemptsKey(userID))
+}
+
+func (s *Service) seal(secret string) (string, error) {
+	nonce := make([]byte, s.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	sealed := s.aead.Seal(nonce, nonce, []byte(secret), nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func (s *Service) open(sealed string) (string, error) {
+	raw, err := base64.StdEncoding.DecodeString(sealed)
+	if err != nil || len(raw) < s.aead.NonceSize() {
+		return "", fmt.Errorf("invalid sealed TOTP secret")
+	}
+	nonce, ciphertext := raw[:s.aead.NonceSize()], raw[s.aead.NonceSize():]
+	secret, err := s.aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to open TOTP secret: %w", err)
+	}
+	return string(secret), nil
+}
+
+// generateRecoveryCodes returns codes formatted as xxxxx-xxxxx and the hashes
+// stored in their place
+func generateRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+
+	for i := range codes {
+		raw := make([]byte, 7)
+		if _, err := rand.Read(raw); err != nil {
+			return nil, nil, fmt.Errorf("failed to generate recovery code: %w", err)
+		}
+		encoded := strings.ToLower(recoveryCodeEncoding.EncodeToString(raw))[:10]
+		codes[i] = encoded[:5] + "-" + encoded[5:]
+		hashes[i] = hashRecoveryCode(codes[i])
+	}
+
+	return codes, hashes, nil
+}
+
+// hashRecoveryCode ignores case and separators so codes can be typed loosely
+func hashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
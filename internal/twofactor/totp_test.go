@@ -0,0 +1,63 @@
+package twofactor
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+)
+
+// RFC 6238 appendix B test vectors for the SHA-1 seed, truncated to 6 digits
+func TestCode_RFC6238Vectors(t *testing.T) {
+	secret := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+	cases := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+
+	for _, tc := range cases {
+		got, err := Code(secret, Step(time.Unix(tc.unix, 0)))
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if got != tc.want {
+			t.Errorf("Code at %d = %s, want %s", tc.unix, got, tc.want)
+		}
+	}
+}
+
+func TestMatch_AllowsOneStepOfDrift(t *testing.T) {
+	secret, err := GenerateSecret()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	now := time.Now()
+	previous, _ := Code(secret, Step(now)-1)
+	if step, ok := Match(secret, previous, now); !ok || step != Step(now)-1 {
+		t.Errorf("Expected previous step code to match at step %d, got %d %v", Step(now)-1, step, ok)
+	}
+
+	stale, _ := Code(secret, Step(now)-3)
+	if _, ok := Match(secret, stale, now); ok {
+		t.Error("Expected code three steps old to be rejected")
+	}
+}
+
+func TestProvisioningURI(t *testing.T) {
+	uri := ProvisioningURI("Wallet App", "user_001@example.com", "JBSWY3DPEHPK3PXP")
+
+	if !strings.HasPrefix(uri, "otpauth://totp/Wallet%20App:user_001@example.com?") {
+		t.Errorf("Unexpected URI label: %s", uri)
+	}
+	if !strings.Contains(uri, "secret=JBSWY3DPEHPK3PXP") || !strings.Contains(uri, "issuer=Wallet+App") {
+		t.Errorf("Expected secret and issuer in URI: %s", uri)
+	}
+}
@@ -0,0 +1,138 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"wallet-service/internal/events"
+	"wallet-service/internal/models"
+	"wallet-service/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// Envelope is the JSON body POSTed to webhook endpoints
+type Envelope struct {
+	ID        uuid.UUID        `json:"id"`
+	Type      models.EventType `json:"type"`
+	CreatedAt time.Time        `json:"created_at"`
+	Data      json.RawMessage  `json:"data"`
+}
+
+// Dispatcher is an events.Sink that queues a delivery for every webhook
+// endpoint whose owner has a wallet touched by the event
+type Dispatcher struct {
+	webhookRepo repository.IWebhookRepository
+	walletRepo  repository.IWalletRepository
+}
+
+func NewDispatcher(webhookRepo repository.IWebhookRepository, walletRepo repository.IWalletRepository) *Dispatcher {
+	return &Dispatcher{
+		webhookRepo: webhookRepo,
+		walletRepo:  walletRepo,
+	}
+}
+
+func (d *Dispatcher) Publish(ctx context.Context, event *models.OutboxEvent) error {
+	userIDs, err := d.affectedUsers(event)
+	if err != nil {
+		return err
+	}
+
+	for _, userID := range userIDs {
+		endpoints, err := d.webhookRepo.GetEndpointsByUserID(userID)
+		if err != nil {
+			return err
+		}
+
+		for _, endpoint := range endpoints {
+			if !subscribed(&endpoint, event.EventType) {
+				continue
+			}
+
+			delivery, err := NewDelivery(endpoint.ID, event.ID, event.EventType, event.CreatedAt, event.Payload)
+			if err != nil {
+				return err
+			}
+			if err := d.webhookRepo.CreateDelivery(ctx, delivery); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// affectedUsers returns the owners of the wallets an event touches
+func (d *Dispatcher) affectedUsers(event *models.OutboxEvent) ([]uuid.UUID, error) {
+	switch event.EventType {
+	case models.EventTypeWalletCredited, models.EventTypeWalletDebited:
+		var payload events.WalletBalanceChanged
+		if err := json.Unmarshal(event.Payload, &payload); err != nil {
+			return nil, fmt.Errorf("failed to decode %s payload: %w", event.EventType, err)
+		}
+		return []uuid.UUID{payload.UserID}, nil
+
+	case models.EventTypeWithdrawalStatusChanged:
+		var payload events.WithdrawalStatusChanged
+		if err := json.Unmarshal(event.Payload, &payload); err != nil {
+			return nil, fmt.Errorf("failed to decode %s payload: %w", event.EventType, err)
+		}
+		return []uuid.UUID{payload.UserID}, nil
+
+	case models.EventTypeTransactionCompleted:
+		var payload events.TransactionCompleted
+		if err := json.Unmarshal(event.Payload, &payload); err != nil {
+			return nil, fmt.Errorf("failed to decode %s payload: %w", event.EventType, err)
+		}
+
+		seen := make(map[uuid.UUID]bool)
+		var userIDs []uuid.UUID
+		for _, entry := range payload.Entries {
+			wallet, err := d.walletRepo.GetByID(entry.WalletID)
+			if err != nil {
+				return nil, err
+			}
+			if wallet != nil && !seen[wallet.UserID] {
+				seen[wallet.UserID] = true
+				userIDs = append(userIDs, wallet.UserID)
+			}
+		}
+		return userIDs, nil
+	}
+
+	return nil, nil
+}
+
+func subscribed(endpoint *models.WebhookEndpoint, eventType models.EventType) bool {
+	for _, subscribedType := range endpoint.EventTypes {
+		if subscribedType == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// NewDelivery wraps an event payload in the webhook envelope, queued for endpointID
+func NewDelivery(endpointID, eventID uuid.UUID, eventType models.EventType, createdAt time.Time, data json.RawMessage) (*models.WebhookDelivery, error) {
+	body, err := json.Marshal(Envelope{
+		ID:        eventID,
+		Type:      eventType,
+		CreatedAt: createdAt,
+		Data:      data,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal webhook envelope: %w", err)
+	}
+
+	return &models.WebhookDelivery{
+		ID:         uuid.New(),
+		EndpointID: endpointID,
+		EventID:    eventID,
+		EventType:  eventType,
+		Payload:    body,
+		Status:     models.WebhookDeliveryStatusPending,
+	}, nil
+}
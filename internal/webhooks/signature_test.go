@@ -0,0 +1,42 @@
+package webhooks
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestVerify(t *testing.T) {
+	secret := "whsec_test_secret_value"
+	body := []byte(`{"id":"evt","type":"WalletCredited"}`)
+	now := time.Now()
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	signature := Sign(secret, now.Unix(), body)
+
+	if err := Verify(secret, timestamp, body, signature, 5*time.Minute, now); err != nil {
+		t.Errorf("Expected valid signature, got %v", err)
+	}
+
+	if err := Verify("other-secret", timestamp, body, signature, 5*time.Minute, now); err != ErrInvalidSignature {
+		t.Errorf("Expected ErrInvalidSignature for wrong secret, got %v", err)
+	}
+
+	if err := Verify(secret, timestamp, []byte(`{"id":"evt","type":"WalletDebited"}`), signature, 5*time.Minute, now); err != ErrInvalidSignature {
+		t.Errorf("Expected ErrInvalidSignature for tampered body, got %v", err)
+	}
+
+	if err := Verify(secret, timestamp, body, signature, 5*time.Minute, now.Add(10*time.Minute)); err != ErrStaleTimestamp {
+		t.Errorf("Expected ErrStaleTimestamp for replayed delivery, got %v", err)
+	}
+}
+
+func TestWorker_Backoff(t *testing.T) {
+	worker := NewWorker(nil, NewTargetPolicy(false), time.Second, time.Second, 8, 30*time.Second, 10*time.Minute)
+
+	expected := []time.Duration{30 * time.Second, time.Minute, 2 * time.Minute, 4 * time.Minute, 8 * time.Minute, 10 * time.Minute}
+	for i, want := range expected {
+		if got := worker.Backoff(i + 1); got != want {
+			t.Errorf("Backoff(%d) = %s, want %s", i+1, got, want)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"syscall"
+)
+
+// ErrTargetNotAllowed is returned for webhook URLs that are not https or do
+// not resolve to public addresses only
+var ErrTargetNotAllowed = errors.New("webhook target not allowed")
+
+// reservedNets are non-public ranges the net.IP predicates do not cover
+var reservedNets = mustParseCIDRs(
+	"0.0.0.0/8",     // "this network"
+	"100.64.0.0/10", // carrier-grade NAT
+	"192.0.0.0/24",  // IETF protocol assignments
+	"198.18.0.0/15", // benchmarking
+	"64:ff9b::/96",  // NAT64, which maps onto IPv4 addresses
+)
+
+// TargetPolicy keeps webhooks from reaching the service's own network: only
+// https URLs whose host resolves to public addresses are accepted, checked
+// when an endpoint is registered and again on every dial, so a DNS record
+// changed after registration cannot point deliveries inward. AllowInsecure
+// lifts both rules for local development against a receiver on localhost.
+type TargetPolicy struct {
+	AllowInsecure bool
+	resolver      *net.Resolver
+}
+
+func NewTargetPolicy(allowInsecure bool) *TargetPolicy {
+	return &TargetPolicy{
+		AllowInsecure: allowInsecure,
+		resolver:      net.DefaultResolver,
+	}
+}
+
+// Validate checks a webhook URL at registration
+func (p *TargetPolicy) Validate(ctx context.Context, rawURL string) error {
+	target, err := url.Parse(rawURL)
+	if err != nil || target.Hostname() == "" || target.User != nil {
+		return ErrTargetNotAllowed
+	}
+
+	switch target.Scheme {
+	case "https":
+	case "http":
+		if !p.AllowInsecure {
+			return ErrTargetNotAllowed
+		}
+	default:
+		return ErrTargetNotAllowed
+	}
+
+	_, err = p.resolve(ctx, target.Hostname())
+	return err
+}
+
+// DialContext dials only public addresses, connecting to the address it
+// checked rather than resolving the host a second time
+func (p *TargetPolicy) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+
+	ips, err := p.resolve(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+
+	dialer := &net.Dialer{Control: p.control}
+	var dialErr error
+	for _, ip := range ips {
+		conn, err := dialer.DialContext(ctx, network, net.JoinHostPort(ip.String(), port))
+		if err == nil {
+			return conn, nil
+		}
+		dialErr = err
+	}
+	return nil, dialErr
+}
+
+// resolve returns the addresses of host, refusing it if any is not public
+func (p *TargetPolicy) resolve(ctx context.Context, host string) ([]net.IP, error) {
+	addrs, err := p.resolver.LookupIPAddr(ctx, host)
+	if err != nil || len(addrs) == 0 {
+		return nil, ErrTargetNotAllowed
+	}
+
+	ips := make([]net.IP, 0, len(addrs))
+	for _, addr := range addrs {
+		if !p.allowed(addr.IP) {
+			return nil, ErrTargetNotAllowed
+		}
+		ips = append(ips, addr.IP)
+	}
+	return ips, nil
+}
+
+// control re-checks the address actually being connected to
+func (p *TargetPolicy) control(network, address string, conn syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); ip == nil || !p.allowed(ip) {
+		return fmt.Errorf("%w: %s", ErrTargetNotAllowed, address)
+	}
+	return nil
+}
+
+func (p *TargetPolicy) allowed(ip net.IP) bool {
+	return p.AllowInsecure || PublicIP(ip)
+}
+
+// PublicIP reports whether ip is a globally routable unicast address
+func PublicIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() {
+		return false
+	}
+	for _, reserved := range reservedNets {
+		if reserved.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+func mustParseCIDRs(cidrs ...string) []*net.IPNet {
+	nets := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, ipNet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		nets = append(nets, ipNet)
+	}
+	return nets
+}
@@ -0,0 +1,194 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"wallet-service/internal/models"
+	"wallet-service/internal/repository"
+)
+
+// errRequestFailed is all a delivery records of a failed request: the
+// underlying dial or TLS error would tell a caller what answers inside the
+// network, so it is only logged
+var errRequestFailed = errors.New("request failed")
+
+// Worker sends queued deliveries, retrying failures with exponential backoff
+// until MaxAttempts is reached and the delivery is dead-lettered
+type Worker struct {
+	webhookRepo repository.IWebhookRepository
+	targets     *TargetPolicy
+	client      *http.Client
+	interval    time.Duration
+	batchSize   int
+	maxAttempts int
+	backoffBase time.Duration
+	backoffMax  time.Duration
+}
+
+func NewWorker(webhookRepo repository.IWebhookRepository, targets *TargetPolicy, timeout, interval time.Duration, maxAttempts int, backoffBase, backoffMax time.Duration) *Worker {
+	return &Worker{
+		webhookRepo: webhookRepo,
+		targets:     targets,
+		client: &http.Client{
+			Timeout: timeout,
+			// No proxy, so the dialer sees the real destination; redirects are
+			// not followed and count as a failed delivery
+			Transport: &http.Transport{
+				DialContext:         targets.DialContext,
+				TLSHandshakeTimeout: timeout,
+				MaxIdleConns:        100,
+				IdleConnTimeout:     90 * time.Second,
+			},
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		interval:    interval,
+		batchSize:   50,
+		maxAttempts: maxAttempts,
+		backoffBase: backoffBase,
+		backoffMax:  backoffMax,
+	}
+}
+
+// Start processes due deliveries on every interval until ctx is done
+func (w *Worker) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(w.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if _, err := w.ProcessDue(ctx); err != nil {
+					log.Printf("Warning: webhook delivery failed: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+// ProcessDue claims due deliveries and attempts each of them once. A delivery
+// whose endpoint or outcome cannot be read or stored does not hold up the
+// rest of the leased batch; the errors are returned together at the end.
+func (w *Worker) ProcessDue(ctx context.Context) (int, error) {
+	// Lease for longer than an attempt can take so no other instance picks
+	// the delivery up while it is in flight
+	deliveries, err := w.webhookRepo.ClaimDueDeliveries(ctx, w.batchSize, 2*w.client.Timeout)
+	if err != nil {
+		return 0, err
+	}
+
+	attempted := 0
+	var errs []error
+	for i := range deliveries {
+		endpoint, err := w.webhookRepo.GetEndpointByID(deliveries[i].EndpointID)
+		if err != nil {
+			log.Printf("Warning: webhook delivery %s: %v", deliveries[i].ID, err)
+			errs = append(errs, err)
+			continue
+		}
+		if endpoint == nil {
+			continue
+		}
+
+		if err := w.Attempt(ctx, endpoint, &deliveries[i]); err != nil {
+			log.Printf("Warning: webhook delivery %s: %v", deliveries[i].ID, err)
+			errs = append(errs, err)
+			continue
+		}
+		attempted++
+	}
+
+	return attempted, errors.Join(errs...)
+}
+
+// ValidateURL checks that a URL may be registered as a webhook endpoint
+func (w *Worker) ValidateURL(ctx context.Context, rawURL string) error {
+	return w.targets.Validate(ctx, rawURL)
+}
+
+// Attempt sends a delivery once and records the outcome on it. An error is
+// only returned when the outcome could not be stored.
+func (w *Worker) Attempt(ctx context.Context, endpoint *models.WebhookEndpoint, delivery *models.WebhookDelivery) error {
+	statusCode, sendErr := w.send(ctx, endpoint, delivery)
+
+	now := time.Now().UTC()
+	delivery.Attempts++
+	delivery.LastStatusCode = nil
+	delivery.LastError = nil
+	if statusCode != 0 {
+		delivery.LastStatusCode = &statusCode
+	}
+
+	switch {
+	case sendErr == nil:
+		delivery.Status = models.WebhookDeliveryStatusDelivered
+		delivery.DeliveredAt = &now
+		delivery.NextAttemptAt = nil
+	case delivery.Attempts >= w.maxAttempts:
+		errMsg := sendErr.Error()
+		delivery.Status = models.WebhookDeliveryStatusDead
+		delivery.LastError = &errMsg
+		delivery.NextAttemptAt = nil
+	default:
+		errMsg := sendErr.Error()
+		next := now.Add(w.Backoff(delivery.Attempts))
+		delivery.Status = models.WebhookDeliveryStatusPending
+		delivery.LastError = &errMsg
+		delivery.NextAttemptAt = &next
+	}
+
+	return w.webhookRepo.UpdateDelivery(ctx, delivery)
+}
+
+// Backoff returns the wait before the next attempt after `attempts` failures
+func (w *Worker) Backoff(attempts int) time.Duration {
+	backoff := w.backoffBase
+	for i := 1; i < attempts && backoff < w.backoffMax; i++ {
+		backoff *= 2
+	}
+	if backoff > w.backoffMax {
+		backoff = w.backoffMax
+	}
+	return backoff
+}
+
+func (w *Worker) send(ctx context.Context, endpoint *models.WebhookEndpoint, delivery *models.WebhookDelivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		log.Printf("Warning: webhook delivery %s to endpoint %s failed: %v", delivery.ID, endpoint.ID, err)
+		return 0, errRequestFailed
+	}
+
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderID, delivery.ID.String())
+	req.Header.Set(HeaderEvent, string(delivery.EventType))
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(endpoint.Secret, timestamp, delivery.Payload))
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		log.Printf("Warning: webhook delivery %s to endpoint %s failed: %v", delivery.ID, endpoint.ID, err)
+		return 0, errRequestFailed
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("endpoint responded with status %d", resp.StatusCode)
+	}
+
+	return resp.StatusCode, nil
+}
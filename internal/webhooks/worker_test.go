@@ -0,0 +1,68 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"wallet-service/internal/models"
+	"wallet-service/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// flakyWebhookRepository fails to store the outcome of one delivery
+type flakyWebhookRepository struct {
+	*repository.MockWebhookRepository
+	failing uuid.UUID
+}
+
+func (r *flakyWebhookRepository) UpdateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	if delivery.ID == r.failing {
+		return errors.New("connection reset")
+	}
+	return r.MockWebhookRepository.UpdateDelivery(ctx, delivery)
+}
+
+func TestWorker_ProcessDueContinuesPastStorageErrors(t *testing.T) {
+	received := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received++
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	webhookRepo := &flakyWebhookRepository{MockWebhookRepository: repository.NewMockWebhookRepository()}
+	endpoint := &models.WebhookEndpoint{ID: uuid.New(), UserID: uuid.New(), URL: server.URL, Secret: "0123456789abcdef"}
+	webhookRepo.CreateEndpoint(endpoint)
+
+	var ids []uuid.UUID
+	for i := 0; i < 3; i++ {
+		delivery, err := NewDelivery(endpoint.ID, uuid.New(), models.EventTypeWebhookTest, time.Now(), json.RawMessage(`{}`))
+		if err != nil {
+			t.Fatalf("Failed to build delivery: %v", err)
+		}
+		webhookRepo.CreateDelivery(ctx, delivery)
+		ids = append(ids, delivery.ID)
+	}
+	webhookRepo.failing = ids[0]
+
+	worker := NewWorker(webhookRepo, NewTargetPolicy(true), time.Second, time.Second, 3, time.Second, time.Minute)
+	attempted, err := worker.ProcessDue(ctx)
+	if err == nil {
+		t.Error("Expected the storage error to be returned")
+	}
+	if attempted != 2 || received != 3 {
+		t.Errorf("Expected every leased delivery sent and 2 recorded, got %d sent and %d recorded", received, attempted)
+	}
+	for _, id := range ids[1:] {
+		if delivery, _ := webhookRepo.GetDeliveryByID(id); delivery == nil || delivery.Status != models.WebhookDeliveryStatusDelivered {
+			t.Errorf("Expected delivery %s delivered despite the earlier failure", id)
+		}
+	}
+}
@@ -1,22 +1,49 @@
 package main
 
 import (
+	"context"
 	"log"
+	"net"
 	"os"
 
+	"wallet-service/internal/accountstatus"
+	"wallet-service/internal/apikey"
+	"wallet-service/internal/audit"
 	"wallet-service/internal/cache"
 	"wallet-service/internal/config"
+	"wallet-service/internal/events"
+	"wallet-service/internal/grpcapi"
 	"wallet-service/internal/handlers"
+	"wallet-service/internal/idempotency"
+	"wallet-service/internal/kyc"
+	"wallet-service/internal/mailer"
 	"wallet-service/internal/middleware"
+	"wallet-service/internal/models"
+	"wallet-service/internal/openapi"
 	"wallet-service/internal/persistence"
+	"wallet-service/internal/pricefeed"
+	"wallet-service/internal/problem"
 	"wallet-service/internal/repository"
+	"wallet-service/internal/service"
+	"wallet-service/internal/session"
+	"wallet-service/internal/signing"
+	"wallet-service/internal/stream"
+	"wallet-service/internal/twofactor"
+	"wallet-service/internal/webhooks"
 
 	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
 )
 
 func main() {
 	// Load configuration
 	cfg := config.Load()
+	if err := cfg.Validate(); err != nil {
+		if !cfg.IsDevelopment() {
+			log.Fatal(err, " (set APP_ENV=development to run anyway)")
+		}
+		log.Printf("Warning: %v", err)
+	}
 
 	// Initialize Redis instance
 	redisClient := cache.NewRedisClient(cfg.RedisHost, cfg.RedisPort)
@@ -35,33 +62,335 @@ func main() {
 	var userRepo repository.IUserRepository = repository.NewUserRepository(db)
 	var walletRepo repository.IWalletRepository = repository.NewWalletRepository(db)
 	var transactionRepo repository.ITransactionRepository = repository.NewTransactionRepository(db)
+	var priceRepo repository.IPriceRepository = repository.NewPriceRepository(db)
+	var outboxRepo repository.IOutboxRepository = repository.NewOutboxRepository(db)
+	var webhookRepo repository.IWebhookRepository = repository.NewWebhookRepository(db)
+	var auditRepo repository.IAuditRepository = repository.NewAuditRepository(db)
+	var idempotencyRepo repository.IIdempotencyRepository = repository.NewIdempotencyRepository(db)
+	var passwordResetRepo repository.IPasswordResetRepository = repository.NewPasswordResetRepository(db)
+	var refreshTokenRepo repository.IRefreshTokenRepository = repository.NewRefreshTokenRepository(db)
+	var twoFactorRepo repository.ITwoFactorRepository = repository.NewTwoFactorRepository(db)
+	var apiKeyRepo repository.IAPIKeyRepository = repository.NewAPIKeyRepository(db)
+	var signingKeyRepo repository.ISigningKeyRepository = repository.NewSigningKeyRepository(db)
+	var registrationRepo repository.IRegistrationRepository = repository.NewRegistrationRepository(db)
+	var kycRepo repository.IKYCRepository = repository.NewKYCRepository(db)
+	var adjustmentRepo repository.IAdjustmentRepository = repository.NewAdjustmentRepository(db)
+	var withdrawalReviewRepo repository.IWithdrawalReviewRepository = repository.NewWithdrawalReviewRepository(db)
+
+	auditor := audit.NewAuditor(auditRepo, txManager)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Initialize idempotency store; Redis only fronts the idempotency_keys table
+	idempotencyStore := idempotency.NewStore(idempotencyRepo, txManager, redisClient, cfg.IdempotencyRetention, cfg.IdempotencyLockTTL)
+	idempotencyStore.StartCleanup(ctx, cfg.IdempotencyCleanupInterval)
+
+	// Initialize price feed for fiat valuation
+	priceSource, err := pricefeed.NewPriceSource(cfg.PriceSource)
+	if err != nil {
+		log.Fatal("Failed to configure price source:", err)
+	}
+	priceFeed := pricefeed.NewFeed(priceSource, priceRepo, cfg.PriceCurrencies, cfg.PriceRefreshInterval, cfg.PriceStaleAfter)
+	priceFeed.Start(ctx)
+
+	// Initialize outbox relay publishing domain events
+	eventSink, err := events.NewSink(cfg.EventSink, redisClient, cfg.EventStream)
+	if err != nil {
+		log.Fatal("Failed to configure event sink:", err)
+	}
+	webhookDispatcher := webhooks.NewDispatcher(webhookRepo, walletRepo)
+	streamSink := events.NewPubSubSink(redisClient, cfg.StreamChannelPrefix)
+	consumers := []events.Consumer{
+		{Name: "events", Sink: eventSink},
+		{Name: "webhooks", Sink: webhookDispatcher},
+		{Name: "stream", Sink: streamSink},
+	}
+	events.NewRelay(outboxRepo, txManager, consumers, cfg.OutboxPollInterval, cfg.OutboxBatchSize).Start(ctx)
+
+	// Initialize webhook delivery worker
+	webhookWorker := webhooks.NewWorker(webhookRepo, webhooks.NewTargetPolicy(cfg.IsDevelopment()), cfg.WebhookTimeout, cfg.WebhookPollInterval, cfg.WebhookMaxAttempts, cfg.WebhookBackoffBase, cfg.WebhookBackoffMax)
+	webhookWorker.Start(ctx)
+
+	// Initialize live stream fan-out from Redis pub/sub
+	streamHub := stream.NewHub(redisClient, cfg.StreamChannelPrefix)
+	streamHub.Start(ctx)
+
+	// Initialize access token signing keys; a key keeps verifying for one
+	// access token lifetime after its successor takes over
+	keyring := signing.NewKeyring()
+	keyRotator, err := signing.NewRotator(signingKeyRepo, txManager, keyring, cfg.JWTSecret, cfg.JWTSigningAlgorithm, cfg.JWTKeyRotation, cfg.AccessTokenTTL)
+	if err != nil {
+		log.Fatal("Failed to configure token signing:", err)
+	}
+	if err := keyRotator.Rotate(ctx); err != nil {
+		log.Fatal("Failed to load signing keys:", err)
+	}
+	keyRotator.Start(ctx, cfg.JWTKeyRefreshInterval)
+
+	tokens := middleware.TokenConfig{
+		Keys:      keyring,
+		Issuer:    cfg.JWTIssuer,
+		Audience:  cfg.JWTAudience,
+		AccessTTL: cfg.AccessTokenTTL,
+	}
+
+	// Sessions live as long as their refresh token family
+	sessions := session.NewStore(redisClient, cfg.RefreshTokenTTL)
+
+	twoFactor, err := twofactor.NewService(twoFactorRepo, txManager, redisClient, cfg.TOTPIssuer, cfg.TOTPEncryptionKey, cfg.OTPMaxAttempts, cfg.OTPLockout)
+	if err != nil {
+		log.Fatal("Failed to configure two-factor authentication:", err)
+	}
+
+	// Transfers above their coin's threshold need a fresh one-time code
+	stepUpThresholds := middleware.NewStepUpThresholds(walletRepo, coinAmounts("STEP_UP_TRANSFER_THRESHOLDS", cfg.StepUpTransferThresholds))
+
+	// Initialize outbound mail for verification and password reset messages
+	mail, err := mailer.New(cfg.MailTransport, cfg.MailFrom)
+	if err != nil {
+		log.Fatal("Failed to configure mail transport:", err)
+	}
 
-	authHandler := handlers.NewAuthHandler(userRepo, redisClient, cfg.JWTSecret)
-	walletHandler := handlers.NewWalletHandler(walletRepo, transactionRepo, txManager)
+	// Account status is checked on every authenticated request
+	statuses := accountstatus.NewCache(userRepo, redisClient, cfg.UserStatusCacheTTL)
+	statusReadAccess := make([]models.UserStatus, 0, len(cfg.UserStatusReadAccess))
+	for _, status := range cfg.UserStatusReadAccess {
+		if !middleware.IsUserStatus(models.UserStatus(status)) {
+			log.Fatalf("Unknown status %q in USER_STATUS_READ_ACCESS", status)
+		}
+		statusReadAccess = append(statusReadAccess, models.UserStatus(status))
+	}
+
+	// KYC documents are kept on local disk and reviewed by staff
+	kycService := kyc.NewService(kycRepo, userRepo, txManager, kyc.NewStore(cfg.KYCStorageDir, cfg.KYCMaxDocumentSize), kyc.NewManualReview())
+	kycPolicy := kyc.NewPolicy(map[models.KYCTier]map[models.CoinType]decimal.Decimal{
+		models.KYCTierUnverified: coinAmounts("KYC_LIMITS_UNVERIFIED", cfg.KYCLimitsUnverified),
+		models.KYCTierBasic:      coinAmounts("KYC_LIMITS_BASIC", cfg.KYCLimitsBasic),
+		models.KYCTierFull:       coinAmounts("KYC_LIMITS_FULL", cfg.KYCLimitsFull),
+	}, cfg.KYCLimitWindow)
+
+	// New users get a wallet for each of these coins
+	walletCoins := make([]models.CoinType, 0, len(cfg.WalletCoins))
+	for _, coin := range cfg.WalletCoins {
+		switch coinType := models.CoinType(coin); coinType {
+		case models.CoinTypeBTC, models.CoinTypeETH, models.CoinTypeADA:
+			walletCoins = append(walletCoins, coinType)
+		default:
+			log.Fatalf("Unknown coin %q in WALLET_COINS", coin)
+		}
+	}
+
+	// Withdrawals above these amounts wait for an operator
+	reviewThresholds := coinAmounts("WITHDRAWAL_REVIEW_THRESHOLDS", cfg.WithdrawalReviewThresholds)
+	walletService := service.NewWalletService(walletRepo, transactionRepo, outboxRepo, withdrawalReviewRepo, userRepo, txManager, reviewThresholds, kycPolicy)
+
+	// Balance adjustments nobody reviewed in time expire in the background
+	adjustmentExpiry := service.NewAdjustmentExpiry(adjustmentRepo, txManager, auditor)
+	adjustmentExpiry.Start(ctx, cfg.AdjustmentExpiryInterval)
+
+	authHandler := handlers.NewAuthHandler(userRepo, passwordResetRepo, refreshTokenRepo, txManager, sessions, twoFactor, mail, tokens, cfg.RefreshTokenTTL, cfg.PasswordResetTTL)
+	registrationHandler := handlers.NewRegistrationHandler(userRepo, walletRepo, registrationRepo, txManager, mail, walletCoins, cfg.PublicBaseURL, cfg.EmailVerificationTTL, cfg.EmailVerificationMaxAttempts)
+	sessionHandler := handlers.NewSessionHandler(refreshTokenRepo, txManager, sessions)
+	twoFactorHandler := handlers.NewTwoFactorHandler(userRepo, twoFactor)
+	apiKeyHandler := handlers.NewAPIKeyHandler(apiKeyRepo, walletRepo)
+	walletHandler := handlers.NewWalletHandler(walletService, priceFeed)
+	webhookHandler := handlers.NewWebhookHandler(webhookRepo, webhookWorker)
+	auditHandler := handlers.NewAuditHandler(auditRepo, auditor)
+	jwksHandler := handlers.NewJWKSHandler(keyring)
+	adjustmentHandler := handlers.NewAdjustmentHandler(adjustmentRepo, walletService, adjustmentExpiry, txManager, cfg.AdjustmentTTL)
+	withdrawalHandler := handlers.NewWithdrawalHandler(userRepo, walletService, mail)
+	kycHandler := handlers.NewKYCHandler(userRepo, kycRepo, kycService, cfg.KYCMaxDocumentSize)
+	adminHandler := handlers.NewAdminHandler(userRepo, walletRepo, transactionRepo, refreshTokenRepo, txManager, sessions, statuses)
+	errorHandler := handlers.NewErrorHandler()
+	apiSpec := openapi.Build(cfg.PublicBaseURL)
+	openAPIHandler, err := handlers.NewOpenAPIHandler(apiSpec)
+	if err != nil {
+		log.Fatal("Failed to build the OpenAPI document:", err)
+	}
+	streamHandler := handlers.NewStreamHandler(walletRepo, outboxRepo, streamHub, sessions, tokens, cfg.StreamHeartbeat)
+
+	// Money and API rate limits are shared by the REST and gRPC frontends, so
+	// switching protocol does not buy a second budget
+	rateLimiter := middleware.NewRateLimiter(redisClient)
+	moneyPolicy := middleware.RateLimitPolicy{Name: "money", Limit: cfg.RateLimitMoney.Limit, Window: cfg.RateLimitMoney.Window, Key: middleware.ByCaller}
+	apiPolicy := middleware.RateLimitPolicy{Name: "api", Limit: cfg.RateLimitAPI.Limit, Window: cfg.RateLimitAPI.Window, Key: middleware.ByCaller}
+
+	// The gRPC API shares the REST routes' credentials, rules, rate limits and
+	// idempotency store; its interceptors mirror their middleware
+	grpcGuard := grpcapi.NewGuard(tokens, sessions, apikey.NewAuthenticator(apiKeyRepo), statuses, statusReadAccess, twoFactor, stepUpThresholds, idempotencyStore, auditor, rateLimiter, apiPolicy, moneyPolicy)
+	grpcServer := grpcapi.NewGRPCServer(grpcapi.NewServer(walletService, walletRepo, outboxRepo, streamHub, grpcGuard, cfg.StreamHeartbeat))
 
 	router := gin.Default()
-	router.Use(middleware.Logger())
+	if err := router.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		log.Fatal("Invalid TRUSTED_PROXIES:", err)
+	}
+	// Errors reported with c.Error are answered in one place, inside the
+	// logger so it records the mapped status. Every problem links to the
+	// error catalog and quotes the request ID.
+	problem.SetDocsBaseURL(cfg.PublicBaseURL + "/errors")
+	router.Use(middleware.RequestID(), middleware.Logger(), middleware.HandleErrors(), middleware.Recover())
+	// Requests that break the API contract are turned away before any work
+	router.Use(middleware.ValidateRequest(apiSpec))
+	router.NoRoute(middleware.NotFound)
+	router.NoMethod(middleware.NotFound)
+
+	// Rate limits run before auditing so a flood cannot bloat the audit log
+	authLimit := rateLimiter.Limit(middleware.RateLimitPolicy{Name: "auth", Limit: cfg.RateLimitAuth.Limit, Window: cfg.RateLimitAuth.Window, Key: middleware.ByIP})
+	moneyLimit := rateLimiter.Limit(moneyPolicy)
+	apiLimit := rateLimiter.Limit(apiPolicy)
+	adminLimit := rateLimiter.Limit(middleware.RateLimitPolicy{Name: "admin", Limit: cfg.RateLimitAdmin.Limit, Window: cfg.RateLimitAdmin.Window, Key: middleware.ByCaller})
 
 	// Public routes
-	router.POST("/auth/login", authHandler.Login)
+	router.GET("/openapi.json", openAPIHandler.GetSpec)
+	router.GET("/.well-known/jwks.json", jwksHandler.GetJWKS)
+	router.GET("/errors", errorHandler.ListErrors)
+	router.GET("/errors/:code", errorHandler.GetError)
+	router.POST("/auth/register", authLimit, middleware.Audit(auditor, "auth.register"), registrationHandler.Register)
+	router.GET("/auth/verify-email", authLimit, middleware.Audit(auditor, "auth.verify_email"), registrationHandler.VerifyEmail)
+	router.POST("/auth/verify-email", authLimit, middleware.Audit(auditor, "auth.verify_email"), registrationHandler.VerifyEmail)
+	router.POST("/auth/login", authLimit, middleware.Audit(auditor, "auth.login"), authHandler.Login)
+	router.POST("/auth/refresh", authLimit, middleware.Audit(auditor, "auth.refresh"), authHandler.Refresh)
+	router.POST("/auth/password/reset-request", authLimit, middleware.Audit(auditor, "auth.password_reset_request"), authHandler.RequestPasswordReset)
+	router.POST("/auth/password/reset", authLimit, middleware.Audit(auditor, "auth.password_reset"), authHandler.ResetPassword)
+
+	idempotencyGuard := middleware.IdempotencyGuard(idempotencyStore)
+	// Step-up runs after the idempotency guard so replays of a completed
+	// request need no new code, and before the handler's ledger transaction
+	requireOTP := middleware.RequireOTP(twoFactor)
+
+	authMiddleware := middleware.AuthMiddleware(tokens, sessions, apikey.NewAuthenticator(apiKeyRepo))
+	// Suspended and locked accounts stop here, after authentication
+	statusGuard := middleware.UserStatusGuard(statuses, statusReadAccess)
+
+	// Session routes skip the status check so any user can always log out
+	sessionRouter := router.Group("/")
+	sessionRouter.Use(authMiddleware, apiLimit, middleware.SessionOnly())
+	{
+		sessionRouter.POST("/auth/logout", middleware.Audit(auditor, "auth.logout"), sessionHandler.Logout)
+		sessionRouter.GET("/auth/sessions", sessionHandler.ListSessions)
+		sessionRouter.DELETE("/auth/sessions", middleware.Audit(auditor, "auth.sessions.revoke_all"), sessionHandler.RevokeAllSessions)
+		sessionRouter.DELETE("/auth/sessions/:session_id", middleware.Audit(auditor, "auth.sessions.revoke"), sessionHandler.RevokeSession)
+	}
 
+	// Account and security routes need a logged-in user; API keys are refused
+	accountRouter := router.Group("/")
+	accountRouter.Use(authMiddleware, apiLimit, middleware.SessionOnly(), statusGuard)
+	{
+		accountRouter.POST("/auth/password", middleware.Audit(auditor, "auth.password_change"), requireOTP, authHandler.ChangePassword)
+
+		// Two-factor routes
+		accountRouter.POST("/auth/2fa/enroll", middleware.Audit(auditor, "auth.2fa.enroll"), twoFactorHandler.Enroll)
+		accountRouter.POST("/auth/2fa/confirm", middleware.Audit(auditor, "auth.2fa.confirm"), twoFactorHandler.Confirm)
+		accountRouter.DELETE("/auth/2fa", middleware.Audit(auditor, "auth.2fa.disable"), requireOTP, twoFactorHandler.Disable)
+		accountRouter.POST("/auth/2fa/recovery-codes", middleware.Audit(auditor, "auth.2fa.recovery_codes"), requireOTP, twoFactorHandler.RegenerateRecoveryCodes)
+
+		// Wallet lifecycle routes
+		accountRouter.POST("/wallets", middleware.Audit(auditor, "wallet.open"), walletHandler.OpenWallet)
+		accountRouter.POST("/wallets/:wallet_id/close", moneyLimit, middleware.Audit(auditor, "wallet.close"), idempotencyGuard, requireOTP, walletHandler.CloseWallet)
+
+		// KYC routes
+		accountRouter.POST("/kyc/submissions", middleware.Audit(auditor, "kyc.submit"), kycHandler.SubmitDocument)
+		accountRouter.GET("/kyc", kycHandler.GetStatus)
+
+		// API key routes
+		accountRouter.POST("/api-keys", middleware.Audit(auditor, "api_key.create"), requireOTP, apiKeyHandler.CreateAPIKey)
+		accountRouter.GET("/api-keys", apiKeyHandler.ListAPIKeys)
+		accountRouter.DELETE("/api-keys/:key_id", middleware.Audit(auditor, "api_key.revoke"), apiKeyHandler.RevokeAPIKey)
+	}
+
+	// Routes open to API keys declare the scope they need
 	walletRouter := router.Group("/")
-	walletRouter.Use(middleware.AuthMiddleware(cfg.JWTSecret, redisClient))
+	walletRouter.Use(authMiddleware, apiLimit, statusGuard)
 	{
 		// Wallet routes
-		walletRouter.GET("/wallets", walletHandler.GetUserWallets)
-		walletRouter.POST("/wallets/:wallet_id/deposit", middleware.IdempotencyGuard(redisClient), walletHandler.Deposit)
-		walletRouter.POST("/wallets/:wallet_id/withdraw", middleware.IdempotencyGuard(redisClient), walletHandler.Withdraw)
-		walletRouter.POST("/wallets/:wallet_id/transfer", middleware.IdempotencyGuard(redisClient), walletHandler.Transfer)
-		walletRouter.GET("/wallets/:wallet_id/balance", walletHandler.GetBalance)
-		walletRouter.GET("/wallets/:wallet_id/transactions", walletHandler.GetTransactions)
+		walletRouter.GET("/wallets", middleware.RequireScope(models.ScopeWalletsRead), walletHandler.GetUserWallets)
+		walletRouter.POST("/wallets/:wallet_id/deposit", moneyLimit, middleware.Audit(auditor, "wallet.deposit"), middleware.RequireScope(models.ScopeDepositsWrite), idempotencyGuard, walletHandler.Deposit)
+		walletRouter.POST("/wallets/:wallet_id/withdraw", moneyLimit, middleware.Audit(auditor, "wallet.withdraw"), middleware.RequireScope(models.ScopeWithdrawalsWrite), idempotencyGuard, requireOTP, walletHandler.Withdraw)
+		walletRouter.POST("/wallets/:wallet_id/transfer", moneyLimit, middleware.Audit(auditor, "wallet.transfer"), middleware.RequireScope(models.ScopeTransfersWrite), idempotencyGuard, middleware.RequireOTPAbove(twoFactor, stepUpThresholds), walletHandler.Transfer)
+		walletRouter.GET("/wallets/:wallet_id/balance", middleware.RequireScope(models.ScopeWalletsRead), walletHandler.GetBalance)
+		walletRouter.GET("/wallets/:wallet_id/transactions", middleware.RequireScope(models.ScopeTransactionsRead), walletHandler.GetTransactions)
+
+		// Withdrawals held for review
+		walletRouter.GET("/withdrawals", middleware.RequireScope(models.ScopeTransactionsRead), withdrawalHandler.ListUserWithdrawals)
+		walletRouter.POST("/withdrawals/:transaction_id/cancel", middleware.Audit(auditor, "wallet.withdrawal.cancel"), middleware.RequireScope(models.ScopeWithdrawalsWrite), withdrawalHandler.CancelWithdrawal)
+
+		// Webhook routes
+		walletRouter.POST("/webhooks", middleware.Audit(auditor, "webhook.create"), middleware.RequireScope(models.ScopeWebhooksWrite), webhookHandler.CreateWebhook)
+		walletRouter.GET("/webhooks", middleware.RequireScope(models.ScopeWebhooksRead), webhookHandler.ListWebhooks)
+		walletRouter.DELETE("/webhooks/:webhook_id", middleware.Audit(auditor, "webhook.delete"), middleware.RequireScope(models.ScopeWebhooksWrite), webhookHandler.DeleteWebhook)
+		walletRouter.POST("/webhooks/:webhook_id/test", middleware.Audit(auditor, "webhook.test"), middleware.RequireScope(models.ScopeWebhooksWrite), webhookHandler.TestWebhook)
+		walletRouter.GET("/webhooks/:webhook_id/deliveries", middleware.RequireScope(models.ScopeWebhooksRead), webhookHandler.GetDeliveries)
+		walletRouter.POST("/webhooks/:webhook_id/deliveries/:delivery_id/retry", middleware.Audit(auditor, "webhook.retry_delivery"), middleware.RequireScope(models.ScopeWebhooksWrite), webhookHandler.RetryDelivery)
 	}
 
+	// Staff routes; every call, reads included, is audited
+	staffOnly := middleware.RequireRole(middleware.StaffRoles...)
+	operatorOnly := middleware.RequireRole(models.RoleOperator, models.RoleAdmin)
+	adminOnly := middleware.RequireRole(models.RoleAdmin)
+
+	adminRouter := router.Group("/admin")
+	adminRouter.Use(middleware.StaffAuth(cfg.AdminAPIToken, tokens, sessions), adminLimit, statusGuard)
+	{
+		// Audit routes
+		adminRouter.GET("/audit-logs", middleware.Audit(auditor, "admin.audit_logs.list"), adminOnly, auditHandler.ListAuditLogs)
+		adminRouter.GET("/audit-logs/verify", middleware.Audit(auditor, "admin.audit_logs.verify"), adminOnly, auditHandler.VerifyAuditChain)
+
+		// Customer lookups
+		adminRouter.GET("/users/:user_id", middleware.Audit(auditor, "admin.users.get"), staffOnly, adminHandler.GetUser)
+		adminRouter.GET("/users/:user_id/wallets", middleware.Audit(auditor, "admin.users.wallets"), staffOnly, adminHandler.GetUserWallets)
+		adminRouter.GET("/wallets/:wallet_id/transactions", middleware.Audit(auditor, "admin.wallets.transactions"), staffOnly, adminHandler.GetWalletTransactions)
+
+		// User management
+		adminRouter.PUT("/users/:user_id/role", middleware.Audit(auditor, "admin.users.role"), adminOnly, adminHandler.UpdateUserRole)
+		adminRouter.PUT("/users/:user_id/status", middleware.Audit(auditor, "admin.users.status"), operatorOnly, adminHandler.UpdateUserStatus)
+		adminRouter.DELETE("/users/:user_id/sessions", middleware.Audit(auditor, "admin.sessions.revoke_all"), operatorOnly, sessionHandler.RevokeUserSessions)
+
+		// KYC review
+		adminRouter.GET("/kyc/submissions", middleware.Audit(auditor, "admin.kyc.list"), staffOnly, kycHandler.ListSubmissions)
+		adminRouter.GET("/kyc/submissions/:submission_id/document", middleware.Audit(auditor, "admin.kyc.document"), operatorOnly, kycHandler.GetDocument)
+		adminRouter.POST("/kyc/submissions/:submission_id/approve", middleware.Audit(auditor, "admin.kyc.approve"), operatorOnly, kycHandler.ApproveSubmission)
+		adminRouter.POST("/kyc/submissions/:submission_id/reject", middleware.Audit(auditor, "admin.kyc.reject"), operatorOnly, kycHandler.RejectSubmission)
+		adminRouter.PUT("/users/:user_id/kyc-tier", middleware.Audit(auditor, "admin.users.kyc_tier"), operatorOnly, kycHandler.UpdateUserTier)
+
+		// Balance adjustments: filed by operators, approved by a second admin
+		adminRouter.GET("/adjustments", middleware.Audit(auditor, "admin.adjustments.list"), staffOnly, adjustmentHandler.ListAdjustments)
+		adminRouter.GET("/adjustments/:adjustment_id", middleware.Audit(auditor, "admin.adjustments.get"), staffOnly, adjustmentHandler.GetAdjustment)
+		adminRouter.POST("/adjustments", middleware.Audit(auditor, "admin.adjustments.create"), operatorOnly, adjustmentHandler.CreateAdjustment)
+		adminRouter.POST("/adjustments/:adjustment_id/approve", middleware.Audit(auditor, "admin.adjustments.approve"), adminOnly, adjustmentHandler.ApproveAdjustment)
+		adminRouter.POST("/adjustments/:adjustment_id/reject", middleware.Audit(auditor, "admin.adjustments.reject"), adminOnly, adjustmentHandler.RejectAdjustment)
+
+		// Withdrawal review queue
+		adminRouter.GET("/withdrawals", middleware.Audit(auditor, "admin.withdrawals.list"), staffOnly, withdrawalHandler.ListWithdrawals)
+		adminRouter.POST("/withdrawals/:transaction_id/approve", middleware.Audit(auditor, "admin.withdrawals.approve"), operatorOnly, withdrawalHandler.ApproveWithdrawal)
+		adminRouter.POST("/withdrawals/:transaction_id/reject", middleware.Audit(auditor, "admin.withdrawals.reject"), operatorOnly, withdrawalHandler.RejectWithdrawal)
+
+		// Wallet holds
+		adminRouter.POST("/wallets/:wallet_id/freeze", middleware.Audit(auditor, "admin.wallets.freeze"), operatorOnly, adminHandler.FreezeWallet)
+		adminRouter.POST("/wallets/:wallet_id/unfreeze", middleware.Audit(auditor, "admin.wallets.unfreeze"), operatorOnly, adminHandler.UnfreezeWallet)
+	}
+
+	// Live balance and transaction stream (SSE)
+	router.GET("/stream/events", middleware.StreamAuthMiddleware(tokens, sessions), apiLimit, statusGuard, streamHandler.StreamEvents)
+
 	// Health check
 	router.GET("/health", func(c *gin.Context) {
 		c.JSON(200, gin.H{"status": "ok"})
 	})
 
+	// Start gRPC server
+	grpcListener, err := net.Listen("tcp", ":"+cfg.GRPCPort)
+	if err != nil {
+		log.Fatal("Failed to listen for gRPC:", err)
+	}
+	go func() {
+		log.Printf("gRPC server starting on port %s", cfg.GRPCPort)
+		if err := grpcServer.Serve(grpcListener); err != nil {
+			log.Fatal("Failed to start gRPC server:", err)
+		}
+	}()
+	defer grpcServer.GracefulStop()
+
 	// Start server
 	port := os.Getenv("PORT")
 	if port == "" {
@@ -73,3 +402,18 @@ func main() {
 		log.Fatal("Failed to start server:", err)
 	}
 }
+
+// coinAmounts keys a per-coin setting by coin type, refusing coins the
+// service does not know
+func coinAmounts(setting string, amounts map[string]decimal.Decimal) map[models.CoinType]decimal.Decimal {
+	byCoin := make(map[models.CoinType]decimal.Decimal, len(amounts))
+	for coin, amount := range amounts {
+		switch coinType := models.CoinType(coin); coinType {
+		case models.CoinTypeBTC, models.CoinTypeETH, models.CoinTypeADA:
+			byCoin[coinType] = amount
+		default:
+			log.Fatalf("Unknown coin %q in %s", coin, setting)
+		}
+	}
+	return byCoin
+}
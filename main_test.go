@@ -0,0 +1,116 @@
+package main
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strconv"
+	"strings"
+	"testing"
+
+	"wallet-service/internal/openapi"
+)
+
+// registeredRoutes reads the routes main registers, as "METHOD /path" with
+// gin's :param segments, by walking main.go's router and group calls
+func registeredRoutes(t *testing.T) map[string]bool {
+	t.Helper()
+	file, err := parser.ParseFile(token.NewFileSet(), "main.go", nil, 0)
+	if err != nil {
+		t.Fatalf("Failed to parse main.go: %v", err)
+	}
+
+	prefixes := map[string]string{"router": ""}
+	routes := map[string]bool{}
+	ast.Inspect(file, func(node ast.Node) bool {
+		switch node := node.(type) {
+		case *ast.AssignStmt:
+			// group := router.Group("/admin")
+			if len(node.Lhs) != 1 || len(node.Rhs) != 1 {
+				return true
+			}
+			name, ok := node.Lhs[0].(*ast.Ident)
+			if !ok {
+				return true
+			}
+			if receiver, method, path, ok := routerCall(node.Rhs[0]); ok && method == "Group" {
+				if parent, ok := prefixes[receiver]; ok {
+					prefixes[name.Name] = joinRoute(parent, path)
+				}
+			}
+		case *ast.CallExpr:
+			receiver, method, path, ok := routerCall(node)
+			if !ok {
+				return true
+			}
+			switch method {
+			case "GET", "POST", "PUT", "PATCH", "DELETE":
+				prefix, known := prefixes[receiver]
+				if !known {
+					t.Errorf("Route %s %s is registered on an unknown router %s", method, path, receiver)
+					return true
+				}
+				routes[method+" "+joinRoute(prefix, path)] = true
+			}
+		}
+		return true
+	})
+	return routes
+}
+
+// routerCall matches receiver.Method("literal", ...)
+func routerCall(expr ast.Expr) (receiver, method, path string, ok bool) {
+	call, isCall := expr.(*ast.CallExpr)
+	if !isCall || len(call.Args) == 0 {
+		return "", "", "", false
+	}
+	selector, isSelector := call.Fun.(*ast.SelectorExpr)
+	if !isSelector {
+		return "", "", "", false
+	}
+	ident, isIdent := selector.X.(*ast.Ident)
+	literal, isLiteral := call.Args[0].(*ast.BasicLit)
+	if !isIdent || !isLiteral || literal.Kind != token.STRING {
+		return "", "", "", false
+	}
+	path, err := strconv.Unquote(literal.Value)
+	if err != nil {
+		return "", "", "", false
+	}
+	return ident.Name, selector.Sel.Name, path, true
+}
+
+func joinRoute(prefix, path string) string {
+	return strings.TrimRight(prefix, "/") + path
+}
+
+func TestEveryRouteIsSpecified(t *testing.T) {
+	routes := registeredRoutes(t)
+	if len(routes) == 0 {
+		t.Fatal("Found no routes in main.go")
+	}
+
+	doc := openapi.Build("")
+	for route := range routes {
+		method, path, _ := strings.Cut(route, " ")
+		if doc.Operation(method, path) == nil {
+			t.Errorf("%s has no entry in the OpenAPI document", route)
+		}
+	}
+
+	// The document lists no route that does not exist
+	for operation := range doc.Operations() {
+		method, path, _ := strings.Cut(operation, " ")
+		found := false
+		for route := range routes {
+			routeMethod, routePath, _ := strings.Cut(route, " ")
+			if routeMethod == method && openapi.PathFromRoute(routePath) == path {
+				found = true
+				break
+			}
+		}
+		if !found {
+			t.Errorf("%s is documented but not registered", operation)
+		}
+	}
+}
@@ -1,7 +1,8 @@
 
 CREATE TYPE coin_type AS ENUM ('BTC', 'ETH', 'ADA');
+CREATE TYPE wallet_status AS ENUM ('ACTIVE', 'FROZEN', 'CLOSING', 'CLOSED');
 
-CREATE TYPE transaction_type AS ENUM ('DEPOSIT', 'WITHDRAWAL', 'TRANSFER');
+CREATE TYPE transaction_type AS ENUM ('DEPOSIT', 'WITHDRAWAL', 'TRANSFER', 'ADJUSTMENT');
 
 CREATE TYPE transaction_status AS ENUM ('PENDING', 'DONE', 'FAILED');
 
@@ -11,6 +12,115 @@ CREATE TABLE users (
     id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
     name TEXT NOT NULL,
     email TEXT UNIQUE NOT NULL,
+    password_hash TEXT NOT NULL DEFAULT '',
+    password_changed_at TIMESTAMP,
+    role TEXT NOT NULL DEFAULT 'user' CHECK (role IN ('user', 'support-readonly', 'operator', 'admin')),
+    status TEXT NOT NULL DEFAULT 'active' CHECK (status IN ('active', 'suspended', 'locked-for-review')),
+    status_reason TEXT NOT NULL DEFAULT '',
+    status_changed_at TIMESTAMP,
+    kyc_tier TEXT NOT NULL DEFAULT 'unverified' CHECK (kyc_tier IN ('unverified', 'basic', 'full')),
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+-- Only the SHA-256 of a reset token is stored; the token itself is sent to the user
+CREATE TABLE password_reset_tokens (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    token_hash TEXT UNIQUE NOT NULL,
+    created_at TIMESTAMP DEFAULT NOW(),
+    expires_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP
+);
+
+-- Sign-ups waiting for email verification. The user and their wallets are only
+-- created once a link token or code is presented; both are stored as SHA-256
+CREATE TABLE registrations (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    email TEXT NOT NULL,
+    name TEXT NOT NULL,
+    password_hash TEXT NOT NULL,
+    token_hash TEXT UNIQUE NOT NULL,
+    code_hash TEXT NOT NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT NOW(),
+    expires_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP
+);
+
+-- KYC documents; the file itself is kept on local disk at storage_path
+CREATE TABLE kyc_submissions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    requested_tier TEXT NOT NULL CHECK (requested_tier IN ('basic', 'full')),
+    document_type TEXT NOT NULL CHECK (document_type IN ('passport', 'national-id', 'drivers-license', 'proof-of-address')),
+    file_name TEXT NOT NULL,
+    content_type TEXT NOT NULL,
+    size_bytes BIGINT NOT NULL,
+    sha256 TEXT NOT NULL,
+    storage_path TEXT NOT NULL,
+    provider TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'approved', 'rejected')),
+    reviewer_id UUID REFERENCES users(id),
+    review_note TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT NOW(),
+    reviewed_at TIMESTAMP
+);
+
+-- Refresh tokens rotate on every use; all tokens descending from one login
+-- share a family_id so reuse of a rotated token can revoke the whole chain
+CREATE TABLE refresh_tokens (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    family_id UUID NOT NULL,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    token_hash TEXT UNIQUE NOT NULL,
+    created_at TIMESTAMP DEFAULT NOW(),
+    expires_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP,
+    revoked_at TIMESTAMP
+);
+
+-- TOTP secrets are sealed with AES-GCM; last_used_step rejects replayed codes
+CREATE TABLE user_totp (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    secret TEXT NOT NULL,
+    enabled_at TIMESTAMP,
+    last_used_step BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE TABLE recovery_codes (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    code_hash TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT NOW(),
+    used_at TIMESTAMP,
+    UNIQUE(user_id, code_hash)
+);
+
+-- API keys are stored as SHA-256 hashes; prefix identifies a key in listings
+CREATE TABLE api_keys (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    prefix TEXT NOT NULL,
+    key_hash TEXT UNIQUE NOT NULL,
+    scopes TEXT[] NOT NULL,
+    wallet_ids UUID[] NOT NULL DEFAULT '{}',
+    max_amount NUMERIC(20, 6),
+    expires_at TIMESTAMP,
+    last_used_at TIMESTAMP,
+    revoked_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+-- Access token signing keys. The private key is sealed with JWT_SECRET; a key
+-- signs from activates_at until the next key activates
+CREATE TABLE signing_keys (
+    kid TEXT PRIMARY KEY,
+    algorithm TEXT NOT NULL CHECK (algorithm IN ('EdDSA', 'RS256')),
+    private_key TEXT NOT NULL,
+    public_key BYTEA NOT NULL,
+    activates_at TIMESTAMP NOT NULL,
     created_at TIMESTAMP DEFAULT NOW()
 );
 
@@ -20,8 +130,9 @@ CREATE TABLE wallets (
     coin_type coin_type NOT NULL,
     amount NUMERIC(20, 6) DEFAULT 0,
     frozen_amount NUMERIC(20, 6) DEFAULT 0,
+    status wallet_status NOT NULL DEFAULT 'ACTIVE',
     created_at TIMESTAMP DEFAULT NOW(),
-    UNIQUE(user_id, coin_type)
+    closed_at TIMESTAMP
 );
 
 CREATE TABLE transactions (
@@ -41,8 +152,176 @@ CREATE TABLE transaction_entries (
     created_at TIMESTAMP DEFAULT NOW()
 );
 
+-- Staff balance corrections (maker-checker): booked as an ADJUSTMENT
+-- transaction only once a different admin approves them before they expire
+CREATE TABLE balance_adjustments (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    wallet_id UUID NOT NULL REFERENCES wallets(id),
+    direction direction NOT NULL,
+    amount NUMERIC(20, 6) NOT NULL CHECK (amount > 0),
+    reason TEXT NOT NULL,
+    ticket_ref TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'PENDING' CHECK (status IN ('PENDING', 'EXECUTED', 'REJECTED', 'EXPIRED')),
+    requested_by UUID NOT NULL REFERENCES users(id),
+    reviewed_by UUID REFERENCES users(id),
+    review_note TEXT NOT NULL DEFAULT '',
+    transaction_id UUID REFERENCES transactions(id),
+    -- With time zone: expires_at is set by the service and compared with the
+    -- database's NOW() by the expiry sweep
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    expires_at TIMESTAMPTZ NOT NULL,
+    reviewed_at TIMESTAMPTZ,
+    CHECK (status <> 'EXECUTED' OR reviewed_by <> requested_by)
+);
+
+-- Withdrawals above their coin's review threshold: the amount is held in the
+-- wallet's frozen_amount until an operator approves (debit) or rejects, or
+-- the user cancels (release)
+CREATE TABLE withdrawal_reviews (
+    transaction_id UUID PRIMARY KEY REFERENCES transactions(id),
+    wallet_id UUID NOT NULL REFERENCES wallets(id),
+    user_id UUID NOT NULL REFERENCES users(id),
+    coin_type coin_type NOT NULL,
+    amount NUMERIC(20, 6) NOT NULL CHECK (amount > 0),
+    status TEXT NOT NULL DEFAULT 'PENDING' CHECK (status IN ('PENDING', 'APPROVED', 'REJECTED', 'CANCELLED')),
+    reviewed_by UUID REFERENCES users(id),
+    review_note TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT NOW(),
+    decided_at TIMESTAMP
+);
+
+CREATE TABLE idempotency_keys (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    endpoint TEXT NOT NULL,
+    key TEXT NOT NULL,
+    fingerprint TEXT NOT NULL,
+    status_code INT NOT NULL,
+    content_type TEXT NOT NULL,
+    response_body BYTEA NOT NULL,
+    created_at TIMESTAMP DEFAULT NOW(),
+    expires_at TIMESTAMP NOT NULL,
+    UNIQUE(user_id, endpoint, key)
+);
+
+CREATE TABLE prices (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    coin_type coin_type NOT NULL,
+    currency TEXT NOT NULL,
+    price NUMERIC(30, 10) NOT NULL,
+    source TEXT NOT NULL,
+    as_of TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+-- seq is taken from the sequence when the event is inserted, not when its
+-- transaction commits, so it is not commit order: a later seq can commit, and
+-- be relayed, before an earlier one. publish_seq is assigned by the relay to
+-- committed events only, in its own transaction, so it is commit order and is
+-- what live streams resume from.
+CREATE SEQUENCE outbox_publish_seq;
+
+CREATE TABLE outbox (
+    seq BIGSERIAL PRIMARY KEY,
+    publish_seq BIGINT UNIQUE,
+    id UUID UNIQUE NOT NULL DEFAULT gen_random_uuid(),
+    event_type TEXT NOT NULL,
+    aggregate_id UUID NOT NULL,
+    payload JSONB NOT NULL,
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+-- How far each relay consumer (event sink, webhooks, live stream) has
+-- published, by publish_seq, so one failing consumer never replays the others
+CREATE TABLE outbox_cursors (
+    consumer TEXT PRIMARY KEY,
+    publish_seq BIGINT NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE TABLE webhook_endpoints (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    url TEXT NOT NULL,
+    secret TEXT NOT NULL,
+    event_types TEXT[] NOT NULL,
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE TABLE webhook_deliveries (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    endpoint_id UUID NOT NULL REFERENCES webhook_endpoints(id) ON DELETE CASCADE,
+    event_id UUID NOT NULL,
+    event_type TEXT NOT NULL,
+    payload JSONB NOT NULL,
+    status TEXT NOT NULL DEFAULT 'PENDING',
+    attempts INT NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP DEFAULT NOW(),
+    last_status_code INT,
+    last_error TEXT,
+    delivered_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT NOW(),
+    UNIQUE(endpoint_id, event_id)
+);
+
+CREATE TABLE audit_logs (
+    seq BIGSERIAL PRIMARY KEY,
+    id UUID UNIQUE NOT NULL,
+    actor_id UUID,
+    ip TEXT NOT NULL,
+    user_agent TEXT NOT NULL,
+    action TEXT NOT NULL,
+    method TEXT NOT NULL,
+    route TEXT NOT NULL,
+    wallet_ids UUID[] NOT NULL DEFAULT '{}',
+    amount NUMERIC(20, 6),
+    idempotency_key TEXT NOT NULL DEFAULT '',
+    details TEXT,
+    status_code INT NOT NULL,
+    outcome TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    prev_hash TEXT NOT NULL,
+    hash TEXT NOT NULL
+);
+
+-- The audit log is append-only
+CREATE FUNCTION reject_audit_log_change() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_logs is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_logs_append_only
+    BEFORE UPDATE OR DELETE OR TRUNCATE ON audit_logs
+    FOR EACH STATEMENT EXECUTE FUNCTION reject_audit_log_change();
+
 -- Create indexes
 CREATE INDEX idx_transaction_entries_wallet_created ON transaction_entries(wallet_id, created_at);
 CREATE INDEX idx_transaction_entries_wallet_counterparty ON transaction_entries(wallet_id, counterparty_wallet_id);
 CREATE INDEX idx_wallets_user_id ON wallets(user_id);
-CREATE INDEX idx_transaction_entries_txn_id ON transaction_entries(txn_id); 
\ No newline at end of file
+-- One open wallet per coin; closed wallets stay behind as an archive
+CREATE UNIQUE INDEX idx_wallets_user_coin_open ON wallets(user_id, coin_type) WHERE status <> 'CLOSED';
+CREATE INDEX idx_transaction_entries_txn_id ON transaction_entries(txn_id);
+CREATE INDEX idx_prices_coin_currency_as_of ON prices(coin_type, currency, as_of DESC);
+CREATE INDEX idx_outbox_unsequenced ON outbox(seq) WHERE publish_seq IS NULL;
+CREATE INDEX idx_outbox_aggregate_seq ON outbox(aggregate_id, seq);
+CREATE INDEX idx_outbox_aggregate_publish_seq ON outbox(aggregate_id, publish_seq);
+CREATE INDEX idx_webhook_endpoints_user_id ON webhook_endpoints(user_id);
+CREATE INDEX idx_webhook_deliveries_due ON webhook_deliveries(next_attempt_at) WHERE status = 'PENDING';
+CREATE INDEX idx_webhook_deliveries_endpoint_created ON webhook_deliveries(endpoint_id, created_at);
+CREATE INDEX idx_audit_logs_actor_created ON audit_logs(actor_id, created_at);
+CREATE INDEX idx_audit_logs_action_created ON audit_logs(action, created_at);
+CREATE INDEX idx_audit_logs_wallet_ids ON audit_logs USING GIN(wallet_ids);
+CREATE INDEX idx_idempotency_keys_expires_at ON idempotency_keys(expires_at);
+CREATE INDEX idx_password_reset_tokens_user_id ON password_reset_tokens(user_id) WHERE used_at IS NULL;
+CREATE INDEX idx_registrations_email ON registrations(email) WHERE used_at IS NULL;
+CREATE INDEX idx_balance_adjustments_pending ON balance_adjustments(expires_at) WHERE status = 'PENDING';
+CREATE INDEX idx_balance_adjustments_wallet_created ON balance_adjustments(wallet_id, created_at);
+CREATE INDEX idx_withdrawal_reviews_pending ON withdrawal_reviews(created_at) WHERE status = 'PENDING';
+CREATE INDEX idx_withdrawal_reviews_user_created ON withdrawal_reviews(user_id, created_at);
+CREATE INDEX idx_kyc_submissions_user_created ON kyc_submissions(user_id, created_at);
+CREATE INDEX idx_kyc_submissions_pending ON kyc_submissions(created_at) WHERE status = 'pending';
+CREATE INDEX idx_refresh_tokens_family_id ON refresh_tokens(family_id);
+CREATE INDEX idx_refresh_tokens_user_id ON refresh_tokens(user_id) WHERE revoked_at IS NULL;
+CREATE INDEX idx_api_keys_user_id ON api_keys(user_id);
+CREATE INDEX idx_signing_keys_activates_at ON signing_keys(activates_at);